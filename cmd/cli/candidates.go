@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dfanso/commit-msg/cmd/cli/store"
+	"github.com/dfanso/commit-msg/internal/display"
+	"github.com/dfanso/commit-msg/internal/llm"
+	"github.com/dfanso/commit-msg/pkg/types"
+	"github.com/pterm/pterm"
+)
+
+const (
+	acceptCandidatePrefix = "Accept candidate "
+	editCandidatePrefix   = "Edit candidate "
+	mergeCandidatesOption = "Merge candidates in editor"
+	mergeCandidatesHeader = "# Combine the candidates into one commit message.\n# Lines starting with '#' are removed."
+)
+
+// generateChoices produces the messages to review: a single message, or n
+// alternatives when more than one candidate was requested.
+func generateChoices(ctx context.Context, provider llm.Provider, store *store.StoreMethods, providerType types.LLMProvider, changes string, opts *types.GenerationOptions, options CommitOptions, progressText, successText, failText string) ([]string, error) {
+	if options.Candidates > 1 {
+		return generateCandidatesWithProgress(ctx, provider, store, providerType, changes, opts, options.Candidates, options.Timeout, progressText, successText, failText)
+	}
+
+	message, err := generateWithProgress(ctx, provider, store, providerType, changes, opts, options.Timeout, progressText, successText, failText)
+	if err != nil {
+		return nil, err
+	}
+	return []string{message}, nil
+}
+
+// generateCandidatesWithProgress asks for n alternative messages behind a
+// spinner. Candidates are not cached, but their usage is recorded.
+func generateCandidatesWithProgress(ctx context.Context, provider llm.Provider, store *store.StoreMethods, providerType types.LLMProvider, changes string, opts *types.GenerationOptions, n int, timeout time.Duration, progressText, successText, failText string) ([]string, error) {
+	ctx, cancel := generationContext(ctx, timeout)
+	defer cancel()
+
+	if err := apiRateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	spinner, err := pterm.DefaultSpinner.
+		WithSequence("⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏").
+		Start(fmt.Sprintf("%s (%d candidates)", progressText, n))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start spinner: %w", err)
+	}
+
+	// Parallel requests may report attempts concurrently.
+	var mu sync.Mutex
+	var attempts, failed []llm.FallbackAttempt
+	var answeredBy types.LLMProvider
+	ctx = llm.WithFallbackTrace(ctx, &llm.FallbackTrace{
+		AttemptStart: func(next types.LLMProvider, index int) {
+			if index > 0 {
+				spinner.UpdateText(fmt.Sprintf("Trying fallback provider %s...", next.String()))
+			}
+		},
+		AttemptDone: func(attempt llm.FallbackAttempt) {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts = append(attempts, attempt)
+			if attempt.Err != nil {
+				failed = append(failed, attempt)
+				return
+			}
+			answeredBy = attempt.Provider
+		},
+	})
+
+	start := time.Now()
+	results, err := llm.GenerateCandidates(ctx, provider, changes, opts, n)
+	if len(attempts) == 0 {
+		attempts = append(attempts, llm.FallbackAttempt{Provider: providerType, Model: llm.ProviderModel(provider), Duration: time.Since(start), Err: err})
+	}
+	recordGenerationAttempts(ctx, store, attempts, candidatesUsage(results, changes, opts), false)
+
+	if err != nil {
+		spinner.Fail(failText)
+		showFallbackAttempts(failed, answeredBy)
+		return nil, contextError(ctx, err)
+	}
+
+	spinner.Success(successText)
+	showFallbackAttempts(failed, answeredBy)
+	if len(results) < n {
+		pterm.Warning.Printf("Only %d of %d candidates could be generated.\n", len(results), n)
+	}
+
+	messages := make([]string, len(results))
+	for i, result := range results {
+		messages[i] = types.ParseCommitMessage(result.Message).String()
+	}
+	return messages, nil
+}
+
+// candidatesUsage adds up the usage reported for the candidates. When the
+// provider reported none, it is estimated from the prompt and messages.
+func candidatesUsage(results []types.GenerationResult, changes string, opts *types.GenerationOptions) *types.UsageInfo {
+	if len(results) == 0 {
+		return nil
+	}
+
+	var prompt, completion int
+	reported := false
+	for _, result := range results {
+		if result.Usage != nil {
+			prompt += result.Usage.PromptTokens
+			completion += result.Usage.CompletionTokens
+			reported = true
+		}
+	}
+	if reported {
+		return types.NewUsageInfo(prompt, completion)
+	}
+
+	prompt = estimateTokens(types.BuildPrompt(changes, opts).String())
+	for _, result := range results {
+		completion += estimateTokens(result.Message)
+	}
+	return types.NewUsageInfo(prompt*len(results), completion)
+}
+
+// chooseCandidate shows the candidates side by side and lets the user accept
+// or edit one of them, or merge them in the editor. Titles, when given, head
+// the candidates of a provider comparison. The returned action is
+// actionAcceptOption or actionExitOption when the choice ends the review, and
+// empty when the message should be reviewed further. Generated messages are
+// normalized; text edited by the user is returned as written.
+func chooseCandidate(candidates, titles []string) (string, string, error) {
+	if len(candidates) == 1 {
+		return types.ParseCommitMessage(candidates[0]).String(), "", nil
+	}
+
+	for {
+		pterm.Println()
+		if titles != nil {
+			display.ShowCommitComparison(titles, candidates)
+		} else {
+			display.ShowCommitCandidates(candidates)
+		}
+
+		options := make([]string, 0, 2*len(candidates)+2)
+		for i := range candidates {
+			options = append(options, fmt.Sprintf("%s%d", acceptCandidatePrefix, i+1))
+		}
+		for i := range candidates {
+			options = append(options, fmt.Sprintf("%s%d", editCandidatePrefix, i+1))
+		}
+		options = append(options, mergeCandidatesOption, actionExitOption)
+
+		choice, err := pterm.DefaultInteractiveSelect.
+			WithOptions(options).
+			WithDefaultOption(options[0]).
+			Show()
+		if err != nil {
+			return "", "", err
+		}
+
+		var index int
+		switch {
+		case choice == actionExitOption:
+			return "", actionExitOption, nil
+		case choice == mergeCandidatesOption:
+			merged, err := editCommitMessage(mergeCandidatesText(candidates))
+			if err != nil {
+				pterm.Error.Printf("Failed to edit commit message: %v\n", err)
+				continue
+			}
+			merged = stripCommentLines(merged)
+			if merged == "" {
+				pterm.Warning.Println("Merged commit message is empty; pick a candidate instead.")
+				continue
+			}
+			return merged, "", nil
+		case parseCandidateChoice(choice, acceptCandidatePrefix, &index):
+			return types.ParseCommitMessage(candidates[index]).String(), actionAcceptOption, nil
+		case parseCandidateChoice(choice, editCandidatePrefix, &index):
+			edited, err := editCommitMessage(candidates[index])
+			if err != nil {
+				pterm.Error.Printf("Failed to edit commit message: %v\n", err)
+				continue
+			}
+			if strings.TrimSpace(edited) == "" {
+				pterm.Warning.Println("Edited commit message is empty; pick a candidate again.")
+				continue
+			}
+			return strings.TrimSpace(edited), "", nil
+		}
+	}
+}
+
+// parseCandidateChoice reads the 0-based candidate index from a menu entry
+// starting with prefix.
+func parseCandidateChoice(choice, prefix string, index *int) bool {
+	number, ok := strings.CutPrefix(choice, prefix)
+	if !ok {
+		return false
+	}
+	if _, err := fmt.Sscanf(number, "%d", index); err != nil {
+		return false
+	}
+	*index--
+	return true
+}
+
+// mergeCandidatesText lays out the candidates for merging in the editor,
+// separated by comment lines.
+func mergeCandidatesText(candidates []string) string {
+	var text strings.Builder
+	text.WriteString(mergeCandidatesHeader)
+	for i, candidate := range candidates {
+		fmt.Fprintf(&text, "\n\n# ----- Candidate %d -----\n%s", i+1, strings.TrimSpace(candidate))
+	}
+	return text.String()
+}
+
+// stripCommentLines removes lines starting with '#', as git does for commit
+// message templates.
+func stripCommentLines(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
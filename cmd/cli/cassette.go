@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dfanso/commit-msg/internal/cassette"
+	"github.com/dfanso/commit-msg/internal/doctor"
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/internal/llm"
+	"github.com/dfanso/commit-msg/pkg/types"
+	"github.com/manifoldco/promptui"
+)
+
+// useCassetteFromEnv records every provider request to the cassette named by
+// COMMIT_CASSETTE, or answers them from it, as COMMIT_CASSETTE_MODE says.
+func useCassetteFromEnv() error {
+	path := strings.TrimSpace(os.Getenv(cassette.EnvPath))
+	if path == "" {
+		return nil
+	}
+
+	mode, err := cassette.ParseMode(os.Getenv(cassette.EnvMode))
+	if err != nil {
+		return err
+	}
+
+	switch mode {
+	case cassette.ModeRecord:
+		recorder := cassette.NewRecorder(path)
+		internalHTTP.SetInterceptor(recorder)
+		llm.RecordTo(recorder)
+	default:
+		replayer, err := cassette.LoadReplayer(path)
+		if err != nil {
+			return err
+		}
+		internalHTTP.SetInterceptor(replayer)
+	}
+	return nil
+}
+
+// promptCassettePath asks for a recorded cassette and returns its absolute
+// path, so commit finds it from any repository.
+func promptCassettePath(current string) (string, error) {
+	pathPrompt := promptui.Prompt{
+		Label:    "Enter Cassette File",
+		Default:  current,
+		Validate: validateCassettePath,
+	}
+	path, err := pathPrompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("failed to read cassette file: %w", err)
+	}
+	return filepath.Abs(strings.TrimSpace(path))
+}
+
+func validateCassettePath(input string) error {
+	_, err := loadReplayableCassette(strings.TrimSpace(input))
+	return err
+}
+
+// loadReplayableCassette loads a cassette that names the provider it was
+// recorded with.
+func loadReplayableCassette(path string) (*cassette.Cassette, error) {
+	c, err := cassette.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if c.Provider == "" {
+		return nil, errors.New("the cassette does not say which provider recorded it")
+	}
+	return c, nil
+}
+
+// checkCassetteProvider reports whether the cassette of the Cassette
+// provider can be replayed. Replays need no network, so there is nothing
+// to probe.
+func checkCassetteProvider(path string) doctor.Result {
+	name := "Provider " + types.ProviderCassette.String()
+	if path == "" {
+		path = strings.TrimSpace(os.Getenv(cassette.EnvPath))
+	}
+	if path == "" {
+		return doctor.Result{Name: name, Status: doctor.StatusFail, Detail: "No cassette file is configured",
+			Fix: "Run 'commit llm update' and choose the cassette file, or set " + cassette.EnvPath + "."}
+	}
+
+	c, err := loadReplayableCassette(path)
+	if err != nil {
+		return doctor.Result{Name: name, Status: doctor.StatusFail, Detail: err.Error(),
+			Fix: "Record the cassette again with " + cassette.EnvMode + "=" + string(cassette.ModeRecord) + "."}
+	}
+	return doctor.Result{Name: name, Status: doctor.StatusPass,
+		Detail: fmt.Sprintf("%d recorded exchange(s) from %s", len(c.Interactions), c.Provider)}
+}
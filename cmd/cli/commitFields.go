@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+	"github.com/pterm/pterm"
+)
+
+const (
+	fieldType     = "Type"
+	fieldScope    = "Scope"
+	fieldSubject  = "Subject"
+	fieldBody     = "Body"
+	fieldBreaking = "Breaking change"
+	fieldTrailers = "Trailers"
+	fieldsDone    = "Done editing fields"
+	noCommitType  = "(none)"
+)
+
+var commitFields = []string{fieldType, fieldScope, fieldSubject, fieldBody, fieldBreaking, fieldTrailers}
+
+// editCommitFields lets the user change the parts of a commit message one at
+// a time until they choose to finish.
+func editCommitFields(message types.CommitMessage) (types.CommitMessage, error) {
+	for {
+		options := make([]string, 0, len(commitFields)+1)
+		for _, field := range commitFields {
+			options = append(options, fieldLabel(message, field))
+		}
+		options = append(options, fieldsDone)
+
+		choice, err := pterm.DefaultInteractiveSelect.
+			WithOptions(options).
+			WithDefaultOption(fieldsDone).
+			Show()
+		if err != nil {
+			return message, err
+		}
+
+		index := slices.Index(options, choice)
+		if index < 0 || index >= len(commitFields) {
+			return message, nil
+		}
+
+		message, err = editCommitField(message, commitFields[index])
+		if err != nil {
+			return message, err
+		}
+		pterm.Println()
+		pterm.Info.Printf("Header: %s\n", message.Header())
+	}
+}
+
+// editCommitField prompts for a new value of a single field.
+func editCommitField(message types.CommitMessage, field string) (types.CommitMessage, error) {
+	switch field {
+	case fieldType:
+		commitType, err := promptCommitType(message.Type)
+		if err != nil {
+			return message, err
+		}
+		message.Type = commitType
+	case fieldScope:
+		scope, err := pterm.DefaultInteractiveTextInput.
+			WithDefaultText("Scope (leave empty for none)").
+			WithDefaultValue(message.Scope).
+			Show()
+		if err != nil {
+			return message, err
+		}
+		message.Scope = strings.TrimSpace(scope)
+	case fieldSubject:
+		subject, err := pterm.DefaultInteractiveTextInput.
+			WithDefaultText("Subject").
+			WithDefaultValue(message.Subject).
+			Show()
+		if err != nil {
+			return message, err
+		}
+		if strings.TrimSpace(subject) == "" {
+			pterm.Warning.Println("Subject cannot be empty; keeping the previous one.")
+			return message, nil
+		}
+		message.Subject = strings.TrimSpace(subject)
+	case fieldBody:
+		body, err := editCommitMessage(message.Body)
+		if err != nil {
+			return message, err
+		}
+		message.Body = body
+	case fieldBreaking:
+		breaking, err := pterm.DefaultInteractiveConfirm.
+			WithDefaultText("Is this a breaking change?").
+			WithDefaultValue(message.Breaking).
+			Show()
+		if err != nil {
+			return message, err
+		}
+		message.Breaking = breaking
+	case fieldTrailers:
+		edited, err := editCommitMessage(formatTrailers(message.Trailers))
+		if err != nil {
+			return message, err
+		}
+		trailers, err := types.ParseTrailers(edited)
+		if err != nil {
+			pterm.Warning.Printf("%v; keeping the previous trailers.\n", err)
+			return message, nil
+		}
+		message.Trailers = trailers
+	}
+	return message, nil
+}
+
+// promptCommitType offers the conventional commit types, keeping a
+// non-standard current type selectable.
+func promptCommitType(current string) (string, error) {
+	options := append([]string{}, types.ConventionalCommitTypes...)
+	if current != "" && !slices.Contains(options, current) {
+		options = append(options, current)
+	}
+	options = append(options, noCommitType)
+
+	defaultOption := current
+	if defaultOption == "" {
+		defaultOption = noCommitType
+	}
+
+	choice, err := pterm.DefaultInteractiveSelect.
+		WithOptions(options).
+		WithDefaultOption(defaultOption).
+		Show()
+	if err != nil {
+		return current, err
+	}
+	if choice == noCommitType {
+		return "", nil
+	}
+	return choice, nil
+}
+
+// fieldLabel renders a field together with its current value for the menu.
+func fieldLabel(message types.CommitMessage, field string) string {
+	var value string
+	switch field {
+	case fieldType:
+		value = message.Type
+	case fieldScope:
+		value = message.Scope
+	case fieldSubject:
+		value = message.Subject
+	case fieldBody:
+		if body := strings.TrimSpace(message.Body); body != "" {
+			lines := strings.Count(body, "\n") + 1
+			value = fmt.Sprintf("%d line(s)", lines)
+		}
+	case fieldBreaking:
+		value = "no"
+		if message.Breaking {
+			value = "yes"
+		}
+	case fieldTrailers:
+		if len(message.Trailers) > 0 {
+			value = fmt.Sprintf("%d trailer(s)", len(message.Trailers))
+		}
+	}
+
+	if value == "" {
+		value = "(empty)"
+	}
+	return fmt.Sprintf("%s: %s", field, value)
+}
+
+func formatTrailers(trailers []types.Trailer) string {
+	lines := make([]string, len(trailers))
+	for i, trailer := range trailers {
+		lines[i] = trailer.Key + ": " + trailer.Value
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+func TestFieldLabel(t *testing.T) {
+	t.Parallel()
+
+	message := types.CommitMessage{
+		Type:     "feat",
+		Subject:  "add parser",
+		Body:     "first\nsecond",
+		Breaking: true,
+		Trailers: []types.Trailer{{Key: "Refs", Value: "#1"}},
+	}
+
+	cases := map[string]string{
+		fieldType:     "Type: feat",
+		fieldScope:    "Scope: (empty)",
+		fieldSubject:  "Subject: add parser",
+		fieldBody:     "Body: 2 line(s)",
+		fieldBreaking: "Breaking change: yes",
+		fieldTrailers: "Trailers: 1 trailer(s)",
+	}
+	for field, want := range cases {
+		if got := fieldLabel(message, field); got != want {
+			t.Fatalf("fieldLabel(%s) = %q, want %q", field, got, want)
+		}
+	}
+}
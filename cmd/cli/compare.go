@@ -0,0 +1,349 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dfanso/commit-msg/cmd/cli/store"
+	"github.com/dfanso/commit-msg/internal/llm"
+	"github.com/dfanso/commit-msg/internal/pricing"
+	"github.com/dfanso/commit-msg/pkg/types"
+	"github.com/pterm/pterm"
+)
+
+// compareAll is the value of a bare --compare: every configured provider.
+const compareAll = "all"
+
+// comparedProvider is a provider taking part in a comparison.
+type comparedProvider struct {
+	Type     types.LLMProvider
+	Provider llm.Provider
+}
+
+// label names the provider and its model.
+func (p comparedProvider) label() string {
+	return providerLabel(p.Type, llm.ProviderModel(p.Provider))
+}
+
+// comparison runs several providers on the same changes and, when a judge
+// is set, has it rank their messages.
+type comparison struct {
+	providers []comparedProvider
+	judge     *comparedProvider
+}
+
+// comparisonResult is what one provider produced in a comparison.
+type comparisonResult struct {
+	Type     types.LLMProvider
+	Model    string
+	Message  string
+	Duration time.Duration
+	Usage    *types.UsageInfo
+	// Cost is the estimated price of the request; Priced is false when the
+	// model has no known rate.
+	Cost   float64
+	Priced bool
+	Err    error
+	// Rank is the judge's 1-based ranking, or 0 when nothing was ranked.
+	Rank int
+}
+
+// newComparison builds the providers named by names, or every configured
+// provider for compareAll, and the judge provider when one is named. The
+// default provider keeps the --model override of primary. Providers that
+// cannot be created are skipped with a warning, but at least two must
+// remain.
+func newComparison(Store *store.StoreMethods, names []string, judgeName string, primary *store.LLMProvider, config *types.Config) (*comparison, error) {
+	var providerTypes []types.LLMProvider
+	if slices.Contains(names, compareAll) {
+		savedModels, err := store.ListSavedModels()
+		if err != nil {
+			return nil, err
+		}
+		providerTypes = append(providerTypes, primary.LLM)
+		for _, provider := range savedModels.LLMProviders {
+			if provider != primary.LLM {
+				providerTypes = append(providerTypes, provider)
+			}
+		}
+	} else {
+		parsed, err := parseFallbackProviders(names)
+		if err != nil {
+			return nil, err
+		}
+		for _, provider := range parsed {
+			if !slices.Contains(providerTypes, provider) {
+				providerTypes = append(providerTypes, provider)
+			}
+		}
+	}
+
+	c := &comparison{}
+	for _, provider := range providerTypes {
+		compared, err := newComparedProvider(Store, provider, primary, config)
+		if err != nil {
+			pterm.Warning.Printf("Skipping %s: %v\n", provider.String(), err)
+			continue
+		}
+		c.providers = append(c.providers, compared)
+	}
+	if len(c.providers) < 2 {
+		return nil, errors.New("--compare needs at least two configured providers; add more with 'commit llm setup'")
+	}
+
+	if strings.TrimSpace(judgeName) != "" {
+		provider, ok := parseProviderName(judgeName)
+		if !ok {
+			return nil, fmt.Errorf("unknown LLM provider %q, expected one of: %s", judgeName, strings.Join(types.GetSupportedProviderStrings(), ", "))
+		}
+		judge, err := newComparedProvider(Store, provider, primary, config)
+		if err != nil {
+			return nil, fmt.Errorf("cannot use %s as the judge: %w", provider.String(), err)
+		}
+		c.judge = &judge
+	}
+
+	return c, nil
+}
+
+// newComparedProvider creates a saved provider for a comparison.
+func newComparedProvider(Store *store.StoreMethods, provider types.LLMProvider, primary *store.LLMProvider, config *types.Config) (comparedProvider, error) {
+	saved := primary
+	if provider != primary.LLM {
+		var err error
+		if saved, err = Store.LoadLLM(provider); err != nil {
+			return comparedProvider{}, err
+		}
+	}
+
+	instance, err := llm.NewProvider(provider, llm.ProviderOptions{
+		Credential: saved.APIKey,
+		Config:     config,
+		Settings:   saved.Settings,
+	})
+	if err != nil {
+		return comparedProvider{}, err
+	}
+	return comparedProvider{Type: provider, Provider: instance}, nil
+}
+
+// run asks every provider for a message in parallel, has the judge rank
+// them and shows the comparison. It returns the messages to choose from,
+// best first when they were ranked, with a title for each. Every request
+// is recorded in the usage statistics; results are not cached.
+func (c *comparison) run(ctx context.Context, store *store.StoreMethods, changes string, opts *types.GenerationOptions, timeout time.Duration) ([]string, []string, error) {
+	ctx, cancel := generationContext(ctx, timeout)
+	defer cancel()
+
+	spinner, err := pterm.DefaultSpinner.
+		WithSequence("⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏").
+		Start(fmt.Sprintf("Comparing %d providers...", len(c.providers)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start spinner: %w", err)
+	}
+
+	results := compareProviders(ctx, c.providers, changes, opts)
+	for _, result := range results {
+		recordGenerationAttempts(ctx, store, []llm.FallbackAttempt{{Provider: result.Type, Model: result.Model, Duration: result.Duration, Err: result.Err}}, result.Usage, false)
+	}
+
+	succeeded := slices.DeleteFunc(slices.Clone(results), func(result comparisonResult) bool { return result.Err != nil })
+	if len(succeeded) == 0 {
+		spinner.Fail("Every provider failed")
+		showComparisonFailures(results)
+		if ctx.Err() != nil {
+			return nil, nil, contextError(ctx, results[0].Err)
+		}
+		return nil, nil, errors.Join(comparisonErrors(results)...)
+	}
+	spinner.Success(fmt.Sprintf("%d of %d providers answered", len(succeeded), len(results)))
+	showComparisonFailures(results)
+
+	if c.judge != nil && len(succeeded) > 1 {
+		succeeded = c.rank(ctx, store, changes, opts, succeeded)
+	}
+
+	showComparisonTable(succeeded)
+
+	messages := make([]string, len(succeeded))
+	titles := make([]string, len(succeeded))
+	for i, result := range succeeded {
+		messages[i] = result.Message
+		titles[i] = fmt.Sprintf("%d. %s", i+1, providerLabel(result.Type, result.Model))
+		if result.Rank == 1 {
+			titles[i] += ", judge's pick"
+		}
+	}
+	return messages, titles, nil
+}
+
+// compareProviders asks every provider for a message at the same time and
+// returns their results in the order of providers.
+func compareProviders(ctx context.Context, providers []comparedProvider, changes string, opts *types.GenerationOptions) []comparisonResult {
+	results := make([]comparisonResult, len(providers))
+
+	var wg sync.WaitGroup
+	for i, compared := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := comparisonResult{Type: compared.Type, Model: llm.ProviderModel(compared.Provider)}
+			if result.Err = apiRateLimiter.Wait(ctx); result.Err != nil {
+				results[i] = result
+				return
+			}
+
+			start := time.Now()
+			generated, err := generateFromProvider(ctx, compared.Provider, changes, opts, nil)
+			result.Duration = time.Since(start)
+			if err != nil {
+				result.Err = err
+				results[i] = result
+				return
+			}
+
+			result.Message = types.ParseCommitMessage(generated.Message).String()
+			result.Usage = generated.Usage
+			if result.Usage == nil {
+				result.Usage = types.NewUsageInfo(estimateTokens(types.BuildPrompt(changes, opts).String()), estimateTokens(generated.Message))
+			}
+			rate, priced := pricing.Default().Lookup(result.Type, result.Model)
+			result.Cost, result.Priced = rate.Cost(result.Usage), priced
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// rank has the judge order results, best first. When the judge fails the
+// results are kept in their order and a warning is shown.
+func (c *comparison) rank(ctx context.Context, store *store.StoreMethods, changes string, opts *types.GenerationOptions, results []comparisonResult) []comparisonResult {
+	messages := make([]string, len(results))
+	for i, result := range results {
+		messages[i] = result.Message
+	}
+	judgeOpts := &types.GenerationOptions{StyleInstruction: judgeInstruction(messages), Sampling: types.SamplingOf(opts)}
+
+	spinner, _ := pterm.DefaultSpinner.Start(fmt.Sprintf("Asking %s to rank the messages...", c.judge.label()))
+	start := time.Now()
+	answer, err := generateMessage(ctx, c.judge.Provider, changes, judgeOpts)
+	attempt := llm.FallbackAttempt{Provider: c.judge.Type, Model: llm.ProviderModel(c.judge.Provider), Duration: time.Since(start), Err: err}
+
+	var usage *types.UsageInfo
+	var order []int
+	if err == nil {
+		usage = answer.Usage
+		if usage == nil {
+			usage = types.NewUsageInfo(estimateTokens(types.BuildPrompt(changes, judgeOpts).String()), estimateTokens(answer.Message))
+		}
+		order, err = parseJudgeRanking(answer.Message, len(results))
+	}
+	recordGenerationAttempts(ctx, store, []llm.FallbackAttempt{attempt}, usage, false)
+
+	if err != nil {
+		if spinner != nil {
+			spinner.Warning(fmt.Sprintf("The judge could not rank the messages: %v", err))
+		}
+		return results
+	}
+	if spinner != nil {
+		spinner.Success(fmt.Sprintf("Ranked by %s", c.judge.label()))
+	}
+
+	ranked := make([]comparisonResult, 0, len(results))
+	for position, index := range order {
+		result := results[index]
+		result.Rank = position + 1
+		ranked = append(ranked, result)
+	}
+	return ranked
+}
+
+// judgeInstruction asks the judge to rank messages instead of writing one.
+// It is sent as the style instruction, so the judge sees the changes the
+// messages describe.
+func judgeInstruction(messages []string) string {
+	var text strings.Builder
+	text.WriteString("Do not write a new commit message. Rank the candidate commit messages below by how accurately and clearly they describe the changes. ")
+	text.WriteString("Answer with only the candidate numbers, best first, separated by commas (for example: 2, 1, 3).")
+	for i, message := range messages {
+		fmt.Fprintf(&text, "\n\nCandidate %d:\n%s", i+1, strings.TrimSpace(message))
+	}
+	return text.String()
+}
+
+var judgeNumberPattern = regexp.MustCompile(`\d+`)
+
+// parseJudgeRanking reads the judge's answer as 1-based candidate numbers,
+// best first, and returns the 0-based order of all n candidates. Numbers
+// out of range and repeats are ignored; candidates the judge left out
+// follow in their own order.
+func parseJudgeRanking(answer string, n int) ([]int, error) {
+	order := make([]int, 0, n)
+	for _, match := range judgeNumberPattern.FindAllString(answer, -1) {
+		number, err := strconv.Atoi(match)
+		if err != nil || number < 1 || number > n || slices.Contains(order, number-1) {
+			continue
+		}
+		order = append(order, number-1)
+	}
+	if len(order) == 0 {
+		return nil, fmt.Errorf("no candidate numbers in the answer %q", strings.TrimSpace(answer))
+	}
+
+	for i := range n {
+		if !slices.Contains(order, i) {
+			order = append(order, i)
+		}
+	}
+	return order, nil
+}
+
+// showComparisonTable prints the latency, tokens and estimated cost of
+// every message, numbered as they are offered.
+func showComparisonTable(results []comparisonResult) {
+	rows := [][]string{{"#", "Provider", "Model", "Latency", "Tokens", "Est. Cost"}}
+	for i, result := range results {
+		tokens, cost := "-", "unknown"
+		if result.Usage != nil {
+			tokens = strconv.Itoa(result.Usage.TotalTokens)
+		}
+		if result.Priced {
+			cost = fmt.Sprintf("$%.4f", result.Cost)
+		}
+		rows = append(rows, []string{strconv.Itoa(i + 1), result.Type.String(), result.Model, result.Duration.Round(10 * time.Millisecond).String(), tokens, cost})
+	}
+
+	pterm.Println()
+	pterm.DefaultTable.WithHasHeader(true).WithData(rows).Render()
+}
+
+// showComparisonFailures reports the providers that did not answer.
+func showComparisonFailures(results []comparisonResult) {
+	for _, result := range results {
+		if result.Err != nil {
+			pterm.Warning.Printf("%s failed: %v\n", providerLabel(result.Type, result.Model), result.Err)
+		}
+	}
+}
+
+// comparisonErrors returns the error of every failed provider, named after it.
+func comparisonErrors(results []comparisonResult) []error {
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", result.Type.String(), result.Err))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+// failingProvider always fails to generate.
+type failingProvider struct{ FakeProvider }
+
+func (f failingProvider) Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return types.GenerationResult{}, errors.New("quota exceeded")
+}
+
+func TestCompareProviders(t *testing.T) {
+	results := compareProviders(context.Background(), []comparedProvider{
+		{Type: types.ProviderClaude, Provider: structuredFakeProvider{}},
+		{Type: types.ProviderGroq, Provider: failingProvider{}},
+		{Type: types.ProviderOllama, Provider: FakeProvider{}},
+	}, "diff --git a/x b/x", nil)
+
+	if len(results) != 3 {
+		t.Fatalf("expected a result per provider, got %d", len(results))
+	}
+	if results[0].Message != "feat(cli): add fields" || results[0].Usage.TotalTokens != 128 {
+		t.Fatalf("unexpected Claude result %+v", results[0])
+	}
+	if results[1].Err == nil || results[1].Type != types.ProviderGroq {
+		t.Fatalf("expected Groq to fail, got %+v", results[1])
+	}
+	if results[2].Message != "mock commit message" || results[2].Usage == nil || results[2].Usage.CompletionTokens == 0 {
+		t.Fatalf("expected the Ollama usage to be estimated, got %+v", results[2])
+	}
+	if !results[2].Priced || results[2].Cost != 0 {
+		t.Fatalf("expected Ollama to be free, got %+v", results[2])
+	}
+}
+
+func TestParseJudgeRanking(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string][]int{
+		"2, 1, 3":                        {1, 0, 2},
+		"Candidate 3 is best, then 3, 1": {2, 0, 1},
+		"1 > 7 > 1":                      {0, 1, 2},
+	}
+	for answer, want := range cases {
+		got, err := parseJudgeRanking(answer, 3)
+		if err != nil || !slices.Equal(got, want) {
+			t.Fatalf("parseJudgeRanking(%q) = %v, %v; want %v", answer, got, err, want)
+		}
+	}
+
+	if _, err := parseJudgeRanking("They are all fine.", 3); err == nil {
+		t.Fatal("expected an answer without numbers to be rejected")
+	}
+}
+
+func TestJudgeInstructionListsCandidates(t *testing.T) {
+	t.Parallel()
+
+	instruction := judgeInstruction([]string{"feat: add compare\n", "feat(cli): compare providers"})
+	for _, want := range []string{"Do not write a new commit message", "Candidate 1:\nfeat: add compare", "Candidate 2:\nfeat(cli): compare providers"} {
+		if !strings.Contains(instruction, want) {
+			t.Fatalf("expected %q in the instruction:\n%s", want, instruction)
+		}
+	}
+}
@@ -6,16 +6,27 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
 	"runtime"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/atotto/clipboard"
 	"github.com/dfanso/commit-msg/cmd/cli/store"
+	"github.com/dfanso/commit-msg/internal/azure"
+	"github.com/dfanso/commit-msg/internal/bedrock"
+	"github.com/dfanso/commit-msg/internal/cassette"
 	"github.com/dfanso/commit-msg/internal/display"
 	"github.com/dfanso/commit-msg/internal/git"
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
 	"github.com/dfanso/commit-msg/internal/llm"
+	"github.com/dfanso/commit-msg/internal/ollama"
+	"github.com/dfanso/commit-msg/internal/openaicompat"
+	"github.com/dfanso/commit-msg/internal/pricing"
 	"github.com/dfanso/commit-msg/internal/stats"
+	"github.com/dfanso/commit-msg/internal/vertex"
 	"github.com/dfanso/commit-msg/pkg/types"
 	"github.com/google/shlex"
 	"github.com/pterm/pterm"
@@ -26,11 +37,76 @@ import (
 // Make the limiter a global variable to better control the rate when it is used.
 var apiRateLimiter = rate.NewLimiter(rate.Every(time.Second/5), 5)
 
+// CommitOptions carries the command-line flags of `commit .`.
+type CommitOptions struct {
+	// DryRun displays the prompt without making an API call.
+	DryRun bool
+	// AutoCommit runs git commit with the accepted message.
+	AutoCommit bool
+	// Verbose shows detailed diff statistics and processing info.
+	Verbose bool
+	// Timeout bounds each generation request when positive.
+	Timeout time.Duration
+	// Model overrides the model configured for the default provider.
+	Model string
+	// MaxAttempts bounds how often a rate-limited or failed request is sent.
+	MaxAttempts int
+	// Candidates is the number of alternative messages generated at once.
+	Candidates int
+	// Deterministic asks for temperature 0 and a fixed seed, so the same
+	// diff gives the same message wherever the provider allows it.
+	Deterministic bool
+	// Compare names the providers whose messages are compared side by
+	// side; compareAll compares every configured provider.
+	Compare []string
+	// Judge names the provider that ranks the compared messages.
+	Judge string
+	// Scope selects the changes that are described. With AutoCommit,
+	// unstaged and untracked changes are staged only when Scope names them
+	// explicitly, and ScopeAuto requires staged changes unless amending.
+	Scope types.ChangeScope
+	// Amend describes HEAD together with the changes in Scope, with HEAD's
+	// message as context; AutoCommit amends HEAD.
+	Amend bool
+	// Reword names a commit whose changes are described instead, and whose
+	// message is replaced by the accepted one.
+	Reword string
+	// NoVerify skips the commit-msg hook when rewording.
+	NoVerify bool
+
+	// previousMessage is the message of the amended or reworded commit.
+	previousMessage string
+}
+
+// generationOptions returns the options of attempt in the style of
+// styleOpts, with deterministic sampling when it was asked for.
+func (o CommitOptions) generationOptions(styleOpts *types.GenerationOptions, attempt int) *types.GenerationOptions {
+	opts := withAttempt(styleOpts, attempt)
+	opts.PreviousMessage = o.previousMessage
+	if o.Deterministic {
+		opts.Sampling = opts.Sampling.Merge(types.DeterministicSampling())
+	}
+	return opts
+}
+
+// commitScope checks the resolved scope against what --auto will commit.
+// Without an explicit scope only the index is committed, so when nothing is
+// staged an amend describes HEAD alone and a new commit is refused before
+// any generation is paid for.
+func (o CommitOptions) commitScope(resolved types.ChangeScope) (types.ChangeScope, error) {
+	if !o.AutoCommit || o.Reword != "" || o.Scope != types.ScopeAuto || resolved == types.ScopeStaged {
+		return resolved, nil
+	}
+	if o.Amend {
+		return types.ScopeStaged, nil
+	}
+	return "", errors.New("nothing is staged, so --auto would have nothing to commit")
+}
+
 // CreateCommitMsg launches the interactive flow for reviewing, regenerating,
 // editing, and accepting AI-generated commit messages in the current repo.
-// If dryRun is true, it displays the prompt without making an API call.
-// If verbose is true, shows detailed diff statistics and processing info.
-func CreateCommitMsg(Store *store.StoreMethods, dryRun bool, autoCommit bool, verbose bool) {
+// Ctrl-C aborts the generation request that is currently in flight.
+func CreateCommitMsg(Store *store.StoreMethods, options CommitOptions) {
 	// Validate COMMIT_LLM and required API keys
 	useLLM, err := Store.DefaultLLMKey()
 	if err != nil {
@@ -40,6 +116,9 @@ func CreateCommitMsg(Store *store.StoreMethods, dryRun bool, autoCommit bool, ve
 
 	commitLLM := useLLM.LLM
 	apiKey := useLLM.APIKey
+	if model := strings.TrimSpace(options.Model); model != "" {
+		useLLM.Settings.Model = model
+	}
 
 	// Get current directory
 	currentDir, err := os.Getwd()
@@ -58,7 +137,40 @@ func CreateCommitMsg(Store *store.StoreMethods, dryRun bool, autoCommit bool, ve
 		GrokAPI: "https://api.x.ai/v1/chat/completions",
 	}
 
-	repoConfig := types.RepoConfig{Path: currentDir}
+	// rewriting is the commit whose message --amend or reword replaces.
+	rewriting := ""
+	switch {
+	case options.Reword != "":
+		rewriting = options.Reword
+	case options.Amend:
+		rewriting = "HEAD"
+	}
+	if rewriting != "" {
+		rewriting, err = git.ResolveCommit(currentDir, rewriting)
+		if err == nil {
+			options.previousMessage, err = git.CommitMessage(currentDir, rewriting)
+		}
+		if err != nil {
+			pterm.Error.Printf("Cannot read the commit to rewrite: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	repoConfig := types.RepoConfig{Path: currentDir, Scope: options.Scope}
+
+	// Resolve the scope once, so the statistics, the diff and the commit
+	// agree even if the index changes meanwhile.
+	repoConfig.Scope, err = git.ResolveScope(&repoConfig)
+	if err != nil {
+		pterm.Error.Printf("Failed to read the Git index: %v\n", err)
+		os.Exit(1)
+	}
+	repoConfig.Scope, err = options.commitScope(repoConfig.Scope)
+	if err != nil {
+		pterm.Error.Println(err)
+		pterm.Info.Println("Stage the changes to commit with git add, or pass --all or --include-untracked to commit every change.")
+		os.Exit(1)
+	}
 
 	fileStats, err := stats.GetFileStatistics(&repoConfig)
 	if err != nil {
@@ -72,27 +184,54 @@ func CreateCommitMsg(Store *store.StoreMethods, dryRun bool, autoCommit bool, ve
 		Println("Commit Message Generator")
 
 	pterm.Println()
-	display.ShowFileStatistics(fileStats)
+	if options.Reword != "" {
+		subject, _, _ := strings.Cut(options.previousMessage, "\n")
+		pterm.Info.Printf("Rewording %s: %s\n", rewriting[:7], subject)
+		if signed, err := git.SignedCommits(currentDir, rewriting); err != nil {
+			pterm.Warning.Printf("Cannot check the commits for signatures: %v\n", err)
+		} else if len(signed) > 0 {
+			pterm.Warning.Printf("Rewording recreates %d signed commit(s) without their signatures.\n", len(signed))
+		}
+	} else {
+		display.ShowFileStatistics(fileStats)
+	}
 
-	if verbose {
+	if options.Verbose && options.Reword == "" {
 		pterm.Info.Printf("Repository: %s\n", currentDir)
 		pterm.Info.Printf("File summary: %d staged, %d unstaged, %d untracked\n",
 			len(fileStats.StagedFiles), len(fileStats.UnstagedFiles), len(fileStats.UntrackedFiles))
 	}
 
-	if fileStats.TotalFiles == 0 {
-		pterm.Warning.Println("No changes detected in the Git repository.")
+	if fileStats.TotalFiles == 0 && rewriting == "" {
+		pterm.Warning.Printf("No %s detected in the Git repository.\n", repoConfig.Scope.Describe())
 		pterm.Info.Println("Tips:")
+		if fileStats.OmittedFiles > 0 {
+			pterm.Info.Println("  - Describe unstaged changes with --all, and new files with --include-untracked")
+		}
 		pterm.Info.Println("  - Stage your changes with: git add .")
 		pterm.Info.Println("  - Check repository status with: git status")
 		pterm.Info.Println("  - Make sure you're in the correct Git repository")
 		return
 	}
 
-	changes, err := git.GetChanges(&repoConfig)
-	if err != nil {
-		pterm.Error.Printf("Failed to get Git changes: %v\n", err)
-		os.Exit(1)
+	changes := ""
+	if fileStats.TotalFiles > 0 && options.Reword == "" {
+		changes, err = git.GetChanges(&repoConfig)
+		if err != nil {
+			pterm.Error.Printf("Failed to get Git changes: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// An amended or reworded commit is described with its own changes,
+	// followed by those an amend adds.
+	if rewriting != "" {
+		committed, err := git.GetCommitChanges(currentDir, rewriting)
+		if err != nil {
+			pterm.Error.Printf("Failed to get the changes of the commit: %v\n", err)
+			os.Exit(1)
+		}
+		changes = committed + changes
 	}
 
 	if len(changes) == 0 {
@@ -104,98 +243,118 @@ func CreateCommitMsg(Store *store.StoreMethods, dryRun bool, autoCommit bool, ve
 		return
 	}
 
-	//  Large diff handling
-	const maxDiffChars = 8000 // can change as needed
-	const maxDiffLines = 300
+	// Large diff handling: keep the diff within what the model's context
+	// window leaves next to the prompt and the answer.
+	capabilities := llm.ResolveCapabilities(commitLLM, apiKey, useLLM.Settings)
+	overhead := promptOverhead()
+	budget := capabilities.DiffBudget(overhead)
+	maxDiffChars := budget * charsPerToken
 
 	diffLines := strings.Split(changes, "\n")
-	diffTooLarge := len(changes) > maxDiffChars || len(diffLines) > maxDiffLines
+	diffTokens := estimateTokens(changes)
 
-	if diffTooLarge {
-		pterm.Warning.Println("The diff is very large and may exceed the LLM's context window.")
-		pterm.Info.Printf("Diff size: %d lines, %d characters.\n", len(diffLines), len(changes))
+	if len(changes) > maxDiffChars {
+		pterm.Warning.Println("The diff is too large for the model's context window.")
+		pterm.Info.Printf("Diff size: %d lines, %d characters (about %d tokens, %d fit).\n", len(diffLines), len(changes), diffTokens, budget)
 		pterm.Info.Println("Only the first part of the diff will be used for commit message generation.")
 
-		// Truncate the diff for LLM input, preserving whole lines and UTF-8 safety
-		truncatedLines := make([]string, 0, len(diffLines))
-		totalChars := 0
-
-		for i, line := range diffLines {
-			lineLen := len([]rune(line)) + 1 // +1 for newline, using rune count for UTF-8 safety
-
-			// Stop if we've reached max lines or adding this line would exceed max chars
-			if i >= maxDiffLines || (totalChars+lineLen) > maxDiffChars {
-				break
-			}
-
-			truncatedLines = append(truncatedLines, line)
-			totalChars += lineLen
-		}
-
-		changes = strings.Join(truncatedLines, "\n")
-		actualLineCount := len(truncatedLines)
+		changes = truncateDiff(changes, maxDiffChars)
 
-		pterm.Info.Printf("Truncated diff to %d lines, %d characters.\n", actualLineCount, len(changes))
+		pterm.Info.Printf("Truncated diff to %d lines, %d characters.\n", strings.Count(changes, "\n")+1, len(changes))
 		pterm.Info.Println("Consider committing smaller changes for more accurate commit messages.")
-	} else if verbose {
+	} else if options.Verbose {
 		pterm.Info.Printf("Diff statistics: %d lines, %d characters (within limits).\n", len(diffLines), len(changes))
-		inputTokens := estimateTokens(changes)
-		pterm.Info.Printf("Estimated tokens for LLM: %d input tokens.\n", inputTokens)
+		pterm.Info.Printf("Estimated tokens for LLM: %d input tokens.\n", diffTokens+overhead)
+	}
+	if options.Verbose {
+		pterm.Info.Printf("Context window: %d tokens; prompt overhead %d, diff budget %d, %d left after the diff.\n",
+			capabilities.ContextWindow, overhead, budget, budget-estimateTokens(changes))
 	}
 
 	// Handle dry-run mode: display what would be sent to LLM without making API call
-	if dryRun {
+	if options.DryRun {
 		pterm.Println()
-		displayDryRunInfo(commitLLM, config, changes, apiKey, verbose)
+		displayDryRunInfo(commitLLM, config, changes, apiKey, useLLM.Settings, options.generationOptions(loadStylePresets()[0].options(), 1), options.Verbose)
 		return
 	}
 
-	ctx := context.Background()
+	ctx := internalHTTP.WithMaxAttempts(context.Background(), options.MaxAttempts)
+	var retries retryLog
+	if options.Verbose {
+		ctx = internalHTTP.WithRetryTrace(ctx, retries.trace())
+	}
 
-	providerInstance, err := llm.NewProvider(commitLLM, llm.ProviderOptions{
-		Credential: apiKey,
-		Config:     config,
-	})
-	if err != nil {
-		displayProviderError(commitLLM, err)
-		os.Exit(1)
+	var providerInstance llm.Provider
+	var compared *comparison
+	if len(options.Compare) > 0 {
+		compared, err = newComparison(Store, options.Compare, options.Judge, useLLM, config)
+		if err != nil {
+			pterm.Error.Printf("%v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		providerInstance, err = newProviderChain(Store, useLLM, config)
+		if err != nil {
+			displayProviderError(commitLLM, err)
+			os.Exit(1)
+		}
 	}
 
-	pterm.Println()
-	spinnerGenerating, err := pterm.DefaultSpinner.
-		WithSequence("⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏").
-		Start("Generating commit message with " + commitLLM.String() + "...")
-	if err != nil {
-		pterm.Error.Printf("Failed to start spinner: %v\n", err)
-		os.Exit(1)
+	// generate produces the messages to choose from, with their titles
+	// when providers are compared.
+	generate := func(opts *types.GenerationOptions, progressText, successText, failText string) ([]string, []string, error) {
+		if compared != nil {
+			return compared.run(ctx, Store, changes, opts, options.Timeout)
+		}
+		choices, err := generateChoices(ctx, providerInstance, Store, commitLLM, changes, opts, options, progressText, successText, failText)
+		return choices, nil, err
 	}
 
+	presets := loadStylePresets()
+	currentStyleLabel := presets[0].Label
+	currentStyleOpts := presets[0].options()
+
+	pterm.Println()
 	attempt := 1
-	commitMsg, err := generateMessageWithCache(ctx, providerInstance, Store, commitLLM, changes, withAttempt(nil, attempt))
+	choices, titles, err := generate(options.generationOptions(currentStyleOpts, attempt),
+		"Generating commit message with "+providerLabel(commitLLM, llm.ResolveModel(commitLLM, useLLM.Settings))+"...",
+		"Commit message generated successfully!",
+		"Failed to generate commit message")
+	retries.show()
+	if errors.Is(err, context.Canceled) {
+		pterm.Warning.Println("Commit message generation cancelled.")
+		return
+	}
 	if err != nil {
-		spinnerGenerating.Fail("Failed to generate commit message")
-		displayProviderError(commitLLM, err)
+		displayGenerationError(commitLLM, options.Timeout, err)
 		os.Exit(1)
 	}
 
-	spinnerGenerating.Success("Commit message generated successfully!")
-
-	currentMessage := strings.TrimSpace(commitMsg)
+	// pendingAction carries a choice made while picking a candidate into the
+	// review loop, so it is not asked for again. currentMessage is kept as
+	// text, so what the user edits is committed as written.
+	currentMessage, pendingAction, err := chooseCandidate(choices, titles)
+	if err != nil {
+		pterm.Error.Printf("Failed to read selection: %v\n", err)
+		return
+	}
 	validateCommitMessageLength(currentMessage)
-	currentStyleLabel := stylePresets[0].Label
-	var currentStyleOpts *types.GenerationOptions
 	accepted := false
 	finalMessage := ""
 
 interactionLoop:
 	for {
-		pterm.Println()
-		display.ShowCommitMessage(currentMessage)
+		action := pendingAction
+		pendingAction = ""
+		if action == "" {
+			pterm.Println()
+			display.ShowCommitMessage(currentMessage)
 
-		action, err := promptActionSelection()
-		if err != nil {
-			pterm.Error.Printf("Failed to read selection: %v\n", err)
-			return
+			action, err = promptActionSelection()
+			if err != nil {
+				pterm.Error.Printf("Failed to read selection: %v\n", err)
+				return
+			}
 		}
 
 		switch action {
@@ -205,6 +364,13 @@ interactionLoop:
 				pterm.Warning.Println("Commit message is empty; please edit or regenerate before accepting.")
 				continue
 			}
+			if options.AutoCommit {
+				if err := types.ParseCommitMessage(currentMessage).Validate(); err != nil {
+					pterm.Warning.Printf("Not a valid conventional commit: %v\n", err)
+					pterm.Info.Println("Edit the fields or regenerate before committing with --auto.")
+					continue
+				}
+			}
 			if err := clipboard.WriteAll(finalMessage); err != nil {
 				pterm.Warning.Printf("Could not copy to clipboard: %v\n", err)
 			} else {
@@ -213,7 +379,7 @@ interactionLoop:
 			accepted = true
 			break interactionLoop
 		case actionRegenerateOption:
-			opts, styleLabel, err := promptStyleSelection(currentStyleLabel, currentStyleOpts)
+			opts, styleLabel, err := promptStyleSelection(presets, currentStyleLabel, currentStyleOpts)
 			if errors.Is(err, errSelectionCancelled) {
 				continue
 			}
@@ -226,24 +392,31 @@ interactionLoop:
 			}
 			currentStyleOpts = opts
 			nextAttempt := attempt + 1
-			generationOpts := withAttempt(currentStyleOpts, nextAttempt)
-			spinner, err := pterm.DefaultSpinner.
-				WithSequence("⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏").
-				Start(fmt.Sprintf("Regenerating commit message (%s)...", currentStyleLabel))
-			if err != nil {
-				pterm.Error.Printf("Failed to start spinner: %v\n", err)
+			generationOpts := options.generationOptions(currentStyleOpts, nextAttempt)
+			updatedChoices, updatedTitles, genErr := generate(generationOpts,
+				fmt.Sprintf("Regenerating commit message (%s)...", currentStyleLabel),
+				"Commit message regenerated!",
+				"Regeneration failed")
+			retries.show()
+			if errors.Is(genErr, context.Canceled) {
+				pterm.Warning.Println("Regeneration cancelled; keeping the previous message.")
 				continue
 			}
-			updatedMessage, genErr := generateMessageWithCache(ctx, providerInstance, Store, commitLLM, changes, generationOpts)
 			if genErr != nil {
-				spinner.Fail("Regeneration failed")
-				displayProviderError(commitLLM, genErr)
+				displayGenerationError(commitLLM, options.Timeout, genErr)
 				continue
 			}
-			spinner.Success("Commit message regenerated!")
 			attempt = nextAttempt
-			currentMessage = strings.TrimSpace(updatedMessage)
-			validateCommitMessageLength(currentMessage)
+			updatedMessage, nextAction, chooseErr := chooseCandidate(updatedChoices, updatedTitles)
+			if chooseErr != nil {
+				pterm.Error.Printf("Failed to read selection: %v\n", chooseErr)
+				continue
+			}
+			if nextAction != actionExitOption {
+				currentMessage = updatedMessage
+				validateCommitMessageLength(currentMessage)
+			}
+			pendingAction = nextAction
 		case actionEditOption:
 			edited, editErr := editCommitMessage(currentMessage)
 			if editErr != nil {
@@ -256,6 +429,18 @@ interactionLoop:
 			}
 			currentMessage = strings.TrimSpace(edited)
 			validateCommitMessageLength(currentMessage)
+		case actionEditFieldsOption:
+			edited, editErr := editCommitFields(types.ParseCommitMessage(currentMessage))
+			if editErr != nil {
+				pterm.Error.Printf("Failed to edit commit message: %v\n", editErr)
+				continue
+			}
+			if strings.TrimSpace(edited.Subject) == "" {
+				pterm.Warning.Println("Subject is empty; keeping previous message.")
+				continue
+			}
+			currentMessage = edited.String()
+			validateCommitMessageLength(currentMessage)
 		case actionExitOption:
 			pterm.Info.Println("Exiting without copying commit message.")
 			return
@@ -268,11 +453,27 @@ interactionLoop:
 		return
 	}
 
+	if options.Reword != "" {
+		pterm.Println()
+		spinner, _ := pterm.DefaultSpinner.Start("Rewording commit...")
+		if err := git.Reword(currentDir, rewriting, finalMessage, !options.NoVerify); err != nil {
+			if spinner != nil {
+				spinner.Fail("Reword failed")
+			}
+			pterm.Error.Printf("Failed to reword the commit: %v\n", err)
+			return
+		}
+		if spinner != nil {
+			spinner.Success("Commit reworded!")
+		}
+		return
+	}
+
 	pterm.Println()
 	display.ShowChangesPreview(fileStats)
 
 	// Auto-commit if flag is set (cross-platform compatible)
-	if autoCommit && !dryRun {
+	if options.AutoCommit && !options.DryRun {
 		pterm.Println()
 		spinner, err := pterm.DefaultSpinner.
 			WithSequence("⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏").
@@ -282,12 +483,9 @@ interactionLoop:
 			return
 		}
 
-		cmd := exec.Command("git", "commit", "-m", finalMessage)
-		cmd.Dir = currentDir
-		// Ensure git command works across all platforms
-		cmd.Env = os.Environ()
-
-		output, err := cmd.CombinedOutput()
+		// The explicit scope, not the resolved one: ScopeAuto commits only
+		// the index, and commitScope made sure that is what was described.
+		output, err := commitChanges(currentDir, options.Scope, finalMessage, options.Amend)
 		if err != nil {
 			spinner.Fail("Commit failed")
 			pterm.Error.Printf("Failed to commit: %v\n", err)
@@ -305,55 +503,317 @@ interactionLoop:
 }
 
 type styleOption struct {
+	// Name identifies the preset in config.json.
+	Name        string
 	Label       string
 	Instruction string
+	// Sampling overrides the provider's sampling settings for the preset.
+	Sampling types.Sampling
+}
+
+// options returns the generation options of the preset, or nil when it
+// keeps the defaults.
+func (s styleOption) options() *types.GenerationOptions {
+	if strings.TrimSpace(s.Instruction) == "" && s.Sampling.IsZero() {
+		return nil
+	}
+	return &types.GenerationOptions{StyleInstruction: s.Instruction, Sampling: s.Sampling}
+}
+
+// loadStylePresets returns the style presets with the sampling settings
+// saved for them in config.json.
+func loadStylePresets() []styleOption {
+	presets := slices.Clone(stylePresets)
+	cfg, err := store.ListSavedModels()
+	if err != nil {
+		return presets
+	}
+	for i := range presets {
+		presets[i].Sampling = cfg.StyleSampling[presets[i].Name]
+	}
+	return presets
 }
 
 const (
 	actionAcceptOption     = "Accept and copy commit message"
 	actionRegenerateOption = "Regenerate with different tone/style"
 	actionEditOption       = "Edit message in editor"
+	actionEditFieldsOption = "Edit individual fields"
 	actionExitOption       = "Discard and exit"
 	customStyleOption      = "Custom instructions (enter your own)"
 	styleBackOption        = "Back to actions"
 )
 
 var (
-	actionOptions = []string{actionAcceptOption, actionRegenerateOption, actionEditOption, actionExitOption}
+	actionOptions = []string{actionAcceptOption, actionRegenerateOption, actionEditOption, actionEditFieldsOption, actionExitOption}
 	stylePresets  = []styleOption{
-		{Label: "Concise conventional (default)", Instruction: ""},
-		{Label: "Detailed summary (adds bullet list)", Instruction: "Produce a conventional commit subject line followed by a blank line and bullet points summarizing the key changes."},
-		{Label: "Casual tone", Instruction: "Write the commit message in a friendly, conversational tone while still clearly explaining the changes."},
-		{Label: "Bug fix emphasis", Instruction: "Highlight the bug being fixed, reference the root cause when possible, and describe the remedy in the body."},
+		{Name: "default", Label: "Concise conventional (default)", Instruction: ""},
+		{Name: "detailed", Label: "Detailed summary (adds bullet list)", Instruction: "Produce a conventional commit subject line followed by a blank line and bullet points summarizing the key changes."},
+		{Name: "casual", Label: "Casual tone", Instruction: "Write the commit message in a friendly, conversational tone while still clearly explaining the changes."},
+		{Name: "bugfix", Label: "Bug fix emphasis", Instruction: "Highlight the bug being fixed, reference the root cause when possible, and describe the remedy in the body."},
 	}
 	errSelectionCancelled = errors.New("selection cancelled")
 )
 
-// resolveOllamaConfig returns the URL and model for Ollama, using environment variables as fallbacks
-func resolveOllamaConfig(apiKey string) (url, model string) {
-	url = apiKey
+// commitArgs returns the git commands that commit the changes of scope
+// with message, amending HEAD when amend is set. Only ScopeAll and
+// ScopeUntracked stage anything; the other scopes commit the index.
+func commitArgs(scope types.ChangeScope, message string, amend bool) [][]string {
+	commit := []string{"commit"}
+	if amend {
+		commit = append(commit, "--amend")
+	}
+
+	switch scope {
+	case types.ScopeAll:
+		return [][]string{append(commit, "-a", "-m", message)}
+	case types.ScopeUntracked:
+		return [][]string{{"add", "--all"}, append(commit, "-m", message)}
+	default:
+		return [][]string{append(commit, "-m", message)}
+	}
+}
+
+// commitChanges commits the changes of scope in dir with message and
+// returns the combined output of git.
+func commitChanges(dir string, scope types.ChangeScope, message string, amend bool) ([]byte, error) {
+	var output []byte
+	for _, args := range commitArgs(scope, message, amend) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		// Ensure git command works across all platforms
+		cmd.Env = os.Environ()
+
+		out, err := cmd.CombinedOutput()
+		output = append(output, out...)
+		if err != nil {
+			return output, err
+		}
+	}
+	return output, nil
+}
+
+// resolveOllamaURL returns the URL for Ollama, using the environment variable as fallback
+func resolveOllamaURL(apiKey string) string {
+	url := apiKey
 	if strings.TrimSpace(url) == "" {
 		url = os.Getenv("OLLAMA_URL")
 		if url == "" {
-			url = "http://localhost:11434/api/generate"
+			url = ollama.DefaultURL
 		}
 	}
-	model = os.Getenv("OLLAMA_MODEL")
+	return url
+}
+
+// providerLabel renders a provider name together with its model, if known.
+func providerLabel(provider types.LLMProvider, model string) string {
 	if model == "" {
-		model = "llama3.1"
+		return provider.String()
 	}
-	return url, model
+	return fmt.Sprintf("%s (%s)", provider.String(), model)
 }
 
-func generateMessage(ctx context.Context, provider llm.Provider, changes string, opts *types.GenerationOptions) (string, error) {
+// newProviderChain builds the default provider. When a fallback list is
+// configured the default and the fallback providers are chained, primary
+// first, in an llm.FallbackProvider; fallback providers that cannot be
+// created are skipped with a warning.
+func newProviderChain(Store *store.StoreMethods, primary *store.LLMProvider, config *types.Config) (llm.Provider, error) {
+	primaryProvider, primaryErr := llm.NewProvider(primary.LLM, llm.ProviderOptions{
+		Credential: primary.APIKey,
+		Config:     config,
+		Settings:   primary.Settings,
+	})
+
+	savedModels, err := store.ListSavedModels()
+	if err != nil || len(savedModels.Fallback) == 0 {
+		return primaryProvider, primaryErr
+	}
+
+	var chain []llm.Provider
+	if primaryErr == nil {
+		chain = append(chain, primaryProvider)
+	} else if !llm.ShouldFallback(primaryErr) {
+		return nil, primaryErr
+	}
+
+	for _, name := range savedModels.Fallback {
+		if name == primary.LLM {
+			continue
+		}
+		saved, err := Store.LoadLLM(name)
+		if err != nil {
+			pterm.Warning.Printf("Skipping fallback %s: %v\n", name.String(), err)
+			continue
+		}
+		provider, err := llm.NewProvider(name, llm.ProviderOptions{
+			Credential: saved.APIKey,
+			Config:     config,
+			Settings:   saved.Settings,
+		})
+		if err != nil {
+			pterm.Warning.Printf("Skipping fallback %s: %v\n", name.String(), err)
+			continue
+		}
+		chain = append(chain, provider)
+	}
+
+	switch {
+	case len(chain) == 0:
+		return nil, primaryErr
+	case primaryErr != nil:
+		pterm.Warning.Printf("%s is unavailable (%v); using the fallback providers.\n", primary.LLM.String(), primaryErr)
+	case len(chain) == 1:
+		return primaryProvider, nil
+	}
+
+	return llm.NewFallbackProvider(chain...)
+}
+
+func generateMessage(ctx context.Context, provider llm.Provider, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
 	if err := apiRateLimiter.Wait(ctx); err != nil {
-		return "", err
+		return types.GenerationResult{}, err
+	}
+	return provider.Generate(ctx, changes, opts)
+}
+
+// generationContext derives the context for a single generation request. It is
+// cancelled when the user presses Ctrl-C or, if timeout is positive, once the
+// timeout elapses.
+func generationContext(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt)
+	if timeout <= 0 {
+		return ctx, stop
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// generateWithProgress runs a generation while keeping the user informed:
+// streaming providers render the message live as it arrives, the others
+// show a spinner until the full message is ready. If the request is aborted
+// by Ctrl-C or the timeout, the context error is returned instead of the
+// provider's transport error.
+func generateWithProgress(ctx context.Context, provider llm.Provider, store *store.StoreMethods, providerType types.LLMProvider, changes string, opts *types.GenerationOptions, timeout time.Duration, progressText, successText, failText string) (string, error) {
+	ctx, cancel := generationContext(ctx, timeout)
+	defer cancel()
+
+	// onFallback is pointed at the active progress display below.
+	var onFallback func(note string)
+	var failed []llm.FallbackAttempt
+	var answeredBy types.LLMProvider
+	ctx = llm.WithFallbackTrace(ctx, &llm.FallbackTrace{
+		AttemptStart: func(next types.LLMProvider, index int) {
+			if index > 0 && onFallback != nil {
+				onFallback(fmt.Sprintf("Trying fallback provider %s...", next.String()))
+			}
+		},
+		AttemptDone: func(attempt llm.FallbackAttempt) {
+			if attempt.Err != nil {
+				failed = append(failed, attempt)
+				return
+			}
+			answeredBy = attempt.Provider
+		},
+	})
+
+	if llm.Streams(provider) {
+		pterm.Info.Println(progressText)
+		if preview, err := display.StartStreamingCommitMessage(); err == nil {
+			onFallback = preview.Restart
+			message, genErr := generateMessageWithCache(ctx, provider, store, providerType, changes, opts, preview.Append)
+			preview.Stop()
+			showFallbackAttempts(failed, answeredBy)
+			if genErr != nil {
+				pterm.Error.Println(failText)
+				return "", contextError(ctx, genErr)
+			}
+			pterm.Success.Println(successText)
+			return message, nil
+		}
+	}
+
+	spinner, err := pterm.DefaultSpinner.
+		WithSequence("⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏").
+		Start(progressText)
+	if err != nil {
+		return "", fmt.Errorf("failed to start spinner: %w", err)
+	}
+	onFallback = spinner.UpdateText
+
+	message, err := generateMessageWithCache(ctx, provider, store, providerType, changes, opts, nil)
+	if err != nil {
+		spinner.Fail(failText)
+		showFallbackAttempts(failed, answeredBy)
+		return "", contextError(ctx, err)
+	}
+
+	spinner.Success(successText)
+	showFallbackAttempts(failed, answeredBy)
+	return message, nil
+}
+
+// showFallbackAttempts reports the providers that failed before answeredBy
+// produced the message. Nothing is shown when the first provider answered.
+func showFallbackAttempts(failed []llm.FallbackAttempt, answeredBy types.LLMProvider) {
+	if len(failed) == 0 {
+		return
+	}
+	for _, attempt := range failed {
+		pterm.Warning.Printf("%s failed: %v\n", attempt.Provider.String(), attempt.Err)
+	}
+	if answeredBy != "" {
+		pterm.Info.Printf("Commit message generated by fallback provider %s.\n", answeredBy.String())
+	}
+}
+
+// contextError prefers the context's error over err once the context is done,
+// so callers can tell cancellations and timeouts apart from provider failures.
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// generateFromProvider streams through onChunk when both the provider and
+// the caller support it, parsing the streamed text into the fields of the
+// message afterwards. Otherwise it prefers structured output, rendering the
+// returned fields as a commit message, and falls back to a blocking
+// Generate call.
+func generateFromProvider(ctx context.Context, provider llm.Provider, changes string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	if streamer, ok := provider.(llm.StreamingProvider); ok && onChunk != nil && llm.Streams(provider) {
+		result, err := streamer.GenerateStream(ctx, changes, opts, onChunk)
+		if err != nil {
+			return types.GenerationResult{}, err
+		}
+		commit := types.ParseCommitMessage(result.Message)
+		result.Commit = &commit
+		return result, nil
+	}
+	if structured, ok := provider.(llm.StructuredProvider); ok && llm.Structured(provider) {
+		result, err := structured.GenerateStructured(ctx, changes, opts)
+		if err != nil {
+			return types.GenerationResult{}, err
+		}
+		if result.Commit != nil {
+			result.Message = result.Commit.String()
+		}
+		if onChunk != nil {
+			onChunk(result.Message)
+		}
+		return result, nil
 	}
 	return provider.Generate(ctx, changes, opts)
 }
 
 // generateMessageWithCache generates a commit message with caching support.
-func generateMessageWithCache(ctx context.Context, provider llm.Provider, store *store.StoreMethods, providerType types.LLMProvider, changes string, opts *types.GenerationOptions) (string, error) {
+// When onChunk is non-nil and the provider can stream, partial output is
+// forwarded to it as it arrives.
+func generateMessageWithCache(ctx context.Context, provider llm.Provider, store *store.StoreMethods, providerType types.LLMProvider, changes string, opts *types.GenerationOptions, onChunk func(string)) (string, error) {
 	startTime := time.Now()
 	
 	// Determine if this is a first attempt (cache check eligible)
@@ -361,8 +821,8 @@ func generateMessageWithCache(ctx context.Context, provider llm.Provider, store
 	
 	// Check cache first (only for first attempt to avoid caching regenerations)
 	if isFirstAttempt {
-		if cachedEntry, found := store.GetCachedMessage(providerType, changes, opts); found {
-			pterm.Info.Printf("Using cached commit message (saved $%.4f)\n", cachedEntry.Cost)
+		if cachedEntry, found := store.GetCachedMessage(providerType, llm.ProviderModel(provider), changes, opts); found {
+			pterm.Info.Printf("Using cached commit message (saved $%.4f)\n", store.GetCacheManager().EntryCost(cachedEntry))
 			
 			// Record cache hit event
 			event := &types.GenerationEvent{
@@ -385,51 +845,75 @@ func generateMessageWithCache(ctx context.Context, provider llm.Provider, store
 		}
 	}
 
-	// Generate new message
-	message, err := provider.Generate(ctx, changes, opts)
-	generationTime := float64(time.Since(startTime).Nanoseconds()) / 1e6 // Convert to milliseconds
-	
-	// Estimate tokens and cost
-	inputTokens := estimateTokens(types.BuildCommitPrompt(changes, opts))
-	outputTokens := 100 // Estimate output tokens
-	cost := estimateCost(providerType, inputTokens, outputTokens)
-	
-	// Record generation event
-	event := &types.GenerationEvent{
-		Provider:       providerType,
-		Success:        err == nil,
-		GenerationTime: generationTime,
-		TokensUsed:     inputTokens + outputTokens,
-		Cost:           cost,
-		CacheHit:       false,
-		CacheChecked:   isFirstAttempt, // Only first attempts check cache
-		Timestamp:      time.Now().UTC().Format(time.RFC3339),
-	}
-	
-	if err != nil {
-		event.ErrorMessage = err.Error()
+	// Generate new message, tracing every provider a fallback chain tries
+	var attempts []llm.FallbackAttempt
+	traceCtx := llm.WithFallbackTrace(ctx, &llm.FallbackTrace{
+		AttemptDone: func(attempt llm.FallbackAttempt) {
+			attempts = append(attempts, attempt)
+		},
+	})
+	result, err := generateFromProvider(traceCtx, provider, changes, opts, onChunk)
+	if len(attempts) == 0 {
+		attempts = append(attempts, llm.FallbackAttempt{Provider: providerType, Model: llm.ProviderModel(provider), Duration: time.Since(startTime), Err: err})
 	}
-	
-	// Record the event regardless of success/failure
-	if statsErr := store.RecordGenerationEvent(event); statsErr != nil {
-		// Log the error but don't fail the operation
-		fmt.Printf("Warning: Failed to record usage statistics: %v\n", statsErr)
+
+	// Prefer the token counts reported by the provider and only estimate
+	// them for backends that do not return usage.
+	usage := result.Usage
+	if err == nil && usage == nil {
+		usage = types.NewUsageInfo(estimateTokens(types.BuildPrompt(changes, opts).String()), estimateTokens(result.Message))
 	}
-	
+	recordGenerationAttempts(ctx, store, attempts, usage, isFirstAttempt)
+
 	if err != nil {
 		return "", err
 	}
 
 	// Cache the result (only for first attempt)
 	if isFirstAttempt {
-		// Store in cache
-		if cacheErr := store.SetCachedMessage(providerType, changes, opts, message, cost, nil); cacheErr != nil {
+		// Store in cache under the provider and model that produced the
+		// message, which is the last attempt of a fallback chain.
+		answered := attempts[len(attempts)-1]
+		if cacheErr := store.SetCachedMessage(answered.Provider, answered.Model, changes, opts, result.Message, usage); cacheErr != nil {
 			// Log cache error but don't fail the generation
 			fmt.Printf("Warning: Failed to cache message: %v\n", cacheErr)
 		}
 	}
 
-	return message, nil
+	return result.Message, nil
+}
+
+// recordGenerationAttempts records one generation event per provider attempt.
+// Only the attempt that produced the message is charged for usage; the
+// statistics price it by provider and model.
+func recordGenerationAttempts(ctx context.Context, store *store.StoreMethods, attempts []llm.FallbackAttempt, usage *types.UsageInfo, cacheChecked bool) {
+	for i, attempt := range attempts {
+		event := &types.GenerationEvent{
+			Provider:       attempt.Provider,
+			Model:          attempt.Model,
+			Success:        attempt.Err == nil,
+			GenerationTime: float64(attempt.Duration.Nanoseconds()) / 1e6, // Convert to milliseconds
+			CacheHit:       false,
+			CacheChecked:   cacheChecked && i == 0, // Only first attempts check cache
+			Timestamp:      time.Now().UTC().Format(time.RFC3339),
+		}
+
+		if attempt.Err != nil {
+			event.ErrorMessage = attempt.Err.Error()
+			event.Cancelled = i == len(attempts)-1 && errors.Is(ctx.Err(), context.Canceled)
+		} else if usage != nil {
+			event.TokensUsed = usage.TotalTokens
+			event.PromptTokens = usage.PromptTokens
+			event.CompletionTokens = usage.CompletionTokens
+			event.CachedPromptTokens = usage.CachedPromptTokens
+		}
+
+		// Record the event regardless of success/failure
+		if statsErr := store.RecordGenerationEvent(event); statsErr != nil {
+			// Log the error but don't fail the operation
+			fmt.Printf("Warning: Failed to record usage statistics: %v\n", statsErr)
+		}
+	}
 }
 
 func promptActionSelection() (string, error) {
@@ -439,10 +923,10 @@ func promptActionSelection() (string, error) {
 		Show()
 }
 
-func promptStyleSelection(currentLabel string, currentOpts *types.GenerationOptions) (*types.GenerationOptions, string, error) {
-	options := make([]string, 0, len(stylePresets)+3)
+func promptStyleSelection(presets []styleOption, currentLabel string, currentOpts *types.GenerationOptions) (*types.GenerationOptions, string, error) {
+	options := make([]string, 0, len(presets)+3)
 	foundCurrent := false
-	for _, preset := range stylePresets {
+	for _, preset := range presets {
 		options = append(options, preset.Label)
 		if preset.Label == currentLabel {
 			foundCurrent = true
@@ -479,12 +963,9 @@ func promptStyleSelection(currentLabel string, currentOpts *types.GenerationOpti
 		}
 		return &types.GenerationOptions{StyleInstruction: text}, formatCustomStyleLabel(text), nil
 	default:
-		for _, preset := range stylePresets {
+		for _, preset := range presets {
 			if choice == preset.Label {
-				if strings.TrimSpace(preset.Instruction) == "" {
-					return nil, preset.Label, nil
-				}
-				return &types.GenerationOptions{StyleInstruction: preset.Instruction}, preset.Label, nil
+				return preset.options(), preset.Label, nil
 			}
 		}
 		if currentOpts != nil && choice == currentLabel {
@@ -586,6 +1067,16 @@ func withAttempt(styleOpts *types.GenerationOptions, attempt int) *types.Generat
 	return &clone
 }
 
+// displayGenerationError reports a failed generation, explaining timeouts
+// before falling back to the provider-specific hints.
+func displayGenerationError(provider types.LLMProvider, timeout time.Duration, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		pterm.Error.Printf("%s did not respond within %s. Retry or raise the limit with --timeout.\n", provider, timeout)
+		return
+	}
+	displayProviderError(provider, err)
+}
+
 func displayProviderError(provider types.LLMProvider, err error) {
 	if errors.Is(err, llm.ErrMissingCredential) {
 		displayMissingCredentialHint(provider)
@@ -604,7 +1095,21 @@ func displayProviderError(provider types.LLMProvider, err error) {
 	case types.ProviderGrok:
 		pterm.Error.Printf("Grok API error: %v. Check your GROK_API_KEY environment variable or run: commit llm setup\n", err)
 	case types.ProviderOllama:
+		if errors.Is(err, ollama.ErrModelNotPulled) {
+			pterm.Error.Printf("%v\n", err)
+			return
+		}
 		pterm.Error.Printf("Ollama error: %v. Verify the Ollama service URL or run: commit llm setup\n", err)
+	case types.ProviderOpenAICompatible:
+		pterm.Error.Printf("OpenAI-compatible server error: %v. Verify the base URL and model or run: commit llm setup\n", err)
+	case types.ProviderAzureOpenAI:
+		pterm.Error.Printf("Azure OpenAI error: %v. Verify the resource endpoint, deployment, api-version and credential or run: commit llm setup\n", err)
+	case types.ProviderBedrock:
+		pterm.Error.Printf("Bedrock error: %v. Verify the region, model ID and model access of your AWS account or run: commit llm setup\n", err)
+	case types.ProviderVertexAI:
+		pterm.Error.Printf("Vertex AI error: %v. Verify the project, location, model and the roles of your credentials or run: commit llm setup\n", err)
+	case types.ProviderCassette:
+		pterm.Error.Printf("Cassette replay error: %v. Record the cassette again with %s=%s\n", err, cassette.EnvMode, cassette.ModeRecord)
 	default:
 		pterm.Error.Printf("LLM error: %v\n", err)
 	}
@@ -624,13 +1129,23 @@ func displayMissingCredentialHint(provider types.LLMProvider) {
 		pterm.Error.Println("Grok requires an API key. Run: commit llm setup or set GROK_API_KEY.")
 	case types.ProviderOllama:
 		pterm.Error.Println("Ollama requires a reachable service URL. Run: commit llm setup or set OLLAMA_URL.")
+	case types.ProviderOpenAICompatible:
+		pterm.Error.Println("OpenAI-compatible servers require a base URL. Run: commit llm setup or set OPENAI_COMPATIBLE_BASE_URL.")
+	case types.ProviderAzureOpenAI:
+		pterm.Error.Println("Azure OpenAI requires a resource endpoint, a deployment and an API key or token. Run: commit llm setup or set AZURE_OPENAI_ENDPOINT, AZURE_OPENAI_DEPLOYMENT and AZURE_OPENAI_API_KEY.")
+	case types.ProviderBedrock:
+		pterm.Error.Println("Bedrock requires AWS credentials. Set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or choose a profile from ~/.aws/credentials with commit llm setup or AWS_PROFILE.")
+	case types.ProviderVertexAI:
+		pterm.Error.Println("Vertex AI requires a Google Cloud project and a service account key or Application Default Credentials. Run: commit llm setup, or set GOOGLE_CLOUD_PROJECT and GOOGLE_APPLICATION_CREDENTIALS.")
+	case types.ProviderCassette:
+		pterm.Error.Printf("Cassette requires a recorded cassette file. Run: commit llm setup or set %s.\n", cassette.EnvPath)
 	default:
 		pterm.Error.Printf("%s is missing credentials. Run: commit llm setup.\n", provider)
 	}
 }
 
 // displayDryRunInfo shows what would be sent to the LLM without making an API call
-func displayDryRunInfo(provider types.LLMProvider, config *types.Config, changes string, apiKey string, verbose bool) {
+func displayDryRunInfo(provider types.LLMProvider, config *types.Config, changes string, apiKey string, settings types.ProviderSettings, opts *types.GenerationOptions, verbose bool) {
 	pterm.DefaultHeader.WithFullWidth().
 		WithBackgroundStyle(pterm.NewStyle(pterm.BgBlue)).
 		WithTextStyle(pterm.NewStyle(pterm.FgWhite, pterm.Bold)).
@@ -645,27 +1160,133 @@ func displayDryRunInfo(provider types.LLMProvider, config *types.Config, changes
 	providerInfo := [][]string{
 		{"Provider", provider.String()},
 	}
+	if model := llm.ResolveModel(provider, settings); model != "" {
+		providerInfo = append(providerInfo, []string{"Model", model})
+	}
 
 	// Add provider-specific info
 	switch provider {
 	case types.ProviderOllama:
-		url, model := resolveOllamaConfig(apiKey)
-		providerInfo = append(providerInfo, []string{"Ollama URL", url})
-		providerInfo = append(providerInfo, []string{"Model", model})
+		chatURL, err := ollama.ChatURL(resolveOllamaURL(apiKey))
+		if err != nil {
+			chatURL = err.Error()
+		}
+		providerInfo = append(providerInfo, []string{"Ollama URL", chatURL})
+		if opts := settings.Ollama; opts != nil {
+			if opts.Temperature != nil {
+				providerInfo = append(providerInfo, []string{"Temperature", fmt.Sprintf("%g", *opts.Temperature)})
+			}
+			if opts.NumCtx > 0 {
+				providerInfo = append(providerInfo, []string{"Context Window (num_ctx)", fmt.Sprintf("%d", opts.NumCtx)})
+			}
+			if opts.NumPredict != 0 {
+				providerInfo = append(providerInfo, []string{"Max Tokens (num_predict)", fmt.Sprintf("%d", opts.NumPredict)})
+			}
+			if opts.KeepAlive != "" {
+				providerInfo = append(providerInfo, []string{"Keep Alive", opts.KeepAlive})
+			}
+		}
+	case types.ProviderOpenAICompatible:
+		providerInfo = append(providerInfo, []string{"API Endpoint", openaicompat.ChatCompletionsURL(settings.BaseURL)})
+		providerInfo = append(providerInfo, []string{"API Key", maskAPIKey(apiKey)})
+		headerNames := make([]string, 0, len(settings.Headers))
+		for name := range settings.Headers {
+			headerNames = append(headerNames, name)
+		}
+		sort.Strings(headerNames)
+		for _, name := range headerNames {
+			providerInfo = append(providerInfo, []string{"Header", name + ": [REDACTED]"})
+		}
+	case types.ProviderAzureOpenAI:
+		endpoint := azure.Endpoint{ResourceURL: settings.BaseURL, Deployment: llm.ResolveModel(provider, settings)}
+		auth := azure.AuthAPIKey
+		if settings.Azure != nil {
+			endpoint.APIVersion = settings.Azure.APIVersion
+			if settings.Azure.Auth != "" {
+				auth = settings.Azure.Auth
+			}
+		}
+		providerInfo = append(providerInfo, []string{"API Endpoint", azure.ChatCompletionsURL(endpoint)})
+		providerInfo = append(providerInfo, []string{"Auth", auth})
+		providerInfo = append(providerInfo, []string{"API Key", maskAPIKey(apiKey)})
+	case types.ProviderBedrock:
+		var profile, region string
+		if settings.Bedrock != nil {
+			profile = settings.Bedrock.Profile
+			region = settings.Bedrock.Region
+		}
+		endpoint := bedrock.Endpoint{
+			Region:  bedrock.ResolveRegion(region, profile),
+			ModelID: llm.ResolveModel(provider, settings),
+			URL:     settings.BaseURL,
+		}
+		if profile == "" {
+			profile = "environment or AWS_PROFILE"
+		}
+		providerInfo = append(providerInfo, []string{"Region", endpoint.Region})
+		providerInfo = append(providerInfo, []string{"API Endpoint", bedrock.ConverseURL(endpoint)})
+		providerInfo = append(providerInfo, []string{"AWS Credentials", profile})
+	case types.ProviderVertexAI:
+		endpoint := vertex.Endpoint{Location: vertex.DefaultLocation, Model: llm.ResolveModel(provider, settings), URL: settings.BaseURL}
+		auth := vertex.AuthServiceAccount
+		if settings.Vertex != nil {
+			endpoint.Project = settings.Vertex.Project
+			if settings.Vertex.Location != "" {
+				endpoint.Location = settings.Vertex.Location
+			}
+			if settings.Vertex.Auth != "" {
+				auth = settings.Vertex.Auth
+			}
+		}
+		if endpoint.Project == "" {
+			endpoint.Project = os.Getenv("GOOGLE_CLOUD_PROJECT")
+		}
+		providerInfo = append(providerInfo, []string{"API Endpoint", vertex.ModelURL(endpoint) + ":generateContent"})
+		providerInfo = append(providerInfo, []string{"Credentials", auth})
+	case types.ProviderCassette:
+		path := apiKey
+		if path == "" {
+			path = os.Getenv(cassette.EnvPath)
+		}
+		providerInfo = append(providerInfo, []string{"Cassette File", path})
+		if recorded, err := cassette.Load(path); err == nil {
+			providerInfo = append(providerInfo, []string{"Recorded Provider", recorded.Provider.String()})
+			providerInfo = append(providerInfo, []string{"Recorded Model", recorded.Model})
+		}
 	case types.ProviderGrok:
 		providerInfo = append(providerInfo, []string{"API Endpoint", config.GrokAPI})
 		providerInfo = append(providerInfo, []string{"API Key", maskAPIKey(apiKey)})
 	default:
+		if p, ok := llm.LookupPlugin(provider); ok {
+			providerInfo = append(providerInfo, []string{"Plugin Command", strings.Join(p.Command, " ")})
+		}
 		providerInfo = append(providerInfo, []string{"API Key", maskAPIKey(apiKey)})
 	}
 
+	// Settings made for the run win over the provider's
+	var sampling types.Sampling
+	if settings.Sampling != nil {
+		sampling = *settings.Sampling
+	}
+	providerInfo = append(providerInfo, samplingRows(sampling.Merge(types.SamplingOf(opts)))...)
+
+	// Providers with structured output receive the JSON instructions, unless
+	// they stream, which renders the message as text
+	prompt := types.BuildPrompt(changes, opts).String()
+	outputFormat := "Text"
+	if instance, err := llm.NewProvider(provider, llm.ProviderOptions{Credential: apiKey, Config: config, Settings: settings}); err == nil {
+		if llm.Structured(instance) && !llm.Streams(instance) {
+			prompt = types.BuildStructuredPrompt(changes, opts).String()
+			outputFormat = "Structured (JSON)"
+		}
+	}
+	providerInfo = append(providerInfo, []string{"Output Format", outputFormat})
+
 	pterm.DefaultTable.WithHasHeader(false).WithData(providerInfo).Render()
 
 	pterm.Println()
 
-	// Build and display the prompt
-	opts := &types.GenerationOptions{Attempt: 1}
-	prompt := types.BuildCommitPrompt(changes, opts)
+	// Display the prompt
 
 	pterm.DefaultSection.Println("Prompt That Would Be Sent")
 	pterm.Println()
@@ -686,7 +1307,8 @@ func displayDryRunInfo(provider types.LLMProvider, config *types.Config, changes
 	inputTokens := estimateTokens(prompt)
 	// Estimate output tokens (typically 50-200 for commit messages)
 	outputTokens := 100
-	estimatedCost := estimateCost(provider, inputTokens, outputTokens)
+	model := llm.ResolveModel(provider, settings)
+	rate, priced := pricing.Default().Lookup(provider, model)
 	minTime, maxTime := estimateProcessingTime(provider)
 
 	statsData := [][]string{
@@ -697,7 +1319,19 @@ func displayDryRunInfo(provider types.LLMProvider, config *types.Config, changes
 		{"Estimated Total Tokens", fmt.Sprintf("%d", inputTokens+outputTokens)},
 	}
 
-	if provider != types.ProviderOllama {
+	capabilities := llm.ResolveCapabilities(provider, apiKey, settings)
+	statsData = append(statsData, []string{"Context Window", fmt.Sprintf("%d tokens", capabilities.ContextWindow)})
+	if verbose {
+		budget := capabilities.DiffBudget(promptOverhead())
+		statsData = append(statsData, []string{"Diff Budget", fmt.Sprintf("%d tokens, %d left after the diff", budget, budget-estimateTokens(changes))})
+	}
+
+	switch {
+	case !priced:
+		statsData = append(statsData, []string{"Estimated Cost", fmt.Sprintf("Unknown, add a price for the model to %s", pricing.FileName)})
+	case rate != pricing.Rate{}:
+		// Local models are free and show no cost.
+		estimatedCost := rate.Cost(types.NewUsageInfo(inputTokens, outputTokens))
 		statsData = append(statsData, []string{"Estimated Cost", fmt.Sprintf("$%.4f", estimatedCost)})
 	}
 
@@ -731,47 +1365,60 @@ func maskAPIKey(apiKey string) string {
 	return apiKey[:4] + strings.Repeat("*", len(apiKey)-8) + apiKey[len(apiKey)-4:]
 }
 
+// charsPerToken is the rough number of characters in a token.
+const charsPerToken = 4
+
 // estimateTokens provides a rough estimate of token count (1 token ≈ 4 characters)
 func estimateTokens(text string) int {
-	return len(text) / 4
+	return len(text) / charsPerToken
 }
 
-// estimateCost calculates the estimated cost for a given provider and token count
-func estimateCost(provider types.LLMProvider, inputTokens, outputTokens int) float64 {
-	// Pricing per 1M tokens (as of 2024, approximate)
-	switch provider {
-	case types.ProviderOpenAI:
-		// GPT-4o pricing: ~$2.50/M input, ~$10/M output
-		return float64(inputTokens)*2.50/1000000 + float64(outputTokens)*10.00/1000000
-	case types.ProviderClaude:
-		// Claude pricing: ~$3/M input, ~$15/M output
-		return float64(inputTokens)*3.00/1000000 + float64(outputTokens)*15.00/1000000
-	case types.ProviderGemini:
-		// Gemini pricing: ~$0.15/M input, ~$0.60/M output
-		return float64(inputTokens)*0.15/1000000 + float64(outputTokens)*0.60/1000000
-	case types.ProviderGrok:
-		// Grok pricing: ~$5/M input, ~$15/M output
-		return float64(inputTokens)*5.00/1000000 + float64(outputTokens)*15.00/1000000
-	case types.ProviderGroq:
-		// Groq pricing: similar to OpenAI ~$2.50/M input, ~$10/M output
-		return float64(inputTokens)*2.50/1000000 + float64(outputTokens)*10.00/1000000
-	case types.ProviderOllama:
-		// Local model - no cost
-		return 0.0
-	default:
-		return 0.0
+// promptOverhead estimates the tokens the prompt takes besides the diff. It
+// assumes the larger structured prompt, a regeneration and a style
+// instruction, so a truncated diff fits whichever prompt is sent.
+func promptOverhead() int {
+	longestStyle := ""
+	for _, preset := range stylePresets {
+		if len(preset.Instruction) > len(longestStyle) {
+			longestStyle = preset.Instruction
+		}
+	}
+	opts := &types.GenerationOptions{Attempt: 2, StyleInstruction: longestStyle}
+	return estimateTokens(types.BuildStructuredPrompt("", opts).String())
+}
+
+// truncateDiff cuts changes to at most maxChars bytes, keeping whole lines.
+func truncateDiff(changes string, maxChars int) string {
+	if len(changes) <= maxChars {
+		return changes
 	}
+
+	lines := strings.Split(changes, "\n")
+	kept := make([]string, 0, len(lines))
+	total := 0
+	for _, line := range lines {
+		// +1 for the newline joining it to the previous line
+		if total+len(line)+1 > maxChars {
+			break
+		}
+		kept = append(kept, line)
+		total += len(line) + 1
+	}
+	return strings.Join(kept, "\n")
 }
 
 // estimateProcessingTime returns estimated processing time in seconds for a provider
 func estimateProcessingTime(provider types.LLMProvider) (minTime, maxTime int) {
 	switch provider {
-	case types.ProviderOllama:
+	case types.ProviderOllama, types.ProviderOpenAICompatible:
 		// Local models take longer
 		return 10, 30
-	case types.ProviderOpenAI, types.ProviderClaude, types.ProviderGemini, types.ProviderGrok, types.ProviderGroq:
+	case types.ProviderOpenAI, types.ProviderClaude, types.ProviderGemini, types.ProviderGrok, types.ProviderGroq, types.ProviderAzureOpenAI, types.ProviderBedrock, types.ProviderVertexAI:
 		// Cloud providers are faster
 		return 5, 15
+	case types.ProviderCassette:
+		// Replays are read from disk
+		return 0, 1
 	default:
 		return 5, 15
 	}
@@ -2,8 +2,14 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/dfanso/commit-msg/pkg/types"
 )
@@ -13,8 +19,8 @@ type FakeProvider struct{}
 
 func (f FakeProvider) Name() types.LLMProvider { return "fake" }
 
-func (f FakeProvider) Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (string, error) {
-	return "mock commit message", nil
+func (f FakeProvider) Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return types.GenerationResult{Message: "mock commit message"}, nil
 }
 
 func TestGenerateMessageRateLimiter(t *testing.T) {
@@ -46,3 +52,237 @@ func TestGenerateMessageRateLimiter(t *testing.T) {
 		t.Errorf("expected %d successful calls but got %d", numCalls, successCount)
 	}
 }
+
+func TestGenerationContextTimeout(t *testing.T) {
+	ctx, cancel := generationContext(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	<-ctx.Done()
+	if !errors.Is(contextError(ctx, errors.New("transport closed")), context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", ctx.Err())
+	}
+}
+
+func TestContextErrorKeepsProviderError(t *testing.T) {
+	providerErr := errors.New("quota exceeded")
+	if err := contextError(context.Background(), providerErr); err != providerErr {
+		t.Fatalf("expected provider error to be returned, got %v", err)
+	}
+}
+
+// structuredFakeProvider answers through structured output.
+type structuredFakeProvider struct{ FakeProvider }
+
+func (f structuredFakeProvider) GenerateStructured(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return types.GenerationResult{
+		Commit: &types.CommitMessage{Type: "feat", Scope: "cli", Subject: "add fields"},
+		Usage:  types.NewUsageInfo(120, 8),
+	}, nil
+}
+
+func TestGenerateFromProviderPrefersStructuredOutput(t *testing.T) {
+	var chunks []string
+	msg, err := generateFromProvider(context.Background(), structuredFakeProvider{}, "diff", nil, func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg.Message != "feat(cli): add fields" {
+		t.Fatalf("unexpected message: %q", msg.Message)
+	}
+	if msg.Usage == nil || msg.Usage.TotalTokens != 128 {
+		t.Fatalf("expected the provider usage to be kept, got %+v", msg.Usage)
+	}
+	if len(chunks) != 1 || chunks[0] != msg.Message {
+		t.Fatalf("expected the rendered message as a single chunk, got %v", chunks)
+	}
+}
+
+// streamingStructuredFakeProvider streams as well as answering through
+// structured output, as OpenAI and Claude do.
+type streamingStructuredFakeProvider struct{ structuredFakeProvider }
+
+func (f streamingStructuredFakeProvider) GenerateStream(ctx context.Context, changes string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	onChunk("feat(api)!: drop ")
+	onChunk("v1 endpoints")
+	return types.GenerationResult{Message: "feat(api)!: drop v1 endpoints"}, nil
+}
+
+func TestGenerateFromProviderStreamsBeforeStructuredOutput(t *testing.T) {
+	var chunks []string
+	msg, err := generateFromProvider(context.Background(), streamingStructuredFakeProvider{}, "diff", nil, func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("expected the message to be streamed, got chunks %v", chunks)
+	}
+	if msg.Message != "feat(api)!: drop v1 endpoints" {
+		t.Fatalf("expected the streamed text as the message, got %q", msg.Message)
+	}
+	if msg.Commit == nil || msg.Commit.Scope != "api" || !msg.Commit.Breaking {
+		t.Fatalf("expected the streamed text to be parsed, got %+v", msg.Commit)
+	}
+
+	// Without a caller to stream to, structured output is still preferred.
+	msg, err = generateFromProvider(context.Background(), streamingStructuredFakeProvider{}, "diff", nil, nil)
+	if err != nil || msg.Message != "feat(cli): add fields" {
+		t.Fatalf("expected the structured message, got %q, %v", msg.Message, err)
+	}
+}
+
+func TestMergeCandidatesTextStripsComments(t *testing.T) {
+	text := mergeCandidatesText([]string{"feat: first", "feat: second\n\nMore detail"})
+	if !strings.Contains(text, "# ----- Candidate 2 -----") {
+		t.Fatalf("expected numbered separators, got %q", text)
+	}
+
+	merged := stripCommentLines(text)
+	if merged != "feat: first\n\nfeat: second\n\nMore detail" {
+		t.Fatalf("unexpected merged text: %q", merged)
+	}
+}
+
+func TestParseCandidateChoice(t *testing.T) {
+	var index int
+	if !parseCandidateChoice("Edit candidate 3", editCandidatePrefix, &index) || index != 2 {
+		t.Fatalf("expected index 2, got %d", index)
+	}
+	if parseCandidateChoice("Edit candidate 3", acceptCandidatePrefix, &index) {
+		t.Fatal("expected a different prefix not to match")
+	}
+}
+
+func TestCandidatesUsage(t *testing.T) {
+	results := []types.GenerationResult{
+		{Message: "feat: a", Usage: types.NewUsageInfo(300, 20)},
+		{Message: "feat: b"},
+	}
+	if usage := candidatesUsage(results, "diff", nil); usage == nil || usage.TotalTokens != 320 {
+		t.Fatalf("expected the reported usage to be summed, got %+v", usage)
+	}
+
+	estimated := candidatesUsage([]types.GenerationResult{{Message: "feat: a"}, {Message: "feat: b"}}, "diff", nil)
+	if estimated == nil || estimated.PromptTokens != 2*estimateTokens(types.BuildPrompt("diff", nil).String()) {
+		t.Fatalf("expected one estimated prompt per request, got %+v", estimated)
+	}
+}
+
+func TestTruncateDiff(t *testing.T) {
+	t.Parallel()
+
+	diff := "diff --git a/a.go b/a.go\n+first line\n+second line"
+	if got := truncateDiff(diff, len(diff)); got != diff {
+		t.Fatalf("expected a diff within the limit to be kept, got %q", got)
+	}
+	if got := truncateDiff(diff, 40); got != "diff --git a/a.go b/a.go\n+first line" {
+		t.Fatalf("expected whole lines to be kept, got %q", got)
+	}
+}
+
+func TestPromptOverheadCoversPrompt(t *testing.T) {
+	t.Parallel()
+
+	prompt := types.BuildStructuredPrompt("", &types.GenerationOptions{Attempt: 1})
+	if overhead := promptOverhead(); overhead < estimateTokens(prompt.String()) {
+		t.Fatalf("overhead %d is below the prompt's %d tokens", overhead, estimateTokens(prompt.String()))
+	}
+}
+
+func TestCommitScope(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		options  CommitOptions
+		resolved types.ChangeScope
+		want     types.ChangeScope
+		wantErr  bool
+	}{
+		{name: "without --auto", options: CommitOptions{}, resolved: types.ScopeUntracked, want: types.ScopeUntracked},
+		{name: "staged changes", options: CommitOptions{AutoCommit: true}, resolved: types.ScopeStaged, want: types.ScopeStaged},
+		{name: "nothing staged", options: CommitOptions{AutoCommit: true}, resolved: types.ScopeUntracked, wantErr: true},
+		{name: "nothing staged amend", options: CommitOptions{AutoCommit: true, Amend: true}, resolved: types.ScopeUntracked, want: types.ScopeStaged},
+		{name: "explicit scope", options: CommitOptions{AutoCommit: true, Scope: types.ScopeUntracked}, resolved: types.ScopeUntracked, want: types.ScopeUntracked},
+		{name: "reword", options: CommitOptions{AutoCommit: true, Reword: "HEAD~1"}, resolved: types.ScopeUntracked, want: types.ScopeUntracked},
+	}
+	for _, tc := range cases {
+		got, err := tc.options.commitScope(tc.resolved)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Fatalf("%s: commitScope() = %q, %v; want %q (error %v)", tc.name, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+func TestCommitChangesFollowsScope(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration-style test in short mode")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git executable not available")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init")
+	git("config", "user.name", "Test User")
+	git("config", "user.email", "test@example.com")
+	write("tracked.txt", "v1\n")
+	git("add", "tracked.txt")
+	git("commit", "-m", "initial commit")
+
+	// --all commits the unstaged edit but leaves the new file alone.
+	write("tracked.txt", "v2\n")
+	write("new.txt", "new\n")
+	if output, err := commitChanges(dir, types.ScopeAll, "feat: update tracked", false); err != nil {
+		t.Fatalf("commitChanges failed: %v\n%s", err, output)
+	}
+	if got := git("show", "--name-only", "--format=", "HEAD"); got != "tracked.txt" {
+		t.Fatalf("expected only tracked.txt in the commit, got %q", got)
+	}
+
+	// --include-untracked stages the new file first.
+	if output, err := commitChanges(dir, types.ScopeUntracked, "feat: add new file", false); err != nil {
+		t.Fatalf("commitChanges failed: %v\n%s", err, output)
+	}
+	if got := git("status", "--porcelain"); got != "" {
+		t.Fatalf("expected a clean tree, got %q", got)
+	}
+
+	// Without an explicit scope only the index is committed.
+	write("tracked.txt", "v3\n")
+	if output, err := commitChanges(dir, types.ScopeAuto, "feat: nothing staged", false); err == nil {
+		t.Fatalf("expected the commit to fail with nothing staged, got:\n%s", output)
+	}
+	git("add", "tracked.txt")
+	if output, err := commitChanges(dir, types.ScopeAuto, "feat: update tracked again", false); err != nil {
+		t.Fatalf("commitChanges failed: %v\n%s", err, output)
+	}
+
+	// --amend folds the edit into HEAD and replaces its message.
+	write("new.txt", "newer\n")
+	if output, err := commitChanges(dir, types.ScopeAll, "feat: add newer file", true); err != nil {
+		t.Fatalf("commitChanges failed: %v\n%s", err, output)
+	}
+	if got := git("log", "--format=%s"); got != "feat: add newer file\nfeat: add new file\nfeat: update tracked\ninitial commit" {
+		t.Fatalf("expected HEAD to be amended, got log %q", got)
+	}
+}
@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/99designs/keyring"
+	"github.com/dfanso/commit-msg/cmd/cli/store"
+	"github.com/dfanso/commit-msg/internal/doctor"
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/internal/llm"
+	"github.com/dfanso/commit-msg/pkg/types"
+	StoreUtils "github.com/dfanso/commit-msg/utils"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// providerProbeTimeout bounds the request sent to each configured provider.
+const providerProbeTimeout = 15 * time.Second
+
+// doctorCmd checks every piece commit depends on. It runs without the store,
+// since a broken keyring or statistics file is what it has to diagnose.
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check git, the keyring, configuration files and providers",
+	Long: `Diagnose the environment: the git version and repository state, the keyring
+backend, config.json, cache.json, usage_stats.json and every configured
+provider. Each provider receives a minimal authenticated request and its
+latency is reported. --endpoint sends those requests to another server,
+such as a local stub, instead of the provider APIs.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		endpoint, err := cmd.Flags().GetString("endpoint")
+		if err != nil {
+			return err
+		}
+		return RunDoctor(endpoint)
+	},
+}
+
+func init() {
+	doctorCmd.Flags().String("endpoint", "", "Send the provider checks to this base URL instead of the provider APIs")
+}
+
+// RequiresStore reports whether the command selected by args needs the
+// credential store to open. Only doctor does without it.
+func RequiresStore(args []string) bool {
+	found, _, err := rootCmd.Find(args)
+	return err != nil || found != doctorCmd
+}
+
+// RunDoctor runs all checks, prints a report and fails when any check failed.
+func RunDoctor(endpoint string) error {
+	ctx := context.Background()
+	var results []doctor.Result
+
+	results = append(results, doctor.CheckGitVersion(ctx))
+	if dir, err := os.Getwd(); err == nil {
+		results = append(results, doctor.CheckRepository(ctx, dir))
+	}
+
+	ring, keyringResult := doctor.OpenKeyring("commit-msg")
+	results = append(results, keyringResult)
+
+	configPath, err := StoreUtils.GetConfigPath()
+	if err != nil {
+		results = append(results, doctor.Result{Name: "Config", Status: doctor.StatusFail, Detail: err.Error(), Fix: "Set HOME (or LOCALAPPDATA on Windows) so the config directory can be found."})
+		renderDoctorResults(results)
+		return doctorOutcome(results)
+	}
+	configDir := filepath.Dir(configPath)
+
+	cfg, configResult := checkConfigFile(configPath)
+	results = append(results, configResult)
+	results = append(results, checkCacheFile(filepath.Join(configDir, "cache.json")))
+	results = append(results, checkUsageFile(filepath.Join(configDir, "usage_stats.json")))
+
+	var network types.NetworkSettings
+	if cfg != nil && cfg.Network != nil {
+		network = *cfg.Network
+	}
+	results = append(results, checkNetworkSettings(network))
+
+	if cfg != nil {
+		// One attempt per probe: retries would hide the latency being measured.
+		probeCtx := internalHTTP.WithMaxAttempts(ctx, 1)
+		for _, provider := range cfg.LLMProviders {
+			results = append(results, checkConfiguredProvider(probeCtx, ring, cfg, provider, endpoint))
+		}
+	}
+
+	renderDoctorResults(results)
+	return doctorOutcome(results)
+}
+
+// checkNetworkSettings reports the proxy provider requests go through and
+// whether the CA bundle and client certificate can be loaded.
+func checkNetworkSettings(settings types.NetworkSettings) doctor.Result {
+	const name = "Network"
+	fix := "Run 'commit llm network' with corrected values, or 'commit llm network --clear'."
+
+	if _, err := internalHTTP.TLSConfig(settings); err != nil {
+		return doctor.Result{Name: name, Status: doctor.StatusFail, Detail: err.Error(), Fix: fix}
+	}
+	proxy, err := internalHTTP.ProxyFunc(settings)
+	if err != nil {
+		return doctor.Result{Name: name, Status: doctor.StatusFail, Detail: err.Error(), Fix: fix}
+	}
+
+	var details []string
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	proxyURL, err := proxy(req)
+	switch {
+	case err != nil:
+		return doctor.Result{Name: name, Status: doctor.StatusFail, Detail: err.Error(), Fix: "Fix HTTPS_PROXY or set a proxy with 'commit llm network --proxy <url>'."}
+	case proxyURL == nil:
+		details = append(details, "direct connections")
+	case strings.TrimSpace(settings.Proxy) != "":
+		details = append(details, "proxy "+proxyURL.Redacted())
+	default:
+		details = append(details, "proxy "+proxyURL.Redacted()+" from the environment")
+	}
+	if settings.CABundle != "" {
+		details = append(details, "CA bundle "+settings.CABundle)
+	}
+	if settings.ClientCert != "" {
+		details = append(details, "client certificate "+settings.ClientCert)
+	}
+	return doctor.Result{Name: name, Status: doctor.StatusPass, Detail: strings.Join(details, ", ")}
+}
+
+// checkConfigFile validates config.json against the schema the store writes.
+func checkConfigFile(path string) (*store.Config, doctor.Result) {
+	const name = "Config"
+	fix := fmt.Sprintf("Delete %s and run 'commit llm setup' again.", path)
+
+	var cfg store.Config
+	exists, err := doctor.ReadJSON(path, &cfg, false)
+	switch {
+	case !exists:
+		return nil, doctor.Result{Name: name, Status: doctor.StatusWarn, Detail: fmt.Sprintf("%s does not exist", path), Fix: "Run 'commit llm setup' to configure a provider."}
+	case err != nil:
+		return nil, doctor.Result{Name: name, Status: doctor.StatusFail, Detail: fmt.Sprintf("%s is not valid: %v", path, err), Fix: fix}
+	}
+
+	problems := configProblems(&cfg)
+	if _, err := doctor.ReadJSON(path, &store.Config{}, true); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(problems) > 0 {
+		return &cfg, doctor.Result{Name: name, Status: doctor.StatusWarn, Detail: strings.Join(problems, "; "), Fix: "Run 'commit llm update' and 'commit llm fallback' to repair the entries."}
+	}
+	return &cfg, doctor.Result{Name: name, Status: doctor.StatusPass, Detail: fmt.Sprintf("%d provider(s), default %s", len(cfg.LLMProviders), cfg.Default.String())}
+}
+
+// configProblems lists the entries of cfg that the store would not have
+// written: unknown providers, a default or fallback that is not set up, and
+// settings for providers that are gone.
+func configProblems(cfg *store.Config) []string {
+	var problems []string
+
+	if len(cfg.LLMProviders) == 0 {
+		problems = append(problems, "no providers are configured")
+	}
+
+	seen := make(map[types.LLMProvider]bool)
+	for _, provider := range cfg.LLMProviders {
+		if !provider.IsValid() {
+			problems = append(problems, fmt.Sprintf("unknown provider %q", provider))
+		}
+		if seen[provider] {
+			problems = append(problems, fmt.Sprintf("%s is listed twice", provider))
+		}
+		seen[provider] = true
+	}
+
+	switch {
+	case cfg.Default == "":
+		if len(cfg.LLMProviders) > 0 {
+			problems = append(problems, "no default provider is set")
+		}
+	case !seen[cfg.Default]:
+		problems = append(problems, fmt.Sprintf("default provider %s is not configured", cfg.Default))
+	}
+
+	settingsProviders := make([]types.LLMProvider, 0, len(cfg.Settings))
+	for provider := range cfg.Settings {
+		settingsProviders = append(settingsProviders, provider)
+	}
+	slices.Sort(settingsProviders)
+	for _, provider := range settingsProviders {
+		if !seen[provider] {
+			problems = append(problems, fmt.Sprintf("settings for %s, which is not configured", provider))
+		}
+	}
+
+	for _, provider := range cfg.Fallback {
+		switch {
+		case provider == cfg.Default:
+			problems = append(problems, fmt.Sprintf("fallback repeats the default provider %s", provider))
+		case !seen[provider]:
+			problems = append(problems, fmt.Sprintf("fallback provider %s is not configured", provider))
+		}
+	}
+	return problems
+}
+
+// checkCacheFile verifies that cache.json parses and holds usable entries. A
+// broken cache is only a warning: commit starts over with an empty one.
+func checkCacheFile(path string) doctor.Result {
+	const name = "Cache"
+
+	var cacheData struct {
+		Entries map[string]*types.CacheEntry `json:"entries"`
+		Stats   *types.CacheStats            `json:"stats"`
+		Config  *types.CacheConfig           `json:"config"`
+	}
+	exists, err := doctor.ReadJSON(path, &cacheData, false)
+	switch {
+	case !exists:
+		return doctor.Result{Name: name, Status: doctor.StatusPass, Detail: "No cache yet"}
+	case err != nil:
+		return doctor.Result{Name: name, Status: doctor.StatusWarn, Detail: fmt.Sprintf("%s is corrupt: %v", path, err), Fix: fmt.Sprintf("Delete %s; cached messages are regenerated as needed.", path)}
+	}
+
+	broken := 0
+	for _, entry := range cacheData.Entries {
+		if entry == nil || strings.TrimSpace(entry.Message) == "" || !entry.Provider.IsValid() {
+			broken++
+		}
+	}
+	if broken > 0 {
+		return doctor.Result{Name: name, Status: doctor.StatusWarn, Detail: fmt.Sprintf("%d of %d entries are incomplete", broken, len(cacheData.Entries)), Fix: "Run 'commit cache clear'."}
+	}
+	return doctor.Result{Name: name, Status: doctor.StatusPass, Detail: fmt.Sprintf("%d cached message(s)", len(cacheData.Entries))}
+}
+
+// checkUsageFile verifies that usage_stats.json parses. Every command except
+// doctor refuses to start while it is corrupt.
+func checkUsageFile(path string) doctor.Result {
+	const name = "Usage statistics"
+
+	var stats types.UsageStats
+	exists, err := doctor.ReadJSON(path, &stats, false)
+	switch {
+	case !exists:
+		return doctor.Result{Name: name, Status: doctor.StatusPass, Detail: "No statistics yet"}
+	case err != nil:
+		return doctor.Result{Name: name, Status: doctor.StatusFail, Detail: fmt.Sprintf("%s is corrupt: %v", path, err), Fix: fmt.Sprintf("Delete %s to start the statistics over.", path)}
+	}
+	return doctor.Result{Name: name, Status: doctor.StatusPass, Detail: fmt.Sprintf("%d generation(s) recorded", stats.TotalGenerations)}
+}
+
+// checkConfiguredProvider reads the provider's credential from the keyring
+// and probes its API.
+func checkConfiguredProvider(ctx context.Context, ring keyring.Keyring, cfg *store.Config, provider types.LLMProvider, endpoint string) doctor.Result {
+	if p, ok := llm.LookupPlugin(provider); ok {
+		return checkPluginProvider(provider, p)
+	}
+
+	var credential string
+	if ring != nil {
+		item, err := ring.Get(string(provider))
+		switch {
+		case err == nil:
+			credential = string(item.Data)
+		case !errors.Is(err, keyring.ErrKeyNotFound):
+			return doctor.Result{Name: "Provider " + provider.String(), Status: doctor.StatusFail, Detail: fmt.Sprintf("Reading the credential failed: %v", err), Fix: "Unlock the keyring and try again."}
+		}
+	}
+
+	if provider == types.ProviderCassette {
+		return checkCassetteProvider(credential)
+	}
+
+	// A single try keeps retry waits out of the reported latency; a rate
+	// limit is reported as such.
+	ctx, cancel := context.WithTimeout(internalHTTP.WithMaxAttempts(ctx, 1), providerProbeTimeout)
+	defer cancel()
+	return doctor.CheckProvider(ctx, internalHTTP.GetClient(), doctor.ProviderProbe{
+		Provider:   provider,
+		Credential: credential,
+		Settings:   cfg.Settings[provider],
+		Endpoint:   endpoint,
+	})
+}
+
+// renderDoctorResults prints the checks as a table followed by the
+// suggested fixes.
+func renderDoctorResults(results []doctor.Result) {
+	pterm.DefaultSection.Println("Commit Doctor")
+
+	tableData := [][]string{{"Check", "Status", "Details"}}
+	for _, result := range results {
+		tableData = append(tableData, []string{result.Name, doctorStatusLabel(result.Status), result.Detail})
+	}
+	pterm.DefaultTable.WithHasHeader(true).WithData(tableData).Render()
+
+	var fixes []string
+	for _, result := range results {
+		if result.Status != doctor.StatusPass && result.Fix != "" {
+			fixes = append(fixes, fmt.Sprintf("%s: %s", result.Name, result.Fix))
+		}
+	}
+	if len(fixes) > 0 {
+		pterm.Println()
+		pterm.DefaultSection.WithLevel(2).Println("Suggested fixes")
+		for _, fix := range fixes {
+			pterm.Println("  • " + fix)
+		}
+	}
+}
+
+func doctorStatusLabel(status doctor.Status) string {
+	label := strings.ToUpper(status.String())
+	switch status {
+	case doctor.StatusPass:
+		return pterm.Green(label)
+	case doctor.StatusWarn:
+		return pterm.Yellow(label)
+	default:
+		return pterm.Red(label)
+	}
+}
+
+func doctorOutcome(results []doctor.Result) error {
+	failed := 0
+	for _, result := range results {
+		if result.Status == doctor.StatusFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dfanso/commit-msg/cmd/cli/store"
+	"github.com/dfanso/commit-msg/internal/git"
+	"github.com/dfanso/commit-msg/internal/hook"
+	"github.com/dfanso/commit-msg/internal/llm"
+	"github.com/dfanso/commit-msg/pkg/types"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// hookTimeout bounds the generation of the hook when --timeout is not set,
+// so a slow provider does not hold up 'git commit' for long.
+const hookTimeout = 60 * time.Second
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manage the prepare-commit-msg git hook",
+	Long: `Install a prepare-commit-msg hook that fills in a generated message whenever
+'git commit' opens the editor, so the message is reviewed in the editor git
+already uses. Merges, squashes, amends and messages given with -m, -F, -c or
+-C are left alone. The hook follows core.hooksPath, and an existing hook is
+kept and run first.`,
+}
+
+var hookInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the prepare-commit-msg hook in this repository",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return InstallHook()
+	},
+}
+
+var hookUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the hook and restore the hook it replaced",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return UninstallHook()
+	},
+}
+
+var hookStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the hook is installed",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return ShowHookStatus()
+	},
+}
+
+// hookRunCmd is what the installed hook calls, with the arguments git
+// passes to prepare-commit-msg.
+var hookRunCmd = &cobra.Command{
+	Use:    "run <message-file> [source] [commit]",
+	Short:  "Write a generated message into a commit message file",
+	Hidden: true,
+	Args:   cobra.RangeArgs(1, 3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		source := ""
+		if len(args) > 1 {
+			source = args[1]
+		}
+
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			return err
+		}
+
+		RunHook(Store, args[0], source, timeout)
+		return nil
+	},
+}
+
+func init() {
+	hookRunCmd.Flags().Duration("timeout", hookTimeout, "Give up on the LLM after this long and leave the message empty")
+}
+
+// InstallHook installs the hook in the repository of the current directory.
+func InstallHook() error {
+	repoPath, err := hookRepository()
+	if err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate the commit binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+
+	status, err := hook.Install(repoPath, executable)
+	if err != nil {
+		return err
+	}
+
+	pterm.Success.Printf("Installed %s\n", status.Path())
+	if status.Chained {
+		pterm.Info.Printf("The existing hook was kept as %s and runs first.\n", hook.ChainedName)
+	}
+	pterm.Info.Println("'git commit' now opens the editor with a generated message to review.")
+	return nil
+}
+
+// UninstallHook removes the hook from the repository of the current
+// directory.
+func UninstallHook() error {
+	repoPath, err := hookRepository()
+	if err != nil {
+		return err
+	}
+
+	status, err := hook.Uninstall(repoPath)
+	if err != nil {
+		return err
+	}
+
+	pterm.Success.Printf("Removed the hook from %s\n", status.Dir)
+	if status.Foreign {
+		pterm.Info.Printf("Restored the previous hook as %s\n", status.Path())
+	}
+	return nil
+}
+
+// ShowHookStatus prints the state of the hook in the repository of the
+// current directory.
+func ShowHookStatus() error {
+	repoPath, err := hookRepository()
+	if err != nil {
+		return err
+	}
+
+	status, err := hook.Inspect(repoPath)
+	if err != nil {
+		return err
+	}
+
+	state := "not installed"
+	switch {
+	case status.Installed:
+		state = "installed"
+	case status.Foreign:
+		state = "another hook is installed"
+	}
+
+	rows := [][]string{
+		{"Setting", "Value"},
+		{"Hooks directory", status.Dir},
+		{"Hook", state},
+	}
+	if status.Installed {
+		rows = append(rows, []string{"Runs", status.Executable})
+		if _, err := os.Stat(status.Executable); err != nil {
+			rows = append(rows, []string{"Warning", "the binary is missing; run 'commit hook install' again"})
+		}
+	}
+	if status.Chained {
+		rows = append(rows, []string{"Chained", hook.ChainedName + " runs first"})
+	}
+
+	pterm.DefaultTable.WithHasHeader(true).WithData(rows).Render()
+	if !status.Installed {
+		pterm.Info.Println("Install it with 'commit hook install'.")
+	}
+	return nil
+}
+
+// hookRepository returns the current directory when it is in a git
+// repository.
+func hookRepository() (string, error) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	if !git.IsRepository(currentDir) {
+		return "", fmt.Errorf("current directory is not a Git repository: %s", currentDir)
+	}
+	return currentDir, nil
+}
+
+// RunHook generates a message for the staged changes and writes it into
+// messageFile, ahead of what git put there. It never fails the commit:
+// problems are reported as warnings and leave the file as it was, so the
+// message can still be written by hand.
+func RunHook(Store *store.StoreMethods, messageFile, source string, timeout time.Duration) {
+	if !hook.ShouldGenerate(source) {
+		return
+	}
+
+	message, err := hookMessage(Store, timeout)
+	if err != nil {
+		pterm.Warning.Printf("commit-msg could not generate a message: %v\n", err)
+		return
+	}
+	if message == "" {
+		return
+	}
+
+	if err := hook.WriteMessage(messageFile, message); err != nil {
+		pterm.Warning.Printf("commit-msg could not write the message: %v\n", err)
+	}
+}
+
+// hookMessage generates a message for the changes being committed without
+// asking anything. git runs the hook at the top of the work tree with the
+// index of the commit, which includes the changes of 'git commit -a'.
+func hookMessage(Store *store.StoreMethods, timeout time.Duration) (string, error) {
+	useLLM, err := Store.DefaultLLMKey()
+	if err != nil {
+		return "", errors.New("no LLM configured; run 'commit llm setup'")
+	}
+
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	changes, err := git.GetChanges(&types.RepoConfig{Path: currentDir, Scope: types.ScopeStaged})
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(changes) == "" {
+		return "", nil
+	}
+
+	capabilities := llm.ResolveCapabilities(useLLM.LLM, useLLM.APIKey, useLLM.Settings)
+	changes = truncateDiff(changes, capabilities.DiffBudget(promptOverhead())*charsPerToken)
+
+	config := &types.Config{
+		GrokAPI: "https://api.x.ai/v1/chat/completions",
+	}
+	provider, err := newProviderChain(Store, useLLM, config)
+	if err != nil {
+		return "", err
+	}
+
+	options := CommitOptions{Deterministic: deterministicFromEnv()}
+	opts := options.generationOptions(loadStylePresets()[0].options(), 1)
+
+	ctx, cancel := generationContext(context.Background(), timeout)
+	defer cancel()
+
+	message, err := generateMessageWithCache(ctx, provider, Store, useLLM.LLM, changes, opts, nil)
+	if err != nil {
+		return "", contextError(ctx, err)
+	}
+	return strings.TrimSpace(message), nil
+}
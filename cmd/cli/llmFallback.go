@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dfanso/commit-msg/cmd/cli/store"
+	"github.com/dfanso/commit-msg/pkg/types"
+	"github.com/pterm/pterm"
+)
+
+// ConfigureFallback sets the providers tried, in order, after the default
+// provider fails. Without names the current chain is printed; clearChain
+// removes it.
+func ConfigureFallback(Store *store.StoreMethods, names []string, clearChain bool) error {
+	if clearChain {
+		if len(names) > 0 {
+			return errors.New("--clear does not take provider names")
+		}
+		if err := store.SetFallback(nil); err != nil {
+			return err
+		}
+		pterm.Success.Println("Fallback chain removed")
+		return nil
+	}
+
+	if len(names) == 0 {
+		return showFallbackChain()
+	}
+
+	providers, err := parseFallbackProviders(names)
+	if err != nil {
+		return err
+	}
+
+	if err := store.SetFallback(providers); err != nil {
+		return err
+	}
+
+	pterm.Success.Println("Fallback chain updated")
+	return showFallbackChain()
+}
+
+// parseFallbackProviders resolves provider names, accepting them case-insensitively.
+func parseFallbackProviders(names []string) ([]types.LLMProvider, error) {
+	providers := make([]types.LLMProvider, 0, len(names))
+	for _, name := range names {
+		provider, ok := parseProviderName(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown LLM provider %q, expected one of: %s", name, strings.Join(types.GetSupportedProviderStrings(), ", "))
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// showFallbackChain prints the default provider followed by its fallbacks.
+func showFallbackChain() error {
+	savedModels, err := store.ListSavedModels()
+	if err != nil {
+		return err
+	}
+
+	if len(savedModels.Fallback) == 0 {
+		pterm.Info.Println("No fallback providers configured. Set them with 'commit llm fallback <provider>...'.")
+		return nil
+	}
+
+	chain := make([]string, 0, len(savedModels.Fallback)+1)
+	if savedModels.Default != "" {
+		chain = append(chain, savedModels.Default.String()+" (default)")
+	}
+	for _, provider := range savedModels.Fallback {
+		if provider == savedModels.Default {
+			continue
+		}
+		chain = append(chain, provider.String())
+	}
+
+	pterm.Info.Printf("Fallback chain: %s\n", strings.Join(chain, " → "))
+	return nil
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+func TestParseFallbackProviders(t *testing.T) {
+	t.Parallel()
+
+	providers, err := parseFallbackProviders([]string{"groq", "Ollama"})
+	if err != nil {
+		t.Fatalf("parseFallbackProviders returned error: %v", err)
+	}
+	if !slices.Equal(providers, []types.LLMProvider{types.ProviderGroq, types.ProviderOllama}) {
+		t.Fatalf("unexpected providers: %v", providers)
+	}
+
+	if _, err := parseFallbackProviders([]string{"Claude", "mistral"}); err == nil {
+		t.Fatal("expected an unknown provider to be rejected")
+	}
+}
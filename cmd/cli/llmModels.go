@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dfanso/commit-msg/cmd/cli/store"
+	"github.com/dfanso/commit-msg/internal/llm"
+	"github.com/dfanso/commit-msg/pkg/types"
+	"github.com/pterm/pterm"
+)
+
+// modelListTimeout bounds the request that asks a provider for its models.
+const modelListTimeout = 30 * time.Second
+
+// ListLLMModels prints the well-known models of a provider and, when the
+// provider has been set up, the models its API reports as available. An empty
+// name selects the default provider.
+func ListLLMModels(Store *store.StoreMethods, name string) error {
+	var provider types.LLMProvider
+	var saved *store.LLMProvider
+
+	if strings.TrimSpace(name) == "" {
+		defaultLLM, err := Store.DefaultLLMKey()
+		if err != nil {
+			return fmt.Errorf("no default LLM configured, pass a provider name or run 'commit llm setup': %w", err)
+		}
+		provider, saved = defaultLLM.LLM, defaultLLM
+	} else {
+		parsed, ok := parseProviderName(name)
+		if !ok {
+			return fmt.Errorf("unknown LLM provider %q, expected one of: %s", name, strings.Join(types.GetSupportedProviderStrings(), ", "))
+		}
+		provider = parsed
+		// A provider that has not been set up can still show its known models.
+		saved, _ = Store.LoadLLM(provider)
+	}
+
+	var settings types.ProviderSettings
+	if saved != nil {
+		settings = saved.Settings
+	}
+	current := llm.ResolveModel(provider, settings)
+
+	pterm.DefaultSection.Printf("%s Models", provider.String())
+
+	known := llm.KnownModels(provider)
+	if len(known) == 0 {
+		pterm.Info.Printf("%s has no built-in model list; its models depend on the server.\n", provider.String())
+	} else {
+		tableData := [][]string{{"Known Model", "Context", "Max Output", ""}}
+		for _, model := range known {
+			tableData = append(tableData, modelRow(provider, model, current))
+		}
+		if current != "" && !slices.Contains(known, current) {
+			tableData = append(tableData, modelRow(provider, current, current))
+		}
+		pterm.DefaultTable.WithHasHeader(true).WithData(tableData).Render()
+	}
+
+	capabilities := llm.LookupCapabilities(provider, current)
+	pterm.Info.Printf("Streaming: %s, structured JSON output: %s.\n", yesNo(capabilities.Streaming), yesNo(capabilities.JSON))
+
+	if envVar := llm.ModelEnvVar(provider); envVar != "" {
+		pterm.Info.Printf("Change the model with 'commit llm update', the --model flag, or %s.\n", envVar)
+	}
+
+	if saved == nil {
+		pterm.Println()
+		pterm.Info.Printf("%s is not configured. Run 'commit llm setup' to list the models its API offers.\n", provider.String())
+		return nil
+	}
+
+	available, err := fetchAvailableModels(saved)
+	if err != nil {
+		return err
+	}
+	if available == nil {
+		return nil
+	}
+
+	pterm.Println()
+	pterm.DefaultSection.WithLevel(2).Printf("Available from %s (%d)", provider.String(), len(available))
+	for _, model := range available {
+		if marker := modelMarkers(provider, model, current); marker != "" {
+			pterm.Printf("  %s  %s\n", model, pterm.Gray("("+marker+")"))
+			continue
+		}
+		pterm.Printf("  %s\n", model)
+	}
+
+	return nil
+}
+
+// fetchAvailableModels asks the provider's API for its model list. It returns
+// nil without error when the provider cannot list models.
+func fetchAvailableModels(saved *store.LLMProvider) ([]string, error) {
+	providerInstance, err := llm.NewProvider(saved.LLM, llm.ProviderOptions{
+		Credential: saved.APIKey,
+		Settings:   saved.Settings,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lister, ok := providerInstance.(llm.ModelLister)
+	if !ok {
+		pterm.Info.Printf("%s does not offer a model listing.\n", saved.LLM.String())
+		return nil, nil
+	}
+
+	ctx, cancel := generationContext(context.Background(), modelListTimeout)
+	defer cancel()
+
+	spinner, err := pterm.DefaultSpinner.Start("Fetching available models from " + saved.LLM.String() + "...")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start spinner: %w", err)
+	}
+
+	models, err := lister.ListModels(ctx)
+	if err != nil {
+		spinner.Fail("Failed to fetch available models")
+		return nil, fmt.Errorf("failed to list %s models: %w", saved.LLM.String(), contextError(ctx, err))
+	}
+	spinner.Success(fmt.Sprintf("Found %d models", len(models)))
+
+	sort.Strings(models)
+	return models, nil
+}
+
+// parseProviderName matches a provider name case-insensitively.
+func parseProviderName(name string) (types.LLMProvider, bool) {
+	name = strings.TrimSpace(name)
+	for _, provider := range types.GetSupportedProviders() {
+		if strings.EqualFold(provider.String(), name) {
+			return provider, true
+		}
+	}
+	return "", false
+}
+
+// modelRow renders model with its token limits and markers.
+func modelRow(provider types.LLMProvider, model, current string) []string {
+	capabilities := llm.LookupCapabilities(provider, model)
+	return []string{
+		model,
+		formatTokenCount(capabilities.ContextWindow),
+		formatTokenCount(capabilities.MaxOutputTokens),
+		modelMarkers(provider, model, current),
+	}
+}
+
+// formatTokenCount renders a token limit compactly, e.g. 128k or 1M.
+func formatTokenCount(tokens int) string {
+	switch {
+	case tokens >= 1_000_000:
+		return fmt.Sprintf("%.0fM", float64(tokens)/1_000_000)
+	case tokens >= 1_000:
+		return fmt.Sprintf("%.0fk", float64(tokens)/1_000)
+	default:
+		return fmt.Sprintf("%d", tokens)
+	}
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+// modelMarkers describes whether model is the provider default and/or the
+// model currently in use.
+func modelMarkers(provider types.LLMProvider, model, current string) string {
+	var markers []string
+	if model == llm.DefaultModel(provider) {
+		markers = append(markers, "default")
+	}
+	if model == current {
+		markers = append(markers, "in use")
+	}
+	return strings.Join(markers, ", ")
+}
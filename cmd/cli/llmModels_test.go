@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+func TestParseProviderName(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]types.LLMProvider{
+		"OpenAI":           types.ProviderOpenAI,
+		"groq":             types.ProviderGroq,
+		" ollama ":         types.ProviderOllama,
+		"openaicompatible": types.ProviderOpenAICompatible,
+	}
+	for input, expected := range cases {
+		got, ok := parseProviderName(input)
+		if !ok || got != expected {
+			t.Fatalf("parseProviderName(%q) = %q, %v; want %q", input, got, ok, expected)
+		}
+	}
+
+	if _, ok := parseProviderName("mistral"); ok {
+		t.Fatal("expected unknown provider to be rejected")
+	}
+}
+
+func TestModelMarkers(t *testing.T) {
+	t.Parallel()
+
+	if got := modelMarkers(types.ProviderGroq, "llama-3.3-70b-versatile", "llama-3.3-70b-versatile"); got != "default, in use" {
+		t.Fatalf("unexpected markers for default model in use: %q", got)
+	}
+
+	if got := modelMarkers(types.ProviderGroq, "llama-3.3-70b-versatile", "llama-3.1-8b-instant"); got != "default" {
+		t.Fatalf("unexpected markers for default model: %q", got)
+	}
+
+	if got := modelMarkers(types.ProviderGroq, "other", "llama-3.1-8b-instant"); got != "" {
+		t.Fatalf("expected no markers, got %q", got)
+	}
+}
+
+func TestProviderLabel(t *testing.T) {
+	t.Parallel()
+
+	if got := providerLabel(types.ProviderOpenAI, "gpt-4o"); got != "OpenAI (gpt-4o)" {
+		t.Fatalf("unexpected label: %q", got)
+	}
+
+	if got := providerLabel(types.ProviderOpenAICompatible, ""); got != "OpenAICompatible" {
+		t.Fatalf("unexpected label without model: %q", got)
+	}
+}
+
+func TestFormatTokenCount(t *testing.T) {
+	t.Parallel()
+
+	cases := map[int]string{512: "512", 8_192: "8k", 128_000: "128k", 1_048_576: "1M"}
+	for tokens, want := range cases {
+		if got := formatTokenCount(tokens); got != want {
+			t.Fatalf("formatTokenCount(%d) = %q, want %q", tokens, got, want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dfanso/commit-msg/cmd/cli/store"
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/pkg/types"
+	"github.com/pterm/pterm"
+)
+
+// networkFlags are the flags of 'commit llm network', each naming one
+// network setting.
+var networkFlags = []string{"proxy", "no-proxy", "ca-bundle", "client-cert", "client-key"}
+
+// configureNetwork makes the shared HTTP clients use the network settings
+// in config.json. Invalid settings are reported and ignored, so a broken
+// proxy entry does not lock the user out of 'commit llm network'.
+func configureNetwork() {
+	cfg, err := store.ListSavedModels()
+	if err != nil || cfg.Network == nil {
+		return
+	}
+	if err := internalHTTP.Configure(*cfg.Network); err != nil {
+		pterm.Warning.Printf("Ignoring the network settings in config.json: %v\n", err)
+	}
+}
+
+// ConfigureNetwork updates the proxy and TLS settings used for provider
+// requests with changes, keyed by flag name; an empty value removes that
+// setting. Without changes the current settings are printed; clearSettings
+// removes them all.
+func ConfigureNetwork(changes map[string]string, clearSettings bool) error {
+	if clearSettings {
+		if len(changes) > 0 {
+			return errors.New("--clear cannot be combined with other network flags")
+		}
+		if err := store.SetNetwork(types.NetworkSettings{}); err != nil {
+			return err
+		}
+		pterm.Success.Println("Network settings removed")
+		return nil
+	}
+
+	var current types.NetworkSettings
+	if cfg, err := store.ListSavedModels(); err == nil && cfg.Network != nil {
+		current = *cfg.Network
+	}
+
+	if len(changes) == 0 {
+		showNetworkSettings(current)
+		return nil
+	}
+
+	settings, err := applyNetworkChanges(current, changes)
+	if err != nil {
+		return err
+	}
+	if _, err := internalHTTP.NewTransport(settings); err != nil {
+		return err
+	}
+
+	if err := store.SetNetwork(settings); err != nil {
+		return err
+	}
+
+	pterm.Success.Println("Network settings updated")
+	showNetworkSettings(settings)
+	return nil
+}
+
+// applyNetworkChanges returns settings with changes applied. File paths are
+// made absolute so they keep working from any repository.
+func applyNetworkChanges(settings types.NetworkSettings, changes map[string]string) (types.NetworkSettings, error) {
+	for flag, value := range changes {
+		value = strings.TrimSpace(value)
+
+		var field *string
+		isPath := true
+		switch flag {
+		case "proxy":
+			field, isPath = &settings.Proxy, false
+		case "no-proxy":
+			field, isPath = &settings.NoProxy, false
+		case "ca-bundle":
+			field = &settings.CABundle
+		case "client-cert":
+			field = &settings.ClientCert
+		case "client-key":
+			field = &settings.ClientKey
+		default:
+			return settings, fmt.Errorf("unknown network setting %q", flag)
+		}
+
+		if isPath && value != "" {
+			abs, err := filepath.Abs(value)
+			if err != nil {
+				return settings, err
+			}
+			value = abs
+		}
+		*field = value
+	}
+	return settings, nil
+}
+
+// showNetworkSettings prints the saved network settings.
+func showNetworkSettings(settings types.NetworkSettings) {
+	if settings.IsZero() {
+		pterm.Info.Println("No network settings configured; HTTPS_PROXY, HTTP_PROXY and NO_PROXY apply. Set them with 'commit llm network --proxy <url>'.")
+		return
+	}
+
+	rows := [][]string{{"Setting", "Value"}}
+	add := func(name, value string) {
+		if value != "" {
+			rows = append(rows, []string{name, value})
+		}
+	}
+	add("Proxy", settings.Proxy)
+	add("No proxy", settings.NoProxy)
+	add("CA bundle", settings.CABundle)
+	add("Client certificate", settings.ClientCert)
+	add("Client key", settings.ClientKey)
+
+	pterm.DefaultTable.WithHasHeader(true).WithData(rows).Render()
+}
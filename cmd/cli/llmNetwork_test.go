@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+func TestApplyNetworkChanges(t *testing.T) {
+	t.Parallel()
+
+	current := types.NetworkSettings{Proxy: "http://old:3128", NoProxy: "internal", ClientCert: "/etc/ssl/client.pem"}
+	settings, err := applyNetworkChanges(current, map[string]string{
+		"proxy":     " http://corp-proxy:3128 ",
+		"no-proxy":  "",
+		"ca-bundle": "certs/corp.pem",
+	})
+	if err != nil {
+		t.Fatalf("applyNetworkChanges returned error: %v", err)
+	}
+
+	bundle, _ := filepath.Abs("certs/corp.pem")
+	want := types.NetworkSettings{Proxy: "http://corp-proxy:3128", CABundle: bundle, ClientCert: "/etc/ssl/client.pem"}
+	if settings != want {
+		t.Fatalf("settings = %+v, want %+v", settings, want)
+	}
+
+	if _, err := applyNetworkChanges(current, map[string]string{"socks": "x"}); err == nil {
+		t.Fatal("expected an unknown setting to be rejected")
+	}
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dfanso/commit-msg/cmd/cli/store"
+	"github.com/dfanso/commit-msg/pkg/types"
+	"github.com/pterm/pterm"
+)
+
+// samplingFlags are the flags of 'commit llm sampling', each naming one
+// sampling setting.
+var samplingFlags = []string{"temperature", "max-tokens", "top-p", "seed"}
+
+// envDeterministic turns on deterministic mode for every 'commit .' run,
+// which keeps CI runs reproducible without changing their command lines.
+const envDeterministic = "COMMIT_DETERMINISTIC"
+
+// deterministicFromEnv reports whether COMMIT_DETERMINISTIC asks for
+// deterministic mode.
+func deterministicFromEnv() bool {
+	enabled, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(envDeterministic)))
+	return err == nil && enabled
+}
+
+// ConfigureSampling updates the sampling settings of a saved provider, or
+// of the style preset named style, with changes keyed by flag name; an
+// empty value removes that setting. The provider defaults to the default
+// one. Without changes the current settings are printed; clearSettings
+// removes them all.
+func ConfigureSampling(name, style string, changes map[string]string, clearSettings bool) error {
+	if clearSettings && len(changes) > 0 {
+		return errors.New("--clear cannot be combined with other sampling flags")
+	}
+
+	cfg, err := store.ListSavedModels()
+	if err != nil {
+		return err
+	}
+
+	var current types.Sampling
+	var save func(types.Sampling) error
+	var target string
+	if style = strings.TrimSpace(style); style != "" {
+		if name != "" {
+			return errors.New("--style cannot be combined with a provider name")
+		}
+		if !slices.ContainsFunc(stylePresets, func(preset styleOption) bool { return preset.Name == style }) {
+			return fmt.Errorf("unknown style preset %q, expected one of: %s", style, strings.Join(stylePresetNames(), ", "))
+		}
+		current = cfg.StyleSampling[style]
+		save = func(sampling types.Sampling) error { return store.SetStyleSampling(style, sampling) }
+		target = "the " + style + " style"
+	} else {
+		provider := cfg.Default
+		if strings.TrimSpace(name) != "" {
+			parsed, ok := parseProviderName(name)
+			if !ok {
+				return fmt.Errorf("unknown LLM provider %q, expected one of: %s", name, strings.Join(types.GetSupportedProviderStrings(), ", "))
+			}
+			provider = parsed
+		}
+		if provider == "" {
+			return errors.New("no default LLM configured, pass a provider name or run 'commit llm setup'")
+		}
+		if settings := cfg.Settings[provider].Sampling; settings != nil {
+			current = *settings
+		}
+		save = func(sampling types.Sampling) error {
+			if sampling.IsZero() {
+				return store.ChangeSampling(provider, nil)
+			}
+			return store.ChangeSampling(provider, &sampling)
+		}
+		target = provider.String()
+	}
+
+	if clearSettings {
+		if err := save(types.Sampling{}); err != nil {
+			return err
+		}
+		pterm.Success.Printf("Sampling settings of %s removed\n", target)
+		return nil
+	}
+
+	if len(changes) == 0 {
+		showSamplingSettings(target, current)
+		return nil
+	}
+
+	sampling, err := applySamplingChanges(current, changes)
+	if err != nil {
+		return err
+	}
+	if err := sampling.Validate(); err != nil {
+		return err
+	}
+
+	if err := save(sampling); err != nil {
+		return err
+	}
+
+	pterm.Success.Printf("Sampling settings of %s updated\n", target)
+	showSamplingSettings(target, sampling)
+	return nil
+}
+
+// applySamplingChanges returns sampling with changes applied.
+func applySamplingChanges(sampling types.Sampling, changes map[string]string) (types.Sampling, error) {
+	for flag, value := range changes {
+		value = strings.TrimSpace(value)
+
+		switch flag {
+		case "temperature", "top-p":
+			field := &sampling.Temperature
+			if flag == "top-p" {
+				field = &sampling.TopP
+			}
+			if value == "" {
+				*field = nil
+				continue
+			}
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return sampling, fmt.Errorf("invalid --%s %q: expected a number", flag, value)
+			}
+			*field = &parsed
+		case "max-tokens":
+			if value == "" {
+				sampling.MaxTokens = 0
+				continue
+			}
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return sampling, fmt.Errorf("invalid --max-tokens %q: expected a whole number", value)
+			}
+			sampling.MaxTokens = parsed
+		case "seed":
+			if value == "" {
+				sampling.Seed = nil
+				continue
+			}
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return sampling, fmt.Errorf("invalid --seed %q: expected a whole number", value)
+			}
+			sampling.Seed = &parsed
+		default:
+			return sampling, fmt.Errorf("unknown sampling setting %q", flag)
+		}
+	}
+	return sampling, nil
+}
+
+// showSamplingSettings prints the saved sampling settings of target.
+func showSamplingSettings(target string, sampling types.Sampling) {
+	if sampling.IsZero() {
+		pterm.Info.Printf("No sampling settings configured for %s; its defaults apply. Set them with 'commit llm sampling --temperature <value>'.\n", target)
+		return
+	}
+
+	pterm.DefaultTable.WithHasHeader(true).WithData(append([][]string{{"Setting", "Value"}}, samplingRows(sampling)...)).Render()
+}
+
+// samplingRows returns a table row for every sampling setting that is made.
+func samplingRows(sampling types.Sampling) [][]string {
+	var rows [][]string
+	if sampling.Temperature != nil {
+		rows = append(rows, []string{"Temperature", strconv.FormatFloat(*sampling.Temperature, 'g', -1, 64)})
+	}
+	if sampling.MaxTokens > 0 {
+		rows = append(rows, []string{"Max Tokens", strconv.Itoa(sampling.MaxTokens)})
+	}
+	if sampling.TopP != nil {
+		rows = append(rows, []string{"Top P", strconv.FormatFloat(*sampling.TopP, 'g', -1, 64)})
+	}
+	if sampling.Seed != nil {
+		rows = append(rows, []string{"Seed", strconv.FormatInt(*sampling.Seed, 10)})
+	}
+	return rows
+}
+
+// stylePresetNames lists the names the style presets are configured by.
+func stylePresetNames() []string {
+	names := make([]string, 0, len(stylePresets))
+	for _, preset := range stylePresets {
+		names = append(names, preset.Name)
+	}
+	return names
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+func TestApplySamplingChanges(t *testing.T) {
+	t.Parallel()
+
+	temperature := 0.7
+	current := types.Sampling{Temperature: &temperature, MaxTokens: 200}
+	sampling, err := applySamplingChanges(current, map[string]string{
+		"temperature": "",
+		"max-tokens":  " 800 ",
+		"top-p":       "0.9",
+		"seed":        "7",
+	})
+	if err != nil {
+		t.Fatalf("applySamplingChanges returned error: %v", err)
+	}
+	if sampling.Temperature != nil || sampling.MaxTokens != 800 || sampling.TopP == nil || *sampling.TopP != 0.9 || sampling.Seed == nil || *sampling.Seed != 7 {
+		t.Fatalf("unexpected sampling %+v", sampling)
+	}
+
+	for flag, value := range map[string]string{"temperature": "warm", "max-tokens": "1.5", "seed": "x", "top-k": "5"} {
+		if _, err := applySamplingChanges(current, map[string]string{flag: value}); err == nil {
+			t.Fatalf("expected --%s %q to be rejected", flag, value)
+		}
+	}
+}
+
+func TestGenerationOptionsDeterministic(t *testing.T) {
+	t.Parallel()
+
+	temperature := 0.9
+	style := &types.GenerationOptions{StyleInstruction: "casual", Sampling: types.Sampling{Temperature: &temperature, MaxTokens: 300}}
+
+	opts := CommitOptions{}.generationOptions(style, 2)
+	if opts.Attempt != 2 || opts.StyleInstruction != "casual" || *opts.Sampling.Temperature != 0.9 || opts.Sampling.Seed != nil {
+		t.Fatalf("unexpected options %+v", opts)
+	}
+
+	opts = CommitOptions{Deterministic: true}.generationOptions(style, 1)
+	if *opts.Sampling.Temperature != 0 || opts.Sampling.Seed == nil || *opts.Sampling.Seed != types.DeterministicSeed || opts.Sampling.MaxTokens != 300 {
+		t.Fatalf("expected deterministic sampling over the style's, got %+v", opts.Sampling)
+	}
+	if *style.Sampling.Temperature != 0.9 {
+		t.Fatal("the style options must not change")
+	}
+
+	if opts := (styleOption{Name: "default"}).options(); opts != nil {
+		t.Fatalf("expected no options for a preset without settings, got %+v", opts)
+	}
+}
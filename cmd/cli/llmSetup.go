@@ -1,14 +1,35 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/dfanso/commit-msg/cmd/cli/store"
+	"github.com/dfanso/commit-msg/internal/azure"
+	"github.com/dfanso/commit-msg/internal/bedrock"
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/internal/llm"
+	"github.com/dfanso/commit-msg/internal/ollama"
+	"github.com/dfanso/commit-msg/internal/vertex"
 	"github.com/dfanso/commit-msg/pkg/types"
 	"github.com/manifoldco/promptui"
+	"github.com/pterm/pterm"
 )
 
+// otherModelOption lets the user type a model that is not in the known list.
+const otherModelOption = "Other (enter model name)"
+
+// ollamaListTimeout bounds the request listing the models installed on an
+// Ollama server during setup.
+const ollamaListTimeout = 5 * time.Second
+
 // SetupLLM walks the user through selecting an LLM provider and storing the
 // corresponding API key or endpoint configuration.
 func SetupLLM(Store *store.StoreMethods) error {
@@ -30,6 +51,7 @@ func SetupLLM(Store *store.StoreMethods) error {
 	}
 
 	var apiKey string
+	var settings types.ProviderSettings
 
 	// Skip API key prompt for Ollama (local LLM)
 	apiKeyPrompt := promptui.Prompt{
@@ -40,12 +62,65 @@ func SetupLLM(Store *store.StoreMethods) error {
 	switch model {
 	case types.ProviderOllama:
 		urlPrompt := promptui.Prompt{
-			Label: "Enter URL",
+			Label:    "Enter URL",
+			Default:  ollama.DefaultURL,
+			Validate: validateBaseURL,
 		}
 		apiKey, err = urlPrompt.Run()
 		if err != nil {
 			return fmt.Errorf("failed to read Url: %w", err)
 		}
+		apiKey = strings.TrimSpace(apiKey)
+
+		settings.Model, err = promptOllamaModel(apiKey, "")
+		if err != nil {
+			return err
+		}
+
+		optionsPrompt := promptui.Prompt{
+			Label:     "Set model options (temperature, num_ctx, num_predict, keep_alive)",
+			IsConfirm: true,
+		}
+		if _, err := optionsPrompt.Run(); err == nil {
+			settings.Ollama, err = promptOllamaOptions(nil)
+			if err != nil {
+				return err
+			}
+		} else if !errors.Is(err, promptui.ErrAbort) {
+			return fmt.Errorf("failed to read answer: %w", err)
+		}
+
+	case types.ProviderOpenAICompatible:
+		settings, apiKey, err = promptOpenAICompatibleSettings()
+		if err != nil {
+			return err
+		}
+
+	case types.ProviderAzureOpenAI:
+		settings, apiKey, err = promptAzureSettings()
+		if err != nil {
+			return err
+		}
+
+	case types.ProviderBedrock:
+		// Bedrock signs with the AWS credential chain; no key is stored.
+		settings, err = promptBedrockSettings()
+		if err != nil {
+			return err
+		}
+
+	case types.ProviderVertexAI:
+		settings, apiKey, err = promptVertexSettings()
+		if err != nil {
+			return err
+		}
+
+	case types.ProviderCassette:
+		// The cassette path is kept as the credential, like Ollama's URL.
+		apiKey, err = promptCassettePath("")
+		if err != nil {
+			return err
+		}
 
 	default:
 		apiKey, err = apiKeyPrompt.Run()
@@ -55,9 +130,22 @@ func SetupLLM(Store *store.StoreMethods) error {
 
 	}
 
+	switch model {
+	case types.ProviderOpenAICompatible, types.ProviderOllama, types.ProviderAzureOpenAI:
+		// The model was chosen with the provider's other settings.
+	case types.ProviderCassette:
+		// Replays use the model of the recording.
+	default:
+		settings.Model, err = promptModelSelection(model, "")
+		if err != nil {
+			return err
+		}
+	}
+
 	LLMConfig := store.LLMProvider{
-		LLM:    model,
-		APIKey: apiKey,
+		LLM:      model,
+		APIKey:   apiKey,
+		Settings: settings,
 	}
 
 	err = Store.Save(LLMConfig)
@@ -69,6 +157,543 @@ func SetupLLM(Store *store.StoreMethods) error {
 	return nil
 }
 
+// promptOpenAICompatibleSettings asks for the base URL, model, optional API key
+// and extra headers of an OpenAI-compatible server.
+func promptOpenAICompatibleSettings() (types.ProviderSettings, string, error) {
+	var settings types.ProviderSettings
+
+	urlPrompt := promptui.Prompt{
+		Label:    "Enter Base URL (e.g. http://localhost:8000/v1)",
+		Validate: validateBaseURL,
+	}
+	baseURL, err := urlPrompt.Run()
+	if err != nil {
+		return settings, "", fmt.Errorf("failed to read base URL: %w", err)
+	}
+	settings.BaseURL = strings.TrimSpace(baseURL)
+
+	settings.Model, err = promptModelSelection(types.ProviderOpenAICompatible, "")
+	if err != nil {
+		return settings, "", err
+	}
+
+	keyPrompt := promptui.Prompt{
+		Label: "Enter API Key (optional)",
+		Mask:  '*',
+	}
+	apiKey, err := keyPrompt.Run()
+	if err != nil {
+		return settings, "", fmt.Errorf("failed to read API Key: %w", err)
+	}
+
+	headersPrompt := promptui.Prompt{
+		Label: "Extra Headers (optional, e.g. X-Org=acme, X-Env=dev)",
+		Validate: func(input string) error {
+			_, err := parseHeaderList(input)
+			return err
+		},
+	}
+	rawHeaders, err := headersPrompt.Run()
+	if err != nil {
+		return settings, "", fmt.Errorf("failed to read headers: %w", err)
+	}
+	settings.Headers, _ = parseHeaderList(rawHeaders)
+
+	return settings, strings.TrimSpace(apiKey), nil
+}
+
+// promptAzureSettings asks for the resource endpoint, deployment,
+// api-version and credential of an Azure OpenAI deployment.
+func promptAzureSettings() (types.ProviderSettings, string, error) {
+	settings := types.ProviderSettings{Azure: &types.AzureSettings{}}
+
+	endpointPrompt := promptui.Prompt{
+		Label:    "Enter Resource Endpoint (e.g. https://my-resource.openai.azure.com)",
+		Validate: validateBaseURL,
+	}
+	resourceURL, err := endpointPrompt.Run()
+	if err != nil {
+		return settings, "", fmt.Errorf("failed to read resource endpoint: %w", err)
+	}
+	settings.BaseURL = strings.TrimSpace(resourceURL)
+
+	settings.Model, err = promptDeploymentName("")
+	if err != nil {
+		return settings, "", err
+	}
+
+	versionPrompt := promptui.Prompt{
+		Label:   "Enter API Version",
+		Default: azure.DefaultAPIVersion,
+		Validate: func(input string) error {
+			if strings.TrimSpace(input) == "" {
+				return errors.New("api-version cannot be empty")
+			}
+			return nil
+		},
+	}
+	version, err := versionPrompt.Run()
+	if err != nil {
+		return settings, "", fmt.Errorf("failed to read api-version: %w", err)
+	}
+	settings.Azure.APIVersion = strings.TrimSpace(version)
+
+	authPrompt := promptui.Select{
+		Label: "Select Authentication",
+		Items: []string{"API key (api-key header)", "Microsoft Entra ID access token (bearer)"},
+	}
+	authIdx, _, err := authPrompt.Run()
+	if err != nil {
+		return settings, "", fmt.Errorf("failed to select authentication: %w", err)
+	}
+	credentialLabel := "Enter API Key"
+	settings.Azure.Auth = azure.AuthAPIKey
+	if authIdx == 1 {
+		credentialLabel = "Enter Access Token"
+		settings.Azure.Auth = azure.AuthBearer
+	}
+
+	credentialPrompt := promptui.Prompt{
+		Label: credentialLabel,
+		Mask:  '*',
+		Validate: func(input string) error {
+			if strings.TrimSpace(input) == "" {
+				return errors.New("credential cannot be empty")
+			}
+			return nil
+		},
+	}
+	credential, err := credentialPrompt.Run()
+	if err != nil {
+		return settings, "", fmt.Errorf("failed to read credential: %w", err)
+	}
+
+	return settings, strings.TrimSpace(credential), nil
+}
+
+// promptBedrockSettings asks for the region, AWS profile and optional
+// endpoint override of Bedrock, and warns when no credentials are found.
+func promptBedrockSettings() (types.ProviderSettings, error) {
+	settings := types.ProviderSettings{Bedrock: &types.BedrockSettings{}}
+
+	regionPrompt := promptui.Prompt{
+		Label:   "Enter AWS Region",
+		Default: bedrock.ResolveRegion("", ""),
+		Validate: func(input string) error {
+			if strings.TrimSpace(input) == "" {
+				return errors.New("region cannot be empty")
+			}
+			return nil
+		},
+	}
+	region, err := regionPrompt.Run()
+	if err != nil {
+		return settings, fmt.Errorf("failed to read region: %w", err)
+	}
+	settings.Bedrock.Region = strings.TrimSpace(region)
+
+	profilePrompt := promptui.Prompt{
+		Label: "Enter AWS Profile (optional, empty uses the AWS environment variables or AWS_PROFILE)",
+	}
+	profile, err := profilePrompt.Run()
+	if err != nil {
+		return settings, fmt.Errorf("failed to read profile: %w", err)
+	}
+	settings.Bedrock.Profile = strings.TrimSpace(profile)
+
+	endpointPrompt := promptui.Prompt{
+		Label: "Enter Endpoint URL (optional, overrides the Bedrock runtime endpoint)",
+		Validate: func(input string) error {
+			if strings.TrimSpace(input) == "" {
+				return nil
+			}
+			return validateBaseURL(input)
+		},
+	}
+	endpointURL, err := endpointPrompt.Run()
+	if err != nil {
+		return settings, fmt.Errorf("failed to read endpoint URL: %w", err)
+	}
+	settings.BaseURL = strings.TrimSpace(endpointURL)
+
+	if _, err := bedrock.LoadCredentials(settings.Bedrock.Profile); err != nil {
+		pterm.Warning.Printf("%v\n", err)
+	}
+	return settings, nil
+}
+
+// promptVertexSettings asks for the project, location, credential and
+// optional endpoint of Vertex AI. For a service account, the key file is
+// read and its contents returned as the credential to keep in the keyring.
+func promptVertexSettings() (types.ProviderSettings, string, error) {
+	settings := types.ProviderSettings{Vertex: &types.VertexSettings{}}
+
+	projectPrompt := promptui.Prompt{
+		Label:   "Enter Google Cloud Project ID",
+		Default: os.Getenv("GOOGLE_CLOUD_PROJECT"),
+		Validate: func(input string) error {
+			if strings.TrimSpace(input) == "" {
+				return errors.New("project cannot be empty")
+			}
+			return nil
+		},
+	}
+	project, err := projectPrompt.Run()
+	if err != nil {
+		return settings, "", fmt.Errorf("failed to read project: %w", err)
+	}
+	settings.Vertex.Project = strings.TrimSpace(project)
+
+	locationPrompt := promptui.Prompt{
+		Label:   "Enter Location",
+		Default: vertex.DefaultLocation,
+		Validate: func(input string) error {
+			if strings.TrimSpace(input) == "" {
+				return errors.New("location cannot be empty")
+			}
+			return nil
+		},
+	}
+	location, err := locationPrompt.Run()
+	if err != nil {
+		return settings, "", fmt.Errorf("failed to read location: %w", err)
+	}
+	settings.Vertex.Location = strings.TrimSpace(location)
+
+	authPrompt := promptui.Select{
+		Label: "Select Credentials",
+		Items: []string{"Service account JSON key file", "Application Default Credentials (gcloud, GOOGLE_APPLICATION_CREDENTIALS)", "None (local emulator)"},
+	}
+	authIdx, _, err := authPrompt.Run()
+	if err != nil {
+		return settings, "", fmt.Errorf("failed to select credentials: %w", err)
+	}
+
+	var serviceAccount string
+	switch authIdx {
+	case 0:
+		settings.Vertex.Auth = vertex.AuthServiceAccount
+		keyPrompt := promptui.Prompt{
+			Label: "Enter Path to the Service Account Key",
+			Validate: func(input string) error {
+				_, err := readServiceAccountKey(input)
+				return err
+			},
+		}
+		keyPath, err := keyPrompt.Run()
+		if err != nil {
+			return settings, "", fmt.Errorf("failed to read key path: %w", err)
+		}
+		serviceAccount, err = readServiceAccountKey(keyPath)
+		if err != nil {
+			return settings, "", err
+		}
+	case 1:
+		settings.Vertex.Auth = vertex.AuthADC
+	default:
+		settings.Vertex.Auth = vertex.AuthNone
+	}
+
+	endpointPrompt := promptui.Prompt{
+		Label: "Enter Endpoint URL (optional, e.g. a local emulator)",
+		Validate: func(input string) error {
+			if strings.TrimSpace(input) == "" {
+				return nil
+			}
+			return validateBaseURL(input)
+		},
+	}
+	endpointURL, err := endpointPrompt.Run()
+	if err != nil {
+		return settings, "", fmt.Errorf("failed to read endpoint URL: %w", err)
+	}
+	settings.BaseURL = strings.TrimSpace(endpointURL)
+
+	return settings, serviceAccount, nil
+}
+
+// readServiceAccountKey reads the JSON key file at path and checks that
+// Google's credential loader accepts it.
+func readServiceAccountKey(path string) (string, error) {
+	data, err := os.ReadFile(strings.TrimSpace(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to read service account key: %w", err)
+	}
+	if _, _, err := vertex.NewTokenSource(context.Background(), vertex.AuthServiceAccount, string(data)); err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// promptDeploymentName asks for the name of an Azure OpenAI deployment.
+func promptDeploymentName(current string) (string, error) {
+	deploymentPrompt := promptui.Prompt{
+		Label:   "Enter Deployment Name",
+		Default: current,
+		Validate: func(input string) error {
+			if strings.TrimSpace(input) == "" {
+				return errors.New("deployment name cannot be empty")
+			}
+			return nil
+		},
+	}
+	deployment, err := deploymentPrompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("failed to read deployment name: %w", err)
+	}
+	return strings.TrimSpace(deployment), nil
+}
+
+// promptModelSelection lets the user pick one of the provider's known models or
+// type another one. Choosing the default returns "" so the provider keeps
+// following the built-in default.
+func promptModelSelection(provider types.LLMProvider, current string) (string, error) {
+	known := llm.KnownModels(provider)
+	if len(known) == 0 {
+		modelPrompt := promptui.Prompt{
+			Label:   "Enter Model Name (leave empty if the server ignores it)",
+			Default: current,
+		}
+		modelName, err := modelPrompt.Run()
+		if err != nil {
+			return "", fmt.Errorf("failed to read model name: %w", err)
+		}
+		return strings.TrimSpace(modelName), nil
+	}
+
+	items := make([]string, 0, len(known)+1)
+	cursor := 0
+	for i, name := range known {
+		if i == 0 {
+			name += " (default)"
+		}
+		if known[i] == current {
+			cursor = i
+		}
+		items = append(items, name)
+	}
+	items = append(items, otherModelOption)
+	if current != "" && !slices.Contains(known, current) {
+		cursor = len(items) - 1
+	}
+
+	prompt := promptui.Select{
+		Label:     "Select Model",
+		Items:     items,
+		CursorPos: cursor,
+	}
+	idx, _, err := prompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("failed to select model: %w", err)
+	}
+
+	switch {
+	case idx == 0:
+		return "", nil
+	case idx < len(known):
+		return known[idx], nil
+	}
+
+	return promptModelName(current)
+}
+
+// promptModelName asks for a model name that is not in any list.
+func promptModelName(current string) (string, error) {
+	modelPrompt := promptui.Prompt{
+		Label:   "Enter Model Name",
+		Default: current,
+		Validate: func(input string) error {
+			if strings.TrimSpace(input) == "" {
+				return errors.New("model name cannot be empty")
+			}
+			return nil
+		},
+	}
+	modelName, err := modelPrompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("failed to read model name: %w", err)
+	}
+	return strings.TrimSpace(modelName), nil
+}
+
+// promptOllamaModel offers the models installed on the Ollama server at
+// serverURL. When the server cannot be listed it falls back to the known
+// models; a model that is not pulled yet is accepted with a warning.
+func promptOllamaModel(serverURL string, current string) (string, error) {
+	serverURL = resolveOllamaURL(serverURL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), ollamaListTimeout)
+	defer cancel()
+	installed, err := ollama.ListModels(internalHTTP.WithMaxAttempts(ctx, 1), serverURL)
+	if err != nil {
+		pterm.Warning.Printf("Could not list the models installed on %s: %v\n", serverURL, err)
+		return promptModelSelection(types.ProviderOllama, current)
+	}
+	if len(installed) == 0 {
+		pterm.Warning.Printf("No models are pulled on %s yet. Run 'ollama pull <model>' before generating.\n", serverURL)
+		return promptModelSelection(types.ProviderOllama, current)
+	}
+
+	items := append(slices.Clone(installed), otherModelOption)
+	cursor := 0
+	for i, name := range installed {
+		if current != "" && ollama.HasModel([]string{name}, current) {
+			cursor = i
+		}
+	}
+
+	prompt := promptui.Select{
+		Label:     "Select Installed Model",
+		Items:     items,
+		CursorPos: cursor,
+	}
+	idx, _, err := prompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("failed to select model: %w", err)
+	}
+	if idx < len(installed) {
+		return installed[idx], nil
+	}
+
+	modelName, err := promptModelName(current)
+	if err != nil {
+		return "", err
+	}
+	if !ollama.HasModel(installed, modelName) {
+		pterm.Warning.Printf("%s is not pulled on %s. Run 'ollama pull %s' before generating.\n", modelName, serverURL, modelName)
+	}
+	return modelName, nil
+}
+
+// promptOllamaOptions asks for the options sent with every Ollama request,
+// starting from current. Empty answers keep the server defaults; nil is
+// returned when every option is left empty.
+func promptOllamaOptions(current *types.OllamaSettings) (*types.OllamaSettings, error) {
+	var options types.OllamaSettings
+	if current != nil {
+		options = *current
+	}
+
+	temperatureDefault := ""
+	if options.Temperature != nil {
+		temperatureDefault = strconv.FormatFloat(*options.Temperature, 'g', -1, 64)
+	}
+	temperature, err := promptOptionalValue("Temperature (optional, e.g. 0.2)", temperatureDefault, func(input string) error {
+		value, err := strconv.ParseFloat(input, 64)
+		if err != nil || value < 0 {
+			return errors.New("enter a non-negative number")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	options.Temperature = nil
+	if temperature != "" {
+		value, _ := strconv.ParseFloat(temperature, 64)
+		options.Temperature = &value
+	}
+
+	numCtx, err := promptOptionalInt("Context window, num_ctx (optional, e.g. 8192)", options.NumCtx, 1)
+	if err != nil {
+		return nil, err
+	}
+	options.NumCtx = numCtx
+
+	numPredict, err := promptOptionalInt("Max response tokens, num_predict (optional, -1 for no limit)", options.NumPredict, -1)
+	if err != nil {
+		return nil, err
+	}
+	options.NumPredict = numPredict
+
+	options.KeepAlive, err = promptOptionalValue("Keep model loaded for, keep_alive (optional, e.g. 10m or -1)", options.KeepAlive, func(input string) error {
+		_, err := ollama.ParseKeepAlive(input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if options == (types.OllamaSettings{}) {
+		return nil, nil
+	}
+	return &options, nil
+}
+
+// promptOptionalInt reads an integer no smaller than minimum; zero and an
+// empty answer both mean unset.
+func promptOptionalInt(label string, current int, minimum int) (int, error) {
+	currentText := ""
+	if current != 0 {
+		currentText = strconv.Itoa(current)
+	}
+	answer, err := promptOptionalValue(label, currentText, func(input string) error {
+		value, err := strconv.Atoi(input)
+		if err != nil || value < minimum {
+			return fmt.Errorf("enter a whole number of at least %d", minimum)
+		}
+		return nil
+	})
+	if err != nil || answer == "" {
+		return 0, err
+	}
+	value, _ := strconv.Atoi(answer)
+	return value, nil
+}
+
+// promptOptionalValue reads a trimmed answer, running validate only on
+// non-empty input.
+func promptOptionalValue(label string, current string, validate func(string) error) (string, error) {
+	prompt := promptui.Prompt{
+		Label:   label,
+		Default: current,
+		Validate: func(input string) error {
+			input = strings.TrimSpace(input)
+			if input == "" {
+				return nil
+			}
+			return validate(input)
+		},
+	}
+	answer, err := prompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("failed to read answer: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// validateBaseURL accepts absolute http(s) URLs.
+func validateBaseURL(input string) error {
+	parsed, err := url.Parse(strings.TrimSpace(input))
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return errors.New("enter an absolute http:// or https:// URL")
+	}
+	return nil
+}
+
+// parseHeaderList turns "Name=Value, Other=Value" into a header map.
+func parseHeaderList(input string) (map[string]string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, nil
+	}
+
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(input, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid header %q, expected Name=Value", pair)
+		}
+		headers[name] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
 // UpdateLLM lets the user switch defaults, rotate API keys, or delete stored
 // LLM provider configurations.
 func UpdateLLM(Store *store.StoreMethods) error {
@@ -84,8 +709,8 @@ func UpdateLLM(Store *store.StoreMethods) error {
 	}
 
 	models := []string{}
-	options1 := []string{"Set Default", "Change API Key", "Delete"}
-	options2 := []string{"Set Default", "Change URL", "Delete"} //different option for local model
+	options1 := []string{"Set Default", "Change API Key", "Change Model", "Delete"}
+	options2 := []string{"Set Default", "Change URL", "Change Model", "Change Options", "Delete"} //different option for local model
 
 	for _, p := range SavedModels.LLMProviders {
 		models = append(models, p.String())
@@ -110,6 +735,27 @@ func UpdateLLM(Store *store.StoreMethods) error {
 		Label: "Enter API Key",
 	}
 
+	if model == types.ProviderVertexAI.String() {
+		prompt = promptui.Select{
+			Label: "Select Option",
+			Items: []string{"Set Default", "Change Model", "Change Vertex Settings", "Delete"},
+		}
+	}
+
+	if model == types.ProviderCassette.String() {
+		prompt = promptui.Select{
+			Label: "Select Option",
+			Items: []string{"Set Default", "Change Cassette File", "Delete"},
+		}
+	}
+
+	if model == types.ProviderBedrock.String() {
+		prompt = promptui.Select{
+			Label: "Select Option",
+			Items: []string{"Set Default", "Change Model", "Change AWS Settings", "Delete"},
+		}
+	}
+
 	if model == types.ProviderOllama.String() {
 		prompt = promptui.Select{
 			Label: "Select Option",
@@ -121,13 +767,13 @@ func UpdateLLM(Store *store.StoreMethods) error {
 		}
 	}
 
-	opNo, _, err := prompt.Run()
+	_, option, err := prompt.Run()
 	if err != nil {
 		return err
 	}
 
-	switch opNo {
-	case 0:
+	switch option {
+	case "Set Default":
 		modelProvider, valid := types.ParseLLMProvider(model)
 		if !valid {
 			return fmt.Errorf("invalid LLM provider: %s", model)
@@ -137,7 +783,7 @@ func UpdateLLM(Store *store.StoreMethods) error {
 			return err
 		}
 		fmt.Printf("%s set as default", model)
-	case 1:
+	case "Change API Key", "Change URL":
 		apiKey, err := apiKeyPrompt.Run()
 		if err != nil {
 			return err
@@ -155,7 +801,86 @@ func UpdateLLM(Store *store.StoreMethods) error {
 			event = "URL"
 		}
 		fmt.Printf("%s %s Updated", model, event)
-	case 2:
+	case "Change Model":
+		modelProvider, valid := types.ParseLLMProvider(model)
+		if !valid {
+			return fmt.Errorf("invalid LLM provider: %s", model)
+		}
+		current := SavedModels.Settings[modelProvider].Model
+		var modelName string
+		switch modelProvider {
+		case types.ProviderOllama:
+			saved, err := Store.LoadLLM(modelProvider)
+			if err != nil {
+				return err
+			}
+			modelName, err = promptOllamaModel(saved.APIKey, current)
+			if err != nil {
+				return err
+			}
+		case types.ProviderAzureOpenAI:
+			modelName, err = promptDeploymentName(current)
+			if err != nil {
+				return err
+			}
+		default:
+			modelName, err = promptModelSelection(modelProvider, current)
+			if err != nil {
+				return err
+			}
+		}
+		err = store.ChangeModel(modelProvider, modelName)
+		if err != nil {
+			return err
+		}
+		if modelName == "" {
+			modelName = llm.ResolveModel(modelProvider, types.ProviderSettings{})
+		}
+		fmt.Printf("%s will use model %s", model, modelName)
+	case "Change Options":
+		options, err := promptOllamaOptions(SavedModels.Settings[types.ProviderOllama].Ollama)
+		if err != nil {
+			return err
+		}
+		if err := store.ChangeOllamaSettings(options); err != nil {
+			return err
+		}
+		fmt.Printf("%s options Updated", model)
+	case "Change Vertex Settings":
+		settings, credential, err := promptVertexSettings()
+		if err != nil {
+			return err
+		}
+		if err := Store.UpdateAPIKey(types.ProviderVertexAI, credential); err != nil {
+			return err
+		}
+		if err := store.ChangeVertexSettings(settings.BaseURL, settings.Vertex); err != nil {
+			return err
+		}
+		fmt.Printf("%s settings Updated", model)
+	case "Change Cassette File":
+		saved, err := Store.LoadLLM(types.ProviderCassette)
+		if err != nil {
+			return err
+		}
+		path, err := promptCassettePath(saved.APIKey)
+		if err != nil {
+			return err
+		}
+		if err := Store.UpdateAPIKey(types.ProviderCassette, path); err != nil {
+			return err
+		}
+		fmt.Printf("%s file Updated", model)
+	case "Change AWS Settings":
+		settings, err := promptBedrockSettings()
+		if err != nil {
+			return err
+		}
+		if err := store.ChangeBedrockSettings(settings.BaseURL, settings.Bedrock); err != nil {
+			return err
+		}
+		fmt.Printf("%s AWS settings Updated", model)
+	case "Delete":
 		modelProvider, valid := types.ParseLLMProvider(model)
 		if !valid {
 			return fmt.Errorf("invalid LLM provider: %s", model)
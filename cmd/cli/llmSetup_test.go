@@ -0,0 +1,38 @@
+package cmd
+
+import "testing"
+
+func TestParseHeaderList(t *testing.T) {
+	headers, err := parseHeaderList(" X-Org=acme, X-Env = dev ,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(headers) != 2 || headers["X-Org"] != "acme" || headers["X-Env"] != "dev" {
+		t.Fatalf("unexpected headers: %v", headers)
+	}
+
+	if headers, err := parseHeaderList("  "); err != nil || headers != nil {
+		t.Fatalf("expected empty input to yield no headers, got %v, %v", headers, err)
+	}
+
+	if _, err := parseHeaderList("missing-separator"); err == nil {
+		t.Fatal("expected error for header without '='")
+	}
+}
+
+func TestValidateBaseURL(t *testing.T) {
+	valid := []string{"http://localhost:8000/v1", "https://llm.internal.example.com/v1"}
+	for _, input := range valid {
+		if err := validateBaseURL(input); err != nil {
+			t.Fatalf("expected %q to be valid, got %v", input, err)
+		}
+	}
+
+	invalid := []string{"", "localhost:8000", "ftp://host/v1"}
+	for _, input := range invalid {
+		if err := validateBaseURL(input); err == nil {
+			t.Fatalf("expected %q to be rejected", input)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/dfanso/commit-msg/cmd/cli/store"
+	"github.com/dfanso/commit-msg/internal/doctor"
+	"github.com/dfanso/commit-msg/internal/llm"
+	"github.com/dfanso/commit-msg/internal/plugin"
+	"github.com/dfanso/commit-msg/pkg/types"
+	"github.com/pterm/pterm"
+)
+
+// registerPlugins makes the commit-msg-provider-<name> executables on PATH
+// and the plugins declared in config.json available as providers. A
+// declared plugin replaces one on PATH with the same name.
+func registerPlugins() {
+	plugins := plugin.Discover(os.Getenv("PATH"))
+
+	if cfg, err := store.ListSavedModels(); err == nil && len(cfg.Plugins) > 0 {
+		declared, err := plugin.Declared(cfg.Plugins)
+		if err != nil {
+			pterm.Warning.Printf("Ignoring the plugins in config.json: %v\n", err)
+		}
+		plugins = append(plugins, declared...)
+	}
+
+	for _, p := range plugins {
+		if err := llm.RegisterPlugin(p); err != nil {
+			pterm.Warning.Printf("Ignoring plugin %s: %v\n", strings.Join(p.Command, " "), err)
+		}
+	}
+}
+
+// checkPluginProvider reports whether the command of a plugin provider can
+// be run. Plugins speak no HTTP, so there is nothing to probe.
+func checkPluginProvider(provider types.LLMProvider, p plugin.Plugin) doctor.Result {
+	name := "Provider " + provider.String()
+	path, err := exec.LookPath(p.Command[0])
+	if err != nil {
+		return doctor.Result{Name: name, Status: doctor.StatusFail, Detail: err.Error(),
+			Fix: "Install the plugin or fix its command under \"plugins\" in config.json."}
+	}
+	return doctor.Result{Name: name, Status: doctor.StatusPass, Detail: "Plugin " + path}
+}
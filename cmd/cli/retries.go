@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/pterm/pterm"
+)
+
+// retryLog records the HTTP retries made during a generation so verbose
+// output can list them once the progress display is gone.
+type retryLog struct {
+	// mu guards attempts against candidates generated in parallel.
+	mu       sync.Mutex
+	attempts []internalHTTP.RetryAttempt
+}
+
+func (l *retryLog) trace() *internalHTTP.RetryTrace {
+	return &internalHTTP.RetryTrace{
+		Retry: func(attempt internalHTTP.RetryAttempt) {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.attempts = append(l.attempts, attempt)
+		},
+	}
+}
+
+// show prints the recorded retries and forgets them.
+func (l *retryLog) show() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, attempt := range l.attempts {
+		pterm.Info.Printf("Retried %s %s: attempt %d/%d failed (%s), waited %s\n",
+			attempt.Method, attempt.Host, attempt.Attempt, attempt.MaxAttempts,
+			retryReason(attempt), attempt.Delay.Round(10*time.Millisecond))
+	}
+	l.attempts = nil
+}
+
+func retryReason(attempt internalHTTP.RetryAttempt) string {
+	if attempt.StatusCode != 0 {
+		return fmt.Sprintf("HTTP %d", attempt.StatusCode)
+	}
+	return attempt.Err.Error()
+}
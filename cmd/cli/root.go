@@ -1,9 +1,15 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/dfanso/commit-msg/cmd/cli/store"
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/internal/llm"
+	"github.com/dfanso/commit-msg/pkg/types"
 	"github.com/spf13/cobra"
 )
 
@@ -30,6 +36,46 @@ var rootCmd = &cobra.Command{
 	# Generate a commit message and automatically commit it
 	commit . --auto
 
+	# Describe and commit every change of tracked files, like git commit -a
+	commit . --all --auto
+
+	# Give up on the LLM if it takes longer than 45 seconds
+	commit . --timeout 45s
+
+	# Use a different model of the default provider for this run
+	commit . --model gpt-4o-mini
+
+	# Generate three messages side by side, then accept, edit or merge them
+	commit . --candidates 3
+
+	# Pin temperature 0 and a fixed seed for reproducible CI runs
+	commit . --dry-run --deterministic
+
+	# Compare the messages of Claude, Gemini and Ollama and let Claude rank them
+	commit . --compare=Claude,Gemini,Ollama --judge Claude
+
+	# Retry a rate-limited provider up to 5 times and list each retry
+	commit . --max-attempts 5 --toggle
+
+	# Write a better message for the commit before HEAD, or amend HEAD
+	commit reword HEAD~1
+	commit . --amend --auto
+
+	# Fill in a generated message whenever plain git commit opens the editor
+	commit hook install
+
+	# Check git, the keyring, config files and every configured provider
+	commit doctor
+
+	# Try Groq, then a local Ollama, when the default provider fails
+	commit llm fallback Groq Ollama
+
+	# Give the default provider a lower temperature and a larger answer limit
+	commit llm sampling --temperature 0.2 --max-tokens 800
+
+	# Send provider requests through a corporate proxy
+	commit llm network --proxy http://proxy.example.com:3128
+
 	# Show verbose debug information (diff stats, full prompts, repository details)
 	commit . --toggle
 	commit . --dry-run --toggle
@@ -42,6 +88,12 @@ var rootCmd = &cobra.Command{
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
+	configureNetwork()
+	registerPlugins()
+	if err := useCassetteFromEnv(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
 	err := rootCmd.Execute()
 	if err != nil {
 		os.Exit(1)
@@ -69,6 +121,131 @@ var llmUpdateCmd = &cobra.Command{
 	},
 }
 
+var llmModelsCmd = &cobra.Command{
+	Use:   "models [provider]",
+	Short: "List known and available models for a provider",
+	Long: `List the well-known models of an LLM provider and, when the provider is
+configured, the models its API reports as available. Defaults to the default provider.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		provider := ""
+		if len(args) == 1 {
+			provider = args[0]
+		}
+		return ListLLMModels(Store, provider)
+	},
+}
+
+var llmFallbackCmd = &cobra.Command{
+	Use:   "fallback [provider...]",
+	Short: "Show or set the providers tried when the default LLM fails",
+	Long: `Set the ordered list of providers tried after the default provider fails
+with a retryable or authentication error, e.g. 'commit llm fallback Groq Ollama'.
+Without arguments the current chain is shown; --clear removes it.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clearChain, err := cmd.Flags().GetBool("clear")
+		if err != nil {
+			return err
+		}
+		return ConfigureFallback(Store, args, clearChain)
+	},
+}
+
+var llmNetworkCmd = &cobra.Command{
+	Use:   "network",
+	Short: "Show or set the proxy and TLS settings for provider requests",
+	Long: `Route provider requests through a proxy, trust an extra CA bundle or present
+a client certificate for mutual TLS, e.g. 'commit llm network --proxy http://proxy:3128'.
+Only the flags given are changed and an empty value removes a setting. Without
+flags the current settings are shown; --clear removes them all.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clearSettings, err := cmd.Flags().GetBool("clear")
+		if err != nil {
+			return err
+		}
+		changes := make(map[string]string)
+		for _, name := range networkFlags {
+			if !cmd.Flags().Changed(name) {
+				continue
+			}
+			value, err := cmd.Flags().GetString(name)
+			if err != nil {
+				return err
+			}
+			changes[name] = value
+		}
+		return ConfigureNetwork(changes, clearSettings)
+	},
+}
+
+var llmSamplingCmd = &cobra.Command{
+	Use:   "sampling [provider]",
+	Short: "Show or set the temperature, max tokens, top-p and seed of a provider",
+	Long: `Set the sampling settings sent to a provider, e.g. 'commit llm sampling Groq
+--temperature 0.3 --max-tokens 800'. The provider defaults to the default one;
+--style sets them for a regeneration style preset instead, which wins over the
+provider's. Only the flags given are changed and an empty value removes a
+setting. Without flags the current settings are shown; --clear removes them all.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clearSettings, err := cmd.Flags().GetBool("clear")
+		if err != nil {
+			return err
+		}
+		style, err := cmd.Flags().GetString("style")
+		if err != nil {
+			return err
+		}
+		provider := ""
+		if len(args) == 1 {
+			provider = args[0]
+		}
+		changes := make(map[string]string)
+		for _, name := range samplingFlags {
+			if !cmd.Flags().Changed(name) {
+				continue
+			}
+			value, err := cmd.Flags().GetString(name)
+			if err != nil {
+				return err
+			}
+			changes[name] = value
+		}
+		return ConfigureSampling(provider, style, changes, clearSettings)
+	},
+}
+
+// changeScopeFromFlags returns the change scope selected by --staged,
+// --all and --include-untracked.
+func changeScopeFromFlags(cmd *cobra.Command) (types.ChangeScope, error) {
+	staged, err := cmd.Flags().GetBool("staged")
+	if err != nil {
+		return "", err
+	}
+	all, err := cmd.Flags().GetBool("all")
+	if err != nil {
+		return "", err
+	}
+	untracked, err := cmd.Flags().GetBool("include-untracked")
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case staged && (all || untracked):
+		return "", errors.New("--staged cannot be combined with --all or --include-untracked")
+	case staged:
+		return types.ScopeStaged, nil
+	case untracked:
+		return types.ScopeUntracked, nil
+	case all:
+		return types.ScopeAll, nil
+	default:
+		return types.ScopeAuto, nil
+	}
+}
+
 var cacheCmd = &cobra.Command{
 	Use:   "cache",
 	Short: "Manage commit message cache",
@@ -103,26 +280,145 @@ var creatCommitMsg = &cobra.Command{
 	Use:   ".",
 	Short: "Create Commit Message",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		dryRun, err := cmd.Flags().GetBool("dry-run")
+		options, err := commitOptionsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		options.Scope, err = changeScopeFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		options.Amend, err = cmd.Flags().GetBool("amend")
 		if err != nil {
 			return err
 		}
 
-		autoCommit, err := cmd.Flags().GetBool("auto")
+		CreateCommitMsg(Store, options)
+		return nil
+	},
+}
+
+var rewordCmd = &cobra.Command{
+	Use:   "reword <rev>",
+	Short: "Rewrite the message of an existing commit",
+	Long: `Generate a new message for an existing commit from the changes it made and
+its current message, review it as with 'commit .', and replace the message.
+HEAD is amended. An older commit and the commits after it are recreated
+with the same trees, authors and messages, like a reword in an interactive
+rebase, without touching the work tree or the index. Commits after the
+reworded one must not include merges. Recreated commits lose their GPG or
+SSH signatures. The commit-msg hook checks the new message unless
+--no-verify is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		options, err := commitOptionsFromFlags(cmd)
 		if err != nil {
 			return err
 		}
 
-		verbose, err := cmd.Flags().GetBool("toggle")
+		options.Reword = args[0]
+		options.NoVerify, err = cmd.Flags().GetBool("no-verify")
 		if err != nil {
 			return err
 		}
 
-		CreateCommitMsg(Store, dryRun, autoCommit, verbose)
+		CreateCommitMsg(Store, options)
 		return nil
 	},
 }
 
+// addGenerationFlags registers the flags that shape generation on cmd.
+func addGenerationFlags(cmd *cobra.Command) {
+	cmd.Flags().Duration("timeout", 0, "Abort a generation request that takes longer than this (e.g. 30s, 2m); 0 disables the limit")
+	cmd.Flags().StringP("model", "m", "", "Use this model instead of the one configured for the default provider")
+	cmd.Flags().IntP("candidates", "n", 1, "Generate this many alternative messages at once and pick one")
+	cmd.Flags().Int("max-attempts", internalHTTP.DefaultMaxAttempts, "Send a rate-limited or overloaded provider request at most this many times")
+	cmd.Flags().StringSlice("compare", nil, "Compare the messages of every configured provider, or of --compare=Claude,Gemini,Ollama, side by side")
+	cmd.Flags().Lookup("compare").NoOptDefVal = compareAll
+	cmd.Flags().String("judge", "", "Have this provider rank the messages of --compare")
+	cmd.Flags().Bool("deterministic", false, "Use temperature 0 and a fixed seed for reproducible messages (also set by COMMIT_DETERMINISTIC=1)")
+}
+
+// commitOptionsFromFlags reads the persistent flags and those of
+// addGenerationFlags.
+func commitOptionsFromFlags(cmd *cobra.Command) (CommitOptions, error) {
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return CommitOptions{}, err
+	}
+
+	autoCommit, err := cmd.Flags().GetBool("auto")
+	if err != nil {
+		return CommitOptions{}, err
+	}
+
+	verbose, err := cmd.Flags().GetBool("toggle")
+	if err != nil {
+		return CommitOptions{}, err
+	}
+
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return CommitOptions{}, err
+	}
+
+	model, err := cmd.Flags().GetString("model")
+	if err != nil {
+		return CommitOptions{}, err
+	}
+
+	maxAttempts, err := cmd.Flags().GetInt("max-attempts")
+	if err != nil {
+		return CommitOptions{}, err
+	}
+	if maxAttempts < 1 {
+		return CommitOptions{}, errors.New("--max-attempts must be at least 1")
+	}
+
+	candidates, err := cmd.Flags().GetInt("candidates")
+	if err != nil {
+		return CommitOptions{}, err
+	}
+	if candidates < 1 || candidates > llm.MaxCandidates {
+		return CommitOptions{}, fmt.Errorf("--candidates must be between 1 and %d", llm.MaxCandidates)
+	}
+
+	deterministic, err := cmd.Flags().GetBool("deterministic")
+	if err != nil {
+		return CommitOptions{}, err
+	}
+
+	compare, err := cmd.Flags().GetStringSlice("compare")
+	if err != nil {
+		return CommitOptions{}, err
+	}
+	judge, err := cmd.Flags().GetString("judge")
+	if err != nil {
+		return CommitOptions{}, err
+	}
+	if judge != "" && len(compare) == 0 {
+		return CommitOptions{}, errors.New("--judge ranks the messages of --compare and needs it")
+	}
+	if len(compare) > 0 && candidates > 1 {
+		return CommitOptions{}, errors.New("--compare cannot be combined with --candidates")
+	}
+
+	return CommitOptions{
+		DryRun:        dryRun,
+		AutoCommit:    autoCommit,
+		Verbose:       verbose,
+		Timeout:       timeout,
+		Model:         model,
+		MaxAttempts:   maxAttempts,
+		Candidates:    candidates,
+		Deterministic: deterministic || deterministicFromEnv(),
+		Compare:       compare,
+		Judge:         judge,
+	}, nil
+}
+
 func init() {
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
@@ -138,13 +434,49 @@ func init() {
 	rootCmd.PersistentFlags().Bool("auto", false, "Automatically commit with the generated message")
 	rootCmd.PersistentFlags().BoolP("toggle", "t", false, "Show verbose debug information (diff stats, full prompts, repository details)")
 
+	addGenerationFlags(creatCommitMsg)
+	addGenerationFlags(rewordCmd)
+	rewordCmd.Flags().Bool("no-verify", false, "Do not run the commit-msg hook on the new message")
+
+	creatCommitMsg.Flags().Bool("staged", false, "Describe only the staged changes, which is what git commit commits (default when something is staged)")
+	creatCommitMsg.Flags().BoolP("all", "a", false, "Describe staged and unstaged changes of tracked files; --auto commits them with git commit -a")
+	creatCommitMsg.Flags().BoolP("include-untracked", "u", false, "Describe untracked files as well, implying --all; --auto stages them first")
+	creatCommitMsg.Flags().Bool("amend", false, "Describe HEAD's changes as well, with its message as context; --auto amends HEAD")
+
+	llmFallbackCmd.Flags().Bool("clear", false, "Remove the fallback chain")
+
+	llmSamplingCmd.Flags().String("temperature", "", "Sampling temperature between 0 and 2")
+	llmSamplingCmd.Flags().String("max-tokens", "", "Most tokens the provider may write in an answer")
+	llmSamplingCmd.Flags().String("top-p", "", "Nucleus sampling probability mass, above 0 and at most 1")
+	llmSamplingCmd.Flags().String("seed", "", "Seed for repeatable answers, where the provider supports one")
+	llmSamplingCmd.Flags().String("style", "", "Set the settings of this style preset instead: "+strings.Join(stylePresetNames(), ", "))
+	llmSamplingCmd.Flags().Bool("clear", false, "Remove all sampling settings of the provider or style")
+
+	llmNetworkCmd.Flags().String("proxy", "", "Send provider requests through this proxy (http, https or socks5 URL)")
+	llmNetworkCmd.Flags().String("no-proxy", "", "Comma-separated hosts reached without the proxy, as in NO_PROXY")
+	llmNetworkCmd.Flags().String("ca-bundle", "", "PEM file with certificate authorities to trust besides the system ones")
+	llmNetworkCmd.Flags().String("client-cert", "", "PEM client certificate presented for mutual TLS")
+	llmNetworkCmd.Flags().String("client-key", "", "PEM private key of the client certificate")
+	llmNetworkCmd.Flags().Bool("clear", false, "Remove all network settings")
+
 	rootCmd.AddCommand(creatCommitMsg)
 	rootCmd.AddCommand(llmCmd)
 	rootCmd.AddCommand(cacheCmd)
 	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(hookCmd)
+	rootCmd.AddCommand(rewordCmd)
 	llmCmd.AddCommand(llmSetupCmd)
 	llmCmd.AddCommand(llmUpdateCmd)
+	llmCmd.AddCommand(llmModelsCmd)
+	llmCmd.AddCommand(llmFallbackCmd)
+	llmCmd.AddCommand(llmNetworkCmd)
+	llmCmd.AddCommand(llmSamplingCmd)
 	cacheCmd.AddCommand(cacheStatsCmd)
 	cacheCmd.AddCommand(cacheClearCmd)
 	cacheCmd.AddCommand(cacheCleanupCmd)
+	hookCmd.AddCommand(hookInstallCmd)
+	hookCmd.AddCommand(hookUninstallCmd)
+	hookCmd.AddCommand(hookStatusCmd)
+	hookCmd.AddCommand(hookRunCmd)
 }
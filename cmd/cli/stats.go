@@ -72,7 +72,11 @@ func displayStatistics(store *store.StoreMethods, detailed bool) error {
 		{"Failed Generations", fmt.Sprintf("%d (%.1f%%)", stats.FailedGenerations, float64(stats.FailedGenerations)/float64(stats.TotalGenerations)*100)},
 		{"Average Generation Time", fmt.Sprintf("%.1f ms", stats.AverageGenerationTime)},
 		{"Total Cost", fmt.Sprintf("$%.4f", stats.TotalCost)},
-		{"Total Tokens Used", fmt.Sprintf("%d", stats.TotalTokensUsed)},
+		{"Total Tokens Used", fmt.Sprintf("%d (%d prompt, %d completion)", stats.TotalTokensUsed, stats.TotalPromptTokens, stats.TotalCompletionTokens)},
+	}
+
+	if stats.CancelledGenerations > 0 {
+		overallData = append(overallData, []string{"Cancelled Generations", fmt.Sprintf("%d", stats.CancelledGenerations)})
 	}
 
 	if stats.CacheHits > 0 || stats.CacheMisses > 0 {
@@ -144,6 +148,8 @@ func displayStatistics(store *store.StoreMethods, detailed bool) error {
 				{"Average Generation Time", fmt.Sprintf("%.1f ms", providerStats.AverageGenerationTime)},
 				{"Total Cost", fmt.Sprintf("$%.4f", providerStats.TotalCost)},
 				{"Total Tokens Used", fmt.Sprintf("%d", providerStats.TotalTokensUsed)},
+				{"Prompt Tokens", fmt.Sprintf("%d", providerStats.TotalPromptTokens)},
+				{"Completion Tokens", fmt.Sprintf("%d", providerStats.TotalCompletionTokens)},
 			}
 
 			if providerStats.FirstUsed != "" {
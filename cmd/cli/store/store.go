@@ -56,14 +56,26 @@ func KeyringInit() (*StoreMethods, error) {
 
 // LLMProvider represents a single stored LLM provider and its credential.
 type LLMProvider struct {
-	LLM    types.LLMProvider `json:"model"`
-	APIKey string            `json:"api_key"`
+	LLM      types.LLMProvider      `json:"model"`
+	APIKey   string                 `json:"api_key"`
+	Settings types.ProviderSettings `json:"settings,omitempty"`
 }
 
 // Config describes the on-disk structure for all saved LLM providers.
 type Config struct {
-	Default      types.LLMProvider   `json:"default"`
-	LLMProviders []types.LLMProvider `json:"models"`
+	Default      types.LLMProvider                            `json:"default"`
+	LLMProviders []types.LLMProvider                          `json:"models"`
+	Settings     map[types.LLMProvider]types.ProviderSettings `json:"settings,omitempty"`
+	// Fallback lists the providers to try, in order, when the default fails.
+	Fallback []types.LLMProvider `json:"fallback,omitempty"`
+	// Plugins declares external provider commands by provider name, in
+	// addition to the commit-msg-provider-<name> executables on PATH.
+	Plugins map[string]string `json:"plugins,omitempty"`
+	// Network holds the proxy and TLS settings used for provider requests.
+	Network *types.NetworkSettings `json:"network,omitempty"`
+	// StyleSampling holds the sampling settings of the regeneration style
+	// presets by preset name. They take precedence over the provider's.
+	StyleSampling map[string]types.Sampling `json:"style_sampling,omitempty"`
 }
 
 // Save persists or updates an LLM provider entry, marking it as the default.
@@ -105,8 +117,9 @@ func (s *StoreMethods) Save(LLMConfig LLMProvider) error {
 	for _, p := range cfg.LLMProviders {
 		if p == LLMConfig.LLM {
 			err := s.ring.Set(keyring.Item{ //save apiKey using keychain to OS credentials
-				Key:  string(LLMConfig.LLM),
-				Data: []byte(LLMConfig.APIKey),
+				Key:   string(LLMConfig.LLM),
+				Label: credentialLabel(LLMConfig.LLM),
+				Data:  []byte(LLMConfig.APIKey),
 			})
 			if err != nil {
 				return fmt.Errorf("failed to store credentials in keyring: %w", err)
@@ -120,8 +133,9 @@ func (s *StoreMethods) Save(LLMConfig LLMProvider) error {
 	if !updated {
 		cfg.LLMProviders = append(cfg.LLMProviders, LLMConfig.LLM)
 		err := s.ring.Set(keyring.Item{ //save apiKey using keychain to OS credentials
-			Key:  string(LLMConfig.LLM),
-			Data: []byte(LLMConfig.APIKey),
+			Key:   string(LLMConfig.LLM),
+			Label: credentialLabel(LLMConfig.LLM),
+			Data:  []byte(LLMConfig.APIKey),
 		})
 		if err != nil {
 			return fmt.Errorf("failed to store credentials in keyring: %w", err)
@@ -129,6 +143,7 @@ func (s *StoreMethods) Save(LLMConfig LLMProvider) error {
 	}
 
 	cfg.Default = LLMConfig.LLM
+	setProviderSettings(&cfg, LLMConfig.LLM, LLMConfig.Settings)
 
 	data, err = json.MarshalIndent(cfg, "", " ")
 	if err != nil {
@@ -179,12 +194,38 @@ func (s *StoreMethods) DefaultLLMKey() (*LLMProvider, error) {
 				return nil, err
 			}
 			useModel.APIKey = string(i.Data)
+			useModel.Settings = cfg.Settings[useModel.LLM]
 			return &useModel, nil
 		}
 	}
 	return nil, fmt.Errorf("default model '%s' not found in saved providers, run 'commit llm setup' to configure it", defaultLLM)
 }
 
+// LoadLLM returns a saved provider, including its credential and settings,
+// whether or not it is the default.
+func (s *StoreMethods) LoadLLM(Model types.LLMProvider) (*LLMProvider, error) {
+
+	cfg, err := ListSavedModels()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range cfg.LLMProviders {
+		if p == Model {
+			item, err := s.ring.Get(string(Model)) // Fetches apiKey from OS credentials
+			if err != nil {
+				return nil, err
+			}
+			return &LLMProvider{
+				LLM:      Model,
+				APIKey:   string(item.Data),
+				Settings: cfg.Settings[Model],
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("no saved entry for %s, run 'commit llm setup' to configure it", Model.String())
+}
+
 // ListSavedModels loads all persisted LLM provider configurations.
 func ListSavedModels() (*Config, error) {
 
@@ -268,6 +309,225 @@ func ChangeDefault(Model types.LLMProvider) error {
 	return os.WriteFile(configPath, data, 0600)
 }
 
+// ChangeModel stores the model to use for a saved provider. An empty model
+// reverts to the provider default.
+func ChangeModel(Model types.LLMProvider, modelName string) error {
+	return updateProviderSettings(Model, "model", func(settings *types.ProviderSettings) {
+		settings.Model = modelName
+	})
+}
+
+// ChangeOllamaSettings replaces the request options sent to Ollama. A nil
+// value restores the server defaults.
+func ChangeOllamaSettings(options *types.OllamaSettings) error {
+	return updateProviderSettings(types.ProviderOllama, "options", func(settings *types.ProviderSettings) {
+		settings.Ollama = options
+	})
+}
+
+// ChangeBedrockSettings replaces the region, profile and endpoint override
+// used by Bedrock. The model ID is kept.
+func ChangeBedrockSettings(endpointURL string, options *types.BedrockSettings) error {
+	return updateProviderSettings(types.ProviderBedrock, "AWS settings", func(settings *types.ProviderSettings) {
+		settings.BaseURL = endpointURL
+		settings.Bedrock = options
+	})
+}
+
+// ChangeVertexSettings replaces the project, location, credential type and
+// endpoint override used by Vertex AI. The model is kept.
+func ChangeVertexSettings(endpointURL string, options *types.VertexSettings) error {
+	return updateProviderSettings(types.ProviderVertexAI, "Vertex AI settings", func(settings *types.ProviderSettings) {
+		settings.BaseURL = endpointURL
+		settings.Vertex = options
+	})
+}
+
+// ChangeSampling stores the sampling settings of a saved provider. A nil
+// value restores the provider defaults.
+func ChangeSampling(Model types.LLMProvider, sampling *types.Sampling) error {
+	return updateProviderSettings(Model, "sampling settings", func(settings *types.ProviderSettings) {
+		settings.Sampling = sampling
+	})
+}
+
+// updateProviderSettings applies update to the saved settings of a
+// configured provider; what names the setting in the error message.
+func updateProviderSettings(Model types.LLMProvider, what string, update func(*types.ProviderSettings)) error {
+
+	configPath, err := StoreUtils.GetConfigPath()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := ListSavedModels()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, p := range cfg.LLMProviders {
+		if p == Model {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("cannot change %s of %s: no saved entry", what, Model.String())
+	}
+
+	settings := cfg.Settings[Model]
+	update(&settings)
+	setProviderSettings(cfg, Model, settings)
+
+	data, err := json.MarshalIndent(cfg, "", " ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(configPath, data, 0600)
+}
+
+// SetFallback stores the ordered list of providers tried after the default
+// one fails. Every entry must be a saved provider; an empty list disables
+// fallback.
+func SetFallback(providers []types.LLMProvider) error {
+
+	configPath, err := StoreUtils.GetConfigPath()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := ListSavedModels()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[types.LLMProvider]bool, len(providers))
+	for _, provider := range providers {
+		if seen[provider] {
+			return fmt.Errorf("%s is listed more than once", provider.String())
+		}
+		seen[provider] = true
+
+		found := false
+		for _, p := range cfg.LLMProviders {
+			if p == provider {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("cannot use %s as fallback: no saved entry, run 'commit llm setup' first", provider.String())
+		}
+	}
+
+	cfg.Fallback = providers
+
+	data, err := json.MarshalIndent(cfg, "", " ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(configPath, data, 0600)
+}
+
+// SetNetwork saves the proxy and TLS settings for provider requests,
+// creating the config file when needed. Zero settings remove them.
+func SetNetwork(settings types.NetworkSettings) error {
+
+	var cfg Config
+
+	configPath, err := StoreUtils.GetConfigPath()
+	if err != nil {
+		return err
+	}
+
+	isConfigExists := StoreUtils.CheckConfig(configPath)
+	if !isConfigExists {
+		err := StoreUtils.CreateConfigFile(configPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	if len(data) > 2 {
+		err = json.Unmarshal(data, &cfg)
+		if err != nil {
+			return fmt.Errorf("config file format error: %w. Please delete the config and run setup again", err)
+		}
+	}
+
+	if settings.IsZero() {
+		cfg.Network = nil
+	} else {
+		cfg.Network = &settings
+	}
+
+	data, err = json.MarshalIndent(cfg, "", " ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(configPath, data, 0600)
+}
+
+// SetStyleSampling stores the sampling settings of a style preset. Zero
+// settings remove the preset's entry.
+func SetStyleSampling(style string, sampling types.Sampling) error {
+
+	var cfg Config
+
+	configPath, err := StoreUtils.GetConfigPath()
+	if err != nil {
+		return err
+	}
+
+	isConfigExists := StoreUtils.CheckConfig(configPath)
+	if !isConfigExists {
+		err := StoreUtils.CreateConfigFile(configPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	if len(data) > 2 {
+		err = json.Unmarshal(data, &cfg)
+		if err != nil {
+			return fmt.Errorf("config file format error: %w. Please delete the config and run setup again", err)
+		}
+	}
+
+	if sampling.IsZero() {
+		delete(cfg.StyleSampling, style)
+		if len(cfg.StyleSampling) == 0 {
+			cfg.StyleSampling = nil
+		}
+	} else {
+		if cfg.StyleSampling == nil {
+			cfg.StyleSampling = make(map[string]types.Sampling)
+		}
+		cfg.StyleSampling[style] = sampling
+	}
+
+	data, err = json.MarshalIndent(cfg, "", " ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(configPath, data, 0600)
+}
+
 // DeleteModel removes the specified provider from the saved configuration.
 func (s *StoreMethods) DeleteModel(Model types.LLMProvider) error {
 
@@ -305,7 +565,15 @@ func (s *StoreMethods) DeleteModel(Model types.LLMProvider) error {
 			if err != nil {
 				return err
 			}
-			return os.WriteFile(configPath, []byte("{}"), 0600)
+			if cfg.Plugins == nil && cfg.Network == nil && cfg.StyleSampling == nil {
+				return os.WriteFile(configPath, []byte("{}"), 0600)
+			}
+			// Settings that do not belong to a provider outlive it.
+			data, err := json.MarshalIndent(Config{Plugins: cfg.Plugins, Network: cfg.Network, StyleSampling: cfg.StyleSampling}, "", " ")
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(configPath, data, 0600)
 		}
 	} else {
 
@@ -321,6 +589,19 @@ func (s *StoreMethods) DeleteModel(Model types.LLMProvider) error {
 			return err
 		}
 		newCfg.Default = cfg.Default
+		newCfg.Plugins = cfg.Plugins
+		newCfg.Network = cfg.Network
+		newCfg.StyleSampling = cfg.StyleSampling
+		for _, p := range cfg.Fallback {
+			if p != Model {
+				newCfg.Fallback = append(newCfg.Fallback, p)
+			}
+		}
+		for provider, settings := range cfg.Settings {
+			if provider != Model {
+				setProviderSettings(&newCfg, provider, settings)
+			}
+		}
 
 		data, err = json.MarshalIndent(newCfg, "", " ")
 		if err != nil {
@@ -363,8 +644,9 @@ func (s *StoreMethods) UpdateAPIKey(Model types.LLMProvider, APIKey string) erro
 	for _, p := range cfg.LLMProviders {
 		if p == Model {
 			err := s.ring.Set(keyring.Item{ // Update the apiKey in OS credential
-				Key:  string(Model),
-				Data: []byte(APIKey),
+				Key:   string(Model),
+				Label: credentialLabel(Model),
+				Data:  []byte(APIKey),
 			})
 			if err != nil {
 				return fmt.Errorf("failed to update credentials in keyring: %w", err)
@@ -386,6 +668,35 @@ func (s *StoreMethods) UpdateAPIKey(Model types.LLMProvider, APIKey string) erro
 
 }
 
+// credentialLabel describes the secret kept in the keyring for provider: an
+// API key for most providers, the server URL for Ollama, the service
+// account key for Vertex AI and the file path for Cassette.
+func credentialLabel(provider types.LLMProvider) string {
+	switch provider {
+	case types.ProviderOllama:
+		return "commit-msg Ollama URL"
+	case types.ProviderVertexAI:
+		return "commit-msg VertexAI service account key"
+	case types.ProviderCassette:
+		return "commit-msg Cassette file"
+	default:
+		return "commit-msg " + provider.String() + " API key"
+	}
+}
+
+// setProviderSettings records settings for a provider, dropping the entry when
+// there is nothing worth persisting.
+func setProviderSettings(cfg *Config, provider types.LLMProvider, settings types.ProviderSettings) {
+	if settings.BaseURL == "" && settings.Model == "" && len(settings.Headers) == 0 && settings.Ollama == nil && settings.Azure == nil && settings.Bedrock == nil && settings.Vertex == nil && settings.Sampling == nil {
+		delete(cfg.Settings, provider)
+		return
+	}
+	if cfg.Settings == nil {
+		cfg.Settings = make(map[types.LLMProvider]types.ProviderSettings)
+	}
+	cfg.Settings[provider] = settings
+}
+
 // Cache management methods
 
 // GetCacheManager returns the cache manager instance.
@@ -393,14 +704,15 @@ func (s *StoreMethods) GetCacheManager() *cache.CacheManager {
 	return s.cache
 }
 
-// GetCachedMessage retrieves a cached commit message if it exists.
-func (s *StoreMethods) GetCachedMessage(provider types.LLMProvider, diff string, opts *types.GenerationOptions) (*types.CacheEntry, bool) {
-	return s.cache.Get(provider, diff, opts)
+// GetCachedMessage retrieves the commit message cached for model of
+// provider, if it exists.
+func (s *StoreMethods) GetCachedMessage(provider types.LLMProvider, model string, diff string, opts *types.GenerationOptions) (*types.CacheEntry, bool) {
+	return s.cache.Get(provider, model, diff, opts)
 }
 
-// SetCachedMessage stores a commit message in the cache.
-func (s *StoreMethods) SetCachedMessage(provider types.LLMProvider, diff string, opts *types.GenerationOptions, message string, cost float64, tokens *types.UsageInfo) error {
-	return s.cache.Set(provider, diff, opts, message, cost, tokens)
+// SetCachedMessage stores a commit message generated by model in the cache.
+func (s *StoreMethods) SetCachedMessage(provider types.LLMProvider, model string, diff string, opts *types.GenerationOptions, message string, tokens *types.UsageInfo) error {
+	return s.cache.Set(provider, model, diff, opts, message, tokens)
 }
 
 // ClearCache removes all entries from the cache.
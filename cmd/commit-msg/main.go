@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"os"
 
 	cmd "github.com/dfanso/commit-msg/cmd/cli"
 	"github.com/dfanso/commit-msg/cmd/cli/store"
@@ -12,7 +13,7 @@ func main() {
 
 	//Initializes the OS credential manager
 	KeyRing, err := store.KeyringInit()
-	if err != nil {
+	if err != nil && cmd.RequiresStore(os.Args[1:]) {
 		log.Fatalf("Failed to initilize Keyring store: %v", err)
 	}
 	cmd.StoreInit(KeyRing) //Passes StoreMethods instance to root
@@ -13,6 +13,8 @@ require (
 	github.com/openai/openai-go/v3 v3.0.1
 	github.com/pterm/pterm v0.12.80
 	github.com/spf13/cobra v1.10.1
+	golang.org/x/net v0.47.0
+	golang.org/x/oauth2 v0.27.0
 	golang.org/x/time v0.10.0
 	google.golang.org/api v0.223.0
 )
@@ -60,8 +62,6 @@ require (
 	go.opentelemetry.io/otel/metric v1.34.0 // indirect
 	go.opentelemetry.io/otel/trace v1.34.0 // indirect
 	golang.org/x/crypto v0.45.0 // indirect
-	golang.org/x/net v0.47.0 // indirect
-	golang.org/x/oauth2 v0.27.0 // indirect
 	golang.org/x/sync v0.18.0 // indirect
 	golang.org/x/sys v0.38.0 // indirect
 	golang.org/x/term v0.37.0 // indirect
@@ -0,0 +1,125 @@
+// Package azure talks to OpenAI models deployed on Azure OpenAI. Each
+// deployment serves the chat completions protocol under its own path, and
+// every request names the REST api-version it was written against.
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"strings"
+
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/internal/openaicompat"
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+// DefaultAPIVersion is the GA data-plane API version used when none is
+// configured.
+const DefaultAPIVersion = "2024-10-21"
+
+// Ways of sending the credential.
+const (
+	// AuthAPIKey sends the resource key in the api-key header.
+	AuthAPIKey = "api-key"
+	// AuthBearer sends a Microsoft Entra ID access token as a bearer token.
+	AuthBearer = "bearer"
+)
+
+const (
+	apiKeyHeader    = "api-key"
+	apiVersionParam = "api-version"
+	deploymentsPath = "/openai/deployments/"
+)
+
+// Endpoint describes how to reach one Azure OpenAI deployment.
+type Endpoint struct {
+	// ResourceURL is the resource endpoint, e.g.
+	// https://my-resource.openai.azure.com.
+	ResourceURL string
+	// Deployment is the deployment name chosen in the Azure portal; it
+	// decides which model answers.
+	Deployment string
+	// APIVersion defaults to DefaultAPIVersion.
+	APIVersion string
+	// Auth is AuthAPIKey (the default) or AuthBearer.
+	Auth string
+	// Credential is the API key or the access token, depending on Auth.
+	Credential string
+	// Headers are extra HTTP headers added to every request.
+	Headers map[string]string
+}
+
+// DeploymentURL returns the API root of a deployment, under which the chat
+// completions path lives.
+func DeploymentURL(resourceURL string, deployment string) string {
+	return strings.TrimRight(strings.TrimSpace(resourceURL), "/") + deploymentsPath + url.PathEscape(strings.TrimSpace(deployment))
+}
+
+// ChatCompletionsURL returns the full chat completions URL of endpoint,
+// including the api-version.
+func ChatCompletionsURL(endpoint Endpoint) string {
+	return openaicompat.ChatCompletionsURL(DeploymentURL(endpoint.ResourceURL, endpoint.Deployment)) +
+		"?" + url.Values{apiVersionParam: {endpoint.apiVersion()}}.Encode()
+}
+
+// GenerateCommitMessage requests a commit message from the deployment.
+func GenerateCommitMessage(ctx context.Context, config *types.Config, changes string, endpoint Endpoint, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	compat, err := endpoint.compatEndpoint()
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
+	return openaicompat.GenerateCommitMessage(ctx, config, changes, compat, opts)
+}
+
+// StreamCommitMessage streams the completion from the deployment, passing
+// each content delta to onChunk.
+func StreamCommitMessage(ctx context.Context, config *types.Config, changes string, endpoint Endpoint, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	compat, err := endpoint.compatEndpoint()
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
+	return openaicompat.StreamCommitMessage(ctx, config, changes, compat, opts, onChunk)
+}
+
+func (e Endpoint) apiVersion() string {
+	if version := strings.TrimSpace(e.APIVersion); version != "" {
+		return version
+	}
+	return DefaultAPIVersion
+}
+
+// compatEndpoint maps the deployment onto the chat completions client. The
+// model field is left out: the deployment already names the model. Azure is
+// a cloud service, so requests go through the generation client with its
+// retries rather than the local-server default.
+func (e Endpoint) compatEndpoint() (openaicompat.Endpoint, error) {
+	if strings.TrimSpace(e.ResourceURL) == "" {
+		return openaicompat.Endpoint{}, errors.New("Azure OpenAI resource endpoint is required")
+	}
+	if strings.TrimSpace(e.Deployment) == "" {
+		return openaicompat.Endpoint{}, errors.New("Azure OpenAI deployment name is required")
+	}
+
+	compat := openaicompat.Endpoint{
+		BaseURL: DeploymentURL(e.ResourceURL, e.Deployment),
+		Headers: maps.Clone(e.Headers),
+		Query:   url.Values{apiVersionParam: {e.apiVersion()}},
+		Client:  internalHTTP.GetGenerationClient(),
+	}
+
+	switch e.Auth {
+	case "", AuthAPIKey:
+		if compat.Headers == nil {
+			compat.Headers = make(map[string]string)
+		}
+		compat.Headers[apiKeyHeader] = e.Credential
+	case AuthBearer:
+		compat.APIKey = e.Credential
+	default:
+		return openaicompat.Endpoint{}, fmt.Errorf("unknown Azure OpenAI auth %q, expected %q or %q", e.Auth, AuthAPIKey, AuthBearer)
+	}
+	return compat, nil
+}
@@ -0,0 +1,129 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+func TestGenerateCommitMessage(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/openai/deployments/commit-gpt4o/chat/completions" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.URL.Query().Get("api-version"); got != DefaultAPIVersion {
+			t.Errorf("expected api-version %s, got %q", DefaultAPIVersion, got)
+		}
+		if got := r.Header.Get("api-key"); got != "secret" {
+			t.Errorf("expected the api-key header, got %q", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("expected no Authorization header, got %q", got)
+		}
+
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if _, ok := payload["model"]; ok {
+			t.Errorf("expected no model field, got %v", payload["model"])
+		}
+
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"feat: add azure provider"}}],"usage":{"prompt_tokens":120,"completion_tokens":6,"total_tokens":126}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	result, err := GenerateCommitMessage(context.Background(), &types.Config{}, "diff", Endpoint{
+		ResourceURL: server.URL + "/",
+		Deployment:  "commit-gpt4o",
+		Credential:  "secret",
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Message != "feat: add azure provider" || result.Usage == nil || result.Usage.TotalTokens != 126 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestStreamCommitMessageWithBearerToken(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("api-version"); got != "2025-01-01-preview" {
+			t.Errorf("unexpected api-version %q", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer entra-token" {
+			t.Errorf("expected a bearer token, got %q", got)
+		}
+		if got := r.Header.Get("api-key"); got != "" {
+			t.Errorf("expected no api-key header, got %q", got)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"fix: \"}}]}\n\n"))
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"handle tokens\"}}]}\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	result, err := StreamCommitMessage(context.Background(), &types.Config{}, "diff", Endpoint{
+		ResourceURL: server.URL,
+		Deployment:  "commit-gpt4o",
+		APIVersion:  "2025-01-01-preview",
+		Auth:        AuthBearer,
+		Credential:  "entra-token",
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Message != "fix: handle tokens" {
+		t.Fatalf("unexpected message %q", result.Message)
+	}
+}
+
+func TestEndpointValidation(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]Endpoint{
+		"resource endpoint": {Deployment: "d", Credential: "k"},
+		"deployment name":   {ResourceURL: "https://r.openai.azure.com", Credential: "k"},
+		"unknown Azure":     {ResourceURL: "https://r.openai.azure.com", Deployment: "d", Auth: "oauth"},
+	}
+	for want, endpoint := range cases {
+		_, err := GenerateCommitMessage(context.Background(), nil, "diff", endpoint, nil)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected an error mentioning %q, got %v", want, err)
+		}
+	}
+}
+
+func TestEndpointUsesGenerationClient(t *testing.T) {
+	t.Parallel()
+
+	compat, err := Endpoint{ResourceURL: "https://r.openai.azure.com", Deployment: "d", Credential: "k"}.compatEndpoint()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if compat.Client != internalHTTP.GetGenerationClient() {
+		t.Fatal("expected Azure requests to go through the generation client")
+	}
+}
+
+func TestChatCompletionsURL(t *testing.T) {
+	t.Parallel()
+
+	got := ChatCompletionsURL(Endpoint{ResourceURL: "https://r.openai.azure.com/", Deployment: "gpt 4o"})
+	want := "https://r.openai.azure.com/openai/deployments/gpt%204o/chat/completions?api-version=" + DefaultAPIVersion
+	if got != want {
+		t.Fatalf("ChatCompletionsURL() = %q, want %q", got, want)
+	}
+}
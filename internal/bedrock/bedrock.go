@@ -0,0 +1,206 @@
+// Package bedrock talks to models hosted on Amazon Bedrock through the
+// Converse API, which accepts the same message shape for every model
+// family. Requests are signed with AWS Signature Version 4.
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+// DefaultModel is used when no model ID has been configured for Bedrock.
+const DefaultModel = "anthropic.claude-3-haiku-20240307-v1:0"
+
+// DefaultRegion is used when neither the settings, the environment nor the
+// AWS profile name a region.
+const DefaultRegion = "us-east-1"
+
+// SigningService is the service name Bedrock requests are signed for.
+const SigningService = "bedrock"
+
+const (
+	bedrockMaxTokens = 1024
+	contentTypeJSON  = "application/json"
+)
+
+// Endpoint describes how to reach a Bedrock model.
+type Endpoint struct {
+	// Region selects the Bedrock runtime endpoint and the signing scope.
+	Region string
+	// ModelID is a Bedrock model ID or inference profile ID, e.g.
+	// anthropic.claude-3-haiku-20240307-v1:0.
+	ModelID string
+	// URL overrides the runtime endpoint, e.g. for a VPC endpoint or a local
+	// stand-in server. Requests are still signed for Region.
+	URL string
+	// Credentials sign every request.
+	Credentials Credentials
+}
+
+type contentBlock struct {
+	Text string `json:"text"`
+}
+
+type converseMessage struct {
+	Role    string         `json:"role"`
+	Content []contentBlock `json:"content"`
+}
+
+// inferenceConfig holds the Converse sampling settings, which have no seed.
+type inferenceConfig struct {
+	MaxTokens   int      `json:"maxTokens,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"topP,omitempty"`
+}
+
+func newInferenceConfig(sampling types.Sampling) *inferenceConfig {
+	return &inferenceConfig{
+		MaxTokens:   sampling.MaxTokensOr(bedrockMaxTokens),
+		Temperature: sampling.Temperature,
+		TopP:        sampling.TopP,
+	}
+}
+
+type converseRequest struct {
+	Messages        []converseMessage `json:"messages"`
+	System          []contentBlock    `json:"system,omitempty"`
+	InferenceConfig *inferenceConfig  `json:"inferenceConfig,omitempty"`
+}
+
+type converseResponse struct {
+	Output struct {
+		Message converseMessage `json:"message"`
+	} `json:"output"`
+	Usage struct {
+		InputTokens          int `json:"inputTokens"`
+		OutputTokens         int `json:"outputTokens"`
+		CacheReadInputTokens int `json:"cacheReadInputTokens"`
+	} `json:"usage"`
+}
+
+// ResolveRegion picks the region requests are sent to: the configured one,
+// then AWS_REGION, AWS_DEFAULT_REGION, the profile's region in the shared
+// config file and finally DefaultRegion.
+func ResolveRegion(configured, profile string) string {
+	if region := strings.TrimSpace(configured); region != "" {
+		return region
+	}
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := strings.TrimSpace(os.Getenv(env)); region != "" {
+			return region
+		}
+	}
+	if region := ProfileRegion(profile); region != "" {
+		return region
+	}
+	return DefaultRegion
+}
+
+// ControlPlaneURL returns the endpoint of the Bedrock management API in
+// region, which lists the foundation models.
+func ControlPlaneURL(region string) string {
+	return "https://bedrock." + region + ".amazonaws.com"
+}
+
+// RuntimeURL returns the Bedrock runtime endpoint of region.
+func RuntimeURL(region string) string {
+	return "https://bedrock-runtime." + region + ".amazonaws.com"
+}
+
+// ConverseURL returns the Converse URL of endpoint's model.
+func ConverseURL(endpoint Endpoint) string {
+	base := strings.TrimRight(strings.TrimSpace(endpoint.URL), "/")
+	if base == "" {
+		base = RuntimeURL(endpoint.Region)
+	}
+	return base + "/model/" + uriEncode(endpoint.ModelID) + "/converse"
+}
+
+// GenerateCommitMessage asks the model for a commit message with a signed
+// Converse request.
+func GenerateCommitMessage(ctx context.Context, _ *types.Config, changes string, endpoint Endpoint, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	if changes == "" {
+		return types.GenerationResult{}, fmt.Errorf("no changes provided for commit message generation")
+	}
+	if endpoint.Region == "" || endpoint.ModelID == "" {
+		return types.GenerationResult{}, errors.New("Bedrock region and model ID are required")
+	}
+
+	prompt := types.BuildPrompt(changes, opts)
+	payload := converseRequest{
+		System:          []contentBlock{{Text: prompt.System}},
+		InferenceConfig: newInferenceConfig(types.SamplingOf(opts)),
+	}
+	for _, turn := range prompt.Turns() {
+		payload.Messages = append(payload.Messages, converseMessage{Role: turn.Role, Content: []contentBlock{{Text: turn.Content}}})
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return types.GenerationResult{}, fmt.Errorf("failed to marshal Bedrock request: %w", err)
+	}
+
+	req, err := newSignedRequest(ctx, ConverseURL(endpoint), body, endpoint)
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
+
+	resp, err := internalHTTP.GetGenerationClient().Do(req)
+	if err != nil {
+		return types.GenerationResult{}, fmt.Errorf("failed to call Bedrock API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return types.GenerationResult{}, fmt.Errorf("failed to read Bedrock response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return types.GenerationResult{}, internalHTTP.NewStatusError(resp.StatusCode, "Bedrock API returned status %d: %s", resp.StatusCode, string(responseBody))
+	}
+
+	var converse converseResponse
+	if err := json.Unmarshal(responseBody, &converse); err != nil {
+		return types.GenerationResult{}, fmt.Errorf("failed to decode Bedrock response: %w", err)
+	}
+
+	var message strings.Builder
+	for _, block := range converse.Output.Message.Content {
+		message.WriteString(block.Text)
+	}
+	if message.Len() == 0 {
+		return types.GenerationResult{}, fmt.Errorf("Bedrock API returned empty response")
+	}
+
+	usage := converse.Usage
+	return types.GenerationResult{
+		Message: message.String(),
+		Usage:   types.NewUsageInfo(usage.InputTokens+usage.CacheReadInputTokens, usage.OutputTokens).WithCachedPrompt(usage.CacheReadInputTokens),
+	}, nil
+}
+
+// newSignedRequest builds a POST of body to target signed for endpoint's
+// region.
+func newSignedRequest(ctx context.Context, target string, body []byte, endpoint Endpoint) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Bedrock request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentTypeJSON)
+	req.Header.Set("Accept", contentTypeJSON)
+
+	Sign(req, body, endpoint.Credentials, endpoint.Region, SigningService, time.Now())
+	return req, nil
+}
@@ -0,0 +1,121 @@
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+func TestGenerateCommitMessage(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.EscapedPath() != "/model/anthropic.claude-3-haiku-20240307-v1%3A0/converse" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.EscapedPath())
+		}
+		body, _ := io.ReadAll(r.Body)
+
+		// Re-sign the request as received; the signatures must agree.
+		at, err := time.Parse(amzDateFormat, r.Header.Get("X-Amz-Date"))
+		if err != nil {
+			t.Errorf("invalid X-Amz-Date: %v", err)
+		}
+		check, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+		check.Header.Set("Content-Type", r.Header.Get("Content-Type"))
+		Sign(check, body, exampleCredentials, "eu-central-1", "bedrock", at)
+		if got, want := r.Header.Get("Authorization"), check.Header.Get("Authorization"); got != want {
+			t.Errorf("Authorization = %q, want %q", got, want)
+		}
+
+		var payload converseRequest
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if len(payload.System) != 1 || !strings.Contains(payload.System[0].Text, "commit message") {
+			t.Errorf("expected the system prompt, got %+v", payload.System)
+		}
+		last := payload.Messages[len(payload.Messages)-1]
+		if last.Role != types.RoleUser || !strings.Contains(last.Content[0].Text, "diff --git") {
+			t.Errorf("expected the changes last, got %+v", last)
+		}
+
+		w.Write([]byte(`{"output":{"message":{"role":"assistant","content":[{"text":"feat: sign "},{"text":"bedrock requests"}]}},"stopReason":"end_turn","usage":{"inputTokens":90,"outputTokens":5,"cacheReadInputTokens":10,"totalTokens":105}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	result, err := GenerateCommitMessage(context.Background(), nil, "diff --git a/x b/x", Endpoint{
+		Region:      "eu-central-1",
+		ModelID:     DefaultModel,
+		URL:         server.URL,
+		Credentials: exampleCredentials,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Message != "feat: sign bedrock requests" {
+		t.Fatalf("unexpected message %q", result.Message)
+	}
+	if result.Usage == nil || result.Usage.PromptTokens != 100 || result.Usage.CachedPromptTokens != 10 || result.Usage.CompletionTokens != 5 {
+		t.Fatalf("unexpected usage %+v", result.Usage)
+	}
+}
+
+func TestGenerateCommitMessageStatusError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"The security token included in the request is invalid."}`))
+	}))
+	t.Cleanup(server.Close)
+
+	_, err := GenerateCommitMessage(context.Background(), nil, "diff", Endpoint{
+		Region:      "us-east-1",
+		ModelID:     DefaultModel,
+		URL:         server.URL,
+		Credentials: exampleCredentials,
+	}, nil)
+	var statusErr *internalHTTP.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected a 403 status error, got %v", err)
+	}
+}
+
+func TestConverseURL(t *testing.T) {
+	t.Parallel()
+
+	got := ConverseURL(Endpoint{Region: "us-west-2", ModelID: "us.anthropic.claude-3-5-haiku-20241022-v1:0"})
+	want := "https://bedrock-runtime.us-west-2.amazonaws.com/model/us.anthropic.claude-3-5-haiku-20241022-v1%3A0/converse"
+	if got != want {
+		t.Fatalf("ConverseURL() = %q, want %q", got, want)
+	}
+
+	got = ConverseURL(Endpoint{Region: "us-west-2", ModelID: "m", URL: "http://localhost:9000/"})
+	if got != "http://localhost:9000/model/m/converse" {
+		t.Fatalf("ConverseURL() with override = %q", got)
+	}
+}
+
+func TestNewInferenceConfig(t *testing.T) {
+	t.Parallel()
+
+	if got := newInferenceConfig(types.Sampling{}); got.MaxTokens != bedrockMaxTokens || got.Temperature != nil || got.TopP != nil {
+		t.Fatalf("expected the defaults, got %+v", got)
+	}
+
+	temperature, topP := 0.3, 0.9
+	got := newInferenceConfig(types.Sampling{Temperature: &temperature, MaxTokens: 64, TopP: &topP})
+	data, _ := json.Marshal(got)
+	if string(data) != `{"maxTokens":64,"temperature":0.3,"topP":0.9}` {
+		t.Fatalf("unexpected inference config %s", data)
+	}
+}
@@ -0,0 +1,149 @@
+package bedrock
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultProfile is the shared-file profile used when none is selected.
+const DefaultProfile = "default"
+
+// Credentials are the AWS access keys requests are signed with.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is set for temporary credentials.
+	SessionToken string
+}
+
+// LoadCredentials finds credentials the way the AWS CLI does: the
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables, then
+// the profile in the shared credentials file, then the same profile in the
+// shared config file. An explicit profile skips the environment variables;
+// otherwise AWS_PROFILE picks the profile.
+func LoadCredentials(profile string) (Credentials, error) {
+	if profile == "" {
+		creds := Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+		if creds.AccessKeyID != "" && creds.SecretAccessKey != "" {
+			return creds, nil
+		}
+	}
+	profile = resolveProfile(profile)
+
+	credentialsFile, configFile := sharedFiles()
+	for _, source := range []struct {
+		path    string
+		section string
+	}{
+		{credentialsFile, profile},
+		{configFile, configSection(profile)},
+	} {
+		values, err := readProfile(source.path, source.section)
+		if err != nil {
+			return Credentials{}, err
+		}
+		creds := Credentials{
+			AccessKeyID:     values["aws_access_key_id"],
+			SecretAccessKey: values["aws_secret_access_key"],
+			SessionToken:    values["aws_session_token"],
+		}
+		if creds.AccessKeyID != "" && creds.SecretAccessKey != "" {
+			return creds, nil
+		}
+	}
+
+	return Credentials{}, fmt.Errorf("no AWS credentials found: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY or add profile %q to %s", profile, credentialsFile)
+}
+
+// ProfileRegion returns the region configured for profile in the shared
+// config file, or "" when it has none.
+func ProfileRegion(profile string) string {
+	_, configFile := sharedFiles()
+	values, err := readProfile(configFile, configSection(resolveProfile(profile)))
+	if err != nil {
+		return ""
+	}
+	return values["region"]
+}
+
+func resolveProfile(profile string) string {
+	if profile != "" {
+		return profile
+	}
+	if env := strings.TrimSpace(os.Getenv("AWS_PROFILE")); env != "" {
+		return env
+	}
+	return DefaultProfile
+}
+
+// configSection names profile's section in the shared config file, where
+// every profile but the default carries a "profile " prefix.
+func configSection(profile string) string {
+	if profile == DefaultProfile {
+		return profile
+	}
+	return "profile " + profile
+}
+
+// sharedFiles returns the shared credentials and config file paths,
+// honouring AWS_SHARED_CREDENTIALS_FILE and AWS_CONFIG_FILE.
+func sharedFiles() (string, string) {
+	credentialsFile := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	configFile := os.Getenv("AWS_CONFIG_FILE")
+	if credentialsFile == "" || configFile == "" {
+		home, _ := os.UserHomeDir()
+		if credentialsFile == "" {
+			credentialsFile = filepath.Join(home, ".aws", "credentials")
+		}
+		if configFile == "" {
+			configFile = filepath.Join(home, ".aws", "config")
+		}
+	}
+	return credentialsFile, configFile
+}
+
+// readProfile returns the key/value pairs of section in the INI file at
+// path. A missing file or section yields no values.
+func readProfile(path, section string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	inSection := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.Join(strings.Fields(line[1:len(line)-1]), " ")
+			inSection = name == section
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			values[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return values, nil
+}
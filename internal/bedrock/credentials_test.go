@@ -0,0 +1,87 @@
+package bedrock
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSharedFiles(t *testing.T, credentials, config string) {
+	t.Helper()
+	dir := t.TempDir()
+	credentialsFile := filepath.Join(dir, "credentials")
+	configFile := filepath.Join(dir, "config")
+	if err := os.WriteFile(credentialsFile, []byte(credentials), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configFile, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+}
+
+func TestLoadCredentials(t *testing.T) {
+	writeSharedFiles(t, `
+[default]
+aws_access_key_id = DEFAULTKEY
+aws_secret_access_key = defaultsecret
+
+[work]
+aws_access_key_id=WORKKEY
+aws_secret_access_key=worksecret
+aws_session_token=worktoken
+`, `
+[default]
+region = eu-west-1
+
+[profile sso]
+aws_access_key_id = CONFIGKEY
+aws_secret_access_key = configsecret
+region = ap-southeast-2
+`)
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "ENVKEY")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "envsecret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	cases := []struct {
+		profile string
+		want    string
+	}{
+		{"", "ENVKEY"},
+		{"work", "WORKKEY"},
+		{"sso", "CONFIGKEY"},
+	}
+	for _, tc := range cases {
+		creds, err := LoadCredentials(tc.profile)
+		if err != nil || creds.AccessKeyID != tc.want {
+			t.Fatalf("profile %q: got %+v, %v; want key %s", tc.profile, creds, err, tc.want)
+		}
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	creds, err := LoadCredentials("")
+	if err != nil || creds.AccessKeyID != "DEFAULTKEY" {
+		t.Fatalf("expected the default profile, got %+v, %v", creds, err)
+	}
+
+	t.Setenv("AWS_PROFILE", "work")
+	creds, err = LoadCredentials("")
+	if err != nil || creds.SessionToken != "worktoken" {
+		t.Fatalf("expected AWS_PROFILE to select work, got %+v, %v", creds, err)
+	}
+
+	if _, err := LoadCredentials("missing"); err == nil || !strings.Contains(err.Error(), `"missing"`) {
+		t.Fatalf("expected an error naming the profile, got %v", err)
+	}
+
+	if got := ProfileRegion("sso"); got != "ap-southeast-2" {
+		t.Fatalf("ProfileRegion(sso) = %q", got)
+	}
+	t.Setenv("AWS_PROFILE", "")
+	if got := ProfileRegion(""); got != "eu-west-1" {
+		t.Fatalf("ProfileRegion() = %q", got)
+	}
+}
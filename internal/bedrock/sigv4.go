@@ -0,0 +1,148 @@
+package bedrock
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	signingAlgorithm = "AWS4-HMAC-SHA256"
+	amzDateFormat    = "20060102T150405Z"
+	amzDayFormat     = "20060102"
+	dateHeader       = "X-Amz-Date"
+	tokenHeader      = "X-Amz-Security-Token"
+)
+
+// Sign adds AWS Signature Version 4 headers to req. body must be the exact
+// request payload; region and service select the signing scope.
+func Sign(req *http.Request, body []byte, creds Credentials, region, service string, at time.Time) {
+	at = at.UTC()
+	req.Header.Set(dateHeader, at.Format(amzDateFormat))
+	if creds.SessionToken != "" {
+		req.Header.Set(tokenHeader, creds.SessionToken)
+	}
+
+	signedHeaders, canonicalHeaders := canonicalHeaders(req)
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL.EscapedPath()),
+		canonicalQuery(req.URL.RawQuery),
+		canonicalHeaders,
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	day := at.Format(amzDayFormat)
+	scope := strings.Join([]string{day, region, service, "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		signingAlgorithm,
+		at.Format(amzDateFormat),
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signingAlgorithm, creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalHeaders signs the host, the content type and every x-amz header.
+// Other headers may be changed by proxies and are left out.
+func canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values := map[string]string{"host": host}
+	for name, value := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			values[lower] = strings.Join(strings.Fields(strings.Join(value, ",")), " ")
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + values[name] + "\n")
+	}
+	return strings.Join(names, ";"), canonical.String()
+}
+
+// canonicalURI encodes each segment of the already escaped path once more,
+// as every AWS service except S3 expects.
+func canonicalURI(escapedPath string) string {
+	if escapedPath == "" {
+		return "/"
+	}
+	segments := strings.Split(escapedPath, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery sorts the query parameters and encodes them the way AWS
+// does.
+func canonicalQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	var pairs []string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(pair, "=")
+		pairs = append(pairs, uriEncode(unescape(name))+"="+uriEncode(unescape(value)))
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes every byte except the RFC 3986 unreserved
+// characters.
+func uriEncode(s string) string {
+	var encoded strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			encoded.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&encoded, "%%%02X", c)
+	}
+	return encoded.String()
+}
+
+func unescape(s string) string {
+	if unescaped, err := url.QueryUnescape(s); err == nil {
+		return unescaped
+	}
+	return s
+}
@@ -0,0 +1,62 @@
+package bedrock
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+var exampleCredentials = Credentials{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+var exampleTime = time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+func TestSignMatchesAWSTestSuite(t *testing.T) {
+	t.Parallel()
+
+	// get-vanilla from the AWS Signature Version 4 test suite.
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Sign(req, nil, exampleCredentials, "us-east-1", "service", exampleTime)
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("Authorization = %q, want %q", got, want)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Fatalf("X-Amz-Date = %q", got)
+	}
+}
+
+func TestSignIncludesSessionToken(t *testing.T) {
+	t.Parallel()
+
+	req, _ := http.NewRequest(http.MethodPost, "https://bedrock-runtime.us-west-2.amazonaws.com/model/m/converse", nil)
+	req.Header.Set("Content-Type", "application/json")
+	creds := exampleCredentials
+	creds.SessionToken = "session"
+	Sign(req, []byte("{}"), creds, "us-west-2", "bedrock", exampleTime)
+
+	if got := req.Header.Get("X-Amz-Security-Token"); got != "session" {
+		t.Fatalf("X-Amz-Security-Token = %q", got)
+	}
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-west-2/bedrock/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token, Signature="
+	if got := req.Header.Get("Authorization"); len(got) != len(want)+64 || got[:len(want)] != want {
+		t.Fatalf("unexpected Authorization %q", got)
+	}
+}
+
+func TestCanonicalEncoding(t *testing.T) {
+	t.Parallel()
+
+	if got := canonicalURI("/model/anthropic.claude-v2%3A1/converse"); got != "/model/anthropic.claude-v2%253A1/converse" {
+		t.Fatalf("canonicalURI() = %q", got)
+	}
+	if got := canonicalQuery("b=2&a=x y&a=1"); got != "a=1&a=x%20y&b=2" {
+		t.Fatalf("canonicalQuery() = %q", got)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/dfanso/commit-msg/internal/pricing"
 	"github.com/dfanso/commit-msg/pkg/types"
 	StoreUtils "github.com/dfanso/commit-msg/utils"
 )
@@ -21,6 +22,8 @@ type CacheManager struct {
 	mutex    sync.RWMutex
 	filePath string
 	hasher   *DiffHasher
+	// prices values the entries; costs are 0 when it is nil.
+	prices *pricing.Registry
 }
 
 // NewCacheManager creates a new cache manager instance.
@@ -46,6 +49,7 @@ func NewCacheManager() (*CacheManager, error) {
 		stats:    &types.CacheStats{},
 		filePath: cachePath,
 		hasher:   NewDiffHasher(),
+		prices:   pricing.Default(),
 	}
 
 	// Load existing cache
@@ -57,9 +61,9 @@ func NewCacheManager() (*CacheManager, error) {
 	return cm, nil
 }
 
-// Get retrieves a cached commit message if it exists.
-func (cm *CacheManager) Get(provider types.LLMProvider, diff string, opts *types.GenerationOptions) (*types.CacheEntry, bool) {
-	key := cm.hasher.GenerateCacheKey(provider, diff, opts)
+// Get retrieves the commit message cached for model of provider, if it exists.
+func (cm *CacheManager) Get(provider types.LLMProvider, model string, diff string, opts *types.GenerationOptions) (*types.CacheEntry, bool) {
+	key := cm.hasher.GenerateCacheKey(provider, model, diff, opts)
 
 	// Phase 1: Read with RLock to check existence and copy the entry
 	cm.mutex.RLock()
@@ -90,24 +94,26 @@ func (cm *CacheManager) Get(provider types.LLMProvider, diff string, opts *types
 	return &entryCopy, true
 }
 
-// Set stores a commit message in the cache.
-func (cm *CacheManager) Set(provider types.LLMProvider, diff string, opts *types.GenerationOptions, message string, cost float64, tokens *types.UsageInfo) error {
+// Set stores a commit message generated by model in the cache, priced from
+// the tokens it used.
+func (cm *CacheManager) Set(provider types.LLMProvider, model string, diff string, opts *types.GenerationOptions, message string, tokens *types.UsageInfo) error {
 	cm.mutex.Lock()
 	defer cm.mutex.Unlock()
 
-	key := cm.hasher.GenerateCacheKey(provider, diff, opts)
+	key := cm.hasher.GenerateCacheKey(provider, model, diff, opts)
 	now := time.Now().Format(time.RFC3339)
 
 	entry := &types.CacheEntry{
 		Message:          message,
 		Provider:         provider,
+		Model:            model,
 		DiffHash:         cm.hasher.GenerateHash(diff, opts),
 		StyleInstruction: getStyleInstruction(opts),
 		Attempt:          getAttempt(opts),
 		CreatedAt:        now,
 		LastAccessedAt:   now,
 		AccessCount:      1,
-		Cost:             cost,
+		Cost:             cm.prices.Cost(provider, model, tokens),
 		Tokens:           tokens,
 	}
 
@@ -319,6 +325,19 @@ func (cm *CacheManager) updateHitRate() {
 	}
 }
 
+// EntryCost returns what regenerating entry would cost at current prices.
+// Entries cached without token counts keep the cost recorded when they were
+// stored.
+func (cm *CacheManager) EntryCost(entry *types.CacheEntry) float64 {
+	if entry.Tokens == nil {
+		return entry.Cost
+	}
+	if rate, ok := cm.prices.Lookup(entry.Provider, entry.Model); ok {
+		return rate.Cost(entry.Tokens)
+	}
+	return entry.Cost
+}
+
 // calculateStats calculates additional statistics.
 func (cm *CacheManager) calculateStats() {
 	if len(cm.entries) == 0 {
@@ -347,7 +366,7 @@ func (cm *CacheManager) calculateStats() {
 			cm.stats.NewestEntry = entry.CreatedAt
 		}
 
-		totalCost += entry.Cost
+		totalCost += cm.EntryCost(entry)
 	}
 
 	cm.stats.TotalCostSaved = totalCost
@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/dfanso/commit-msg/internal/pricing"
 	"github.com/dfanso/commit-msg/pkg/types"
 )
 
@@ -24,6 +25,9 @@ func TestCacheManager_GetSet(t *testing.T) {
 		stats:    &types.CacheStats{},
 		filePath: filepath.Join(tempDir, "test-cache.json"),
 		hasher:   NewDiffHasher(),
+		prices: pricing.New(pricing.Table{
+			types.ProviderOpenAI: {"gpt-4o": {Input: 2, Output: 10}},
+		}),
 	}
 
 	// Test data
@@ -34,16 +38,17 @@ func TestCacheManager_GetSet(t *testing.T) {
 		Attempt:          1,
 	}
 	message := "test commit message"
-	cost := 0.001
+	tokens := types.NewUsageInfo(1000, 100)
+	cost := 0.003
 
 	// Test setting a cache entry
-	err := cm.Set(provider, diff, opts, message, cost, nil)
+	err := cm.Set(provider, "gpt-4o", diff, opts, message, tokens)
 	if err != nil {
 		t.Fatalf("Failed to set cache entry: %v", err)
 	}
 
 	// Test getting the cache entry
-	entry, found := cm.Get(provider, diff, opts)
+	entry, found := cm.Get(provider, "gpt-4o", diff, opts)
 	if !found {
 		t.Fatalf("Cache entry not found after setting")
 	}
@@ -56,12 +61,16 @@ func TestCacheManager_GetSet(t *testing.T) {
 		t.Errorf("Expected provider %s, got %s", provider, entry.Provider)
 	}
 
+	if entry.Model != "gpt-4o" {
+		t.Errorf("Expected model gpt-4o, got %s", entry.Model)
+	}
+
 	if entry.Cost != cost {
 		t.Errorf("Expected cost %f, got %f", cost, entry.Cost)
 	}
 
 	// Test getting non-existent entry
-	_, found = cm.Get(types.ProviderClaude, diff, opts)
+	_, found = cm.Get(types.ProviderClaude, "gpt-4o", diff, opts)
 	if found {
 		t.Errorf("Expected cache miss for different provider")
 	}
@@ -98,23 +107,23 @@ func TestCacheManager_Stats(t *testing.T) {
 	diff2 := "test diff 2"
 	opts := &types.GenerationOptions{Attempt: 1}
 
-	cm.Set(provider, diff1, opts, "message 1", 0.001, nil)
-	cm.Set(provider, diff2, opts, "message 2", 0.002, nil)
+	cm.Set(provider, "gpt-4o", diff1, opts, "message 1", nil)
+	cm.Set(provider, "gpt-4o", diff2, opts, "message 2", nil)
 
 	// Test cache hit
-	_, found := cm.Get(provider, diff1, opts)
+	_, found := cm.Get(provider, "gpt-4o", diff1, opts)
 	if !found {
 		t.Errorf("Expected cache hit")
 	}
 
 	// Test cache miss
-	_, found = cm.Get(types.ProviderClaude, diff1, opts)
+	_, found = cm.Get(types.ProviderClaude, "gpt-4o", diff1, opts)
 	if found {
 		t.Errorf("Expected cache miss for different provider")
 	}
 
 	// Test another cache miss
-	_, found = cm.Get(provider, "different diff", opts)
+	_, found = cm.Get(provider, "gpt-4o", "different diff", opts)
 	if found {
 		t.Errorf("Expected cache miss for different diff")
 	}
@@ -163,10 +172,10 @@ func TestCacheManager_Clear(t *testing.T) {
 	diff := "test diff"
 	opts := &types.GenerationOptions{Attempt: 1}
 
-	cm.Set(provider, diff, opts, "message", 0.001, nil)
+	cm.Set(provider, "gpt-4o", diff, opts, "message", nil)
 
 	// Verify entry exists
-	_, found := cm.Get(provider, diff, opts)
+	_, found := cm.Get(provider, "gpt-4o", diff, opts)
 	if !found {
 		t.Fatalf("Cache entry not found after setting")
 	}
@@ -178,7 +187,7 @@ func TestCacheManager_Clear(t *testing.T) {
 	}
 
 	// Verify entry is gone
-	_, found = cm.Get(provider, diff, opts)
+	_, found = cm.Get(provider, "gpt-4o", diff, opts)
 	if found {
 		t.Errorf("Cache entry found after clearing")
 	}
@@ -214,9 +223,8 @@ func TestCacheManager_Persistence(t *testing.T) {
 	diff := "test diff"
 	opts := &types.GenerationOptions{Attempt: 1}
 	message := "test message"
-	cost := 0.001
 
-	err := cm1.Set(provider, diff, opts, message, cost, nil)
+	err := cm1.Set(provider, "gpt-4o", diff, opts, message, nil)
 	if err != nil {
 		t.Fatalf("Failed to set cache entry: %v", err)
 	}
@@ -242,7 +250,7 @@ func TestCacheManager_Persistence(t *testing.T) {
 	}
 
 	// Verify entry exists in second manager
-	entry, found := cm2.Get(provider, diff, opts)
+	entry, found := cm2.Get(provider, "gpt-4o", diff, opts)
 	if !found {
 		t.Fatalf("Cache entry not found in second manager")
 	}
@@ -252,6 +260,36 @@ func TestCacheManager_Persistence(t *testing.T) {
 	}
 }
 
+func TestCacheManager_CostSavedFollowsPrices(t *testing.T) {
+	tempDir := t.TempDir()
+	prices := pricing.New(pricing.Table{
+		types.ProviderClaude: {"claude-sonnet-4": {Input: 3, Output: 15}},
+	})
+
+	cm := &CacheManager{
+		config:   &types.CacheConfig{Enabled: true, MaxEntries: 1000, MaxAgeDays: 30},
+		entries:  make(map[string]*types.CacheEntry),
+		stats:    &types.CacheStats{},
+		filePath: filepath.Join(tempDir, "test-cache.json"),
+		hasher:   NewDiffHasher(),
+		prices:   prices,
+	}
+
+	opts := &types.GenerationOptions{Attempt: 1}
+	if err := cm.Set(types.ProviderClaude, "claude-sonnet-4-20250514", "diff", opts, "message", types.NewUsageInfo(1_000_000, 0)); err != nil {
+		t.Fatalf("Failed to set cache entry: %v", err)
+	}
+	if saved := cm.GetStats().TotalCostSaved; saved != 3 {
+		t.Fatalf("Expected $3 saved, got %f", saved)
+	}
+
+	// A price change is reflected without regenerating the entry.
+	prices.Merge(pricing.Table{types.ProviderClaude: {"claude-sonnet-4": {Input: 1, Output: 5}}})
+	if saved := cm.GetStats().TotalCostSaved; saved != 1 {
+		t.Fatalf("Expected $1 saved after the price change, got %f", saved)
+	}
+}
+
 func TestDiffHasher_NormalizeDiff(t *testing.T) {
 	hasher := NewDiffHasher()
 
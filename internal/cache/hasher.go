@@ -3,6 +3,7 @@ package cache
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -110,6 +111,18 @@ func (h *DiffHasher) buildHashInput(normalizedDiff string, opts *types.Generatio
 		parts = append(parts, "style:"+strings.TrimSpace(opts.StyleInstruction))
 	}
 
+	// Add the message being rewritten, which shapes the answer
+	if opts != nil && strings.TrimSpace(opts.PreviousMessage) != "" {
+		parts = append(parts, "previous:"+strings.TrimSpace(opts.PreviousMessage))
+	}
+
+	// Add sampling settings if present; other settings give other answers
+	if opts != nil && !opts.Sampling.IsZero() {
+		if sampling, err := json.Marshal(opts.Sampling); err == nil {
+			parts = append(parts, "sampling:"+string(sampling))
+		}
+	}
+
 	// Add attempt number (but only if it's the first attempt, as we want to cache
 	// the base generation, not regenerations)
 	if opts == nil || opts.Attempt <= 1 {
@@ -119,8 +132,9 @@ func (h *DiffHasher) buildHashInput(normalizedDiff string, opts *types.Generatio
 	return strings.Join(parts, "|")
 }
 
-// GenerateCacheKey creates a complete cache key including provider information.
-func (h *DiffHasher) GenerateCacheKey(provider types.LLMProvider, diff string, opts *types.GenerationOptions) string {
+// GenerateCacheKey creates a complete cache key including the provider and
+// the model that answer, since another model gives another message.
+func (h *DiffHasher) GenerateCacheKey(provider types.LLMProvider, model string, diff string, opts *types.GenerationOptions) string {
 	diffHash := h.GenerateHash(diff, opts)
-	return fmt.Sprintf("%s:%s", provider.String(), diffHash)
+	return fmt.Sprintf("%s:%s:%s", provider.String(), strings.TrimSpace(model), diffHash)
 }
@@ -148,6 +148,16 @@ index 1111111..2222222 100644
 	hash1 := hasher.GenerateHash(diff1, opts1)
 	hash2 := hasher.GenerateHash(diff2, opts1)
 	hash3 := hasher.GenerateHash(diff1, opts2)
+	hash4 := hasher.GenerateHash(diff1, &types.GenerationOptions{
+		StyleInstruction: opts1.StyleInstruction,
+		Attempt:          1,
+		Sampling:         types.DeterministicSampling(),
+	})
+	hash5 := hasher.GenerateHash(diff1, &types.GenerationOptions{
+		StyleInstruction: opts1.StyleInstruction,
+		Attempt:          1,
+		PreviousMessage:  "fix: typo",
+	})
 
 	// Different diffs should produce different hashes
 	if hash1 == hash2 {
@@ -158,6 +168,16 @@ index 1111111..2222222 100644
 	if hash1 == hash3 {
 		t.Errorf("GenerateHash() returned same hash for different style instructions")
 	}
+
+	// Same diff with different sampling settings should produce different hashes
+	if hash1 == hash4 {
+		t.Errorf("GenerateHash() returned same hash for different sampling settings")
+	}
+
+	// Rewording an existing message should not reuse a fresh message
+	if hash1 == hash5 {
+		t.Errorf("GenerateHash() returned same hash with and without a previous message")
+	}
 }
 
 func TestDiffHasher_GenerateCacheKey(t *testing.T) {
@@ -178,9 +198,9 @@ index 1234567..abcdefg 100644
 		Attempt:          1,
 	}
 
-	key1 := hasher.GenerateCacheKey(types.ProviderOpenAI, diff, opts)
-	key2 := hasher.GenerateCacheKey(types.ProviderClaude, diff, opts)
-	key3 := hasher.GenerateCacheKey(types.ProviderOpenAI, diff, opts)
+	key1 := hasher.GenerateCacheKey(types.ProviderOpenAI, "gpt-4o", diff, opts)
+	key2 := hasher.GenerateCacheKey(types.ProviderClaude, "gpt-4o", diff, opts)
+	key3 := hasher.GenerateCacheKey(types.ProviderOpenAI, "gpt-4o", diff, opts)
 
 	// Same provider and diff should produce same key
 	if key1 != key3 {
@@ -200,6 +220,11 @@ index 1234567..abcdefg 100644
 	if !containsString(key2, "Claude") {
 		t.Errorf("GenerateCacheKey() does not contain provider name")
 	}
+
+	// Different models of a provider should produce different keys
+	if key4 := hasher.GenerateCacheKey(types.ProviderOpenAI, "gpt-4.1", diff, opts); key4 == key1 {
+		t.Errorf("GenerateCacheKey() returned same key for different models")
+	}
 }
 
 func containsString(s, substr string) bool {
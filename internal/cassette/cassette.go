@@ -0,0 +1,201 @@
+// Package cassette records the HTTP exchanges of providers to a file and
+// replays them without a network, so a session can be reproduced offline.
+// Credentials are stripped before anything is written.
+package cassette
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+const (
+	// EnvPath names the cassette file commit records to or replays from.
+	EnvPath = "COMMIT_CASSETTE"
+	// EnvMode selects ModeRecord or ModeReplay; replay is the default.
+	EnvMode = "COMMIT_CASSETTE_MODE"
+)
+
+// Version is the cassette file format written by this package.
+const Version = 1
+
+// Mode says whether a cassette is being recorded or replayed.
+type Mode string
+
+const (
+	ModeRecord Mode = "record"
+	ModeReplay Mode = "replay"
+)
+
+// ParseMode reads the value of EnvMode. An empty value means ModeReplay.
+func ParseMode(value string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return ModeReplay, nil
+	case ModeRecord, ModeReplay:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("cassette: unknown mode %q, want %q or %q", value, ModeRecord, ModeReplay)
+	}
+}
+
+// Cassette is the content of a cassette file: the provider that was
+// recorded and its HTTP exchanges in the order they happened.
+type Cassette struct {
+	Version  int               `json:"version"`
+	Provider types.LLMProvider `json:"provider,omitempty"`
+	Model    string            `json:"model,omitempty"`
+	// Settings are the provider settings of the recording, without the
+	// custom headers, which may carry credentials.
+	Settings     *types.ProviderSettings `json:"settings,omitempty"`
+	Interactions []Interaction           `json:"interactions"`
+}
+
+// Interaction is one request and the response it received.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request with its credentials removed.
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    Body        `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    Body        `json:"body,omitempty"`
+}
+
+// Body is a request or response body. It is written as text so cassettes
+// can be read and edited, or as base64 when it is not valid UTF-8.
+type Body []byte
+
+// MarshalJSON implements json.Marshaler.
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*b = Body(text)
+		return nil
+	}
+
+	var encoded struct {
+		Base64 string `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded.Base64)
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// Load reads the cassette file at path.
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: %w", err)
+	}
+
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("cassette: invalid file %s: %w", path, err)
+	}
+	if c.Version != Version {
+		return nil, fmt.Errorf("cassette: %s has version %d, want %d", path, c.Version, Version)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to path, replacing any file there. Cassettes may
+// hold diffs of private code, so only the owner can read them.
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	return nil
+}
+
+// secretHeaders are the headers providers authenticate with. They are never
+// written to a cassette.
+var secretHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Api-Key",
+	"X-Api-Key",
+	"X-Goog-Api-Key",
+	"X-Amz-Security-Token",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// secretParams are the query parameters that carry credentials.
+var secretParams = []string{"key", "api_key", "access_token"}
+
+// sanitizeHeaders returns a copy of header without its credentials and
+// without the extra headers named.
+func sanitizeHeaders(header http.Header, extra ...string) http.Header {
+	clean := header.Clone()
+	for _, name := range secretHeaders {
+		clean.Del(name)
+	}
+	for _, name := range extra {
+		clean.Del(name)
+	}
+	if len(clean) == 0 {
+		return nil
+	}
+	return clean
+}
+
+// sanitizeURL returns u without its user info and credential parameters.
+func sanitizeURL(u *url.URL) *url.URL {
+	clean := *u
+	clean.User = nil
+
+	query := clean.Query()
+	removed := false
+	for _, name := range secretParams {
+		if query.Has(name) {
+			query.Del(name)
+			removed = true
+		}
+	}
+	if removed {
+		clean.RawQuery = query.Encode()
+	}
+	return &clean
+}
+
+// sanitizeSettings drops the custom headers from settings.
+func sanitizeSettings(settings types.ProviderSettings) *types.ProviderSettings {
+	settings.Headers = nil
+	return &settings
+}
@@ -0,0 +1,151 @@
+package cassette
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+func TestParseMode(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]Mode{"": ModeReplay, "record": ModeRecord, " Replay ": ModeReplay}
+	for value, want := range cases {
+		if got, err := ParseMode(value); err != nil || got != want {
+			t.Fatalf("ParseMode(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseMode("rewind"); err == nil {
+		t.Fatal("expected an error for an unknown mode")
+	}
+}
+
+func TestBodyRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, body := range []Body{Body(`{"text":"héllo"}`), {0x1f, 0x8b, 0xff, 0x00}} {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded Body
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("failed to decode %s: %v", data, err)
+		}
+		if string(decoded) != string(body) {
+			t.Fatalf("round trip of %q gave %q", body, decoded)
+		}
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Authorization") != "Bearer secret" || string(body) != `{"prompt":"diff"}` {
+			t.Errorf("the request did not reach the server intact: %v %q", r.Header, body)
+		}
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"text\":\"feat: add\"}\n\ndata: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	recorder := NewRecorder(path)
+	recorder.Describe(types.ProviderGroq, "llama", types.ProviderSettings{Headers: map[string]string{"X-Team-Token": "t0ken"}})
+	recorder.Describe(types.ProviderOllama, "ignored", types.ProviderSettings{})
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/v1/chat?key=k3y&stream=true", strings.NewReader(`{"prompt":"diff"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Team-Token", "t0ken")
+	resp, err := recorder.Intercept(req, http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("Intercept returned error: %v", err)
+	}
+	live, _ := io.ReadAll(resp.Body)
+	if err := resp.Body.Close(); err != nil {
+		t.Fatalf("saving the cassette failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"secret", "t0ken", "k3y", "session=abc"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("cassette leaks %q:\n%s", secret, data)
+		}
+	}
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if c.Provider != types.ProviderGroq || c.Model != "llama" || len(c.Interactions) != 1 {
+		t.Fatalf("unexpected cassette: %+v", c)
+	}
+
+	// The replay goes to another host and must not touch the network.
+	replayer := NewReplayer(c)
+	for range 2 {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, "https://cassette.invalid/v1/chat?stream=true", strings.NewReader(`{"prompt":"diff"}`))
+		resp, err := replayer.Intercept(req, nil)
+		if err != nil {
+			t.Fatalf("replay failed: %v", err)
+		}
+		replayed, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(replayed) != string(live) || resp.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("replay gave %d %q, want %q", resp.StatusCode, replayed, live)
+		}
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "https://cassette.invalid/v1/models", nil)
+	if _, err := replayer.Intercept(req, nil); err == nil {
+		t.Fatal("expected an error for a request that was never recorded")
+	}
+}
+
+func TestReplayerMatchesInOrder(t *testing.T) {
+	t.Parallel()
+
+	exchange := func(url, body, answer string) Interaction {
+		return Interaction{
+			Request:  Request{Method: http.MethodPost, URL: url, Body: Body(body)},
+			Response: Response{Status: http.StatusOK, Body: Body(answer)},
+		}
+	}
+	replayer := NewReplayer(&Cassette{Version: Version, Interactions: []Interaction{
+		exchange("https://api.example.com/generate", "first", "one"),
+		exchange("https://api.example.com/generate", "second", "two"),
+		exchange("https://api.example.com/other", "third", "three"),
+	}})
+
+	answer := func(path, body string) string {
+		req, _ := http.NewRequest(http.MethodPost, "http://localhost"+path, strings.NewReader(body))
+		resp, err := replayer.Intercept(req, nil)
+		if err != nil {
+			t.Fatalf("replay of %s %q failed: %v", path, body, err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		return string(data)
+	}
+
+	// Bodies pick their exchange, then the path and finally the order.
+	got := []string{answer("/generate", "second"), answer("/generate", "changed"), answer("/moved", "x"), answer("/generate", "again")}
+	want := []string{"two", "one", "three", "one"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("answers = %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package cassette
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+// Recorder sends requests on and writes each exchange to a cassette file as
+// soon as its response body is closed, so a crash loses at most the
+// exchange in flight. Streamed responses still reach the caller as they
+// arrive.
+type Recorder struct {
+	path string
+
+	mu       sync.Mutex
+	cassette Cassette
+	// redacted are the custom headers of the described providers, which
+	// may carry credentials too.
+	redacted []string
+}
+
+// NewRecorder starts an empty cassette that replaces the file at path once
+// the first exchange is recorded.
+func NewRecorder(path string) *Recorder {
+	return &Recorder{path: path, cassette: Cassette{Version: Version}}
+}
+
+// Describe notes the provider being recorded, which the Cassette provider
+// replays through. Only the first provider described is kept: it is the
+// one a fallback chain starts with. The custom headers of every provider
+// described are left out of the recorded requests.
+func (r *Recorder) Describe(provider types.LLMProvider, model string, settings types.ProviderSettings) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name := range settings.Headers {
+		r.redacted = append(r.redacted, name)
+	}
+	if r.cassette.Provider != "" {
+		return
+	}
+	r.cassette.Provider = provider
+	r.cassette.Model = model
+	r.cassette.Settings = sanitizeSettings(settings)
+}
+
+// Intercept sends req through next and records the exchange. Requests that
+// fail without a response are not recorded.
+func (r *Recorder) Intercept(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	recorded := Request{
+		Method:  req.Method,
+		URL:     sanitizeURL(req.URL).String(),
+		Headers: sanitizeHeaders(req.Header, r.redacted...),
+		Body:    body,
+	}
+	r.mu.Unlock()
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = &recordingBody{
+		ReadCloser: resp.Body,
+		done: func(data []byte) error {
+			return r.add(Interaction{
+				Request: recorded,
+				Response: Response{
+					Status:  resp.StatusCode,
+					Headers: sanitizeHeaders(resp.Header),
+					Body:    data,
+				},
+			})
+		},
+	}
+	return resp, nil
+}
+
+func (r *Recorder) add(interaction Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	return r.cassette.Save(r.path)
+}
+
+// readRequestBody returns the body of req and leaves req able to send it.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return data, nil
+}
+
+// recordingBody keeps a copy of what is read from a response body and
+// hands it to done when the body is closed. A body closed before its end is
+// read to the end first, so the cassette holds the whole response.
+type recordingBody struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	done func([]byte) error
+	once sync.Once
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	var err error
+	b.once.Do(func() {
+		_, copyErr := io.Copy(&b.buf, b.ReadCloser)
+		closeErr := b.ReadCloser.Close()
+		if copyErr != nil || closeErr != nil {
+			return
+		}
+		err = b.done(b.buf.Bytes())
+	})
+	return err
+}
@@ -0,0 +1,125 @@
+package cassette
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// Replayer answers requests from a cassette and never touches the network.
+//
+// Hosts are ignored, since a replay may point at a placeholder endpoint. A
+// request is answered by the first unused exchange with the same method,
+// path and body; failing that, the same method and path; failing that, the
+// same method. Once every candidate has been used, the last one is served
+// again, so a session can be replayed any number of times.
+type Replayer struct {
+	cassette *Cassette
+
+	mu   sync.Mutex
+	used []bool
+	last map[string]int
+}
+
+// NewReplayer replays c.
+func NewReplayer(c *Cassette) *Replayer {
+	return &Replayer{cassette: c, used: make([]bool, len(c.Interactions)), last: make(map[string]int)}
+}
+
+// LoadReplayer returns a Replayer for the cassette file at path.
+func LoadReplayer(path string) (*Replayer, error) {
+	c, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayer(c), nil
+}
+
+// Cassette returns the cassette being replayed.
+func (r *Replayer) Cassette() *Cassette {
+	return r.cassette
+}
+
+// Intercept answers req from the cassette. next is never called.
+func (r *Replayer) Intercept(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	target := requestTarget(sanitizeURL(req.URL))
+
+	interaction, ok := r.match(req.Method, target, body)
+	if !ok {
+		return nil, fmt.Errorf("cassette: no recorded response for %s %s", req.Method, target)
+	}
+
+	response := interaction.Response
+	header := response.Headers.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", response.Status, http.StatusText(response.Status)),
+		StatusCode:    response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(response.Body)),
+		ContentLength: int64(len(response.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (r *Replayer) match(method, target string, body []byte) (Interaction, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tiers := []func(Request) bool{
+		func(recorded Request) bool {
+			return recordedTarget(recorded) == target && bytes.Equal(recorded.Body, body)
+		},
+		func(recorded Request) bool { return recordedTarget(recorded) == target },
+		func(Request) bool { return true },
+	}
+	key := method + " " + target
+	for _, matches := range tiers {
+		index := -1
+		for i, interaction := range r.cassette.Interactions {
+			if r.used[i] || interaction.Request.Method != method || !matches(interaction.Request) {
+				continue
+			}
+			index = i
+			break
+		}
+		if index < 0 {
+			continue
+		}
+
+		r.used[index] = true
+		r.last[key] = index
+		return r.cassette.Interactions[index], true
+	}
+
+	if index, ok := r.last[key]; ok {
+		return r.cassette.Interactions[index], true
+	}
+	return Interaction{}, false
+}
+
+// requestTarget is the part of a URL requests are matched on: the path and
+// query without the host.
+func requestTarget(u *url.URL) string {
+	return u.RequestURI()
+}
+
+func recordedTarget(recorded Request) string {
+	u, err := url.Parse(recorded.URL)
+	if err != nil {
+		return recorded.URL
+	}
+	return requestTarget(u)
+}
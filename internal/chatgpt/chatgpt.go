@@ -3,36 +3,238 @@ package chatgpt
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	openai "github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
+	"github.com/openai/openai-go/v3/shared"
 
+	httpClient "github.com/dfanso/commit-msg/internal/http"
 	"github.com/dfanso/commit-msg/pkg/types"
 )
 
-const (
-	chatgptModel = openai.ChatModelGPT4o
-)
+// DefaultModel is used when no model has been configured for OpenAI.
+const DefaultModel = openai.ChatModelGPT4o
+
+// commitMessageSchemaName names the structured output format sent to OpenAI.
+const commitMessageSchemaName = "commit_message"
 
 // GenerateCommitMessage calls OpenAI's chat completions API to turn the provided
 // repository changes into a polished git commit message.
-func GenerateCommitMessage(config *types.Config, changes string, apiKey string, opts *types.GenerationOptions) (string, error) {
+func GenerateCommitMessage(ctx context.Context, config *types.Config, changes string, apiKey string, model string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+
+	client := newClient(apiKey)
+
+	resp, err := client.Chat.Completions.New(ctx, newChatParams(types.BuildPrompt(changes, opts), model, types.SamplingOf(opts)))
+	if err != nil {
+		return types.GenerationResult{}, fmt.Errorf("OpenAI error: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return types.GenerationResult{}, fmt.Errorf("OpenAI error: no response generated")
+	}
+
+	// Extract and return the commit message
+	return types.GenerationResult{
+		Message: resp.Choices[0].Message.Content,
+		Usage:   usageInfo(resp.Usage),
+	}, nil
+}
+
+// GenerateCandidates asks OpenAI for n alternative commit messages in a
+// single request through the n parameter. The usage of the request is
+// reported on the first result.
+func GenerateCandidates(ctx context.Context, config *types.Config, changes string, apiKey string, model string, opts *types.GenerationOptions, n int) ([]types.GenerationResult, error) {
+
+	client := newClient(apiKey)
+
+	params := newChatParams(types.BuildPrompt(changes, opts), model, types.SamplingOf(opts))
+	params.N = openai.Int(int64(n))
+
+	resp, err := client.Chat.Completions.New(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI error: %w", err)
+	}
 
-	client := openai.NewClient(option.WithAPIKey(apiKey))
+	var results []types.GenerationResult
+	for _, choice := range resp.Choices {
+		if choice.Message.Content != "" {
+			results = append(results, types.GenerationResult{Message: choice.Message.Content})
+		}
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("OpenAI error: no response generated")
+	}
+
+	results[0].Usage = usageInfo(resp.Usage)
+	return results, nil
+}
 
-	prompt := types.BuildCommitPrompt(changes, opts)
+// GenerateStructuredCommitMessage requests the commit message as a JSON object
+// through OpenAI's structured outputs and decodes it.
+func GenerateStructuredCommitMessage(ctx context.Context, config *types.Config, changes string, apiKey string, model string, opts *types.GenerationOptions) (types.GenerationResult, error) {
 
-	resp, err := client.Chat.Completions.New(context.TODO(), openai.ChatCompletionNewParams{
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.UserMessage(prompt),
+	client := newClient(apiKey)
+
+	params := newChatParams(types.BuildStructuredPrompt(changes, opts), model, types.SamplingOf(opts))
+	params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+		OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
+			JSONSchema: shared.ResponseFormatJSONSchemaJSONSchemaParam{
+				Name:   commitMessageSchemaName,
+				Strict: openai.Bool(true),
+				Schema: types.CommitMessageSchema(),
+			},
 		},
-		Model: chatgptModel,
-	})
+	}
+
+	resp, err := client.Chat.Completions.New(ctx, params)
+	if err != nil {
+		return types.GenerationResult{}, fmt.Errorf("OpenAI error: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return types.GenerationResult{}, fmt.Errorf("OpenAI error: no response generated")
+	}
+
+	commit, err := types.DecodeCommitMessage([]byte(resp.Choices[0].Message.Content))
 	if err != nil {
-		return "", fmt.Errorf("OpenAI error: %w", err)
+		return types.GenerationResult{}, err
 	}
 
-	// Extract and return the commit message
-	commitMsg := resp.Choices[0].Message.Content
-	return commitMsg, nil
+	return types.GenerationResult{
+		Message: commit.String(),
+		Commit:  &commit,
+		Usage:   usageInfo(resp.Usage),
+	}, nil
+}
+
+// StreamCommitMessage streams the chat completion from OpenAI, passing each
+// content delta to onChunk and returning the assembled commit message.
+func StreamCommitMessage(ctx context.Context, config *types.Config, changes string, apiKey string, model string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+
+	client := newClient(apiKey)
+
+	params := newChatParams(types.BuildPrompt(changes, opts), model, types.SamplingOf(opts))
+	// The usage is only reported in a final chunk when asked for.
+	params.StreamOptions = openai.ChatCompletionStreamOptionsParam{IncludeUsage: openai.Bool(true)}
+
+	stream := client.Chat.Completions.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	var message strings.Builder
+	var usage *types.UsageInfo
+	for stream.Next() {
+		chunk := stream.Current()
+		if chunk.Usage.TotalTokens > 0 {
+			usage = usageInfo(chunk.Usage)
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		delta := chunk.Choices[0].Delta.Content
+		if delta == "" {
+			continue
+		}
+		message.WriteString(delta)
+		if onChunk != nil {
+			onChunk(delta)
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		return types.GenerationResult{}, fmt.Errorf("OpenAI error: %w", err)
+	}
+
+	if message.Len() == 0 {
+		return types.GenerationResult{}, fmt.Errorf("OpenAI error: no response generated")
+	}
+
+	return types.GenerationResult{Message: message.String(), Usage: usage}, nil
+}
+
+// ListModels returns the IDs of the models available to the API key.
+func ListModels(ctx context.Context, apiKey string) ([]string, error) {
+	client := newClient(apiKey)
+
+	var models []string
+	iter := client.Models.ListAutoPaging(ctx)
+	for iter.Next() {
+		models = append(models, iter.Current().ID)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("OpenAI error: %w", err)
+	}
+
+	return models, nil
+}
+
+// newClient sends OpenAI requests through the shared generation client, whose
+// transport handles retries, so the SDK's own retries are turned off. That
+// client has no overall timeout, so long generations and streams are bounded
+// by the request context alone.
+func newClient(apiKey string) openai.Client {
+	return openai.NewClient(
+		option.WithAPIKey(apiKey),
+		option.WithHTTPClient(httpClient.GetGenerationClient()),
+		option.WithMaxRetries(0),
+	)
+}
+
+// usageInfo converts the token counts OpenAI reports for a completion.
+func usageInfo(usage openai.CompletionUsage) *types.UsageInfo {
+	return types.NewUsageInfo(int(usage.PromptTokens), int(usage.CompletionTokens)).
+		WithCachedPrompt(int(usage.PromptTokensDetails.CachedTokens))
+}
+
+// newChatParams maps prompt onto chat messages. The system prompt goes out as
+// a developer message to reasoning models, which use that role in its place
+// and only accept their default temperature and top-p.
+func newChatParams(prompt types.Prompt, model string, sampling types.Sampling) openai.ChatCompletionNewParams {
+	if model == "" {
+		model = DefaultModel
+	}
+
+	var messages []openai.ChatCompletionMessageParamUnion
+	for _, message := range prompt.Messages() {
+		switch message.Role {
+		case types.RoleSystem:
+			if usesDeveloperRole(model) {
+				messages = append(messages, openai.DeveloperMessage(message.Content))
+			} else {
+				messages = append(messages, openai.SystemMessage(message.Content))
+			}
+		case types.RoleAssistant:
+			messages = append(messages, openai.AssistantMessage(message.Content))
+		default:
+			messages = append(messages, openai.UserMessage(message.Content))
+		}
+	}
+
+	params := openai.ChatCompletionNewParams{
+		Messages: messages,
+		Model:    model,
+	}
+	if !usesDeveloperRole(model) {
+		if sampling.Temperature != nil {
+			params.Temperature = openai.Float(*sampling.Temperature)
+		}
+		if sampling.TopP != nil {
+			params.TopP = openai.Float(*sampling.TopP)
+		}
+	}
+	if sampling.MaxTokens > 0 {
+		params.MaxCompletionTokens = openai.Int(int64(sampling.MaxTokens))
+	}
+	if sampling.Seed != nil {
+		params.Seed = openai.Int(*sampling.Seed)
+	}
+	return params
+}
+
+// usesDeveloperRole reports whether model is a reasoning model (o1, o3,
+// o4-mini, gpt-5 and their snapshots).
+func usesDeveloperRole(model string) bool {
+	model = strings.ToLower(model)
+	if strings.HasPrefix(model, "gpt-5") {
+		return true
+	}
+	return len(model) > 1 && model[0] == 'o' && model[1] >= '0' && model[1] <= '9'
 }
@@ -1,6 +1,8 @@
 package chatgpt
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -14,7 +16,7 @@ func TestGenerateCommitMessage(t *testing.T) {
 	t.Run("returns error for empty API key", func(t *testing.T) {
 		t.Parallel()
 
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", "", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", "", "", nil)
 		if err == nil {
 			t.Fatal("expected error for empty API key")
 		}
@@ -23,7 +25,7 @@ func TestGenerateCommitMessage(t *testing.T) {
 	t.Run("returns error for empty changes", func(t *testing.T) {
 		t.Parallel()
 
-		_, err := GenerateCommitMessage(&types.Config{}, "", "test-key", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "", "test-key", "", nil)
 		if err == nil {
 			t.Fatal("expected error for empty changes")
 		}
@@ -39,7 +41,7 @@ func TestGenerateCommitMessage(t *testing.T) {
 
 		// This test would require mocking the OpenAI client or using a test double
 		// For now, we'll test the error handling path by providing an invalid API key
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", "invalid-key", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", "invalid-key", "", nil)
 		if err == nil {
 			t.Fatal("expected error for invalid API key")
 		}
@@ -56,7 +58,7 @@ func TestGenerateCommitMessage(t *testing.T) {
 		}
 
 		// Test with invalid key to verify the function processes the options
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", "invalid-key", opts)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", "invalid-key", "", opts)
 		if err == nil {
 			t.Fatal("expected error for invalid API key")
 		}
@@ -66,10 +68,47 @@ func TestGenerateCommitMessage(t *testing.T) {
 func TestGenerateCommitMessageWithContext(t *testing.T) {
 	t.Parallel()
 
-	// This test would require modifying the function to accept context
-	// For now, we'll test the basic functionality
-	_, err := GenerateCommitMessage(&types.Config{}, "some changes", "test-key", nil)
-	if err == nil {
-		t.Fatal("expected error for invalid API key")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := GenerateCommitMessage(ctx, &types.Config{}, "some changes", "test-key", "", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestNewChatParamsRoles(t *testing.T) {
+	t.Parallel()
+
+	prompt := types.BuildPrompt("some changes", nil)
+
+	params := newChatParams(prompt, "gpt-4o-mini", types.Sampling{})
+	if len(params.Messages) != 4 || params.Messages[0].OfSystem == nil || params.Messages[2].OfAssistant == nil || params.Messages[3].OfUser == nil {
+		t.Fatalf("unexpected messages for gpt-4o-mini: %+v", params.Messages)
+	}
+
+	for _, model := range []string{"o4-mini", "o1-2024-12-17", "gpt-5"} {
+		params = newChatParams(prompt, model, types.Sampling{})
+		if params.Messages[0].OfDeveloper == nil {
+			t.Fatalf("expected a developer message for %s", model)
+		}
+	}
+}
+
+func TestNewChatParamsSampling(t *testing.T) {
+	t.Parallel()
+
+	prompt := types.BuildPrompt("some changes", nil)
+	sampling := types.DeterministicSampling()
+	sampling.MaxTokens = 800
+
+	params := newChatParams(prompt, "gpt-4o", sampling)
+	if params.Temperature.Value != 0 || !params.Temperature.Valid() || params.Seed.Value != types.DeterministicSeed || params.MaxCompletionTokens.Value != 800 {
+		t.Fatalf("unexpected sampling params: %+v", params)
+	}
+
+	params = newChatParams(prompt, "o4-mini", sampling)
+	if params.Temperature.Valid() || params.Seed.Value != types.DeterministicSeed {
+		t.Fatalf("expected reasoning models to keep their temperature: %+v", params)
 	}
 }
@@ -5,27 +5,55 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
 	httpClient "github.com/dfanso/commit-msg/internal/http"
 	"github.com/dfanso/commit-msg/pkg/types"
 )
 
+// DefaultModel is used when no model has been configured for Claude.
+const DefaultModel = "claude-3-5-sonnet-20241022"
+
 const (
-	claudeModel        = "claude-3-5-sonnet-20241022"
-	claudeMaxTokens    = 200
-	claudeAPIEndpoint  = "https://api.anthropic.com/v1/messages"
-	claudeAPIVersion   = "2023-06-01"
-	contentTypeJSON    = "application/json"
+	// claudeMaxTokens leaves room for a detailed body; max_tokens is required.
+	claudeMaxTokens        = 1024
+	claudeAPIEndpoint      = "https://api.anthropic.com/v1/messages"
+	claudeModelsEndpoint   = "https://api.anthropic.com/v1/models?limit=1000"
+	claudeAPIVersion       = "2023-06-01"
+	contentTypeJSON        = "application/json"
 	anthropicVersionHeader = "anthropic-version"
-	xAPIKeyHeader      = "x-api-key"
+	xAPIKeyHeader          = "x-api-key"
+	contentTypeEventStream = "text/event-stream"
+
+	commitMessageToolName = "record_commit_message"
 )
 
 // ClaudeRequest describes the payload sent to Anthropic's Claude messages API.
 type ClaudeRequest struct {
-	Model     string          `json:"model"`
-	Messages  []types.Message `json:"messages"`
-	MaxTokens int             `json:"max_tokens"`
+	Model       string            `json:"model"`
+	System      string            `json:"system,omitempty"`
+	Messages    []types.Message   `json:"messages"`
+	MaxTokens   int               `json:"max_tokens"`
+	Temperature *float64          `json:"temperature,omitempty"`
+	TopP        *float64          `json:"top_p,omitempty"`
+	Stream      bool              `json:"stream,omitempty"`
+	Tools       []claudeTool      `json:"tools,omitempty"`
+	ToolChoice  *claudeToolChoice `json:"tool_choice,omitempty"`
+}
+
+// claudeTool declares a tool the model may call; its input follows InputSchema.
+type claudeTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+// claudeToolChoice forces the model to answer through the named tool.
+type claudeToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
 }
 
 // ClaudeResponse captures the subset of fields used from Anthropic responses.
@@ -36,59 +64,270 @@ type ClaudeResponse struct {
 		Type string `json:"type"`
 		Text string `json:"text"`
 	} `json:"content"`
+	Usage claudeUsage `json:"usage"`
+}
+
+// claudeUsage holds the token counts Anthropic reports for a message.
+// InputTokens excludes the tokens read from or written to the prompt cache.
+type claudeUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// claudeToolResponse captures the tool calls of a response to a request that
+// forced a tool.
+type claudeToolResponse struct {
+	Content []struct {
+		Type  string          `json:"type"`
+		Name  string          `json:"name"`
+		Input json.RawMessage `json:"input"`
+	} `json:"content"`
+	Usage claudeUsage `json:"usage"`
+}
+
+// claudeStreamEvent captures the fields used from streamed message events.
+type claudeStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	// Message is sent with message_start and carries the input token count.
+	Message struct {
+		Usage claudeUsage `json:"usage"`
+	} `json:"message"`
+	// Usage is sent with message_delta and carries the output token count.
+	Usage claudeUsage `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
 }
 
+// claudeModelList captures the model IDs returned by the models API.
+type claudeModelList struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+// allow overrides in tests
+var (
+	apiEndpoint    = claudeAPIEndpoint
+	modelsEndpoint = claudeModelsEndpoint
+)
+
 // GenerateCommitMessage produces a commit summary using Anthropic's Claude API.
-func GenerateCommitMessage(config *types.Config, changes string, apiKey string, opts *types.GenerationOptions) (string, error) {
+func GenerateCommitMessage(ctx context.Context, config *types.Config, changes string, apiKey string, model string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	req, err := newMessagesRequest(ctx, newClaudeRequest(types.BuildPrompt(changes, opts), model, false, types.SamplingOf(opts)), apiKey)
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
 
-	prompt := types.BuildCommitPrompt(changes, opts)
+	var claudeResponse ClaudeResponse
+	if err := sendMessagesRequest(req, &claudeResponse); err != nil {
+		return types.GenerationResult{}, err
+	}
 
-	reqBody := ClaudeRequest{
-		Model:     claudeModel,
-		MaxTokens: claudeMaxTokens,
-		Messages: []types.Message{
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
+	if len(claudeResponse.Content) == 0 {
+		return types.GenerationResult{}, fmt.Errorf("no response generated")
 	}
 
-	jsonData, err := json.Marshal(reqBody)
+	return types.GenerationResult{
+		Message: claudeResponse.Content[0].Text,
+		Usage:   claudeResponse.Usage.info(),
+	}, nil
+}
+
+// GenerateStructuredCommitMessage forces Claude to answer through a tool whose
+// input schema is the structured commit message, and decodes the tool input.
+func GenerateStructuredCommitMessage(ctx context.Context, config *types.Config, changes string, apiKey string, model string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	reqBody := newClaudeRequest(types.BuildStructuredPrompt(changes, opts), model, false, types.SamplingOf(opts))
+	reqBody.Tools = []claudeTool{{
+		Name:        commitMessageToolName,
+		Description: "Record the generated git commit message.",
+		InputSchema: types.CommitMessageSchema(),
+	}}
+	reqBody.ToolChoice = &claudeToolChoice{Type: "tool", Name: commitMessageToolName}
+
+	req, err := newMessagesRequest(ctx, reqBody, apiKey)
 	if err != nil {
-		return "", err
+		return types.GenerationResult{}, err
+	}
+
+	var toolResponse claudeToolResponse
+	if err := sendMessagesRequest(req, &toolResponse); err != nil {
+		return types.GenerationResult{}, err
+	}
+
+	for _, block := range toolResponse.Content {
+		if block.Type != "tool_use" || block.Name != commitMessageToolName {
+			continue
+		}
+		commit, err := types.DecodeCommitMessage(block.Input)
+		if err != nil {
+			return types.GenerationResult{}, err
+		}
+		return types.GenerationResult{
+			Message: commit.String(),
+			Commit:  &commit,
+			Usage:   toolResponse.Usage.info(),
+		}, nil
 	}
 
-	ctx := context.Background()
-	req, err := http.NewRequestWithContext(ctx, "POST", claudeAPIEndpoint, bytes.NewBuffer(jsonData))
+	return types.GenerationResult{}, fmt.Errorf("no response generated")
+}
+
+// info converts the reported counts, returning nil when none were reported.
+func (u claudeUsage) info() *types.UsageInfo {
+	prompt := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return types.NewUsageInfo(prompt, u.OutputTokens).WithCachedPrompt(u.CacheReadInputTokens)
+}
+
+// sendMessagesRequest performs a non-streaming messages API call and decodes
+// the response into out.
+func sendMessagesRequest(req *http.Request, out any) error {
+	client := httpClient.GetGenerationClient()
+	resp, err := client.Do(req)
 	if err != nil {
-		return "", err
+		return err
 	}
+	defer resp.Body.Close()
 
-	req.Header.Set("Content-Type", contentTypeJSON)
+	if resp.StatusCode != http.StatusOK {
+		return httpClient.NewStatusError(resp.StatusCode, "claude AI response %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// StreamCommitMessage requests a streamed response from the messages API and
+// forwards every text delta to onChunk, returning the assembled message.
+func StreamCommitMessage(ctx context.Context, config *types.Config, changes string, apiKey string, model string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	req, err := newMessagesRequest(ctx, newClaudeRequest(types.BuildPrompt(changes, opts), model, true, types.SamplingOf(opts)), apiKey)
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
+	req.Header.Set("Accept", contentTypeEventStream)
+
+	client := httpClient.GetGenerationClient()
+	resp, err := client.Do(req)
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return types.GenerationResult{}, httpClient.NewStatusError(resp.StatusCode, "claude AI response %d", resp.StatusCode)
+	}
+
+	var message strings.Builder
+	var usage claudeUsage
+	err = httpClient.ReadServerSentEvents(resp.Body, func(sse httpClient.ServerSentEvent) error {
+		var event claudeStreamEvent
+		if err := json.Unmarshal([]byte(sse.Data), &event); err != nil {
+			return fmt.Errorf("failed to decode claude stream event: %w", err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			usage = event.Message.Usage
+		case "message_delta":
+			usage.OutputTokens = event.Usage.OutputTokens
+		case "content_block_delta":
+			if event.Delta.Type != "text_delta" || event.Delta.Text == "" {
+				return nil
+			}
+			message.WriteString(event.Delta.Text)
+			if onChunk != nil {
+				onChunk(event.Delta.Text)
+			}
+		case "message_stop":
+			return io.EOF
+		case "error":
+			return fmt.Errorf("claude stream error (%s): %s", event.Error.Type, event.Error.Message)
+		}
+		return nil
+	})
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
+
+	if message.Len() == 0 {
+		return types.GenerationResult{}, fmt.Errorf("no response generated")
+	}
+
+	return types.GenerationResult{Message: message.String(), Usage: usage.info()}, nil
+}
+
+// ListModels returns the IDs of the Claude models available to the API key.
+func ListModels(ctx context.Context, apiKey string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, modelsEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
 	req.Header.Set(xAPIKeyHeader, apiKey)
 	req.Header.Set(anthropicVersionHeader, claudeAPIVersion)
 
 	client := httpClient.GetClient()
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("claude AI response %d", resp.StatusCode)
+		return nil, httpClient.NewStatusError(resp.StatusCode, "claude AI response %d", resp.StatusCode)
 	}
 
-	var claudeResponse ClaudeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&claudeResponse); err != nil {
-		return "", err
+	var list claudeModelList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
 	}
 
-	if len(claudeResponse.Content) == 0 {
-		return "", fmt.Errorf("no response generated")
+	models := make([]string, 0, len(list.Data))
+	for _, model := range list.Data {
+		models = append(models, model.ID)
 	}
+	return models, nil
+}
+
+// newClaudeRequest builds the messages API payload for prompt. The system
+// prompt has its own top-level field; the messages hold the examples and the
+// user turn. Claude takes no seed, so sampling's seed is not sent.
+func newClaudeRequest(prompt types.Prompt, model string, stream bool, sampling types.Sampling) ClaudeRequest {
+	if model == "" {
+		model = DefaultModel
+	}
+
+	return ClaudeRequest{
+		Model:       model,
+		System:      prompt.System,
+		MaxTokens:   sampling.MaxTokensOr(claudeMaxTokens),
+		Temperature: sampling.Temperature,
+		TopP:        sampling.TopP,
+		Stream:      stream,
+		Messages:    prompt.Turns(),
+	}
+}
+
+// newMessagesRequest builds the messages API request for all call styles.
+func newMessagesRequest(ctx context.Context, reqBody ClaudeRequest, apiKey string) (*http.Request, error) {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", apiEndpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", contentTypeJSON)
+	req.Header.Set(xAPIKeyHeader, apiKey)
+	req.Header.Set(anthropicVersionHeader, claudeAPIVersion)
 
-	commitMsg := claudeResponse.Content[0].Text
-	return commitMsg, nil
+	return req, nil
 }
@@ -1,6 +1,7 @@
 package claude
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -16,7 +17,7 @@ func TestGenerateCommitMessage(t *testing.T) {
 	t.Run("returns error for empty API key", func(t *testing.T) {
 		t.Parallel()
 
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", "", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", "", "", nil)
 		if err == nil {
 			t.Fatal("expected error for empty API key")
 		}
@@ -25,7 +26,7 @@ func TestGenerateCommitMessage(t *testing.T) {
 	t.Run("returns error for empty changes", func(t *testing.T) {
 		t.Parallel()
 
-		_, err := GenerateCommitMessage(&types.Config{}, "", "test-key", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "", "test-key", "", nil)
 		if err == nil {
 			t.Fatal("expected error for empty changes")
 		}
@@ -84,7 +85,7 @@ func TestGenerateCommitMessageWithMockServer(t *testing.T) {
 
 		// This would require modifying the function to accept a URL parameter
 		// For now, we'll test the error handling path
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", "invalid-key", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", "invalid-key", "", nil)
 		if err == nil {
 			t.Fatal("expected error for invalid API key")
 		}
@@ -103,7 +104,7 @@ func TestGenerateCommitMessageWithMockServer(t *testing.T) {
 
 		// This would require modifying the function to accept a URL parameter
 		// For now, we'll test the error handling path
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", "invalid-key", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", "invalid-key", "", nil)
 		if err == nil {
 			t.Fatal("expected error for invalid API key")
 		}
@@ -129,7 +130,7 @@ func TestGenerateCommitMessageWithMockServer(t *testing.T) {
 
 		// This would require modifying the function to accept a URL parameter
 		// For now, we'll test the error handling path
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", "invalid-key", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", "invalid-key", "", nil)
 		if err == nil {
 			t.Fatal("expected error for invalid API key")
 		}
@@ -146,7 +147,7 @@ func TestGenerateCommitMessageIncludesStyleInstructions(t *testing.T) {
 	}
 
 	// Test with invalid key to verify the function processes the options
-	_, err := GenerateCommitMessage(&types.Config{}, "some changes", "invalid-key", opts)
+	_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", "invalid-key", "", opts)
 	if err == nil {
 		t.Fatal("expected error for invalid API key")
 	}
@@ -241,7 +242,7 @@ func TestGenerateCommitMessageWithInvalidJSON(t *testing.T) {
 
 	// This would require modifying the function to accept a URL parameter
 	// For now, we'll test the error handling path
-	_, err := GenerateCommitMessage(&types.Config{}, "some changes", "invalid-key", nil)
+	_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", "invalid-key", "", nil)
 	if err == nil {
 		t.Fatal("expected error for invalid API key")
 	}
@@ -254,8 +255,168 @@ func TestGenerateCommitMessageWithLongPrompt(t *testing.T) {
 	longChanges := strings.Repeat("This is a test change. ", 1000)
 
 	// Test with invalid key to verify the function handles long prompts
-	_, err := GenerateCommitMessage(&types.Config{}, longChanges, "invalid-key", nil)
+	_, err := GenerateCommitMessage(context.Background(), &types.Config{}, longChanges, "invalid-key", "", nil)
 	if err == nil {
 		t.Fatal("expected error for invalid API key")
 	}
 }
+
+func TestStreamCommitMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ClaudeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+
+		if !req.Stream {
+			t.Fatal("expected stream to be requested")
+		}
+
+		if req.Model != "claude-3-5-haiku-20241022" {
+			t.Fatalf("unexpected model: %q", req.Model)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":42,\"output_tokens\":1}}}\n\n"))
+		w.Write([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"feat: \"}}\n\n"))
+		w.Write([]byte("event: ping\ndata: {\"type\":\"ping\"}\n\n"))
+		w.Write([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"stream claude\"}}\n\n"))
+		w.Write([]byte("event: message_delta\ndata: {\"type\":\"message_delta\",\"usage\":{\"output_tokens\":7}}\n\n"))
+		w.Write([]byte("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	previous := apiEndpoint
+	apiEndpoint = server.URL
+	t.Cleanup(func() { apiEndpoint = previous })
+
+	var chunks []string
+	msg, err := StreamCommitMessage(context.Background(), &types.Config{}, "some changes", "test-key", "claude-3-5-haiku-20241022", nil, func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if msg.Message != "feat: stream claude" {
+		t.Fatalf("unexpected message: %q", msg.Message)
+	}
+
+	if msg.Usage == nil || msg.Usage.PromptTokens != 42 || msg.Usage.CompletionTokens != 7 || msg.Usage.TotalTokens != 49 {
+		t.Fatalf("unexpected usage: %+v", msg.Usage)
+	}
+
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(chunks))
+	}
+}
+
+func TestStreamCommitMessageErrorEvent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	previous := apiEndpoint
+	apiEndpoint = server.URL
+	t.Cleanup(func() { apiEndpoint = previous })
+
+	_, err := StreamCommitMessage(context.Background(), &types.Config{}, "some changes", "test-key", "", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "overloaded_error") {
+		t.Fatalf("expected overloaded error, got %v", err)
+	}
+}
+
+func TestListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("x-api-key"); got != "test-key" {
+			t.Fatalf("unexpected api key header: %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":[{"id":"claude-sonnet-4-20250514","type":"model"},{"id":"claude-3-5-haiku-20241022","type":"model"}],"has_more":false}`))
+	}))
+	t.Cleanup(server.Close)
+
+	previous := modelsEndpoint
+	modelsEndpoint = server.URL
+	t.Cleanup(func() { modelsEndpoint = previous })
+
+	models, err := ListModels(context.Background(), "test-key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(models) != 2 || models[0] != "claude-sonnet-4-20250514" {
+		t.Fatalf("unexpected models: %v", models)
+	}
+}
+
+func TestGenerateStructuredCommitMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ClaudeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+
+		if len(req.Tools) != 1 || req.ToolChoice == nil || req.ToolChoice.Name != req.Tools[0].Name {
+			t.Fatalf("expected the commit message tool to be forced, got %+v / %+v", req.Tools, req.ToolChoice)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"content":[{"type":"tool_use","name":"record_commit_message","input":{"type":"feat","scope":"cli","subject":"add structured output","body":"","breaking":false,"trailers":[]}}],"usage":{"input_tokens":20,"cache_read_input_tokens":100,"output_tokens":30}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	previous := apiEndpoint
+	apiEndpoint = server.URL
+	t.Cleanup(func() { apiEndpoint = previous })
+
+	msg, err := GenerateStructuredCommitMessage(context.Background(), &types.Config{}, "some changes", "test-key", "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if msg.Message != "feat(cli): add structured output" || msg.Commit == nil || msg.Commit.Scope != "cli" {
+		t.Fatalf("unexpected result: %+v", msg)
+	}
+
+	if msg.Usage == nil || msg.Usage.TotalTokens != 150 || msg.Usage.CachedPromptTokens != 100 {
+		t.Fatalf("unexpected usage: %+v", msg.Usage)
+	}
+}
+
+func TestNewClaudeRequestUsesSystemField(t *testing.T) {
+	t.Parallel()
+
+	prompt := types.BuildPrompt("some changes", nil)
+	req := newClaudeRequest(prompt, "", false, types.Sampling{})
+
+	if req.System != prompt.System {
+		t.Fatalf("expected the system prompt in the system field, got %q", req.System)
+	}
+	for _, message := range req.Messages {
+		if message.Role == types.RoleSystem {
+			t.Fatal("expected no system role in the messages")
+		}
+	}
+	if last := req.Messages[len(req.Messages)-1]; last.Role != types.RoleUser || last.Content != prompt.User {
+		t.Fatalf("expected the changes in the last user message, got %+v", last)
+	}
+}
+
+func TestNewClaudeRequestSampling(t *testing.T) {
+	t.Parallel()
+
+	prompt := types.BuildPrompt("some changes", nil)
+	if req := newClaudeRequest(prompt, "", false, types.Sampling{}); req.MaxTokens != claudeMaxTokens || req.Temperature != nil {
+		t.Fatalf("expected the defaults, got %+v", req)
+	}
+
+	sampling := types.DeterministicSampling()
+	sampling.MaxTokens = 300
+	req := newClaudeRequest(prompt, "", false, sampling)
+	if req.MaxTokens != 300 || req.Temperature == nil || *req.Temperature != 0 {
+		t.Fatalf("expected the sampling settings, got %+v", req)
+	}
+}
@@ -2,7 +2,9 @@ package display
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/dfanso/commit-msg/pkg/types"
 	"github.com/pterm/pterm"
 )
 
@@ -20,6 +22,10 @@ type FileStatistics struct {
 	TotalFiles     int
 	LinesAdded     int
 	LinesDeleted   int
+	// Scope is the scope the files were selected by.
+	Scope types.ChangeScope
+	// OmittedFiles counts the changed files left out by Scope.
+	OmittedFiles int
 }
 
 // ShowFileStatistics displays file statistics with colored output
@@ -99,14 +105,115 @@ func ShowFileStatistics(stats *FileStatistics) {
 	}
 
 	pterm.DefaultBulletList.WithItems(bulletItems).Render()
+
+	if stats.Scope != types.ScopeAuto {
+		note := "Describing " + stats.Scope.Describe()
+		if stats.OmittedFiles > 0 {
+			note += fmt.Sprintf("; %d other changed file(s) left out", stats.OmittedFiles)
+		}
+		pterm.Info.Println(note + ".")
+	}
 }
 
 // ShowCommitMessage displays the commit message in a styled panel
 func ShowCommitMessage(message string) {
 	pterm.DefaultSection.Println("Generated Commit Message")
 
-	// Create a panel with the commit message
-	panel := pterm.DefaultBox.
+	commitMessagePanel().Println(pterm.LightGreen(message))
+}
+
+// ShowCommitCandidates displays alternative commit messages next to each
+// other, wrapping onto further rows when the terminal is too narrow.
+func ShowCommitCandidates(messages []string) {
+	pterm.DefaultSection.Println("Generated Commit Messages")
+
+	panels := candidatePanels(candidateTitles(len(messages)), messages, pterm.GetTerminalWidth())
+	pterm.DefaultPanel.WithPanels(panels).WithPadding(2).Render()
+}
+
+// ShowCommitComparison displays the commit messages of several providers
+// next to each other, each box titled with its entry of titles.
+func ShowCommitComparison(titles, messages []string) {
+	pterm.DefaultSection.Println("Provider Comparison")
+
+	panels := candidatePanels(titles, messages, pterm.GetTerminalWidth())
+	pterm.DefaultPanel.WithPanels(panels).WithPadding(2).Render()
+}
+
+// candidateTitles numbers n candidates.
+func candidateTitles(n int) []string {
+	titles := make([]string, n)
+	for i := range titles {
+		titles[i] = fmt.Sprintf("Candidate %d", i+1)
+	}
+	return titles
+}
+
+// candidatePanels arranges one titled box per message in rows that fit
+// into width columns.
+func candidatePanels(titles, messages []string, width int) pterm.Panels {
+	widest := 0
+	for i, message := range messages {
+		widest = max(widest, len([]rune(titles[i])))
+		for _, line := range strings.Split(message, "\n") {
+			widest = max(widest, len([]rune(line)))
+		}
+	}
+
+	// Each box adds its borders and padding plus the gap between panels.
+	perRow := max(width/(widest+6), 1)
+
+	var rows pterm.Panels
+	for i, message := range messages {
+		if i%perRow == 0 {
+			rows = append(rows, []pterm.Panel{})
+		}
+		box := commitMessagePanel().WithTitle(titles[i]).Sprint(pterm.LightGreen(message))
+		rows[len(rows)-1] = append(rows[len(rows)-1], pterm.Panel{Data: box})
+	}
+	return rows
+}
+
+// StreamingCommitMessage renders a commit message live while a provider is
+// still producing it.
+type StreamingCommitMessage struct {
+	area    *pterm.AreaPrinter
+	message strings.Builder
+}
+
+// StartStreamingCommitMessage opens a live area that is redrawn whenever a new
+// chunk of the commit message arrives. The area is removed once stopped so the
+// final message can be rendered by ShowCommitMessage.
+func StartStreamingCommitMessage() (*StreamingCommitMessage, error) {
+	area, err := pterm.DefaultArea.WithRemoveWhenDone().Start()
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamingCommitMessage{area: area}, nil
+}
+
+// Append adds a chunk of streamed text and redraws the live panel.
+func (s *StreamingCommitMessage) Append(chunk string) {
+	s.message.WriteString(chunk)
+	s.area.Update(commitMessagePanel().Sprint(pterm.LightGreen(s.message.String() + "▌")))
+}
+
+// Restart discards the text streamed so far and shows note until the next
+// chunk arrives, e.g. when another provider takes over.
+func (s *StreamingCommitMessage) Restart(note string) {
+	s.message.Reset()
+	s.area.Update(pterm.Warning.Sprint(note))
+}
+
+// Stop ends the live rendering and clears the preview.
+func (s *StreamingCommitMessage) Stop() error {
+	return s.area.Stop()
+}
+
+// commitMessagePanel returns the box used to frame commit messages.
+func commitMessagePanel() *pterm.BoxPrinter {
+	return pterm.DefaultBox.
 		WithTitle("Commit Message").
 		WithTitleTopCenter().
 		WithBoxStyle(pterm.NewStyle(pterm.FgLightGreen)).
@@ -116,8 +223,6 @@ func ShowCommitMessage(message string) {
 		WithTopRightCornerString("└").
 		WithBottomLeftCornerString("┐").
 		WithBottomRightCornerString("┌")
-
-	panel.Println(pterm.LightGreen(message))
 }
 
 // ShowChangesPreview displays a preview of changes with line statistics
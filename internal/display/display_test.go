@@ -2,7 +2,10 @@ package display
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/dfanso/commit-msg/pkg/types"
 )
 
 func TestShowFileStatistics(t *testing.T) {
@@ -62,6 +65,20 @@ func TestShowFileStatistics(t *testing.T) {
 		ShowFileStatistics(stats)
 	})
 
+	t.Run("notes the scope and omitted files", func(t *testing.T) {
+		t.Parallel()
+
+		stats := &FileStatistics{
+			StagedFiles:  []string{"file1.go"},
+			TotalFiles:   1,
+			Scope:        types.ScopeStaged,
+			OmittedFiles: 2,
+		}
+
+		// Just test that the function doesn't panic
+		ShowFileStatistics(stats)
+	})
+
 	t.Run("handles empty statistics", func(t *testing.T) {
 		t.Parallel()
 
@@ -105,6 +122,30 @@ func TestShowCommitMessage(t *testing.T) {
 	})
 }
 
+func TestCandidatePanels(t *testing.T) {
+	t.Parallel()
+
+	messages := []string{"feat: add candidates", "feat(cli): pick a message\n\nShows several options", "fix: typo"}
+
+	if rows := candidatePanels(candidateTitles(3), messages, 200); len(rows) != 1 || len(rows[0]) != 3 {
+		t.Fatalf("expected all candidates side by side on a wide terminal, got %d rows", len(rows))
+	}
+
+	rows := candidatePanels(candidateTitles(3), messages, 40)
+	if len(rows) != 3 {
+		t.Fatalf("expected one candidate per row on a narrow terminal, got %d rows", len(rows))
+	}
+	if !strings.Contains(rows[2][0].Data, "Candidate 3") {
+		t.Fatalf("expected the boxes to be numbered, got %q", rows[2][0].Data)
+	}
+
+	// Long titles need room as well.
+	titles := []string{"1. Claude (claude-3-5-haiku-latest)", "2. Ollama (llama3.2)", "3. Gemini (gemini-2.5-flash)"}
+	if rows := candidatePanels(titles, messages, 80); len(rows) != 3 {
+		t.Fatalf("expected the titles to widen the boxes, got %d rows", len(rows))
+	}
+}
+
 func TestShowChangesPreview(t *testing.T) {
 	t.Parallel()
 
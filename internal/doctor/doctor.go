@@ -0,0 +1,207 @@
+// Package doctor implements the environment checks run by `commit doctor`.
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/keyring"
+)
+
+// Status grades the outcome of a check.
+type Status int
+
+const (
+	StatusPass Status = iota
+	StatusWarn
+	StatusFail
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusPass:
+		return "pass"
+	case StatusWarn:
+		return "warn"
+	default:
+		return "fail"
+	}
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	Name   string
+	Status Status
+	Detail string
+	// Fix suggests how to resolve a warning or failure.
+	Fix string
+}
+
+func pass(name, detail string) Result {
+	return Result{Name: name, Status: StatusPass, Detail: detail}
+}
+
+func warn(name, detail, fix string) Result {
+	return Result{Name: name, Status: StatusWarn, Detail: detail, Fix: fix}
+}
+
+func fail(name, detail, fix string) Result {
+	return Result{Name: name, Status: StatusFail, Detail: detail, Fix: fix}
+}
+
+// minGitVersion is the oldest git release the diff handling is tested with.
+var minGitVersion = [2]int{2, 0}
+
+var gitVersionPattern = regexp.MustCompile(`(\d+)\.(\d+)(?:\.\d+)?`)
+
+// CheckGitVersion verifies that git is installed and recent enough.
+func CheckGitVersion(ctx context.Context) Result {
+	const name = "Git version"
+
+	output, err := exec.CommandContext(ctx, "git", "--version").Output()
+	if err != nil {
+		return fail(name, fmt.Sprintf("git could not be run: %v", err), "Install git and make sure it is on your PATH.")
+	}
+
+	version := strings.TrimSpace(string(output))
+	match := gitVersionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return warn(name, fmt.Sprintf("Unrecognised version output %q", version), "Check that 'git' on your PATH is the real git.")
+	}
+
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	if major < minGitVersion[0] || (major == minGitVersion[0] && minor < minGitVersion[1]) {
+		return warn(name, fmt.Sprintf("%s is older than %d.%d", version, minGitVersion[0], minGitVersion[1]), "Upgrade git.")
+	}
+	return pass(name, version)
+}
+
+// inProgressMarkers maps files git keeps while an operation is unfinished to
+// the operation's name.
+var inProgressMarkers = []struct {
+	path      string
+	operation string
+}{
+	{"rebase-merge", "rebase"},
+	{"rebase-apply", "rebase"},
+	{"MERGE_HEAD", "merge"},
+	{"CHERRY_PICK_HEAD", "cherry-pick"},
+	{"REVERT_HEAD", "revert"},
+	{"BISECT_LOG", "bisect"},
+}
+
+// CheckRepository reports whether dir is inside a work tree that is ready
+// for a commit: on a branch and with no merge or rebase in progress.
+func CheckRepository(ctx context.Context, dir string) Result {
+	const name = "Git repository"
+
+	gitDir, err := gitOutput(ctx, dir, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return warn(name, fmt.Sprintf("%s is not inside a git repository", dir), "Run commit from the repository you want to commit to.")
+	}
+
+	for _, marker := range inProgressMarkers {
+		if _, err := os.Stat(filepath.Join(gitDir, marker.path)); err == nil {
+			return warn(name, fmt.Sprintf("A %s is in progress", marker.operation),
+				fmt.Sprintf("Finish or abort the %s before generating a commit message.", marker.operation))
+		}
+	}
+
+	branch, err := gitOutput(ctx, dir, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return warn(name, "HEAD is detached", "Check out a branch so the commit is not left dangling.")
+	}
+
+	status, err := gitOutput(ctx, dir, "status", "--porcelain")
+	if err != nil {
+		return fail(name, fmt.Sprintf("git status failed: %v", err), "Run 'git status' to see what is wrong with the repository.")
+	}
+	changed := 0
+	if status != "" {
+		changed = strings.Count(status, "\n") + 1
+	}
+	return pass(name, fmt.Sprintf("On branch %s, %d changed file(s)", branch, changed))
+}
+
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// OpenKeyring opens the keyring the way the store does and reports which
+// backend answered. Backends are tried one by one, in keyring's own order,
+// so the first one that opens is the backend commit uses.
+func OpenKeyring(serviceName string) (keyring.Keyring, Result) {
+	const name = "Keyring"
+
+	var errs []error
+	for _, backend := range keyring.AvailableBackends() {
+		ring, err := keyring.Open(keyring.Config{
+			ServiceName:     serviceName,
+			AllowedBackends: []keyring.BackendType{backend},
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", backend, err))
+			continue
+		}
+		// Some backends open lazily and only fail once they are read.
+		if _, err := ring.Keys(); err != nil {
+			return ring, fail(name, fmt.Sprintf("The %s backend opened but cannot be read: %v", backend, err), keyringFix)
+		}
+		return ring, pass(name, fmt.Sprintf("Using the %s backend", backend))
+	}
+
+	if len(errs) == 0 {
+		return nil, fail(name, "No keyring backend is available on this system", keyringFix)
+	}
+	return nil, fail(name, errors.Join(errs...).Error(), keyringFix)
+}
+
+const keyringFix = "Unlock or install an OS credential store (Keychain, Windows Credential Manager, Secret Service or pass)."
+
+// ReadJSON decodes the JSON file at path into v. It reports whether the file
+// exists; an empty file leaves v untouched. When strict is set, fields v does
+// not know are an error.
+func ReadJSON(path string, v any, strict bool) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return true, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return true, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(v); err != nil {
+		return true, err
+	}
+	if decoder.More() {
+		return true, errors.New("unexpected data after the JSON document")
+	}
+	return true, nil
+}
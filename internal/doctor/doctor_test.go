@@ -0,0 +1,222 @@
+package doctor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+func TestCheckProviderAuthenticates(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		provider types.LLMProvider
+		path     string
+		header   string
+		want     string
+	}{
+		{types.ProviderOpenAI, "/models", "Authorization", "Bearer secret"},
+		{types.ProviderGroq, "/models", "Authorization", "Bearer secret"},
+		{types.ProviderClaude, "/models", "X-Api-Key", "secret"},
+		{types.ProviderGemini, "/models", "X-Goog-Api-Key", "secret"},
+		{types.ProviderOllama, "/api/tags", "", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.provider.String(), func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet || r.URL.Path != tc.path {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				if tc.header != "" && r.Header.Get(tc.header) != tc.want {
+					t.Errorf("%s = %q, want %q", tc.header, r.Header.Get(tc.header), tc.want)
+				}
+				w.Write([]byte(`{"data":[]}`))
+			}))
+			defer server.Close()
+
+			result := CheckProvider(context.Background(), server.Client(), ProviderProbe{
+				Provider:   tc.provider,
+				Credential: "secret",
+				Endpoint:   server.URL,
+			})
+			if result.Status != StatusPass {
+				t.Fatalf("expected pass, got %s: %s", result.Status, result.Detail)
+			}
+			if !strings.Contains(result.Detail, " in ") {
+				t.Fatalf("expected the latency in %q", result.Detail)
+			}
+		})
+	}
+}
+
+func TestCheckProviderGradesFailures(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		status int
+		want   Status
+	}{
+		{http.StatusUnauthorized, StatusFail},
+		{http.StatusTooManyRequests, StatusWarn},
+		{http.StatusBadGateway, StatusWarn},
+		{http.StatusNotFound, StatusFail},
+	}
+
+	for _, tc := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tc.status)
+		}))
+
+		result := CheckProvider(context.Background(), server.Client(), ProviderProbe{
+			Provider:   types.ProviderGrok,
+			Credential: "secret",
+			Endpoint:   server.URL,
+		})
+		server.Close()
+
+		if result.Status != tc.want || result.Fix == "" {
+			t.Fatalf("status %d: got %s with fix %q, want %s", tc.status, result.Status, result.Fix, tc.want)
+		}
+	}
+}
+
+func TestNewProbeRequest(t *testing.T) {
+	req, err := NewProbeRequest(context.Background(), ProviderProbe{
+		Provider:   types.ProviderOllama,
+		Credential: "http://gpu-box:11434/api/generate",
+	})
+	if err != nil {
+		t.Fatalf("NewProbeRequest returned error: %v", err)
+	}
+	if got := req.URL.String(); got != "http://gpu-box:11434/api/tags" {
+		t.Fatalf("unexpected Ollama probe URL %q", got)
+	}
+
+	req, err = NewProbeRequest(context.Background(), ProviderProbe{
+		Provider: types.ProviderOpenAICompatible,
+		Settings: types.ProviderSettings{BaseURL: "http://localhost:8000/v1/", Headers: map[string]string{"X-Team": "core"}},
+	})
+	if err != nil {
+		t.Fatalf("NewProbeRequest returned error: %v", err)
+	}
+	if req.URL.String() != "http://localhost:8000/v1/models" || req.Header.Get("X-Team") != "core" {
+		t.Fatalf("unexpected compatible probe: %s %v", req.URL, req.Header)
+	}
+	if req.Header.Get("Authorization") != "" {
+		t.Fatal("expected no Authorization header without an API key")
+	}
+
+	req, err = NewProbeRequest(context.Background(), ProviderProbe{
+		Provider:   types.ProviderAzureOpenAI,
+		Credential: "secret",
+		Settings:   types.ProviderSettings{BaseURL: "https://r.openai.azure.com", Azure: &types.AzureSettings{APIVersion: "2024-06-01"}},
+	})
+	if err != nil {
+		t.Fatalf("NewProbeRequest returned error: %v", err)
+	}
+	if req.URL.String() != "https://r.openai.azure.com/openai/models?api-version=2024-06-01" || req.Header.Get("api-key") != "secret" {
+		t.Fatalf("unexpected Azure probe: %s %v", req.URL, req.Header)
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	req, err = NewProbeRequest(context.Background(), ProviderProbe{
+		Provider: types.ProviderBedrock,
+		Settings: types.ProviderSettings{Bedrock: &types.BedrockSettings{Region: "eu-west-1"}},
+	})
+	if err != nil {
+		t.Fatalf("NewProbeRequest returned error: %v", err)
+	}
+	if req.URL.String() != "https://bedrock.eu-west-1.amazonaws.com/foundation-models" ||
+		!strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		t.Fatalf("unexpected Bedrock probe: %s %v", req.URL, req.Header)
+	}
+
+	req, err = NewProbeRequest(context.Background(), ProviderProbe{
+		Provider: types.ProviderVertexAI,
+		Endpoint: "http://localhost:8085",
+		Settings: types.ProviderSettings{Vertex: &types.VertexSettings{Project: "p", Auth: "none"}},
+	})
+	if err != nil {
+		t.Fatalf("NewProbeRequest returned error: %v", err)
+	}
+	if req.URL.String() != "http://localhost:8085/v1/projects/p/locations/us-central1/endpoints?pageSize=1" {
+		t.Fatalf("unexpected Vertex AI probe URL %q", req.URL)
+	}
+
+	t.Setenv("OPENAI_API_KEY", "")
+	if _, err := NewProbeRequest(context.Background(), ProviderProbe{Provider: types.ProviderOpenAI}); err == nil {
+		t.Fatal("expected an error without an API key")
+	}
+}
+
+func TestCheckRepository(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git executable not available")
+	}
+
+	if result := CheckRepository(context.Background(), t.TempDir()); result.Status != StatusWarn {
+		t.Fatalf("expected a warning outside a repository, got %s", result.Status)
+	}
+
+	dir := t.TempDir()
+	if output, err := exec.Command("git", "init", "-b", "main", dir).CombinedOutput(); err != nil {
+		t.Fatalf("failed to init git repo: %v: %s", err, output)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result := CheckRepository(context.Background(), dir)
+	if result.Status != StatusPass || result.Detail != "On branch main, 1 changed file(s)" {
+		t.Fatalf("unexpected result: %s %q", result.Status, result.Detail)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".git", "MERGE_HEAD"), []byte("0000000000000000000000000000000000000000\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result = CheckRepository(context.Background(), dir)
+	if result.Status != StatusWarn || !strings.Contains(result.Detail, "merge") {
+		t.Fatalf("expected an unfinished merge warning, got %s %q", result.Status, result.Detail)
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	var v struct {
+		Name string `json:"name"`
+	}
+
+	if exists, err := ReadJSON(filepath.Join(dir, "missing.json"), &v, true); exists || err != nil {
+		t.Fatalf("missing file: exists=%v err=%v", exists, err)
+	}
+
+	path := filepath.Join(dir, "extra.json")
+	os.WriteFile(path, []byte(`{"name":"a","extra":1}`), 0o600)
+	if _, err := ReadJSON(path, &v, false); err != nil || v.Name != "a" {
+		t.Fatalf("lenient read failed: %v", err)
+	}
+	if _, err := ReadJSON(path, &v, true); err == nil {
+		t.Fatal("expected the unknown field to fail a strict read")
+	}
+
+	path = filepath.Join(dir, "corrupt.json")
+	os.WriteFile(path, []byte(`{"name":`), 0o600)
+	if exists, err := ReadJSON(path, &v, false); !exists || err == nil {
+		t.Fatalf("corrupt file: exists=%v err=%v", exists, err)
+	}
+}
@@ -0,0 +1,255 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dfanso/commit-msg/internal/azure"
+	"github.com/dfanso/commit-msg/internal/bedrock"
+	"github.com/dfanso/commit-msg/internal/vertex"
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+// defaultBaseURLs are the API roots the model-list probes are sent to.
+var defaultBaseURLs = map[types.LLMProvider]string{
+	types.ProviderOpenAI: "https://api.openai.com/v1",
+	types.ProviderClaude: "https://api.anthropic.com/v1",
+	types.ProviderGemini: "https://generativelanguage.googleapis.com/v1beta",
+	types.ProviderGrok:   "https://api.x.ai/v1",
+	types.ProviderGroq:   "https://api.groq.com/openai/v1",
+	types.ProviderOllama: "http://localhost:11434",
+}
+
+// credentialEnvVars are the variables the providers read when no credential
+// is stored in the keyring.
+var credentialEnvVars = map[types.LLMProvider]string{
+	types.ProviderOpenAI:           "OPENAI_API_KEY",
+	types.ProviderClaude:           "CLAUDE_API_KEY",
+	types.ProviderGemini:           "GEMINI_API_KEY",
+	types.ProviderGrok:             "GROK_API_KEY",
+	types.ProviderGroq:             "GROQ_API_KEY",
+	types.ProviderOllama:           "OLLAMA_URL",
+	types.ProviderOpenAICompatible: "OPENAI_COMPATIBLE_API_KEY",
+	types.ProviderAzureOpenAI:      "AZURE_OPENAI_API_KEY",
+}
+
+const anthropicAPIVersion = "2023-06-01"
+
+// ProviderProbe describes the cheap authenticated request used to check that
+// a provider is reachable and accepts its credential. Every provider is asked
+// for its model list, which costs no tokens.
+type ProviderProbe struct {
+	Provider types.LLMProvider
+	// Credential is the API key, or the server URL for Ollama. The
+	// provider's environment variable is used when it is empty.
+	Credential string
+	Settings   types.ProviderSettings
+	// Endpoint replaces the provider's API root, e.g. to point every probe
+	// at a local stub.
+	Endpoint string
+}
+
+// NewProbeRequest builds the request sent by CheckProvider.
+func NewProbeRequest(ctx context.Context, probe ProviderProbe) (*http.Request, error) {
+	credential := strings.TrimSpace(probe.Credential)
+	if credential == "" {
+		credential = strings.TrimSpace(os.Getenv(credentialEnvVars[probe.Provider]))
+	}
+
+	base := strings.TrimSpace(probe.Endpoint)
+	path := "/models"
+	switch probe.Provider {
+	case types.ProviderOllama:
+		path = "/api/tags"
+		if base == "" && credential != "" {
+			// The stored credential is the generate URL; keep its host.
+			parsed, err := neturl.Parse(credential)
+			if err != nil || parsed.Host == "" {
+				return nil, fmt.Errorf("invalid Ollama URL %q", credential)
+			}
+			base = parsed.Scheme + "://" + parsed.Host
+		}
+		credential = ""
+	case types.ProviderOpenAICompatible:
+		if base == "" {
+			base = strings.TrimSpace(probe.Settings.BaseURL)
+		}
+		if base == "" {
+			base = strings.TrimSpace(os.Getenv("OPENAI_COMPATIBLE_BASE_URL"))
+		}
+		if base == "" {
+			return nil, errors.New("no base URL is configured")
+		}
+	case types.ProviderBedrock:
+		return newBedrockProbeRequest(ctx, probe)
+	case types.ProviderVertexAI:
+		return newVertexProbeRequest(ctx, probe)
+	case types.ProviderAzureOpenAI:
+		if base == "" {
+			base = strings.TrimSpace(probe.Settings.BaseURL)
+		}
+		if base == "" {
+			base = strings.TrimSpace(os.Getenv("AZURE_OPENAI_ENDPOINT"))
+		}
+		if base == "" {
+			return nil, errors.New("no resource endpoint is configured")
+		}
+		if credential == "" {
+			return nil, errors.New("no API key or token is stored")
+		}
+		version := azure.DefaultAPIVersion
+		if probe.Settings.Azure != nil && probe.Settings.Azure.APIVersion != "" {
+			version = probe.Settings.Azure.APIVersion
+		}
+		path = "/openai/models?" + neturl.Values{"api-version": {version}}.Encode()
+	default:
+		if credential == "" {
+			return nil, errors.New("no API key is stored")
+		}
+	}
+	if base == "" {
+		base = defaultBaseURLs[probe.Provider]
+	}
+	if base == "" {
+		return nil, fmt.Errorf("unsupported provider %q", probe.Provider)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(base, "/")+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	switch probe.Provider {
+	case types.ProviderClaude:
+		req.Header.Set("x-api-key", credential)
+		req.Header.Set("anthropic-version", anthropicAPIVersion)
+	case types.ProviderGemini:
+		req.Header.Set("x-goog-api-key", credential)
+	case types.ProviderAzureOpenAI:
+		if probe.Settings.Azure != nil && probe.Settings.Azure.Auth == azure.AuthBearer {
+			req.Header.Set("Authorization", "Bearer "+credential)
+		} else {
+			req.Header.Set("api-key", credential)
+		}
+	case types.ProviderOllama:
+	default:
+		if credential != "" {
+			req.Header.Set("Authorization", "Bearer "+credential)
+		}
+	}
+	for key, value := range probe.Settings.Headers {
+		req.Header.Set(key, value)
+	}
+	return req, nil
+}
+
+// newBedrockProbeRequest lists the foundation models of the configured
+// region, signed with the AWS credentials the provider would use.
+func newBedrockProbeRequest(ctx context.Context, probe ProviderProbe) (*http.Request, error) {
+	var profile, region string
+	if probe.Settings.Bedrock != nil {
+		profile = probe.Settings.Bedrock.Profile
+		region = probe.Settings.Bedrock.Region
+	}
+	region = bedrock.ResolveRegion(region, profile)
+
+	creds, err := bedrock.LoadCredentials(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	base := strings.TrimSpace(probe.Endpoint)
+	if base == "" {
+		base = bedrock.ControlPlaneURL(region)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(base, "/")+"/foundation-models", nil)
+	if err != nil {
+		return nil, err
+	}
+	bedrock.Sign(req, nil, creds, region, bedrock.SigningService, time.Now())
+	return req, nil
+}
+
+// newVertexProbeRequest lists the Vertex AI endpoints of the configured
+// project and location with a freshly minted access token.
+func newVertexProbeRequest(ctx context.Context, probe ProviderProbe) (*http.Request, error) {
+	var settings types.VertexSettings
+	if probe.Settings.Vertex != nil {
+		settings = *probe.Settings.Vertex
+	}
+
+	source, credentialProject, err := vertex.NewTokenSource(ctx, settings.Auth, probe.Credential)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := vertex.Endpoint{
+		Project:  settings.Project,
+		Location: settings.Location,
+		URL:      strings.TrimSpace(probe.Endpoint),
+	}
+	for _, fallback := range []string{os.Getenv("GOOGLE_CLOUD_PROJECT"), credentialProject} {
+		if endpoint.Project == "" {
+			endpoint.Project = strings.TrimSpace(fallback)
+		}
+	}
+	if endpoint.Project == "" {
+		return nil, errors.New("no Google Cloud project is configured")
+	}
+	if endpoint.Location == "" {
+		endpoint.Location = vertex.DefaultLocation
+	}
+	if endpoint.URL == "" {
+		endpoint.URL = probe.Settings.BaseURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, vertex.LocationURL(endpoint)+"/endpoints?pageSize=1", nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := vertex.Authorize(req, source); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// CheckProvider sends the probe and grades the answer, reporting how long
+// the provider took to respond.
+func CheckProvider(ctx context.Context, client *http.Client, probe ProviderProbe) Result {
+	name := "Provider " + probe.Provider.String()
+
+	req, err := NewProbeRequest(ctx, probe)
+	if err != nil {
+		return fail(name, err.Error(), fmt.Sprintf("Run 'commit llm update' to configure %s.", probe.Provider.String()))
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	latency := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		return fail(name, fmt.Sprintf("%s is unreachable: %v", req.URL.Host, err), "Check your network connection, proxy settings and the provider URL.")
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return pass(name, fmt.Sprintf("Authenticated with %s in %s", req.URL.Host, latency))
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fail(name, fmt.Sprintf("Credential rejected with %s after %s", resp.Status, latency),
+			fmt.Sprintf("Run 'commit llm update' to replace the %s API key.", probe.Provider.String()))
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return warn(name, fmt.Sprintf("Rate limited after %s", latency), "Wait a moment, or check the quota of your account.")
+	case resp.StatusCode >= 500:
+		return warn(name, fmt.Sprintf("Server answered %s after %s", resp.Status, latency), "The provider may be having an outage; try again later.")
+	default:
+		return fail(name, fmt.Sprintf("Unexpected %s from %s after %s", resp.Status, req.URL.Host, latency), "Check the provider URL in your configuration.")
+	}
+}
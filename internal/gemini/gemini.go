@@ -2,50 +2,275 @@ package gemini
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
 	"github.com/dfanso/commit-msg/pkg/types"
 )
 
+// DefaultModel is used when no model has been configured for Gemini.
+const DefaultModel = "gemini-2.0-flash"
+
 const (
-	geminiModel       = "gemini-2.0-flash"
-	geminiTemperature = 0.2
+	geminiTemperature    = 0.2
+	geminiModelPrefix    = "models/"
+	geminiGenerateMethod = "generateContent"
+	geminiJSONMIMEType   = "application/json"
 )
 
+// newClient returns a genai client that sends its requests through the
+// shared generation client, so they are retried and can be recorded like
+// those of the other providers. A custom HTTP client bypasses genai's own API key
+// handling, so the key is added as a header; genai still uses the option
+// for its cache client, which does not take an HTTP client.
+func newClient(ctx context.Context, apiKey string) (*genai.Client, error) {
+	shared := internalHTTP.GetGenerationClient()
+	client := &http.Client{
+		Timeout:   shared.Timeout,
+		Transport: &apiKeyTransport{key: apiKey, base: shared.Transport},
+	}
+	return genai.NewClient(ctx, option.WithAPIKey(apiKey), option.WithHTTPClient(client))
+}
+
+// applySampling sets the sampling settings on model, with a low default
+// temperature for focused answers. The SDK takes no seed.
+func applySampling(model *genai.GenerativeModel, sampling types.Sampling) {
+	model.SetTemperature(float32(sampling.TemperatureOr(geminiTemperature)))
+	if sampling.MaxTokens > 0 {
+		model.SetMaxOutputTokens(int32(sampling.MaxTokens))
+	}
+	if sampling.TopP != nil {
+		model.SetTopP(float32(*sampling.TopP))
+	}
+}
+
+// apiKeyTransport authenticates requests with a Gemini API key.
+type apiKeyTransport struct {
+	key  string
+	base http.RoundTripper
+}
+
+func (t *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("x-goog-api-key", t.key)
+	return t.base.RoundTrip(req)
+}
+
 // GenerateCommitMessage asks Google Gemini to author a commit message for the
 // supplied repository changes and optional style instructions.
-func GenerateCommitMessage(config *types.Config, changes string, apiKey string, opts *types.GenerationOptions) (string, error) {
+func GenerateCommitMessage(ctx context.Context, config *types.Config, changes string, apiKey string, modelName string, opts *types.GenerationOptions) (types.GenerationResult, error) {
 	// Prepare request to Gemini API
-	prompt := types.BuildCommitPrompt(changes, opts)
+	prompt := types.BuildPrompt(changes, opts)
 
-	// Create context and client
-	ctx := context.Background()
-	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	// Create client
+	client, err := newClient(ctx, apiKey)
 	if err != nil {
-		return "", err
+		return types.GenerationResult{}, err
 	}
 	defer client.Close()
 
 	// Create a GenerativeModel with appropriate settings
-	model := client.GenerativeModel(geminiModel)
-	model.SetTemperature(geminiTemperature) // Lower temperature for more focused responses
+	if modelName == "" {
+		modelName = DefaultModel
+	}
+	model := client.GenerativeModel(modelName)
+	applySampling(model, types.SamplingOf(opts))
 
 	// Generate content using the prompt
-	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	resp, err := model.GenerateContent(ctx, usePrompt(model, prompt))
 	if err != nil {
-		return "", err
+		return types.GenerationResult{}, err
 	}
 
 	// Check if we got a valid response
 	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("no response generated")
+		return types.GenerationResult{}, fmt.Errorf("no response generated")
 	}
 
 	// Extract the commit message from the response
 	commitMsg := fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0])
 
-	return commitMsg, nil
+	return types.GenerationResult{Message: commitMsg, Usage: usageInfo(resp.UsageMetadata)}, nil
+}
+
+// GenerateCandidates asks Gemini for n alternative commit messages in a
+// single request through the candidate count. The usage of the request is
+// reported on the first result.
+func GenerateCandidates(ctx context.Context, config *types.Config, changes string, apiKey string, modelName string, opts *types.GenerationOptions, n int) ([]types.GenerationResult, error) {
+	prompt := types.BuildPrompt(changes, opts)
+
+	client, err := newClient(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	if modelName == "" {
+		modelName = DefaultModel
+	}
+	model := client.GenerativeModel(modelName)
+	applySampling(model, types.SamplingOf(opts))
+	model.SetCandidateCount(int32(n))
+
+	resp, err := model.GenerateContent(ctx, usePrompt(model, prompt))
+	if err != nil {
+		return nil, err
+	}
+
+	var results []types.GenerationResult
+	for _, candidate := range resp.Candidates {
+		if text := candidateText(candidate); text != "" {
+			results = append(results, types.GenerationResult{Message: text})
+		}
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no response generated")
+	}
+
+	results[0].Usage = usageInfo(resp.UsageMetadata)
+	return results, nil
+}
+
+// GenerateStructuredCommitMessage asks Gemini for a JSON commit message using
+// its response schema support and decodes the answer.
+func GenerateStructuredCommitMessage(ctx context.Context, config *types.Config, changes string, apiKey string, modelName string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	prompt := types.BuildStructuredPrompt(changes, opts)
+
+	client, err := newClient(ctx, apiKey)
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
+	defer client.Close()
+
+	if modelName == "" {
+		modelName = DefaultModel
+	}
+	model := client.GenerativeModel(modelName)
+	applySampling(model, types.SamplingOf(opts))
+	model.ResponseMIMEType = geminiJSONMIMEType
+	model.ResponseSchema = commitMessageSchema()
+
+	resp, err := model.GenerateContent(ctx, usePrompt(model, prompt))
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
+
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		return types.GenerationResult{}, fmt.Errorf("no response generated")
+	}
+
+	commit, err := types.DecodeCommitMessage([]byte(candidateText(resp.Candidates[0])))
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
+
+	return types.GenerationResult{
+		Message: commit.String(),
+		Commit:  &commit,
+		Usage:   usageInfo(resp.UsageMetadata),
+	}, nil
+}
+
+// usePrompt sets prompt's system message as the model's SystemInstruction and
+// returns the user content to send. genai only sends earlier turns through a
+// ChatSession, which forces a single candidate, so the few-shot examples are
+// written into the instruction instead.
+func usePrompt(model *genai.GenerativeModel, prompt types.Prompt) genai.Text {
+	var system strings.Builder
+	system.WriteString(prompt.System)
+	for _, example := range prompt.Examples {
+		system.WriteString("\n\nExample request:\n")
+		system.WriteString(example.User)
+		system.WriteString("\n\nExample answer:\n")
+		system.WriteString(example.Assistant)
+	}
+	model.SystemInstruction = genai.NewUserContent(genai.Text(system.String()))
+	return genai.Text(prompt.User)
+}
+
+// candidateText joins the text parts of a candidate.
+func candidateText(candidate *genai.Candidate) string {
+	if candidate == nil || candidate.Content == nil {
+		return ""
+	}
+
+	var text strings.Builder
+	for _, part := range candidate.Content.Parts {
+		if t, ok := part.(genai.Text); ok {
+			text.WriteString(string(t))
+		}
+	}
+	return text.String()
+}
+
+// usageInfo converts Gemini's usage metadata, which may be absent.
+func usageInfo(metadata *genai.UsageMetadata) *types.UsageInfo {
+	if metadata == nil {
+		return nil
+	}
+	return types.NewUsageInfo(int(metadata.PromptTokenCount), int(metadata.CandidatesTokenCount)).
+		WithCachedPrompt(int(metadata.CachedContentTokenCount))
+}
+
+// commitMessageSchema mirrors types.CommitMessageSchema in Gemini's schema
+// dialect, which has no additionalProperties keyword.
+func commitMessageSchema() *genai.Schema {
+	return &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"type":     {Type: genai.TypeString, Description: "Conventional commit type: " + strings.Join(types.ConventionalCommitTypes, ", ")},
+			"scope":    {Type: genai.TypeString, Description: "Optional area of the code base affected, or an empty string"},
+			"subject":  {Type: genai.TypeString, Description: "Imperative summary without trailing period"},
+			"body":     {Type: genai.TypeString, Description: "Optional explanation of what changed and why, or an empty string"},
+			"breaking": {Type: genai.TypeBoolean, Description: "True when the change is not backwards compatible"},
+			"trailers": {
+				Type:        genai.TypeArray,
+				Description: "Optional git trailers such as Refs or BREAKING CHANGE",
+				Items: &genai.Schema{
+					Type: genai.TypeObject,
+					Properties: map[string]*genai.Schema{
+						"key":   {Type: genai.TypeString},
+						"value": {Type: genai.TypeString},
+					},
+					Required: []string{"key", "value"},
+				},
+			},
+		},
+		Required: []string{"type", "subject"},
+	}
+}
+
+// ListModels returns the Gemini models that support content generation.
+func ListModels(ctx context.Context, apiKey string) ([]string, error) {
+	client, err := newClient(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	var models []string
+	iter := client.ListModels(ctx)
+	for {
+		info, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(info.SupportedGenerationMethods, geminiGenerateMethod) {
+			continue
+		}
+		models = append(models, strings.TrimPrefix(info.Name, geminiModelPrefix))
+	}
+
+	return models, nil
 }
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -123,15 +124,58 @@ func extractNonBinaryFiles(nameStatusOutput string) []string {
 	return nonBinaryFiles
 }
 
-// GetChanges retrieves all Git changes including staged, unstaged, and untracked files
+// HasStagedChanges reports whether the index differs from HEAD.
+func HasStagedChanges(path string) (bool, error) {
+	cmd := exec.Command("git", "-C", path, "diff", "--cached", "--quiet")
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return false, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return true, nil
+	default:
+		return false, fmt.Errorf("git diff --cached failed: %v", err)
+	}
+}
+
+// ResolveScope returns the scope of config. ScopeAuto describes the index
+// when anything is staged and otherwise every change, untracked files
+// included.
+func ResolveScope(config *types.RepoConfig) (types.ChangeScope, error) {
+	if config.Scope != types.ScopeAuto {
+		return config.Scope, nil
+	}
+
+	staged, err := HasStagedChanges(config.Path)
+	if err != nil {
+		return "", err
+	}
+	if staged {
+		return types.ScopeStaged, nil
+	}
+	return types.ScopeUntracked, nil
+}
+
+// GetChanges retrieves the Git changes in the scope of config: staged
+// changes, unstaged changes of tracked files and untracked files.
 func GetChanges(config *types.RepoConfig) (string, error) {
 	var changes strings.Builder
 
-	// 1. Check for unstaged changes
-	cmd := exec.Command("git", "-C", config.Path, "diff", "--name-status")
-	output, err := cmd.Output()
+	scope, err := ResolveScope(config)
 	if err != nil {
-		return "", fmt.Errorf("git diff failed: %v", err)
+		return "", err
+	}
+
+	// 1. Check for unstaged changes
+	var output []byte
+	if scope.IncludesUnstaged() {
+		cmd := exec.Command("git", "-C", config.Path, "diff", "--name-status")
+		output, err = cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("git diff failed: %v", err)
+		}
 	}
 
 	if len(output) > 0 {
@@ -194,10 +238,13 @@ func GetChanges(config *types.RepoConfig) (string, error) {
 	}
 
 	// 3. Check for untracked files
-	untrackedCmd := exec.Command("git", "-C", config.Path, "ls-files", "--others", "--exclude-standard")
-	untrackedOutput, err := untrackedCmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("git ls-files failed: %v", err)
+	var untrackedOutput []byte
+	if scope.IncludesUntracked() {
+		untrackedCmd := exec.Command("git", "-C", config.Path, "ls-files", "--others", "--exclude-standard")
+		untrackedOutput, err = untrackedCmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("git ls-files failed: %v", err)
+		}
 	}
 
 	if len(untrackedOutput) > 0 {
@@ -89,7 +89,7 @@ func TestGetChangesIncludesSections(t *testing.T) {
 		t.Fatalf("failed to write untracked file: %v", err)
 	}
 
-	output, err := GetChanges(&types.RepoConfig{Path: dir})
+	output, err := GetChanges(&types.RepoConfig{Path: dir, Scope: types.ScopeUntracked})
 	if err != nil {
 		t.Fatalf("GetChanges returned error: %v", err)
 	}
@@ -110,6 +110,71 @@ func TestGetChangesIncludesSections(t *testing.T) {
 	}
 }
 
+func TestGetChangesFollowsScope(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration-style test in short mode")
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git executable not available")
+	}
+
+	dir := t.TempDir()
+
+	runGit(t, dir, "init")
+	runGit(t, dir, "config", "user.name", "Test User")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+
+	tracked := filepath.Join(dir, "tracked.txt")
+	if err := os.WriteFile(tracked, []byte("first version\n"), 0o644); err != nil {
+		t.Fatalf("failed to write tracked file: %v", err)
+	}
+	runGit(t, dir, "add", "tracked.txt")
+	runGit(t, dir, "commit", "-m", "initial commit")
+
+	if err := os.WriteFile(tracked, []byte("unstaged edit\n"), 0o644); err != nil {
+		t.Fatalf("failed to modify tracked file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "new.txt"), []byte("brand new file\n"), 0o644); err != nil {
+		t.Fatalf("failed to write untracked file: %v", err)
+	}
+
+	// Nothing is staged, so every change is described.
+	if scope, err := ResolveScope(&types.RepoConfig{Path: dir}); err != nil || scope != types.ScopeUntracked {
+		t.Fatalf("ResolveScope = %q, %v; want %q", scope, err, types.ScopeUntracked)
+	}
+	output, err := GetChanges(&types.RepoConfig{Path: dir})
+	if err != nil {
+		t.Fatalf("GetChanges returned error: %v", err)
+	}
+	if !strings.Contains(output, "unstaged edit") || !strings.Contains(output, "brand new file") {
+		t.Fatalf("expected the tracked and untracked changes, got: %s", output)
+	}
+
+	output, err = GetChanges(&types.RepoConfig{Path: dir, Scope: types.ScopeAll})
+	if err != nil {
+		t.Fatalf("GetChanges returned error: %v", err)
+	}
+	if !strings.Contains(output, "unstaged edit") || strings.Contains(output, "brand new file") {
+		t.Fatalf("expected only the tracked changes, got: %s", output)
+	}
+
+	staged := filepath.Join(dir, "staged.txt")
+	if err := os.WriteFile(staged, []byte("staged content\n"), 0o644); err != nil {
+		t.Fatalf("failed to write staged file: %v", err)
+	}
+	runGit(t, dir, "add", "staged.txt")
+
+	// Once something is staged, only the index is described.
+	output, err = GetChanges(&types.RepoConfig{Path: dir})
+	if err != nil {
+		t.Fatalf("GetChanges returned error: %v", err)
+	}
+	if !strings.Contains(output, "staged content") || strings.Contains(output, "unstaged edit") || strings.Contains(output, "Unstaged changes:") {
+		t.Fatalf("expected only the staged changes, got: %s", output)
+	}
+}
+
 func TestGetChangesErrorsOutsideRepo(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,398 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dfanso/commit-msg/internal/scrubber"
+)
+
+// ResolveCommit returns the full hash of the commit rev names.
+func ResolveCommit(path, rev string) (string, error) {
+	cmd := exec.Command("git", "-C", path, "rev-parse", "--verify", "--quiet", "--end-of-options", rev+"^{commit}")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%q does not name a commit", rev)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// CommitMessage returns the message of commit.
+func CommitMessage(path, commit string) (string, error) {
+	object, err := readCommit(path, commit)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(object.message), nil
+}
+
+// GetCommitChanges retrieves the changes commit made, in the form GetChanges
+// returns them for uncommitted changes.
+func GetCommitChanges(path, commit string) (string, error) {
+	var changes strings.Builder
+
+	stat, err := exec.Command("git", "-C", path, "show", "--no-color", "--format=", "--name-status", commit).Output()
+	if err != nil {
+		return "", fmt.Errorf("git show failed: %v", err)
+	}
+	if filtered := filterBinaryFiles(string(stat)); filtered != "" {
+		changes.WriteString("Committed changes:\n")
+		changes.WriteString(filtered)
+		changes.WriteString("\n\n")
+	}
+
+	diff, err := exec.Command("git", "-C", path, "show", "--no-color", "--format=", "--patch", commit).Output()
+	if err != nil {
+		return "", fmt.Errorf("git show failed: %v", err)
+	}
+	if len(diff) > 0 {
+		changes.WriteString("Committed diff:\n")
+		changes.Write(diff)
+		changes.WriteString("\n\n")
+	}
+
+	return scrubber.ScrubDiff(changes.String()), nil
+}
+
+// Reword replaces the message of commit, which must be HEAD or one of its
+// ancestors. HEAD is amended; for an older commit it and the commits after
+// it are recreated with their trees, authors, messages and message
+// encodings, and the branch is moved to the new HEAD. Every rewritten
+// commit keeps its committer's name and email, with the current date. The
+// work tree and the index are not touched.
+//
+// With verify, the commit-msg hook checks the message first, as in a
+// rebase reword, and may edit it. Recreated commits lose their GPG or SSH
+// signatures and the signed tags of merges; SignedCommits lists the
+// commits affected.
+func Reword(path, commit, message string, verify bool) error {
+	head, err := ResolveCommit(path, "HEAD")
+	if err != nil {
+		return errors.New("the repository has no commits")
+	}
+
+	message = strings.TrimSpace(message) + "\n"
+	if verify {
+		if message, err = runCommitMsgHook(path, message); err != nil {
+			return err
+		}
+	}
+
+	if commit == head {
+		object, err := readCommit(path, head)
+		if err != nil {
+			return err
+		}
+		// The hook has already run; --no-verify keeps it from running twice
+		// and skips pre-commit, which a reword does not run either.
+		cmd := exec.Command("git", "-C", path, "commit", "--amend", "--only", "--allow-empty", "--no-verify", "-m", message)
+		cmd.Env = append(os.Environ(), committerEnv(object.committer)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("git commit --amend failed: %v: %s", err, strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+
+	if err := exec.Command("git", "-C", path, "merge-base", "--is-ancestor", commit, head).Run(); err != nil {
+		return fmt.Errorf("%s is not an ancestor of HEAD", shortHash(commit))
+	}
+
+	output, err := exec.Command("git", "-C", path, "rev-list", "--reverse", "--topo-order", "--parents", commit+"..HEAD").Output()
+	if err != nil {
+		return fmt.Errorf("git rev-list failed: %v", err)
+	}
+
+	// Each line holds a commit followed by its parents.
+	var descendants [][]string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			if len(fields) > 2 {
+				return fmt.Errorf("cannot reword %s: merge commit %s follows it", shortHash(commit), shortHash(fields[0]))
+			}
+			descendants = append(descendants, fields)
+		}
+	}
+
+	target, err := readCommit(path, commit)
+	if err != nil {
+		return err
+	}
+	// The new message is UTF-8, whatever the old one was written in.
+	target.encoding = ""
+	rewritten := map[string]string{}
+	if rewritten[commit], err = target.recreate(path, target.parents, message); err != nil {
+		return err
+	}
+
+	newHead := rewritten[commit]
+	for _, fields := range descendants {
+		object, err := readCommit(path, fields[0])
+		if err != nil {
+			return err
+		}
+		parents := make([]string, len(object.parents))
+		for i, parent := range object.parents {
+			parents[i] = parent
+			if replacement, ok := rewritten[parent]; ok {
+				parents[i] = replacement
+			}
+		}
+		if rewritten[fields[0]], err = object.recreate(path, parents, object.message); err != nil {
+			return err
+		}
+		newHead = rewritten[fields[0]]
+	}
+
+	reason := "commit reword: " + shortHash(commit)
+	if output, err := exec.Command("git", "-C", path, "update-ref", "-m", reason, "HEAD", newHead, head).CombinedOutput(); err != nil {
+		return fmt.Errorf("git update-ref failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// SignedCommits returns the signed commits among commit and the commits
+// after it, whose signatures Reword drops when it recreates them. Merges
+// of signed tags count as signed. Rewording
+// HEAD amends it with git commit, which signs the new commit as git is
+// configured to, so no commits are returned for HEAD.
+func SignedCommits(path, commit string) ([]string, error) {
+	head, err := ResolveCommit(path, "HEAD")
+	if err != nil {
+		return nil, errors.New("the repository has no commits")
+	}
+	if commit == head {
+		return nil, nil
+	}
+
+	output, err := exec.Command("git", "-C", path, "rev-list", "--reverse", commit+"..HEAD").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git rev-list failed: %v", err)
+	}
+
+	var signed []string
+	for _, hash := range append([]string{commit}, strings.Fields(string(output))...) {
+		object, err := readCommit(path, hash)
+		if err != nil {
+			return nil, err
+		}
+		if object.signed {
+			signed = append(signed, hash)
+		}
+	}
+	return signed, nil
+}
+
+// hookRunVersion is the first git release with git hook run.
+var hookRunVersion = [2]int{2, 36}
+
+// runCommitMsgHook runs the commit-msg hook of the repository on message and
+// returns the message as the hook left it. A missing hook accepts it as is.
+func runCommitMsgHook(path, message string) (string, error) {
+	file, err := os.CreateTemp("", "commit-msg-reword-*")
+	if err != nil {
+		return "", fmt.Errorf("cannot write the message for the commit-msg hook: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(message)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot write the message for the commit-msg hook: %w", err)
+	}
+
+	cmd, err := commitMsgHookCommand(path, file.Name(), gitAtLeast(hookRunVersion))
+	if err != nil {
+		return "", err
+	}
+	if cmd != nil {
+		cmd.Env = os.Environ()
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("commit-msg hook failed: %v: %s", err, strings.TrimSpace(string(output)))
+		}
+	}
+
+	checked, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", fmt.Errorf("cannot read the message back from the commit-msg hook: %w", err)
+	}
+	return string(checked), nil
+}
+
+// commitMsgHookCommand returns the command that runs the commit-msg hook on
+// messageFile, or nil when the repository has none. With hookRun it leaves
+// finding the hook to git hook run; older git lacks it, so the hook is
+// looked up and run from the top of the work tree as git commit would.
+func commitMsgHookCommand(path, messageFile string, hookRun bool) (*exec.Cmd, error) {
+	if hookRun {
+		return exec.Command("git", "-C", path, "hook", "run", "--ignore-missing", "commit-msg", "--", messageFile), nil
+	}
+
+	output, err := exec.Command("git", "-C", path, "rev-parse", "--git-path", "hooks/commit-msg").Output()
+	if err != nil {
+		return nil, fmt.Errorf("cannot find the commit-msg hook: %v", err)
+	}
+	hook := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(hook) {
+		hook = filepath.Join(path, hook)
+	}
+	// Like git, skip a hook that is missing or not executable.
+	if info, err := os.Stat(hook); err != nil || info.IsDir() || info.Mode()&0o111 == 0 {
+		return nil, nil
+	}
+
+	cmd := exec.Command(hook, messageFile)
+	if top, err := exec.Command("git", "-C", path, "rev-parse", "--show-toplevel").Output(); err == nil {
+		cmd.Dir = strings.TrimSpace(string(top))
+	}
+	return cmd, nil
+}
+
+// gitAtLeast reports whether the installed git is version or newer.
+func gitAtLeast(version [2]int) bool {
+	output, err := exec.Command("git", "version").Output()
+	if err != nil {
+		return false
+	}
+	installed, ok := parseGitVersion(string(output))
+	return ok && (installed[0] > version[0] || installed[0] == version[0] && installed[1] >= version[1])
+}
+
+// parseGitVersion reads the major and minor version from git version
+// output such as "git version 2.39.3 (Apple Git-146)".
+func parseGitVersion(output string) ([2]int, bool) {
+	fields := strings.Fields(output)
+	if len(fields) < 3 {
+		return [2]int{}, false
+	}
+	parts := strings.SplitN(fields[2], ".", 3)
+	if len(parts) < 2 {
+		return [2]int{}, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return [2]int{}, false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return [2]int{}, false
+	}
+	return [2]int{major, minor}, true
+}
+
+// commitObject holds the parts of a commit that are kept when it is
+// recreated.
+type commitObject struct {
+	tree    string
+	parents []string
+	// author and committer are raw identity lines: name, email, timestamp
+	// and zone.
+	author    string
+	committer string
+	// encoding names the encoding of message when it is not UTF-8.
+	encoding string
+	message  string
+	// signed reports a GPG or SSH signature, or a merged signed tag, which
+	// is not kept.
+	signed bool
+}
+
+// readCommit parses the commit object of commit.
+func readCommit(path, commit string) (commitObject, error) {
+	output, err := exec.Command("git", "-C", path, "cat-file", "commit", commit).Output()
+	if err != nil {
+		return commitObject{}, fmt.Errorf("cannot read commit %s: %v", shortHash(commit), err)
+	}
+
+	headers, message, _ := strings.Cut(string(output), "\n\n")
+	object := commitObject{message: message}
+	for _, line := range strings.Split(headers, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "tree":
+			object.tree = value
+		case "parent":
+			object.parents = append(object.parents, value)
+		case "author":
+			object.author = value
+		case "committer":
+			object.committer = value
+		case "encoding":
+			object.encoding = value
+		case "gpgsig", "gpgsig-sha256", "mergetag":
+			object.signed = true
+		}
+	}
+	return object, nil
+}
+
+// recreate writes a commit with the tree, author, committer and encoding
+// of c, parents and message, and returns its hash. The commit date is the
+// current time.
+func (c commitObject) recreate(path string, parents []string, message string) (string, error) {
+	args := []string{"-C", path}
+	if c.encoding != "" {
+		args = append(args, "-c", "i18n.commitEncoding="+c.encoding)
+	}
+	args = append(args, "commit-tree", c.tree)
+	for _, parent := range parents {
+		args = append(args, "-p", parent)
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), authorEnv(c.author)...)
+	cmd.Env = append(cmd.Env, committerEnv(c.committer)...)
+	cmd.Stdin = strings.NewReader(message)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git commit-tree failed: %v", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// authorEnv returns the variables that make git record author, a raw
+// "Name <email> timestamp zone" line.
+func authorEnv(author string) []string {
+	name, email, date, ok := parseIdent(author)
+	if !ok {
+		return nil
+	}
+	return []string{"GIT_AUTHOR_NAME=" + name, "GIT_AUTHOR_EMAIL=" + email, "GIT_AUTHOR_DATE=" + date}
+}
+
+// committerEnv returns the variables that make git record the name and
+// email of committer, a raw identity line, leaving the date to git.
+func committerEnv(committer string) []string {
+	name, email, _, ok := parseIdent(committer)
+	if !ok {
+		return nil
+	}
+	return []string{"GIT_COMMITTER_NAME=" + name, "GIT_COMMITTER_EMAIL=" + email}
+}
+
+// parseIdent splits a raw "Name <email> timestamp zone" identity line.
+func parseIdent(ident string) (name, email, date string, ok bool) {
+	name, rest, ok := strings.Cut(ident, " <")
+	if !ok {
+		return "", "", "", false
+	}
+	email, date, ok = strings.Cut(rest, "> ")
+	if !ok {
+		return "", "", "", false
+	}
+	return name, email, date, true
+}
+
+// shortHash abbreviates a commit hash for messages.
+func shortHash(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
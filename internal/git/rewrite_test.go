@@ -0,0 +1,301 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		t.Fatalf("git %v failed: %v", args, err)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// commitFile writes content to name and commits it with message.
+func commitFile(t *testing.T, dir, name, content, message string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	runGit(t, dir, "add", name)
+	runGit(t, dir, "commit", "-m", message)
+}
+
+func TestRewordRewritesOlderCommits(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration-style test in short mode")
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git executable not available")
+	}
+
+	dir := t.TempDir()
+
+	runGit(t, dir, "init")
+	runGit(t, dir, "config", "user.name", "Test User")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "commit.gpgsign", "false")
+
+	commitFile(t, dir, "a.txt", "a\n", "first")
+	commitFile(t, dir, "b.txt", "b\n", "wip")
+	runGit(t, dir, "-c", "user.name=Someone Else", "-c", "user.email=else@example.com", "-c", "i18n.commitEncoding=ISO-8859-1", "commit", "--allow-empty", "-m", "third\n\nwith a body")
+
+	oldTree := gitOutput(t, dir, "rev-parse", "HEAD^{tree}")
+
+	// Staged changes stay staged and out of the rewritten commits.
+	if err := os.WriteFile(filepath.Join(dir, "c.txt"), []byte("c\n"), 0o644); err != nil {
+		t.Fatalf("failed to write c.txt: %v", err)
+	}
+	runGit(t, dir, "add", "c.txt")
+
+	target, err := ResolveCommit(dir, "HEAD~1")
+	if err != nil {
+		t.Fatalf("ResolveCommit returned error: %v", err)
+	}
+	if message, err := CommitMessage(dir, target); err != nil || message != "wip" {
+		t.Fatalf("CommitMessage = %q, %v; want %q", message, err, "wip")
+	}
+
+	changes, err := GetCommitChanges(dir, target)
+	if err != nil {
+		t.Fatalf("GetCommitChanges returned error: %v", err)
+	}
+	for _, fragment := range []string{"Committed changes:", "A\tb.txt", "+b"} {
+		if !strings.Contains(changes, fragment) {
+			t.Fatalf("commit changes missing fragment %q\noutput: %s", fragment, changes)
+		}
+	}
+
+	if err := Reword(dir, target, "feat: add b\n", true); err != nil {
+		t.Fatalf("Reword returned error: %v", err)
+	}
+
+	if got := gitOutput(t, dir, "log", "--format=%s", "-3"); got != "third\nfeat: add b\nfirst" {
+		t.Fatalf("subjects after reword = %q", got)
+	}
+	if got := gitOutput(t, dir, "log", "-1", "--format=%an|%cn|%e|%B"); got != "Someone Else|Someone Else|ISO-8859-1|third\n\nwith a body" {
+		t.Fatalf("HEAD after reword = %q, want its author, committer, encoding and message kept", got)
+	}
+	if got := gitOutput(t, dir, "rev-parse", "HEAD^{tree}"); got != oldTree {
+		t.Fatalf("tree after reword = %s, want %s", got, oldTree)
+	}
+	if got := gitOutput(t, dir, "diff", "--cached", "--name-only"); got != "c.txt" {
+		t.Fatalf("staged files after reword = %q, want c.txt", got)
+	}
+
+	head, err := ResolveCommit(dir, "HEAD")
+	if err != nil {
+		t.Fatalf("ResolveCommit returned error: %v", err)
+	}
+	if err := Reword(dir, head, "docs: explain third", true); err != nil {
+		t.Fatalf("Reword of HEAD returned error: %v", err)
+	}
+	if got := gitOutput(t, dir, "log", "-1", "--format=%cn|%B"); got != "Someone Else|docs: explain third" {
+		t.Fatalf("HEAD after reword = %q, want its committer kept", got)
+	}
+	if got := gitOutput(t, dir, "diff", "--cached", "--name-only"); got != "c.txt" {
+		t.Fatalf("staged files after amending the message = %q, want c.txt", got)
+	}
+}
+
+func TestRewordRunsCommitMsgHook(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration-style test in short mode")
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git executable not available")
+	}
+
+	dir := t.TempDir()
+
+	runGit(t, dir, "init")
+	runGit(t, dir, "config", "user.name", "Test User")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "commit.gpgsign", "false")
+
+	commitFile(t, dir, "a.txt", "a\n", "first")
+	commitFile(t, dir, "b.txt", "b\n", "second")
+
+	// The hook rejects messages mentioning wip and signs off the others.
+	hook := "#!/bin/sh\nif grep -q wip \"$1\"; then echo 'no wip' >&2; exit 1; fi\necho 'Signed-off-by: Hook <hook@example.com>' >> \"$1\"\n"
+	if err := os.WriteFile(filepath.Join(dir, ".git", "hooks", "commit-msg"), []byte(hook), 0o755); err != nil {
+		t.Fatalf("failed to write the hook: %v", err)
+	}
+
+	target, err := ResolveCommit(dir, "HEAD~1")
+	if err != nil {
+		t.Fatalf("ResolveCommit returned error: %v", err)
+	}
+	head, err := ResolveCommit(dir, "HEAD")
+	if err != nil {
+		t.Fatalf("ResolveCommit returned error: %v", err)
+	}
+
+	if err := Reword(dir, head, "feat: wip", true); err == nil || !strings.Contains(err.Error(), "no wip") {
+		t.Fatalf("expected the hook to reject the message, got %v", err)
+	}
+	if got := gitOutput(t, dir, "rev-parse", "HEAD"); got != head {
+		t.Fatalf("HEAD moved to %s after a rejected message", got)
+	}
+
+	if err := Reword(dir, target, "feat: add a", true); err != nil {
+		t.Fatalf("Reword returned error: %v", err)
+	}
+	if got := gitOutput(t, dir, "log", "-1", "--format=%B", "HEAD~1"); got != "feat: add a\nSigned-off-by: Hook <hook@example.com>" {
+		t.Fatalf("reworded message = %q, want the hook's edit", got)
+	}
+
+	if err := Reword(dir, gitOutput(t, dir, "rev-parse", "HEAD"), "feat: wip on b", false); err != nil {
+		t.Fatalf("Reword without verification returned error: %v", err)
+	}
+	if got := gitOutput(t, dir, "log", "-1", "--format=%B"); got != "feat: wip on b" {
+		t.Fatalf("HEAD message = %q, want the hook skipped", got)
+	}
+}
+
+func TestCommitMsgHookCommandWithoutHookRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration-style test in short mode")
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git executable not available")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init")
+
+	message := filepath.Join(t.TempDir(), "message")
+	if err := os.WriteFile(message, []byte("feat: add a\n"), 0o644); err != nil {
+		t.Fatalf("failed to write the message: %v", err)
+	}
+
+	if cmd, err := commitMsgHookCommand(dir, message, false); err != nil || cmd != nil {
+		t.Fatalf("commitMsgHookCommand without a hook = %v, %v; want nil", cmd, err)
+	}
+
+	// The hook lives under core.hooksPath and runs from the top of the work
+	// tree, even when asked from a subdirectory.
+	if err := os.MkdirAll(filepath.Join(dir, "hooks"), 0o755); err != nil {
+		t.Fatalf("failed to create the hooks directory: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatalf("failed to create a subdirectory: %v", err)
+	}
+	runGit(t, dir, "config", "core.hooksPath", "hooks")
+	hook := "#!/bin/sh\ntest -d hooks && echo 'Signed-off-by: Hook <hook@example.com>' >> \"$1\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "hooks", "commit-msg"), []byte(hook), 0o755); err != nil {
+		t.Fatalf("failed to write the hook: %v", err)
+	}
+
+	cmd, err := commitMsgHookCommand(filepath.Join(dir, "sub"), message, false)
+	if err != nil || cmd == nil {
+		t.Fatalf("commitMsgHookCommand = %v, %v; want the hook", cmd, err)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("hook failed: %v: %s", err, output)
+	}
+	if got, _ := os.ReadFile(message); string(got) != "feat: add a\nSigned-off-by: Hook <hook@example.com>\n" {
+		t.Fatalf("message after the hook = %q", got)
+	}
+}
+
+func TestParseGitVersion(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string][2]int{
+		"git version 2.39.5\n":                {2, 39},
+		"git version 2.39.3 (Apple Git-146)":  {2, 39},
+		"git version 2.41.0.windows.1":        {2, 41},
+		"git version 2.36.0.rc1.10.g1234abcd": {2, 36},
+	}
+	for output, want := range cases {
+		if got, ok := parseGitVersion(output); !ok || got != want {
+			t.Fatalf("parseGitVersion(%q) = %v, %v; want %v", output, got, ok, want)
+		}
+	}
+	if _, ok := parseGitVersion("git version unknown"); ok {
+		t.Fatal("expected an unparsable version to be rejected")
+	}
+}
+
+func TestSignedCommits(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration-style test in short mode")
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git executable not available")
+	}
+
+	dir := t.TempDir()
+
+	runGit(t, dir, "init")
+	runGit(t, dir, "config", "user.name", "Test User")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "commit.gpgsign", "false")
+
+	commitFile(t, dir, "a.txt", "a\n", "first")
+	commitFile(t, dir, "b.txt", "b\n", "second")
+
+	// Fake a signature on HEAD; git only checks it when asked to verify.
+	object := gitOutput(t, dir, "cat-file", "commit", "HEAD")
+	headers, message, _ := strings.Cut(object, "\n\n")
+	signed := headers + "\ngpgsig -----BEGIN PGP SIGNATURE-----\n \n -----END PGP SIGNATURE-----\n\n" + message + "\n"
+	cmd := exec.Command("git", "-C", dir, "hash-object", "-t", "commit", "-w", "--stdin")
+	cmd.Stdin = strings.NewReader(signed)
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("failed to write the signed commit: %v", err)
+	}
+	head := strings.TrimSpace(string(output))
+	runGit(t, dir, "update-ref", "HEAD", head)
+
+	target, err := ResolveCommit(dir, "HEAD~1")
+	if err != nil {
+		t.Fatalf("ResolveCommit returned error: %v", err)
+	}
+	if got, err := SignedCommits(dir, target); err != nil || len(got) != 1 || got[0] != head {
+		t.Fatalf("SignedCommits = %v, %v; want [%s]", got, err, head)
+	}
+	if got, err := SignedCommits(dir, head); err != nil || len(got) != 0 {
+		t.Fatalf("SignedCommits of HEAD = %v, %v; want none, as amending signs as configured", got, err)
+	}
+
+	if err := Reword(dir, target, "feat: add a", false); err != nil {
+		t.Fatalf("Reword returned error: %v", err)
+	}
+	if got, err := SignedCommits(dir, target); err != nil || len(got) != 0 {
+		t.Fatalf("SignedCommits after reword = %v, %v; want the signature dropped", got, err)
+	}
+}
+
+func TestResolveCommitRejectsUnknownRevisions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration-style test in short mode")
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git executable not available")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init")
+
+	if _, err := ResolveCommit(dir, "HEAD"); err == nil {
+		t.Fatal("ResolveCommit(HEAD) in an empty repository succeeded, want an error")
+	}
+	if _, err := ResolveCommit(dir, "--all"); err == nil {
+		t.Fatal("ResolveCommit(--all) succeeded, want an error")
+	}
+}
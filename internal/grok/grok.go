@@ -2,80 +2,197 @@ package grok
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	httpClient "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/internal/openaicompat"
 	"github.com/dfanso/commit-msg/pkg/types"
 )
 
+// DefaultModel is used when no model has been configured for Grok.
+const DefaultModel = "grok-3-mini-fast-beta"
+
 const (
-	grokModel          = "grok-3-mini-fast-beta"
-	grokTemperature    = 0
-	grokAPIEndpoint    = "https://api.x.ai/v1/chat/completions"
-	grokContentType    = "application/json"
-	authorizationPrefix = "Bearer "
+	grokTemperature       = 0
+	grokAPIEndpoint       = "https://api.x.ai/v1/chat/completions"
+	grokContentType       = "application/json"
+	authorizationPrefix   = "Bearer "
+	grokStreamContentType = "text/event-stream"
+	grokStreamDone        = "[DONE]"
 )
 
+// grokStreamChunk is a single server-sent event payload from a streaming completion.
+type grokStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *types.UsageInfo `json:"usage,omitempty"`
+}
+
 // GenerateCommitMessage calls X.AI's Grok API to create a commit message from
 // the provided Git diff and generation options.
-func GenerateCommitMessage(config *types.Config, changes string, apiKey string, opts *types.GenerationOptions) (string, error) {
-	// Prepare request to X.AI (Grok) API
-	prompt := types.BuildCommitPrompt(changes, opts)
+func GenerateCommitMessage(ctx context.Context, config *types.Config, changes string, apiKey string, model string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	req, err := newGrokRequest(ctx, config, changes, apiKey, model, opts, false)
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
 
-	request := types.GrokRequest{
+	client := httpClient.GetGenerationClient()
+	resp, err := client.Do(req)
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
+	defer resp.Body.Close()
 
-		Messages: []types.Message{
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
-		Model:       grokModel,
-		Stream:      false,
-		Temperature: grokTemperature,
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return types.GenerationResult{}, httpClient.NewStatusError(resp.StatusCode, "API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
 	}
 
-	requestBody, err := json.Marshal(request)
-	if err != nil {
-		return "", err
+	// Parse response
+	var grokResponse types.GrokResponse
+	if err := json.NewDecoder(resp.Body).Decode(&grokResponse); err != nil {
+		return types.GenerationResult{}, err
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", grokAPIEndpoint, bytes.NewBuffer(requestBody))
-	if err != nil {
-		return "", err
+	// Check if the response follows the expected structure
+	commitMsg := grokResponse.Message.Content
+	if commitMsg == "" && len(grokResponse.Choices) > 0 {
+		commitMsg = grokResponse.Choices[0].Message.Content
 	}
 
-	// Set headers
-	req.Header.Set("Content-Type", grokContentType)
-	req.Header.Set("Authorization", fmt.Sprintf("%s%s", authorizationPrefix, apiKey))
+	return types.GenerationResult{Message: commitMsg, Usage: reportedUsage(grokResponse.Usage)}, nil
+}
 
-	client := httpClient.GetClient()
+// StreamCommitMessage requests a streamed completion from Grok, forwarding each
+// content delta to onChunk and returning the assembled message when done.
+func StreamCommitMessage(ctx context.Context, config *types.Config, changes string, apiKey string, model string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	req, err := newGrokRequest(ctx, config, changes, apiKey, model, opts, true)
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
+	req.Header.Set("Accept", grokStreamContentType)
+
+	client := httpClient.GetGenerationClient()
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", err
+		return types.GenerationResult{}, err
 	}
 	defer resp.Body.Close()
 
-	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return types.GenerationResult{}, httpClient.NewStatusError(resp.StatusCode, "API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
 	}
 
-	// Parse response
-	var grokResponse types.GrokResponse
-	if err := json.NewDecoder(resp.Body).Decode(&grokResponse); err != nil {
-		return "", err
+	var message strings.Builder
+	var usage *types.UsageInfo
+	err = httpClient.ReadServerSentEvents(resp.Body, func(event httpClient.ServerSentEvent) error {
+		if event.Data == grokStreamDone {
+			return io.EOF
+		}
+
+		var chunk grokStreamChunk
+		if err := json.Unmarshal([]byte(event.Data), &chunk); err != nil {
+			return fmt.Errorf("failed to decode Grok stream chunk: %w", err)
+		}
+
+		if chunk.Usage != nil {
+			usage = reportedUsage(*chunk.Usage)
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			message.WriteString(choice.Delta.Content)
+			if onChunk != nil {
+				onChunk(choice.Delta.Content)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return types.GenerationResult{}, err
 	}
 
-	// Check if the response follows the expected structure
-	if grokResponse.Message.Content == "" && grokResponse.Choices != nil && len(grokResponse.Choices) > 0 {
-		return grokResponse.Choices[0].Message.Content, nil
+	if message.Len() == 0 {
+		return types.GenerationResult{}, fmt.Errorf("no response generated")
 	}
 
-	return grokResponse.Message.Content, nil
+	return types.GenerationResult{Message: message.String(), Usage: usage}, nil
+}
+
+// ListModels returns the Grok models available to the API key. X.AI serves
+// the OpenAI-compatible /models listing next to the chat completions endpoint.
+func ListModels(ctx context.Context, config *types.Config, apiKey string) ([]string, error) {
+	return openaicompat.ListModels(ctx, openaicompat.Endpoint{
+		BaseURL: chatEndpoint(config),
+		APIKey:  apiKey,
+	})
+}
+
+// newGrokRequest prepares the chat completion request shared by the blocking
+// and streaming entry points.
+func newGrokRequest(ctx context.Context, config *types.Config, changes string, apiKey string, model string, opts *types.GenerationOptions, stream bool) (*http.Request, error) {
+	// Prepare request to X.AI (Grok) API
+	prompt := types.BuildPrompt(changes, opts)
+
+	if model == "" {
+		model = DefaultModel
+	}
+
+	sampling := types.SamplingOf(opts)
+	request := types.GrokRequest{
+		Messages:    prompt.Messages(),
+		Model:       model,
+		Stream:      stream,
+		Temperature: sampling.TemperatureOr(grokTemperature),
+		MaxTokens:   sampling.MaxTokens,
+		TopP:        sampling.TopP,
+		Seed:        sampling.Seed,
+	}
+	if stream {
+		request.StreamOptions = &types.StreamOptions{IncludeUsage: true}
+	}
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create HTTP request
+	req, err := http.NewRequestWithContext(ctx, "POST", chatEndpoint(config), bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, err
+	}
+
+	// Set headers
+	req.Header.Set("Content-Type", grokContentType)
+	req.Header.Set("Authorization", fmt.Sprintf("%s%s", authorizationPrefix, apiKey))
+
+	return req, nil
+}
+
+// reportedUsage normalises the usage block of a response, returning nil when
+// the API did not fill it in.
+func reportedUsage(usage types.UsageInfo) *types.UsageInfo {
+	return types.NewUsageInfo(usage.PromptTokens, usage.CompletionTokens)
+}
+
+// chatEndpoint returns the configured chat completions URL, falling back to
+// the public X.AI endpoint.
+func chatEndpoint(config *types.Config) string {
+	if config != nil && config.GrokAPI != "" {
+		return config.GrokAPI
+	}
+	return grokAPIEndpoint
 }
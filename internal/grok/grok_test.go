@@ -1,6 +1,7 @@
 package grok
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -16,7 +17,7 @@ func TestGenerateCommitMessage(t *testing.T) {
 	t.Run("returns error for empty API key", func(t *testing.T) {
 		t.Parallel()
 
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", "", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", "", "", nil)
 		if err == nil {
 			t.Fatal("expected error for empty API key")
 		}
@@ -25,7 +26,7 @@ func TestGenerateCommitMessage(t *testing.T) {
 	t.Run("returns error for empty changes", func(t *testing.T) {
 		t.Parallel()
 
-		_, err := GenerateCommitMessage(&types.Config{}, "", "test-key", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "", "test-key", "", nil)
 		if err == nil {
 			t.Fatal("expected error for empty changes")
 		}
@@ -82,7 +83,7 @@ func TestGenerateCommitMessageWithMockServer(t *testing.T) {
 
 		// This would require modifying the function to accept a URL parameter
 		// For now, we'll test the error handling path
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", "invalid-key", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", "invalid-key", "", nil)
 		if err == nil {
 			t.Fatal("expected error for invalid API key")
 		}
@@ -110,7 +111,7 @@ func TestGenerateCommitMessageWithMockServer(t *testing.T) {
 
 		// This would require modifying the function to accept a URL parameter
 		// For now, we'll test the error handling path
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", "invalid-key", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", "invalid-key", "", nil)
 		if err == nil {
 			t.Fatal("expected error for invalid API key")
 		}
@@ -127,7 +128,7 @@ func TestGenerateCommitMessageWithMockServer(t *testing.T) {
 
 		// This would require modifying the function to accept a URL parameter
 		// For now, we'll test the error handling path
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", "invalid-key", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", "invalid-key", "", nil)
 		if err == nil {
 			t.Fatal("expected error for invalid API key")
 		}
@@ -144,7 +145,7 @@ func TestGenerateCommitMessageWithMockServer(t *testing.T) {
 
 		// This would require modifying the function to accept a URL parameter
 		// For now, we'll test the error handling path
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", "invalid-key", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", "invalid-key", "", nil)
 		if err == nil {
 			t.Fatal("expected error for invalid API key")
 		}
@@ -168,7 +169,7 @@ func TestGenerateCommitMessageWithMockServer(t *testing.T) {
 
 		// This would require modifying the function to accept a URL parameter
 		// For now, we'll test the error handling path
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", "invalid-key", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", "invalid-key", "", nil)
 		if err == nil {
 			t.Fatal("expected error for invalid API key")
 		}
@@ -184,7 +185,7 @@ func TestGenerateCommitMessageIncludesStyleInstructions(t *testing.T) {
 	}
 
 	// Test with invalid key to verify the function processes the options
-	_, err := GenerateCommitMessage(&types.Config{}, "some changes", "invalid-key", opts)
+	_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", "invalid-key", "", opts)
 	if err == nil {
 		t.Fatal("expected error for invalid API key")
 	}
@@ -197,7 +198,7 @@ func TestGenerateCommitMessageWithLongChanges(t *testing.T) {
 	longChanges := strings.Repeat("This is a test change. ", 1000)
 
 	// Test with invalid key to verify the function handles long changes
-	_, err := GenerateCommitMessage(&types.Config{}, longChanges, "invalid-key", nil)
+	_, err := GenerateCommitMessage(context.Background(), &types.Config{}, longChanges, "invalid-key", "", nil)
 	if err == nil {
 		t.Fatal("expected error for invalid API key")
 	}
@@ -215,7 +216,7 @@ Line 2
 Line 3`
 
 	// Test with invalid key to verify the function handles special characters
-	_, err := GenerateCommitMessage(&types.Config{}, changes, "invalid-key", nil)
+	_, err := GenerateCommitMessage(context.Background(), &types.Config{}, changes, "invalid-key", "", nil)
 	if err == nil {
 		t.Fatal("expected error for invalid API key")
 	}
@@ -317,7 +318,7 @@ func TestGenerateCommitMessageWithConfig(t *testing.T) {
 	}
 
 	// Test with invalid key to verify the function uses config
-	_, err := GenerateCommitMessage(config, "some changes", "invalid-key", nil)
+	_, err := GenerateCommitMessage(context.Background(), config, "some changes", "invalid-key", "", nil)
 	if err == nil {
 		t.Fatal("expected error for invalid API key")
 	}
@@ -327,8 +328,98 @@ func TestGenerateCommitMessageWithNilConfig(t *testing.T) {
 	t.Parallel()
 
 	// Test with nil config
-	_, err := GenerateCommitMessage(nil, "some changes", "invalid-key", nil)
+	_, err := GenerateCommitMessage(context.Background(), nil, "some changes", "invalid-key", "", nil)
 	if err == nil {
 		t.Fatal("expected error for invalid API key")
 	}
 }
+
+func TestStreamCommitMessage(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req types.GrokRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+
+		if !req.Stream {
+			t.Fatal("expected stream true")
+		}
+
+		if req.Model != DefaultModel {
+			t.Fatalf("expected default model, got %q", req.Model)
+		}
+
+		if req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			t.Fatal("expected usage to be requested")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"feat: \"}}]}\n\n"))
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"stream grok\"}}]}\n\n"))
+		w.Write([]byte("data: {\"choices\":[],\"usage\":{\"prompt_tokens\":80,\"completion_tokens\":6,\"total_tokens\":86}}\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	var chunks []string
+	msg, err := StreamCommitMessage(context.Background(), &types.Config{GrokAPI: server.URL}, "some changes", "test-key", "", nil, func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if msg.Message != "feat: stream grok" {
+		t.Fatalf("unexpected message: %q", msg.Message)
+	}
+
+	if msg.Usage == nil || msg.Usage.TotalTokens != 86 {
+		t.Fatalf("unexpected usage: %+v", msg.Usage)
+	}
+
+	if strings.Join(chunks, "|") != "feat: |stream grok" {
+		t.Fatalf("unexpected chunks: %v", chunks)
+	}
+}
+
+func TestListModels(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"data":[{"id":"grok-3-mini"},{"id":"grok-4"}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	models, err := ListModels(context.Background(), &types.Config{GrokAPI: server.URL + "/v1/chat/completions"}, "test-key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Join(models, ",") != "grok-3-mini,grok-4" {
+		t.Fatalf("unexpected models: %v", models)
+	}
+}
+
+func TestNewGrokRequestSampling(t *testing.T) {
+	t.Parallel()
+
+	sampling := types.DeterministicSampling()
+	sampling.MaxTokens = 600
+	req, err := newGrokRequest(context.Background(), &types.Config{}, "some changes", "test-key", "", &types.GenerationOptions{Sampling: sampling}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var body types.GrokRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+	if body.MaxTokens != 600 || body.Seed == nil || *body.Seed != types.DeterministicSeed || body.TopP != nil {
+		t.Fatalf("unexpected sampling in request: %+v", body)
+	}
+}
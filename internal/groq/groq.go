@@ -2,13 +2,16 @@ package groq
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strings"
 
 	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/internal/openaicompat"
 	"github.com/dfanso/commit-msg/pkg/types"
 )
 
@@ -18,10 +21,15 @@ type chatMessage struct {
 }
 
 type chatRequest struct {
-	Model       string        `json:"model"`
-	Messages    []chatMessage `json:"messages"`
-	Temperature float64       `json:"temperature"`
-	MaxTokens   int           `json:"max_tokens"`
+	Model         string               `json:"model"`
+	Messages      []chatMessage        `json:"messages"`
+	Temperature   float64              `json:"temperature"`
+	MaxTokens     int                  `json:"max_tokens"`
+	TopP          *float64             `json:"top_p,omitempty"`
+	Seed          *int64               `json:"seed,omitempty"`
+	N             int                  `json:"n,omitempty"`
+	Stream        bool                 `json:"stream,omitempty"`
+	StreamOptions *types.StreamOptions `json:"stream_options,omitempty"`
 }
 
 type chatChoice struct {
@@ -29,96 +37,248 @@ type chatChoice struct {
 }
 
 type chatResponse struct {
-	Choices []chatChoice `json:"choices"`
+	Choices []chatChoice    `json:"choices"`
+	Usage   types.UsageInfo `json:"usage"`
 }
 
-// defaultModel uses Groq's recommended general-purpose model as of Oct 2025.
-// If Groq updates their defaults again, override via GROQ_MODEL.
-const defaultModel = "llama-3.3-70b-versatile"
+type chatDelta struct {
+	Content string `json:"content"`
+}
+
+type chatStreamChoice struct {
+	Delta chatDelta `json:"delta"`
+}
+
+type chatStreamChunk struct {
+	Choices []chatStreamChoice `json:"choices"`
+	Usage   *types.UsageInfo   `json:"usage,omitempty"`
+	// XGroq carries Groq's own usage report on the final chunk.
+	XGroq struct {
+		Usage *types.UsageInfo `json:"usage,omitempty"`
+	} `json:"x_groq"`
+}
+
+// DefaultModel uses Groq's recommended general-purpose model as of Oct 2025.
+// If Groq updates their defaults again, pick another one with --model or
+// GROQ_MODEL.
+const DefaultModel = "llama-3.3-70b-versatile"
 
 const (
 	groqTemperature         = 0.2
-	groqMaxTokens           = 200
-	groqSystemMessage       = "You are an assistant that writes clear, concise git commit messages."
+	groqMaxTokens           = 1024
 	groqContentType         = "application/json"
 	groqAuthorizationPrefix = "Bearer "
+	groqStreamContentType   = "text/event-stream"
+	groqStreamDone          = "[DONE]"
 )
 
 var (
 	// allow overrides in tests
 	baseURL = "https://api.groq.com/openai/v1/chat/completions"
-	// httpClient can be overridden in tests; defaults to the internal http client
+	// httpClient can be overridden in tests; when nil, requests go through
+	// the shared generation client, looked up on each request so it follows
+	// the network settings.
 	httpClient *http.Client
 )
 
-func init() {
-	httpClient = internalHTTP.GetClient()
+func client() *http.Client {
+	if httpClient != nil {
+		return httpClient
+	}
+	return internalHTTP.GetGenerationClient()
 }
 
 // GenerateCommitMessage calls Groq's OpenAI-compatible chat completions API.
-func GenerateCommitMessage(_ *types.Config, changes string, apiKey string, opts *types.GenerationOptions) (string, error) {
-	if changes == "" {
-		return "", fmt.Errorf("no changes provided for commit message generation")
+func GenerateCommitMessage(ctx context.Context, _ *types.Config, changes string, apiKey string, model string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	completion, err := complete(ctx, changes, apiKey, model, opts, 1)
+	if err != nil {
+		return types.GenerationResult{}, err
 	}
 
-	prompt := types.BuildCommitPrompt(changes, opts)
-
-	model := os.Getenv("GROQ_MODEL")
-	if model == "" {
-		model = defaultModel
+	if len(completion.Choices) == 0 || completion.Choices[0].Message.Content == "" {
+		return types.GenerationResult{}, fmt.Errorf("groq API returned empty response")
 	}
 
-	payload := chatRequest{
-		Model:       model,
-		Temperature: groqTemperature,
-		MaxTokens:   groqMaxTokens,
-		Messages: []chatMessage{
-			{Role: "system", Content: groqSystemMessage},
-			{Role: "user", Content: prompt},
-		},
-	}
+	return types.GenerationResult{
+		Message: completion.Choices[0].Message.Content,
+		Usage:   types.NewUsageInfo(completion.Usage.PromptTokens, completion.Usage.CompletionTokens),
+	}, nil
+}
 
-	body, err := json.Marshal(payload)
+// GenerateCandidates asks Groq for n alternative commit messages in a single
+// request through the n parameter. The usage of the request is reported on
+// the first result.
+func GenerateCandidates(ctx context.Context, _ *types.Config, changes string, apiKey string, model string, opts *types.GenerationOptions, n int) ([]types.GenerationResult, error) {
+	completion, err := complete(ctx, changes, apiKey, model, opts, n)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal Groq request: %w", err)
+		return nil, err
 	}
 
-	endpoint := baseURL
-	if customEndpoint := os.Getenv("GROQ_API_URL"); customEndpoint != "" {
-		endpoint = customEndpoint
+	var results []types.GenerationResult
+	for _, choice := range completion.Choices {
+		if choice.Message.Content != "" {
+			results = append(results, types.GenerationResult{Message: choice.Message.Content})
+		}
 	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("groq API returned empty response")
+	}
+
+	results[0].Usage = types.NewUsageInfo(completion.Usage.PromptTokens, completion.Usage.CompletionTokens)
+	return results, nil
+}
 
-	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(body))
+// complete sends a blocking chat completion request for n choices.
+func complete(ctx context.Context, changes string, apiKey string, model string, opts *types.GenerationOptions, n int) (chatResponse, error) {
+	req, err := newChatRequest(ctx, changes, apiKey, model, opts, false, n)
 	if err != nil {
-		return "", fmt.Errorf("failed to create Groq request: %w", err)
+		return chatResponse{}, err
 	}
 
-	req.Header.Set("Content-Type", groqContentType)
-	req.Header.Set("Authorization", fmt.Sprintf("%s%s", groqAuthorizationPrefix, apiKey))
-
-	resp, err := httpClient.Do(req)
+	resp, err := client().Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to call Groq API: %w", err)
+		return chatResponse{}, fmt.Errorf("failed to call Groq API: %w", err)
 	}
 	defer resp.Body.Close()
 
 	responseBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read Groq response: %w", err)
+		return chatResponse{}, fmt.Errorf("failed to read Groq response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("groq API returned status %d: %s", resp.StatusCode, string(responseBody))
+		return chatResponse{}, internalHTTP.NewStatusError(resp.StatusCode, "groq API returned status %d: %s", resp.StatusCode, string(responseBody))
 	}
 
 	var completion chatResponse
 	if err := json.Unmarshal(responseBody, &completion); err != nil {
-		return "", fmt.Errorf("failed to decode Groq response: %w", err)
+		return chatResponse{}, fmt.Errorf("failed to decode Groq response: %w", err)
 	}
+	return completion, nil
+}
 
-	if len(completion.Choices) == 0 || completion.Choices[0].Message.Content == "" {
-		return "", fmt.Errorf("groq API returned empty response")
+// StreamCommitMessage behaves like GenerateCommitMessage but requests a
+// server-sent event stream and passes each content delta to onChunk as it
+// arrives. The full message is returned once the stream completes.
+func StreamCommitMessage(ctx context.Context, _ *types.Config, changes string, apiKey string, model string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	req, err := newChatRequest(ctx, changes, apiKey, model, opts, true, 1)
+	if err != nil {
+		return types.GenerationResult{}, err
 	}
+	req.Header.Set("Accept", groqStreamContentType)
+
+	resp, err := client().Do(req)
+	if err != nil {
+		return types.GenerationResult{}, fmt.Errorf("failed to call Groq API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		responseBody, _ := io.ReadAll(resp.Body)
+		return types.GenerationResult{}, internalHTTP.NewStatusError(resp.StatusCode, "groq API returned status %d: %s", resp.StatusCode, string(responseBody))
+	}
+
+	var message strings.Builder
+	var usage *types.UsageInfo
+	err = internalHTTP.ReadServerSentEvents(resp.Body, func(event internalHTTP.ServerSentEvent) error {
+		if event.Data == groqStreamDone {
+			return io.EOF
+		}
+
+		var chunk chatStreamChunk
+		if err := json.Unmarshal([]byte(event.Data), &chunk); err != nil {
+			return fmt.Errorf("failed to decode Groq stream chunk: %w", err)
+		}
+
+		for _, reported := range []*types.UsageInfo{chunk.Usage, chunk.XGroq.Usage} {
+			if reported != nil {
+				usage = types.NewUsageInfo(reported.PromptTokens, reported.CompletionTokens)
+			}
+		}
 
-	return completion.Choices[0].Message.Content, nil
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			message.WriteString(choice.Delta.Content)
+			if onChunk != nil {
+				onChunk(choice.Delta.Content)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return types.GenerationResult{}, fmt.Errorf("failed to read Groq stream: %w", err)
+	}
+
+	if message.Len() == 0 {
+		return types.GenerationResult{}, fmt.Errorf("groq API returned empty response")
+	}
+
+	return types.GenerationResult{Message: message.String(), Usage: usage}, nil
+}
+
+// ListModels returns the models available to the API key from Groq's
+// OpenAI-compatible /models endpoint.
+func ListModels(ctx context.Context, apiKey string) ([]string, error) {
+	return openaicompat.ListModels(ctx, openaicompat.Endpoint{
+		BaseURL: chatEndpoint(),
+		APIKey:  apiKey,
+	})
+}
+
+// newChatRequest builds the HTTP request shared by the blocking and streaming
+// calls. More than one choice is only requested when n is above 1.
+func newChatRequest(ctx context.Context, changes string, apiKey string, model string, opts *types.GenerationOptions, stream bool, n int) (*http.Request, error) {
+	if changes == "" {
+		return nil, fmt.Errorf("no changes provided for commit message generation")
+	}
+
+	prompt := types.BuildPrompt(changes, opts)
+
+	if model == "" {
+		model = DefaultModel
+	}
+
+	sampling := types.SamplingOf(opts)
+	payload := chatRequest{
+		Model:       model,
+		Temperature: sampling.TemperatureOr(groqTemperature),
+		MaxTokens:   sampling.MaxTokensOr(groqMaxTokens),
+		TopP:        sampling.TopP,
+		Seed:        sampling.Seed,
+		Stream:      stream,
+	}
+	for _, message := range prompt.Messages() {
+		payload.Messages = append(payload.Messages, chatMessage{Role: message.Role, Content: message.Content})
+	}
+	if stream {
+		payload.StreamOptions = &types.StreamOptions{IncludeUsage: true}
+	}
+	if n > 1 {
+		payload.N = n
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Groq request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, chatEndpoint(), bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Groq request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", groqContentType)
+	req.Header.Set("Authorization", fmt.Sprintf("%s%s", groqAuthorizationPrefix, apiKey))
+
+	return req, nil
+}
+
+// chatEndpoint honours GROQ_API_URL before falling back to baseURL.
+func chatEndpoint() string {
+	if customEndpoint := os.Getenv("GROQ_API_URL"); customEndpoint != "" {
+		return customEndpoint
+	}
+	return baseURL
 }
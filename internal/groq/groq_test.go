@@ -1,12 +1,18 @@
 package groq
 
 import (
+	"context"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
 	"github.com/dfanso/commit-msg/pkg/types"
 )
 
@@ -59,14 +65,15 @@ func TestGenerateCommitMessageSuccess(t *testing.T) {
 			t.Fatalf("unexpected model: %s", payload.Model)
 		}
 
-		if len(payload.Messages) != 2 {
-			t.Fatalf("expected 2 messages, got %d", len(payload.Messages))
+		if len(payload.Messages) != 4 || payload.Messages[0].Role != "system" || payload.Messages[3].Role != "user" {
+			t.Fatalf("expected the system message, an example and the user message, got %+v", payload.Messages)
 		}
 
 		resp := chatResponse{
 			Choices: []chatChoice{
 				{Message: chatMessage{Role: "assistant", Content: "Feat: add groq provider"}},
 			},
+			Usage: types.UsageInfo{PromptTokens: 310, CompletionTokens: 12, TotalTokens: 322},
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -74,14 +81,51 @@ func TestGenerateCommitMessageSuccess(t *testing.T) {
 			t.Fatalf("failed to write response: %v", err)
 		}
 	}, func() {
-		msg, err := GenerateCommitMessage(&types.Config{}, "diff", "test-key", nil)
+		msg, err := GenerateCommitMessage(context.Background(), &types.Config{}, "diff", "test-key", "", nil)
 		if err != nil {
 			t.Fatalf("GenerateCommitMessage returned error: %v", err)
 		}
 
 		expected := "Feat: add groq provider"
-		if msg != expected {
-			t.Fatalf("expected %q, got %q", expected, msg)
+		if msg.Message != expected {
+			t.Fatalf("expected %q, got %q", expected, msg.Message)
+		}
+
+		if msg.Usage == nil || msg.Usage.PromptTokens != 310 || msg.Usage.CompletionTokens != 12 {
+			t.Fatalf("unexpected usage: %+v", msg.Usage)
+		}
+	})
+}
+
+func TestGenerateCandidates(t *testing.T) {
+	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var payload chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if payload.N != 2 {
+			t.Fatalf("expected n=2, got %d", payload.N)
+		}
+
+		resp := chatResponse{
+			Choices: []chatChoice{
+				{Message: chatMessage{Role: "assistant", Content: "feat: first"}},
+				{Message: chatMessage{Role: "assistant", Content: "feat: second"}},
+			},
+			Usage: types.UsageInfo{PromptTokens: 300, CompletionTokens: 20, TotalTokens: 320},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}, func() {
+		results, err := GenerateCandidates(context.Background(), &types.Config{}, "diff", "test-key", "", nil, 2)
+		if err != nil {
+			t.Fatalf("GenerateCandidates returned error: %v", err)
+		}
+		if len(results) != 2 || results[0].Message != "feat: first" || results[1].Message != "feat: second" {
+			t.Fatalf("unexpected candidates: %+v", results)
+		}
+		if results[0].Usage == nil || results[0].Usage.TotalTokens != 320 || results[1].Usage != nil {
+			t.Fatalf("expected the request usage on the first candidate only, got %+v and %+v", results[0].Usage, results[1].Usage)
 		}
 	})
 }
@@ -90,7 +134,7 @@ func TestGenerateCommitMessageNonOK(t *testing.T) {
 	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"bad things"}`, http.StatusBadGateway)
 	}, func() {
-		_, err := GenerateCommitMessage(&types.Config{}, "changes", "key", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "changes", "key", "", nil)
 		if err == nil {
 			t.Fatal("expected error but got nil")
 		}
@@ -101,7 +145,7 @@ func TestGenerateCommitMessageEmptyChanges(t *testing.T) {
 	t.Setenv("GROQ_MODEL", "")
 	t.Setenv("GROQ_API_URL", "")
 
-	if _, err := GenerateCommitMessage(&types.Config{}, "", "key", nil); err == nil {
+	if _, err := GenerateCommitMessage(context.Background(), &types.Config{}, "", "key", "", nil); err == nil {
 		t.Fatal("expected error for empty changes")
 	}
 }
@@ -114,7 +158,7 @@ func TestGenerateCommitMessageIncludesStyleInstruction(t *testing.T) {
 		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 			t.Fatalf("failed to decode request: %v", err)
 		}
-		recorded = payload.Messages[1].Content
+		recorded = payload.Messages[len(payload.Messages)-1].Content
 
 		resp := chatResponse{
 			Choices: []chatChoice{
@@ -128,7 +172,7 @@ func TestGenerateCommitMessageIncludesStyleInstruction(t *testing.T) {
 		}
 	}, func() {
 		opts := &types.GenerationOptions{StyleInstruction: "Use a casual tone.", Attempt: 2}
-		if _, err := GenerateCommitMessage(&types.Config{}, "diff", "key", opts); err != nil {
+		if _, err := GenerateCommitMessage(context.Background(), &types.Config{}, "diff", "key", "", opts); err != nil {
 			t.Fatalf("GenerateCommitMessage returned error: %v", err)
 		}
 	})
@@ -143,3 +187,186 @@ func TestGenerateCommitMessageIncludesStyleInstruction(t *testing.T) {
 		t.Fatalf("expected request payload to contain regeneration context, got: %q", recorded)
 	}
 }
+
+func TestStreamCommitMessage(t *testing.T) {
+	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Stream bool `json:"stream"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if !payload.Stream {
+			t.Fatal("expected stream to be requested")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunks := []string{
+			`{"choices":[{"delta":{"role":"assistant"}}]}`,
+			`{"choices":[{"delta":{"content":"Feat: "}}]}`,
+			`{"choices":[{"delta":{"content":"stream groq"}}]}`,
+			`{"choices":[],"x_groq":{"usage":{"prompt_tokens":90,"completion_tokens":4,"total_tokens":94}}}`,
+			`[DONE]`,
+		}
+		for _, chunk := range chunks {
+			if _, err := w.Write([]byte("data: " + chunk + "\n\n")); err != nil {
+				t.Fatalf("failed to write chunk: %v", err)
+			}
+		}
+	}, func() {
+		var received []string
+		msg, err := StreamCommitMessage(context.Background(), &types.Config{}, "diff", "key", "", nil, func(chunk string) {
+			received = append(received, chunk)
+		})
+		if err != nil {
+			t.Fatalf("StreamCommitMessage returned error: %v", err)
+		}
+
+		if msg.Message != "Feat: stream groq" {
+			t.Fatalf("unexpected message: %q", msg.Message)
+		}
+
+		if msg.Usage == nil || msg.Usage.TotalTokens != 94 {
+			t.Fatalf("unexpected usage: %+v", msg.Usage)
+		}
+
+		if len(received) != 2 {
+			t.Fatalf("expected 2 chunks, got %d: %v", len(received), received)
+		}
+	})
+}
+
+func TestGenerateCommitMessageHonorsContext(t *testing.T) {
+	release := make(chan struct{})
+
+	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}, func() {
+		defer close(release)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := GenerateCommitMessage(ctx, &types.Config{}, "diff", "key", "", nil)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestGenerateCommitMessageUsesRequestedModel(t *testing.T) {
+	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var payload capturedRequest
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+
+		if payload.Model != "llama-3.1-8b-instant" {
+			t.Fatalf("unexpected model: %s", payload.Model)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"fix: pick model"}}]}`))
+	}, func() {
+		if _, err := GenerateCommitMessage(context.Background(), &types.Config{}, "diff", "key", "llama-3.1-8b-instant", nil); err != nil {
+			t.Fatalf("GenerateCommitMessage returned error: %v", err)
+		}
+	})
+}
+
+func TestListModels(t *testing.T) {
+	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/openai/v1/models" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"data":[{"id":"llama-3.3-70b-versatile"},{"id":"llama-3.1-8b-instant"}]}`))
+	}, func() {
+		baseURL += "/openai/v1/chat/completions"
+
+		models, err := ListModels(context.Background(), "key")
+		if err != nil {
+			t.Fatalf("ListModels returned error: %v", err)
+		}
+
+		if strings.Join(models, ",") != "llama-3.3-70b-versatile,llama-3.1-8b-instant" {
+			t.Fatalf("unexpected models: %v", models)
+		}
+	})
+}
+
+func TestNewChatRequestSampling(t *testing.T) {
+	t.Setenv("GROQ_API_URL", "")
+
+	topP := 0.8
+	opts := &types.GenerationOptions{Sampling: types.Sampling{MaxTokens: 900, TopP: &topP}}
+	req, err := newChatRequest(context.Background(), "diff", "test-key", "", opts, false, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var payload chatRequest
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+	if payload.Temperature != groqTemperature || payload.MaxTokens != 900 || payload.TopP == nil || *payload.TopP != topP || payload.Seed != nil {
+		t.Fatalf("unexpected sampling in request: %+v", payload)
+	}
+}
+
+func TestClientFollowsNetworkSettings(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "")
+	t.Setenv("HTTP_PROXY", "")
+	t.Setenv("NO_PROXY", "")
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(srv.Close)
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(bundle, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The package is initialized by now, as it is when the CLI applies the
+	// saved settings.
+	if err := internalHTTP.Configure(types.NetworkSettings{Proxy: "http://proxy.example:3128", CABundle: bundle}); err != nil {
+		t.Fatalf("Configure returned error: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := internalHTTP.Configure(types.NetworkSettings{}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// Generations may stream for longer than the cloud client allows.
+	if client() != internalHTTP.GetGenerationClient() {
+		t.Fatal("expected requests to go through the generation client")
+	}
+
+	transport, ok := client().Transport.(*internalHTTP.InterceptTransport)
+	if !ok {
+		t.Fatalf("expected the shared client, got transport %T", client().Transport)
+	}
+	retry, ok := transport.Base.(*internalHTTP.RetryTransport)
+	if !ok {
+		t.Fatalf("expected a retrying transport, got %T", transport.Base)
+	}
+	base, ok := retry.Base.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected an *http.Transport, got %T", retry.Base)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, baseURL, nil)
+	proxy, err := base.Proxy(req)
+	if err != nil || proxy == nil || proxy.Host != "proxy.example:3128" {
+		t.Fatalf("expected requests to go through the configured proxy, got %v, %v", proxy, err)
+	}
+
+	// The test server is on localhost, which bypasses the proxy, and its
+	// certificate is only trusted through the CA bundle.
+	resp, err := client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected the CA bundle to be trusted: %v", err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,219 @@
+// Package hook manages the prepare-commit-msg hook that fills in a
+// generated message for plain 'git commit'.
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Name is the git hook the message is generated in.
+const Name = "prepare-commit-msg"
+
+// ChainedName is what an existing hook is renamed to on install. The
+// installed hook runs it first and uninstall puts it back.
+const ChainedName = Name + ".chained"
+
+// marker identifies hooks written by Install.
+const marker = "# Installed by commit-msg"
+
+// Status describes the prepare-commit-msg hook of a repository.
+type Status struct {
+	// Dir is the hooks directory, following core.hooksPath.
+	Dir string
+	// Installed reports whether the hook was written by Install.
+	Installed bool
+	// Foreign reports whether another prepare-commit-msg hook is in place.
+	Foreign bool
+	// Chained reports whether an earlier hook is run before generating.
+	Chained bool
+	// Executable is the commit-msg binary the installed hook runs.
+	Executable string
+}
+
+// Path returns the location of the hook.
+func (s Status) Path() string {
+	return filepath.Join(s.Dir, Name)
+}
+
+// Dir returns the hooks directory of the repository at repoPath, which is
+// core.hooksPath when it is set.
+func Dir(repoPath string) (string, error) {
+	output, err := exec.Command("git", "-C", repoPath, "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %s", repoPath)
+	}
+
+	dir := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repoPath, dir)
+	}
+	return filepath.Abs(dir)
+}
+
+// Inspect reports the state of the hook in the repository at repoPath.
+func Inspect(repoPath string) (Status, error) {
+	dir, err := Dir(repoPath)
+	if err != nil {
+		return Status{}, err
+	}
+
+	status := Status{Dir: dir}
+	if _, err := os.Stat(filepath.Join(dir, ChainedName)); err == nil {
+		status.Chained = true
+	}
+
+	script, err := os.ReadFile(status.Path())
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return status, nil
+	case err != nil:
+		return status, err
+	}
+
+	if !strings.Contains(string(script), marker) {
+		status.Foreign = true
+		return status, nil
+	}
+	status.Installed = true
+	status.Executable = scriptExecutable(string(script))
+	return status, nil
+}
+
+// Install writes the hook that runs executable into the repository at
+// repoPath. A hook written earlier is updated; any other hook is renamed
+// to ChainedName and keeps running before the message is generated.
+func Install(repoPath, executable string) (Status, error) {
+	status, err := Inspect(repoPath)
+	if err != nil {
+		return status, err
+	}
+
+	if err := os.MkdirAll(status.Dir, 0o755); err != nil {
+		return status, err
+	}
+
+	if status.Foreign {
+		if status.Chained {
+			return status, fmt.Errorf("both %s and %s exist; merge them before installing", Name, ChainedName)
+		}
+		if err := os.Rename(status.Path(), filepath.Join(status.Dir, ChainedName)); err != nil {
+			return status, err
+		}
+		status.Foreign, status.Chained = false, true
+	}
+
+	if err := os.WriteFile(status.Path(), []byte(Script(executable)), 0o755); err != nil {
+		return status, err
+	}
+	// WriteFile keeps the mode of an existing file.
+	if err := os.Chmod(status.Path(), 0o755); err != nil {
+		return status, err
+	}
+
+	status.Installed = true
+	status.Executable = executable
+	return status, nil
+}
+
+// Uninstall removes the hook written by Install from the repository at
+// repoPath and puts a chained hook back in its place.
+func Uninstall(repoPath string) (Status, error) {
+	status, err := Inspect(repoPath)
+	if err != nil {
+		return status, err
+	}
+
+	switch {
+	case status.Foreign:
+		return status, fmt.Errorf("%s was not installed by commit-msg; leaving it in place", status.Path())
+	case !status.Installed:
+		return status, fmt.Errorf("no %s hook is installed in %s", Name, status.Dir)
+	}
+
+	if err := os.Remove(status.Path()); err != nil {
+		return status, err
+	}
+	status.Installed, status.Executable = false, ""
+
+	if status.Chained {
+		if err := os.Rename(filepath.Join(status.Dir, ChainedName), status.Path()); err != nil {
+			return status, err
+		}
+		status.Chained, status.Foreign = false, true
+	}
+	return status, nil
+}
+
+// executableVariable names the line of the script holding the binary.
+const executableVariable = "commit_msg="
+
+// Script returns the hook that runs executable. It runs a chained hook
+// first, leaves merges, squashes, amends and messages given with -m, -F, -c
+// or -C alone, and never blocks the commit when generation fails.
+func Script(executable string) string {
+	return `#!/bin/sh
+` + marker + ` ('commit hook install'); remove it with 'commit hook uninstall'.
+# Fills in a generated commit message for the editor to review.
+
+hook_dir=$(dirname "$0")
+if [ -x "$hook_dir/` + ChainedName + `" ]; then
+	"$hook_dir/` + ChainedName + `" "$@" || exit $?
+fi
+
+case "$2" in
+	message|merge|squash|commit) exit 0 ;;
+esac
+
+` + executableVariable + shellQuote(executable) + `
+[ -x "$commit_msg" ] || exit 0
+"$commit_msg" hook run "$1" "$2" "$3" || true
+`
+}
+
+// scriptExecutable reads the binary back from a hook written by Script.
+func scriptExecutable(script string) string {
+	for _, line := range strings.Split(script, "\n") {
+		if value, ok := strings.CutPrefix(line, executableVariable); ok {
+			value = strings.TrimPrefix(strings.TrimSuffix(value, "'"), "'")
+			return strings.ReplaceAll(value, `'\''`, "'")
+		}
+	}
+	return ""
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ShouldGenerate reports whether a message should be generated for the
+// commit source git passes to the hook. Merges, squashes, amends and
+// messages given on the command line keep their message.
+func ShouldGenerate(source string) bool {
+	switch source {
+	case "message", "merge", "squash", "commit":
+		return false
+	default:
+		return true
+	}
+}
+
+// WriteMessage puts message at the top of the commit message file at path,
+// above the template and the comments git wrote there.
+func WriteMessage(path, message string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	content := strings.TrimSpace(message) + "\n"
+	if len(existing) > 0 {
+		content += "\n" + string(existing)
+	}
+	return os.WriteFile(path, []byte(content), 0o644)
+}
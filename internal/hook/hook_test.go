@@ -0,0 +1,210 @@
+package hook
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v: %s", args, err, output)
+	}
+	return string(output)
+}
+
+func initRepo(t *testing.T) string {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("skipping integration-style test in short mode")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git executable not available")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init")
+	runGit(t, dir, "config", "user.name", "Test")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "commit.gpgsign", "false")
+	return dir
+}
+
+// fakeBinary writes a stand-in for the commit binary that writes
+// "generated" into the message file it is given.
+func fakeBinary(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "commit")
+	script := "#!/bin/sh\nprintf 'generated\\n' > \"$3\"\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("failed to write fake binary: %v", err)
+	}
+	return path
+}
+
+func TestDirFollowsHooksPath(t *testing.T) {
+	dir := initRepo(t)
+
+	got, err := Dir(dir)
+	if err != nil {
+		t.Fatalf("Dir returned error: %v", err)
+	}
+	if want := filepath.Join(dir, ".git", "hooks"); got != want {
+		t.Fatalf("Dir = %q, want %q", got, want)
+	}
+
+	runGit(t, dir, "config", "core.hooksPath", ".githooks")
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatalf("failed to create subdirectory: %v", err)
+	}
+	got, err = Dir(filepath.Join(dir, "sub"))
+	if err != nil {
+		t.Fatalf("Dir returned error: %v", err)
+	}
+	if want := filepath.Join(dir, ".githooks"); got != want {
+		t.Fatalf("Dir with core.hooksPath = %q, want %q", got, want)
+	}
+}
+
+func TestInstallChainsAndUninstallRestores(t *testing.T) {
+	dir := initRepo(t)
+	runGit(t, dir, "config", "core.hooksPath", ".githooks")
+
+	hooksDir := filepath.Join(dir, ".githooks")
+	if err := os.Mkdir(hooksDir, 0o755); err != nil {
+		t.Fatalf("failed to create hooks directory: %v", err)
+	}
+	existing := "#!/bin/sh\necho existing\n"
+	if err := os.WriteFile(filepath.Join(hooksDir, Name), []byte(existing), 0o755); err != nil {
+		t.Fatalf("failed to write existing hook: %v", err)
+	}
+
+	status, err := Inspect(dir)
+	if err != nil {
+		t.Fatalf("Inspect returned error: %v", err)
+	}
+	if !status.Foreign || status.Installed {
+		t.Fatalf("Inspect before install = %+v, want a foreign hook", status)
+	}
+
+	binary := fakeBinary(t)
+	status, err = Install(dir, binary)
+	if err != nil {
+		t.Fatalf("Install returned error: %v", err)
+	}
+	if !status.Installed || !status.Chained || status.Foreign {
+		t.Fatalf("Install = %+v, want installed and chained", status)
+	}
+
+	chained, err := os.ReadFile(filepath.Join(hooksDir, ChainedName))
+	if err != nil || string(chained) != existing {
+		t.Fatalf("chained hook = %q, %v; want the existing hook", chained, err)
+	}
+
+	// Installing again updates the hook and keeps the chained one.
+	if _, err := Install(dir, binary); err != nil {
+		t.Fatalf("second Install returned error: %v", err)
+	}
+	status, err = Inspect(dir)
+	if err != nil {
+		t.Fatalf("Inspect returned error: %v", err)
+	}
+	if !status.Installed || !status.Chained || status.Executable != binary {
+		t.Fatalf("Inspect after install = %+v, want installed, chained and running %q", status, binary)
+	}
+
+	if _, err := Uninstall(dir); err != nil {
+		t.Fatalf("Uninstall returned error: %v", err)
+	}
+	restored, err := os.ReadFile(filepath.Join(hooksDir, Name))
+	if err != nil || string(restored) != existing {
+		t.Fatalf("restored hook = %q, %v; want the existing hook", restored, err)
+	}
+	if _, err := os.Stat(filepath.Join(hooksDir, ChainedName)); !os.IsNotExist(err) {
+		t.Fatalf("chained hook still present after uninstall: %v", err)
+	}
+
+	if _, err := Uninstall(dir); err == nil {
+		t.Fatal("Uninstall of a foreign hook succeeded, want an error")
+	}
+}
+
+func TestInstalledHookFillsInPlainCommits(t *testing.T) {
+	dir := initRepo(t)
+
+	if _, err := Install(dir, fakeBinary(t)); err != nil {
+		t.Fatalf("Install returned error: %v", err)
+	}
+
+	lastMessage := func() string {
+		return strings.TrimSpace(runGit(t, dir, "log", "-1", "--format=%B"))
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	runGit(t, dir, "add", "a.txt")
+	runGit(t, dir, "commit")
+	if got := lastMessage(); got != "generated" {
+		t.Fatalf("message of plain commit = %q, want %q", got, "generated")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b\n"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	runGit(t, dir, "add", "b.txt")
+	runGit(t, dir, "commit", "-m", "by hand")
+	if got := lastMessage(); got != "by hand" {
+		t.Fatalf("message of commit -m = %q, want %q", got, "by hand")
+	}
+
+	runGit(t, dir, "commit", "--amend", "--no-edit")
+	if got := lastMessage(); got != "by hand" {
+		t.Fatalf("message after amend = %q, want %q", got, "by hand")
+	}
+}
+
+func TestScriptQuotesExecutable(t *testing.T) {
+	t.Parallel()
+
+	executable := "/opt/it's here/commit"
+	script := Script(executable)
+
+	if !strings.Contains(script, marker) {
+		t.Fatalf("Script is missing the marker:\n%s", script)
+	}
+	if got := scriptExecutable(script); got != executable {
+		t.Fatalf("scriptExecutable = %q, want %q", got, executable)
+	}
+}
+
+func TestWriteMessageKeepsComments(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	comments := "# Please enter the commit message for your changes.\n"
+	if err := os.WriteFile(path, []byte(comments), 0o644); err != nil {
+		t.Fatalf("failed to write message file: %v", err)
+	}
+
+	if err := WriteMessage(path, "feat: add hook\n\nBody.\n\n"); err != nil {
+		t.Fatalf("WriteMessage returned error: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read message file: %v", err)
+	}
+	if want := "feat: add hook\n\nBody.\n\n" + comments; string(got) != want {
+		t.Fatalf("message file = %q, want %q", got, want)
+	}
+}
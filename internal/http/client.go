@@ -1,51 +1,101 @@
 package http
 
 import (
-	"crypto/tls"
 	"net/http"
 	"sync"
 	"time"
 )
 
 var (
-	clientOnce   sync.Once
-	sharedClient *http.Client
-
-	ollamaClientOnce sync.Once
+	// clientsMu guards the shared clients, which are created on first use
+	// and dropped by Configure so they pick up new network settings.
+	clientsMu        sync.Mutex
+	sharedClient     *http.Client
 	ollamaClient     *http.Client
+	directClient     *http.Client
+	generationClient *http.Client
 )
 
-// createTransport creates a shared HTTP transport with optimized settings
-func createTransport() *http.Transport {
-	return &http.Transport{
-		TLSHandshakeTimeout: 10 * time.Second,
-		MaxIdleConns:        10,
-		IdleConnTimeout:     30 * time.Second,
-		DisableCompression:  true,
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: false,
-		},
-	}
-}
+// cloudTimeout bounds each try of a GetClient request, and the whole of a
+// GetDirectClient request, reading the body included; it can be shortened
+// in tests.
+var cloudTimeout = 30 * time.Second
 
-// GetClient returns a shared HTTP client with optimized settings for cloud APIs
+// generationHeaderTimeout bounds the wait for a generation request's
+// response headers, which providers that do not stream send only once the
+// whole message is written.
+const generationHeaderTimeout = 5 * time.Minute
+
+// GetClient returns a shared HTTP client with optimized settings for cloud APIs.
+// Rate-limited and overloaded requests are retried as described by RetryTransport,
+// and an Interceptor sees each request before its retries. Each try has
+// cloudTimeout to finish; the waits between tries are only bounded by the
+// request context.
 func GetClient() *http.Client {
-	clientOnce.Do(func() {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if sharedClient == nil {
+		policy := DefaultRetryPolicy
+		policy.AttemptTimeout = cloudTimeout
 		sharedClient = &http.Client{
-			Timeout:   30 * time.Second,
-			Transport: createTransport(),
+			Transport: &InterceptTransport{Base: NewRetryTransport(createTransport(), policy)},
 		}
-	})
+	}
 	return sharedClient
 }
 
 // GetOllamaClient returns an HTTP client with extended timeout for local Ollama inference
 func GetOllamaClient() *http.Client {
-	ollamaClientOnce.Do(func() {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if ollamaClient == nil {
 		ollamaClient = &http.Client{
 			Timeout:   10 * time.Minute, // 10 minutes for local inference
-			Transport: createTransport(),
+			Transport: &InterceptTransport{Base: NewRetryTransport(createTransport(), DefaultRetryPolicy)},
 		}
-	})
+	}
 	return ollamaClient
 }
+
+// GetGenerationClient returns a shared client for generation requests, which
+// can run or stream for longer than GetClient allows. It has no overall
+// timeout: requests are bounded by their context and by a limit on the wait
+// for the response headers.
+func GetGenerationClient() *http.Client {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if generationClient == nil {
+		transport := createTransport()
+		transport.ResponseHeaderTimeout = generationHeaderTimeout
+		generationClient = &http.Client{
+			Transport: &InterceptTransport{Base: NewRetryTransport(transport, DefaultRetryPolicy)},
+		}
+	}
+	return generationClient
+}
+
+// GetDirectClient returns a client with the network settings of the shared
+// clients but without retries or an Interceptor, for requests that must not
+// be recorded, such as OAuth token exchanges.
+func GetDirectClient() *http.Client {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if directClient == nil {
+		directClient = &http.Client{
+			Timeout:   cloudTimeout,
+			Transport: createTransport(),
+		}
+	}
+	return directClient
+}
+
+// resetClients drops the shared clients, so the next call of each getter
+// creates a client with the current transport settings.
+func resetClients() {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	sharedClient = nil
+	ollamaClient = nil
+	directClient = nil
+	generationClient = nil
+}
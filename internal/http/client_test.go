@@ -1,12 +1,28 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"sync"
 	"testing"
 	"time"
 )
 
+// attemptTimeout returns the time each try of a client's requests has.
+func attemptTimeout(t *testing.T, client *http.Client) time.Duration {
+	t.Helper()
+	intercept, ok := client.Transport.(*InterceptTransport)
+	if !ok {
+		t.Fatal("expected transport to be *InterceptTransport")
+	}
+	retry, ok := intercept.Base.(*RetryTransport)
+	if !ok {
+		t.Fatal("expected transport to be *RetryTransport")
+	}
+	return retry.Policy.AttemptTimeout
+}
+
 func TestGetClient(t *testing.T) {
 	t.Parallel()
 
@@ -30,14 +46,17 @@ func TestGetClient(t *testing.T) {
 		}
 	})
 
-	t.Run("client has correct timeout", func(t *testing.T) {
+	t.Run("client times out each try", func(t *testing.T) {
 		t.Parallel()
 
 		client := GetClient()
-		expectedTimeout := 30 * time.Second
+		if client.Timeout != 0 {
+			t.Fatalf("expected no overall timeout around the retries, got %v", client.Timeout)
+		}
 
-		if client.Timeout != expectedTimeout {
-			t.Fatalf("expected timeout %v, got %v", expectedTimeout, client.Timeout)
+		expectedTimeout := 30 * time.Second
+		if timeout := attemptTimeout(t, client); timeout != expectedTimeout {
+			t.Fatalf("expected attempt timeout %v, got %v", expectedTimeout, timeout)
 		}
 	})
 
@@ -49,9 +68,19 @@ func TestGetClient(t *testing.T) {
 			t.Fatal("expected client to have custom transport")
 		}
 
-		transport, ok := client.Transport.(*http.Transport)
+		intercept, ok := client.Transport.(*InterceptTransport)
+		if !ok {
+			t.Fatal("expected transport to be *InterceptTransport")
+		}
+
+		retry, ok := intercept.Base.(*RetryTransport)
+		if !ok {
+			t.Fatal("expected transport to be *RetryTransport")
+		}
+
+		transport, ok := retry.Base.(*http.Transport)
 		if !ok {
-			t.Fatal("expected transport to be *http.Transport")
+			t.Fatal("expected base transport to be *http.Transport")
 		}
 
 		// Check some transport settings
@@ -123,9 +152,19 @@ func TestGetOllamaClient(t *testing.T) {
 			t.Fatal("expected client to have custom transport")
 		}
 
-		transport, ok := client.Transport.(*http.Transport)
+		intercept, ok := client.Transport.(*InterceptTransport)
+		if !ok {
+			t.Fatal("expected transport to be *InterceptTransport")
+		}
+
+		retry, ok := intercept.Base.(*RetryTransport)
+		if !ok {
+			t.Fatal("expected transport to be *RetryTransport")
+		}
+
+		transport, ok := retry.Base.(*http.Transport)
 		if !ok {
-			t.Fatal("expected transport to be *http.Transport")
+			t.Fatal("expected base transport to be *http.Transport")
 		}
 
 		// Check some transport settings
@@ -167,7 +206,7 @@ func TestClientsAreDifferent(t *testing.T) {
 	}
 
 	// Check that they have different timeouts
-	if regularClient.Timeout == ollamaClient.Timeout {
+	if attemptTimeout(t, regularClient) == ollamaClient.Timeout {
 		t.Fatal("expected regular client and ollama client to have different timeouts")
 	}
 }
@@ -178,7 +217,7 @@ func TestCreateTransport(t *testing.T) {
 	// We can't directly test createTransport since it's not exported,
 	// but we can test its effects through GetClient
 	client := GetClient()
-	transport := client.Transport.(*http.Transport)
+	transport := client.Transport.(*InterceptTransport).Base.(*RetryTransport).Base.(*http.Transport)
 
 	// Test all the transport settings
 	expectedSettings := map[string]interface{}{
@@ -324,10 +363,11 @@ func TestClientTimeoutValues(t *testing.T) {
 	regularClient := GetClient()
 	ollamaClient := GetOllamaClient()
 
-	// Regular client should have 30 second timeout
+	// Regular client should give each try 30 seconds
 	expectedRegularTimeout := 30 * time.Second
-	if regularClient.Timeout != expectedRegularTimeout {
-		t.Fatalf("expected regular client timeout %v, got %v", expectedRegularTimeout, regularClient.Timeout)
+	regularTimeout := attemptTimeout(t, regularClient)
+	if regularTimeout != expectedRegularTimeout {
+		t.Fatalf("expected regular client timeout %v, got %v", expectedRegularTimeout, regularTimeout)
 	}
 
 	// Ollama client should have 10 minute timeout
@@ -337,7 +377,78 @@ func TestClientTimeoutValues(t *testing.T) {
 	}
 
 	// Regular client timeout should be shorter than ollama client timeout
-	if regularClient.Timeout >= ollamaClient.Timeout {
+	if regularTimeout >= ollamaClient.Timeout {
 		t.Fatal("expected regular client timeout to be shorter than ollama client timeout")
 	}
 }
+
+func TestGetGenerationClient(t *testing.T) {
+	t.Parallel()
+
+	client := GetGenerationClient()
+	if client != GetGenerationClient() {
+		t.Fatal("expected the same generation client on every call")
+	}
+	if client.Timeout != 0 {
+		t.Fatalf("expected no overall timeout, got %v", client.Timeout)
+	}
+
+	intercept, ok := client.Transport.(*InterceptTransport)
+	if !ok {
+		t.Fatal("expected transport to be *InterceptTransport")
+	}
+	retry, ok := intercept.Base.(*RetryTransport)
+	if !ok {
+		t.Fatal("expected transport to be *RetryTransport")
+	}
+	transport, ok := retry.Base.(*http.Transport)
+	if !ok {
+		t.Fatal("expected base transport to be *http.Transport")
+	}
+	if transport.ResponseHeaderTimeout != generationHeaderTimeout {
+		t.Fatalf("expected response header timeout %v, got %v", generationHeaderTimeout, transport.ResponseHeaderTimeout)
+	}
+}
+
+func TestGenerationClientStreamsPastCloudTimeout(t *testing.T) {
+	previous := cloudTimeout
+	cloudTimeout = 100 * time.Millisecond
+	resetClients()
+	t.Cleanup(func() {
+		cloudTimeout = previous
+		resetClients()
+	})
+
+	// The stream takes three times as long as the cloud timeout.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 6; i++ {
+			fmt.Fprintf(w, "data: chunk %d\n\n", i)
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	stream := func(client *http.Client) (int, error) {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+
+		chunks := 0
+		err = ReadServerSentEvents(resp.Body, func(ServerSentEvent) error {
+			chunks++
+			return nil
+		})
+		return chunks, err
+	}
+
+	if _, err := stream(GetClient()); err == nil {
+		t.Fatal("expected the cloud client to cut the stream off")
+	}
+	if chunks, err := stream(GetGenerationClient()); err != nil || chunks != 6 {
+		t.Fatalf("expected the generation client to read the whole stream, got %d chunks, %v", chunks, err)
+	}
+}
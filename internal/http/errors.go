@@ -0,0 +1,41 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// StatusError reports that an API answered with an unexpected HTTP status.
+// The message is supplied by the caller so each provider keeps its wording.
+type StatusError struct {
+	StatusCode int
+	message    string
+}
+
+// NewStatusError formats a message and attaches the HTTP status code to it.
+func NewStatusError(statusCode int, format string, args ...any) *StatusError {
+	return &StatusError{
+		StatusCode: statusCode,
+		message:    fmt.Sprintf(format, args...),
+	}
+}
+
+func (e *StatusError) Error() string {
+	return e.message
+}
+
+// RetryableStatus reports whether a request that failed with statusCode may
+// succeed if sent again: timeouts, rate limits and server-side failures
+// (including Anthropic's 529 "overloaded").
+func RetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	}
+	return statusCode >= http.StatusInternalServerError
+}
+
+// AuthStatus reports whether statusCode means the credential was rejected.
+func AuthStatus(statusCode int) bool {
+	return statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden
+}
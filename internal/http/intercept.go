@@ -0,0 +1,59 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Interceptor sees the requests of the shared clients before they are sent.
+// It may answer a request itself or pass it on to next.
+type Interceptor interface {
+	Intercept(req *http.Request, next http.RoundTripper) (*http.Response, error)
+}
+
+type interceptorKey struct{}
+
+var (
+	interceptorMu      sync.RWMutex
+	defaultInterceptor Interceptor
+)
+
+// SetInterceptor routes every request of the shared clients through i,
+// unless the request's context carries its own. A nil i removes it.
+func SetInterceptor(i Interceptor) {
+	interceptorMu.Lock()
+	defer interceptorMu.Unlock()
+	defaultInterceptor = i
+}
+
+// WithInterceptor routes the requests made with the returned context through
+// i instead of the interceptor set with SetInterceptor.
+func WithInterceptor(ctx context.Context, i Interceptor) context.Context {
+	return context.WithValue(ctx, interceptorKey{}, i)
+}
+
+// InterceptTransport hands each request to the active Interceptor and sends
+// it through Base when there is none.
+type InterceptTransport struct {
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *InterceptTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	interceptor, ok := req.Context().Value(interceptorKey{}).(Interceptor)
+	if !ok {
+		interceptorMu.RLock()
+		interceptor = defaultInterceptor
+		interceptorMu.RUnlock()
+	}
+	if interceptor == nil {
+		return base.RoundTrip(req)
+	}
+	return interceptor.Intercept(req, base)
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type answerInterceptor string
+
+func (a answerInterceptor) Intercept(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(string(a))), Request: req}, nil
+}
+
+func TestInterceptTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("network"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &InterceptTransport{Base: http.DefaultTransport}}
+	get := func(ctx context.Context) string {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	if got := get(context.Background()); got != "network" {
+		t.Fatalf("expected the request to reach the server, got %q", got)
+	}
+
+	SetInterceptor(answerInterceptor("default"))
+	defer SetInterceptor(nil)
+	if got := get(context.Background()); got != "default" {
+		t.Fatalf("expected the default interceptor to answer, got %q", got)
+	}
+	if got := get(WithInterceptor(context.Background(), answerInterceptor("context"))); got != "context" {
+		t.Fatalf("expected the context interceptor to win, got %q", got)
+	}
+}
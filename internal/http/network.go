@@ -0,0 +1,148 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+var (
+	transportMu       sync.RWMutex
+	transportTemplate *http.Transport
+)
+
+// Configure makes the shared clients reach the network as settings say.
+// Clients created earlier are replaced, so the getters return clients with
+// the new settings from then on; callers should not keep a client across a
+// call to Configure. Invalid settings are reported and leave the previous
+// ones in place.
+func Configure(settings types.NetworkSettings) error {
+	transport, err := NewTransport(settings)
+	if err != nil {
+		return err
+	}
+
+	transportMu.Lock()
+	transportTemplate = transport
+	transportMu.Unlock()
+
+	resetClients()
+	return nil
+}
+
+// createTransport creates a shared HTTP transport with optimized settings
+// and the network settings passed to Configure.
+func createTransport() *http.Transport {
+	transportMu.RLock()
+	template := transportTemplate
+	transportMu.RUnlock()
+
+	if template == nil {
+		// Without files to read, the default settings cannot fail.
+		template, _ = NewTransport(types.NetworkSettings{})
+	}
+	return template.Clone()
+}
+
+// NewTransport returns a transport with optimized settings that uses the
+// proxy, certificate authorities and client certificate of settings.
+func NewTransport(settings types.NetworkSettings) (*http.Transport, error) {
+	proxy, err := ProxyFunc(settings)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := TLSConfig(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Transport{
+		Proxy:               proxy,
+		TLSHandshakeTimeout: 10 * time.Second,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     30 * time.Second,
+		DisableCompression:  true,
+		TLSClientConfig:     tlsConfig,
+	}, nil
+}
+
+// proxySchemes are the proxy protocols http.Transport speaks.
+var proxySchemes = []string{"http", "https", "socks5", "socks5h"}
+
+// ProxyFunc returns the proxy selection of settings. The proxy and no-proxy
+// list fall back to HTTPS_PROXY, HTTP_PROXY and NO_PROXY, and requests to
+// localhost never use a proxy.
+func ProxyFunc(settings types.NetworkSettings) (func(*http.Request) (*url.URL, error), error) {
+	config := httpproxy.FromEnvironment()
+
+	if proxy := strings.TrimSpace(settings.Proxy); proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %q: %w", proxy, err)
+		}
+		if u.Host == "" || !slices.Contains(proxySchemes, u.Scheme) {
+			return nil, fmt.Errorf("invalid proxy URL %q: expected scheme://host:port with scheme %s", proxy, strings.Join(proxySchemes, ", "))
+		}
+		config.HTTPProxy = proxy
+		config.HTTPSProxy = proxy
+	}
+	if noProxy := strings.TrimSpace(settings.NoProxy); noProxy != "" {
+		config.NoProxy = noProxy
+	}
+
+	proxyForURL := config.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyForURL(req.URL)
+	}, nil
+}
+
+// TLSConfig returns a TLS configuration that trusts the system roots and
+// the CA bundle of settings and presents its client certificate.
+func TLSConfig(settings types.NetworkSettings) (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: false,
+	}
+
+	if bundle := strings.TrimSpace(settings.CABundle); bundle != "" {
+		pem, err := os.ReadFile(bundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", bundle)
+		}
+		config.RootCAs = pool
+	}
+
+	certFile := strings.TrimSpace(settings.ClientCert)
+	keyFile := strings.TrimSpace(settings.ClientKey)
+	switch {
+	case certFile == "" && keyFile == "":
+	case certFile == "" || keyFile == "":
+		return nil, errors.New("mutual TLS needs both a client certificate and its key")
+	default:
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+// writeTestCertificate saves a self-signed certificate and its key as PEM
+// files and returns their paths.
+func writeTestCertificate(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "commit-msg test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "client.pem")
+	keyFile = filepath.Join(dir, "client.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestProxyFunc(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://env-proxy:8080")
+	t.Setenv("HTTP_PROXY", "")
+	t.Setenv("NO_PROXY", "")
+
+	proxyFor := func(settings types.NetworkSettings, target string) string {
+		t.Helper()
+		proxy, err := ProxyFunc(settings)
+		if err != nil {
+			t.Fatalf("ProxyFunc returned error: %v", err)
+		}
+		req, _ := http.NewRequest(http.MethodGet, target, nil)
+		u, err := proxy(req)
+		if err != nil {
+			t.Fatalf("proxy selection failed: %v", err)
+		}
+		if u == nil {
+			return ""
+		}
+		return u.String()
+	}
+
+	if got := proxyFor(types.NetworkSettings{}, "https://api.openai.com/v1"); got != "http://env-proxy:8080" {
+		t.Fatalf("expected the environment proxy, got %q", got)
+	}
+
+	settings := types.NetworkSettings{Proxy: "http://corp-proxy:3128", NoProxy: "internal.example.com"}
+	cases := map[string]string{
+		"https://api.openai.com/v1":           "http://corp-proxy:3128",
+		"http://api.groq.com/openai/v1":       "http://corp-proxy:3128",
+		"https://llm.internal.example.com/v1": "",
+		"http://localhost:11434/api/generate": "",
+	}
+	for target, want := range cases {
+		if got := proxyFor(settings, target); got != want {
+			t.Fatalf("proxy for %s = %q, want %q", target, got, want)
+		}
+	}
+
+	for _, invalid := range []string{"corp-proxy:3128", "ftp://corp-proxy", "http://"} {
+		if _, err := ProxyFunc(types.NetworkSettings{Proxy: invalid}); err == nil {
+			t.Fatalf("expected an error for proxy %q", invalid)
+		}
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	certFile, keyFile := writeTestCertificate(t)
+
+	config, err := TLSConfig(types.NetworkSettings{CABundle: certFile, ClientCert: certFile, ClientKey: keyFile})
+	if err != nil {
+		t.Fatalf("TLSConfig returned error: %v", err)
+	}
+	if config.RootCAs == nil || len(config.Certificates) != 1 {
+		t.Fatalf("expected the CA bundle and client certificate to be loaded, got %+v", config)
+	}
+
+	invalid := map[string]types.NetworkSettings{
+		"missing bundle":  {CABundle: filepath.Join(t.TempDir(), "missing.pem")},
+		"bundle with key": {CABundle: keyFile},
+		"missing key":     {ClientCert: certFile},
+		"mismatched pair": {ClientCert: keyFile, ClientKey: certFile},
+	}
+	for name, settings := range invalid {
+		if _, err := TLSConfig(settings); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxAttempts is the number of times a request is sent, including the
+// first try, when no other budget is configured.
+const DefaultMaxAttempts = 3
+
+// statusOverloaded is Anthropic's "overloaded" status, sent before any work
+// has been done on the request.
+const statusOverloaded = 529
+
+// RetryPolicy controls how RetryTransport spaces out and bounds retries.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries, including the first one.
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry; it doubles for every
+	// further retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff. A server asking to wait longer than this
+	// through Retry-After or a rate-limit header is not retried.
+	MaxDelay time.Duration
+	// AttemptTimeout bounds each try, reading the response body included,
+	// so waiting between tries does not eat into it. Zero leaves tries
+	// bounded by the request context alone.
+	AttemptTimeout time.Duration
+}
+
+// DefaultRetryPolicy is used by the shared clients.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: DefaultMaxAttempts,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    20 * time.Second,
+}
+
+// RetryTransport is an http.RoundTripper that resends requests failing with a
+// rate limit, an overloaded server or a connection error. Delays grow
+// exponentially with jitter unless the server says how long to wait.
+//
+// Requests with non-idempotent methods such as POST are only resent when the
+// server cannot have acted on them: the connection was never established or
+// the answer was 429, 503 or 529. An Idempotency-Key header lifts that
+// restriction. Requests whose body cannot be replayed are never resent.
+type RetryTransport struct {
+	// Base performs the actual requests; http.DefaultTransport when nil.
+	Base   http.RoundTripper
+	Policy RetryPolicy
+}
+
+// RetryAttempt describes a failed try that RetryTransport is about to repeat.
+type RetryAttempt struct {
+	Method string
+	// Host is the server the request was sent to.
+	Host string
+	// Attempt is the 1-based number of the try that failed.
+	Attempt     int
+	MaxAttempts int
+	// StatusCode is 0 when the request failed without a response.
+	StatusCode int
+	Err        error
+	// Delay is the time waited before the next try.
+	Delay time.Duration
+}
+
+// RetryTrace holds hooks invoked by RetryTransport. Attach it to a request
+// context with WithRetryTrace.
+type RetryTrace struct {
+	// Retry is called before RetryTransport waits to resend a request.
+	Retry func(attempt RetryAttempt)
+}
+
+type (
+	retryTraceKey  struct{}
+	maxAttemptsKey struct{}
+)
+
+// NewRetryTransport wraps base with the retry policy.
+func NewRetryTransport(base http.RoundTripper, policy RetryPolicy) *RetryTransport {
+	return &RetryTransport{Base: base, Policy: policy}
+}
+
+// WithRetryTrace returns a context whose requests report their retries to trace.
+func WithRetryTrace(ctx context.Context, trace *RetryTrace) context.Context {
+	return context.WithValue(ctx, retryTraceKey{}, trace)
+}
+
+// WithMaxAttempts overrides the policy's attempt budget for requests made
+// with the returned context. Values below 1 are ignored.
+func WithMaxAttempts(ctx context.Context, attempts int) context.Context {
+	if attempts < 1 {
+		return ctx
+	}
+	return context.WithValue(ctx, maxAttemptsKey{}, attempts)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx := req.Context()
+	maxAttempts := t.Policy.MaxAttempts
+	if attempts, ok := ctx.Value(maxAttemptsKey{}).(int); ok {
+		maxAttempts = attempts
+	}
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.try(base, req)
+		if attempt >= maxAttempts || !replayable || ctx.Err() != nil || !shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		delay, ok := t.delay(attempt, resp)
+		if !ok {
+			return resp, err
+		}
+		if deadline, set := ctx.Deadline(); set && time.Until(deadline) < delay {
+			// The caller would give up before the next try is sent.
+			return resp, err
+		}
+
+		statusCode := 0
+		if resp != nil {
+			statusCode = resp.StatusCode
+			// Drain a little of the body so the connection can be reused.
+			io.CopyN(io.Discard, resp.Body, 4096)
+			resp.Body.Close()
+		}
+
+		if trace, _ := ctx.Value(retryTraceKey{}).(*RetryTrace); trace != nil && trace.Retry != nil {
+			trace.Retry(RetryAttempt{
+				Method:      req.Method,
+				Host:        req.URL.Host,
+				Attempt:     attempt,
+				MaxAttempts: maxAttempts,
+				StatusCode:  statusCode,
+				Err:         err,
+				Delay:       delay,
+			})
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// try sends req once, within the policy's AttemptTimeout. The timeout
+// keeps running while the body is read and ends when it is closed.
+func (t *RetryTransport) try(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	if t.Policy.AttemptTimeout <= 0 {
+		return base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.Policy.AttemptTimeout)
+	resp, err := base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody releases the context of a try once its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// delay returns how long to wait before the next try. The server's own
+// estimate wins over the backoff; ok is false when it exceeds MaxDelay.
+func (t *RetryTransport) delay(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if wait, found := serverDelay(resp.Header); found {
+			return wait, wait <= t.Policy.MaxDelay
+		}
+	}
+
+	backoff := t.Policy.BaseDelay << (attempt - 1)
+	if backoff <= 0 || backoff > t.Policy.MaxDelay {
+		backoff = t.Policy.MaxDelay
+	}
+	// Equal jitter keeps at least half of the backoff while spreading
+	// concurrent clients apart.
+	half := backoff / 2
+	return half + rand.N(half+1), true
+}
+
+// shouldRetry reports whether the outcome of req is worth another try.
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	idempotent := isIdempotent(req)
+
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			// An unknown host will not appear by trying again.
+			return false
+		}
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			// Nothing reached the server.
+			return true
+		}
+		return idempotent
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable, statusOverloaded:
+		return true
+	}
+	return idempotent && RetryableStatus(resp.StatusCode)
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// rateLimitHeaders pairs the "remaining" and "reset" headers providers send
+// for each rate limit. The reset is only relevant once the limit is used up.
+var rateLimitHeaders = [][2]string{
+	{"X-Ratelimit-Remaining-Requests", "X-Ratelimit-Reset-Requests"},
+	{"X-Ratelimit-Remaining-Tokens", "X-Ratelimit-Reset-Tokens"},
+	{"Anthropic-Ratelimit-Requests-Remaining", "Anthropic-Ratelimit-Requests-Reset"},
+	{"Anthropic-Ratelimit-Tokens-Remaining", "Anthropic-Ratelimit-Tokens-Reset"},
+	{"Anthropic-Ratelimit-Input-Tokens-Remaining", "Anthropic-Ratelimit-Input-Tokens-Reset"},
+	{"Anthropic-Ratelimit-Output-Tokens-Remaining", "Anthropic-Ratelimit-Output-Tokens-Reset"},
+}
+
+// serverDelay reads how long the server asked the client to wait, from
+// Retry-After, the millisecond variant OpenAI sends, or the reset time of an
+// exhausted provider rate limit.
+func serverDelay(header http.Header) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil && ms >= 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+	if wait, ok := parseWait(header.Get("Retry-After")); ok {
+		return wait, true
+	}
+
+	var longest time.Duration
+	found := false
+	for _, pair := range rateLimitHeaders {
+		if strings.TrimSpace(header.Get(pair[0])) != "0" {
+			continue
+		}
+		if wait, ok := parseWait(header.Get(pair[1])); ok {
+			longest = max(longest, wait)
+			found = true
+		}
+	}
+	return longest, found
+}
+
+// parseWait understands the formats used for waits: seconds ("2", "0.5"), Go
+// style durations ("1m30s", "250ms"), HTTP dates and RFC 3339 timestamps.
+func parseWait(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if wait, err := time.ParseDuration(value); err == nil && wait >= 0 {
+		return wait, true
+	}
+
+	for _, parse := range []func(string) (time.Time, error){
+		http.ParseTime,
+		func(v string) (time.Time, error) { return time.Parse(time.RFC3339, v) },
+	} {
+		if at, err := parse(value); err == nil {
+			return max(time.Until(at), 0), true
+		}
+	}
+	return 0, false
+}
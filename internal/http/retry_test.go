@@ -0,0 +1,199 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 50 * time.Millisecond}
+
+func newTestClient() *http.Client {
+	return &http.Client{Transport: NewRetryTransport(http.DefaultTransport, testPolicy)}
+}
+
+func TestRetryTransportRetriesRateLimitedPost(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"prompt":"diff"}` {
+			t.Errorf("request body was not replayed, got %q", body)
+		}
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+
+	var retries []RetryAttempt
+	ctx := WithRetryTrace(context.Background(), &RetryTrace{
+		Retry: func(attempt RetryAttempt) { retries = append(retries, attempt) },
+	})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader(`{"prompt":"diff"}`))
+
+	resp, err := newTestClient().Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Fatalf("expected success on the third try, got %d after %d calls", resp.StatusCode, calls.Load())
+	}
+	if len(retries) != 2 || retries[0].StatusCode != http.StatusTooManyRequests || retries[1].Attempt != 2 || retries[1].MaxAttempts != 3 {
+		t.Fatalf("unexpected retry trace: %+v", retries)
+	}
+}
+
+func TestRetryTransportDoesNotRepeatProcessedPost(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+
+	resp, err := newTestClient().Post(server.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if calls.Load() != 1 {
+		t.Fatalf("expected a single POST for a 500, got %d", calls.Load())
+	}
+
+	// GET requests are idempotent and may be repeated.
+	resp, err = newTestClient().Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if calls.Load() != 4 {
+		t.Fatalf("expected the GET to be sent 3 times, got %d calls in total", calls.Load())
+	}
+}
+
+func TestRetryTransportHonorsAttemptBudget(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	req, _ := http.NewRequestWithContext(WithMaxAttempts(context.Background(), 5), http.MethodPost, server.URL, strings.NewReader("{}"))
+	resp, err := newTestClient().Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 5 {
+		t.Fatalf("expected the last 503 after 5 tries, got %d after %d calls", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestRetryTransportGivesUpOnLongRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(server.Close)
+
+	resp, err := newTestClient().Post(server.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTooManyRequests || calls.Load() != 1 {
+		t.Fatalf("expected the 429 to be returned without waiting, got %d after %d calls", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestRetryTransportTimesOutEachTry(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After-Ms", "150")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+
+	// The waits add up to more than one try may take.
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second, AttemptTimeout: 100 * time.Millisecond}
+	client := &http.Client{Transport: NewRetryTransport(http.DefaultTransport, policy)}
+
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != "ok" || calls.Load() != 3 {
+		t.Fatalf("expected the third try to succeed, got %q, %v after %d calls", body, err, calls.Load())
+	}
+}
+
+func TestServerDelay(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+		found  bool
+	}{
+		{"retry-after seconds", http.Header{"Retry-After": {"2"}}, 2 * time.Second, true},
+		{"retry-after-ms wins", http.Header{"Retry-After": {"2"}, "Retry-After-Ms": {"150"}}, 150 * time.Millisecond, true},
+		{"openai reset", http.Header{"X-Ratelimit-Remaining-Requests": {"0"}, "X-Ratelimit-Reset-Requests": {"1m30s"}}, 90 * time.Second, true},
+		{"reset of an open limit", http.Header{"X-Ratelimit-Remaining-Tokens": {"120"}, "X-Ratelimit-Reset-Tokens": {"6s"}}, 0, false},
+		{"longest exhausted limit", http.Header{
+			"X-Ratelimit-Remaining-Requests": {"0"}, "X-Ratelimit-Reset-Requests": {"2s"},
+			"X-Ratelimit-Remaining-Tokens": {"0"}, "X-Ratelimit-Reset-Tokens": {"7.5s"},
+		}, 7500 * time.Millisecond, true},
+		{"nothing", http.Header{}, 0, false},
+	}
+
+	for _, tc := range cases {
+		got, found := serverDelay(tc.header)
+		if got != tc.want || found != tc.found {
+			t.Fatalf("%s: serverDelay = %v, %v; want %v, %v", tc.name, got, found, tc.want, tc.found)
+		}
+	}
+
+	reset := time.Now().Add(10 * time.Second).UTC().Format(time.RFC3339)
+	got, found := serverDelay(http.Header{
+		"Anthropic-Ratelimit-Requests-Remaining": {"0"},
+		"Anthropic-Ratelimit-Requests-Reset":     {reset},
+	})
+	if !found || got <= 8*time.Second || got > 10*time.Second {
+		t.Fatalf("expected about 10s from the Anthropic reset timestamp, got %v (%v)", got, found)
+	}
+}
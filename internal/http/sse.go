@@ -0,0 +1,76 @@
+package http
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// ServerSentEvent is a single event parsed from a text/event-stream body.
+type ServerSentEvent struct {
+	Event string
+	Data  string
+}
+
+// ReadServerSentEvents parses a text/event-stream body and invokes handle for
+// every complete event. Returning a non-nil error from handle stops reading;
+// io.EOF is treated as a clean stop and is not returned to the caller.
+func ReadServerSentEvents(body io.Reader, handle func(ServerSentEvent) error) error {
+	scanner := bufio.NewScanner(body)
+	// Allow large single-line payloads from chatty providers
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var event ServerSentEvent
+	var data []string
+
+	dispatch := func() error {
+		if len(data) == 0 && event.Event == "" {
+			return nil
+		}
+		event.Data = strings.Join(data, "\n")
+		err := handle(event)
+		event = ServerSentEvent{}
+		data = data[:0]
+		return err
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		if line == "" {
+			if err := dispatch(); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+			continue
+		}
+
+		// Lines starting with a colon are comments (often keep-alives)
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	// Flush a trailing event that was not terminated by a blank line
+	if err := dispatch(); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
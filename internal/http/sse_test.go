@@ -0,0 +1,77 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadServerSentEvents(t *testing.T) {
+	t.Parallel()
+
+	t.Run("parses events and data lines", func(t *testing.T) {
+		t.Parallel()
+
+		body := ": keep-alive\n" +
+			"event: content_block_delta\n" +
+			"data: {\"text\":\"feat\"}\n\n" +
+			"data: first\n" +
+			"data: second\r\n\r\n" +
+			"data: [DONE]"
+
+		var events []ServerSentEvent
+		err := ReadServerSentEvents(strings.NewReader(body), func(event ServerSentEvent) error {
+			events = append(events, event)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(events) != 3 {
+			t.Fatalf("expected 3 events, got %d: %+v", len(events), events)
+		}
+
+		if events[0].Event != "content_block_delta" || events[0].Data != `{"text":"feat"}` {
+			t.Fatalf("unexpected first event: %+v", events[0])
+		}
+
+		if events[1].Event != "" || events[1].Data != "first\nsecond" {
+			t.Fatalf("unexpected multi-line event: %+v", events[1])
+		}
+
+		if events[2].Data != "[DONE]" {
+			t.Fatalf("expected trailing event to be flushed, got %+v", events[2])
+		}
+	})
+
+	t.Run("stops cleanly on io.EOF", func(t *testing.T) {
+		t.Parallel()
+
+		body := "data: one\n\ndata: two\n\n"
+		calls := 0
+		err := ReadServerSentEvents(strings.NewReader(body), func(ServerSentEvent) error {
+			calls++
+			return io.EOF
+		})
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if calls != 1 {
+			t.Fatalf("expected handler to stop after first event, got %d calls", calls)
+		}
+	})
+
+	t.Run("propagates handler errors", func(t *testing.T) {
+		t.Parallel()
+
+		boom := errors.New("boom")
+		err := ReadServerSentEvents(strings.NewReader("data: x\n\n"), func(ServerSentEvent) error {
+			return boom
+		})
+		if !errors.Is(err, boom) {
+			t.Fatalf("expected handler error, got %v", err)
+		}
+	})
+}
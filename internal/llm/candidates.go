@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+// MaxCandidates bounds how many alternative messages GenerateCandidates asks for.
+const MaxCandidates = 5
+
+// CandidateProvider is implemented by providers that can return several
+// alternative commit messages from a single request, such as OpenAI's n
+// parameter or Gemini's candidate count.
+type CandidateProvider interface {
+	Provider
+	// GenerateCandidates asks for n alternative messages at once. Backends
+	// may return fewer than n. The usage of the request is reported on the
+	// first result.
+	GenerateCandidates(ctx context.Context, changes string, opts *types.GenerationOptions, n int) ([]types.GenerationResult, error)
+}
+
+// GenerateCandidates asks provider for n alternative commit messages. A
+// CandidateProvider is asked natively; missing candidates are generated with
+// parallel Generate calls. Each result carries the usage of the request that
+// produced it, if any, so the total is the sum over all results. Candidates
+// are returned as long as at least one request succeeds.
+func GenerateCandidates(ctx context.Context, provider Provider, changes string, opts *types.GenerationOptions, n int) ([]types.GenerationResult, error) {
+	n = min(max(n, 1), MaxCandidates)
+
+	var results []types.GenerationResult
+	if native, ok := provider.(CandidateProvider); ok && n > 1 {
+		var err error
+		results, err = native.GenerateCandidates(ctx, changes, opts, n)
+		if err != nil {
+			return nil, err
+		}
+		if len(results) >= n {
+			return results[:n], nil
+		}
+	}
+
+	missing := n - len(results)
+	extra := make([]types.GenerationResult, missing)
+	errs := make([]error, missing)
+
+	var wg sync.WaitGroup
+	for i := range missing {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			extra[i], errs[i] = provider.Generate(ctx, changes, opts)
+		}()
+	}
+	wg.Wait()
+
+	var firstErr error
+	for i, result := range extra {
+		if errs[i] != nil {
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+			continue
+		}
+		results = append(results, result)
+	}
+
+	if len(results) == 0 {
+		return nil, firstErr
+	}
+	return results, nil
+}
@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+type countingProvider struct {
+	calls atomic.Int32
+	fail  bool
+}
+
+func (c *countingProvider) Name() types.LLMProvider {
+	return types.ProviderOllama
+}
+
+func (c *countingProvider) Generate(context.Context, string, *types.GenerationOptions) (types.GenerationResult, error) {
+	call := c.calls.Add(1)
+	if c.fail && call > 1 {
+		return types.GenerationResult{}, errors.New("boom")
+	}
+	return types.GenerationResult{Message: "feat: candidate", Usage: types.NewUsageInfo(100, 10)}, nil
+}
+
+type nativeCandidateProvider struct {
+	countingProvider
+	returned int
+	asked    int
+}
+
+func (n *nativeCandidateProvider) GenerateCandidates(_ context.Context, _ string, _ *types.GenerationOptions, count int) ([]types.GenerationResult, error) {
+	n.asked = count
+	results := make([]types.GenerationResult, n.returned)
+	for i := range results {
+		results[i] = types.GenerationResult{Message: "fix: native"}
+	}
+	return results, nil
+}
+
+func TestGenerateCandidatesRunsParallelCalls(t *testing.T) {
+	t.Parallel()
+
+	provider := &countingProvider{}
+	results, err := GenerateCandidates(context.Background(), provider, "diff", nil, 3)
+	if err != nil {
+		t.Fatalf("GenerateCandidates returned error: %v", err)
+	}
+	if len(results) != 3 || provider.calls.Load() != 3 {
+		t.Fatalf("expected 3 candidates from 3 calls, got %d from %d", len(results), provider.calls.Load())
+	}
+}
+
+func TestGenerateCandidatesPrefersNativeRequests(t *testing.T) {
+	t.Parallel()
+
+	provider := &nativeCandidateProvider{returned: 2}
+	results, err := GenerateCandidates(context.Background(), provider, "diff", nil, 3)
+	if err != nil {
+		t.Fatalf("GenerateCandidates returned error: %v", err)
+	}
+	if provider.asked != 3 {
+		t.Fatalf("expected the provider to be asked for 3 candidates, got %d", provider.asked)
+	}
+	if len(results) != 3 || results[0].Message != "fix: native" || provider.calls.Load() != 1 {
+		t.Fatalf("expected the missing candidate to be generated separately, got %d results and %d calls", len(results), provider.calls.Load())
+	}
+}
+
+func TestGenerateCandidatesKeepsPartialResults(t *testing.T) {
+	t.Parallel()
+
+	provider := &countingProvider{fail: true}
+	results, err := GenerateCandidates(context.Background(), provider, "diff", nil, 3)
+	if err != nil {
+		t.Fatalf("expected the successful candidate to be kept, got %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 candidate, got %d", len(results))
+	}
+
+	results, err = GenerateCandidates(context.Background(), provider, "diff", nil, 2)
+	if err == nil || results != nil {
+		t.Fatalf("expected an error when every request fails, got %v, %v", results, err)
+	}
+}
+
+func TestGenerateCandidatesClampsCount(t *testing.T) {
+	t.Parallel()
+
+	provider := &countingProvider{}
+	results, _ := GenerateCandidates(context.Background(), provider, "diff", nil, MaxCandidates+3)
+	if len(results) != MaxCandidates {
+		t.Fatalf("expected at most %d candidates, got %d", MaxCandidates, len(results))
+	}
+}
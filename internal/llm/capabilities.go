@@ -0,0 +1,192 @@
+package llm
+
+import (
+	"os"
+	"strings"
+
+	"github.com/dfanso/commit-msg/internal/cassette"
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+// Capabilities describes what a model can take and what its provider can do
+// with it. Streams and Structured consult Streaming and JSON before using a
+// built-in provider's optional interfaces.
+type Capabilities struct {
+	// ContextWindow is the most tokens the prompt and the answer may hold
+	// together.
+	ContextWindow int
+	// MaxOutputTokens is the most tokens the model writes in one answer.
+	MaxOutputTokens int
+	// Streaming reports whether the answer can be shown as it is generated.
+	Streaming bool
+	// JSON reports whether the answer can be requested as a structured
+	// commit message.
+	JSON bool
+}
+
+// answerReserve is the part of the context window kept for the answer.
+// Commit messages are short, so models that write much longer answers do
+// not need their whole output limit set aside.
+const answerReserve = 1024
+
+// minDiffBudget keeps some of the diff in the prompt when the window is
+// too small for the prompt itself.
+const minDiffBudget = 256
+
+// DiffBudget returns how many tokens of diff fit in the context window next
+// to overhead tokens of prompt and the answer.
+func (c Capabilities) DiffBudget(overhead int) int {
+	budget := c.ContextWindow - overhead - min(c.MaxOutputTokens, answerReserve)
+	return max(budget, minDiffBudget)
+}
+
+// anyModel is the model key of the limits used when no model entry matches.
+const anyModel = "*"
+
+// limits are the token limits of a model.
+type limits struct {
+	contextWindow   int
+	maxOutputTokens int
+}
+
+// providerCapabilities records what a provider's implementation supports
+// and the limits of its models.
+type providerCapabilities struct {
+	streaming bool
+	json      bool
+	models    map[string]limits
+}
+
+// openAILimits are shared by Azure OpenAI, whose deployments are usually
+// named after their model.
+var openAILimits = map[string]limits{
+	"gpt-4o":      {128_000, 16_384},
+	"gpt-4o-mini": {128_000, 16_384},
+	"gpt-4.1":     {1_047_576, 32_768},
+	"o3":          {200_000, 100_000},
+	"o4-mini":     {200_000, 100_000},
+	anyModel:      {128_000, 16_384},
+}
+
+// geminiLimits are shared by the Gemini API and Vertex AI.
+var geminiLimits = map[string]limits{
+	"gemini-2.0-flash":      {1_048_576, 8_192},
+	"gemini-2.0-flash-lite": {1_048_576, 8_192},
+	"gemini-2.5-flash":      {1_048_576, 65_536},
+	"gemini-2.5-pro":        {1_048_576, 65_536},
+	anyModel:                {1_048_576, 8_192},
+}
+
+// unknownLimits apply to servers and plugins whose models are not known.
+// They are small enough for most local models.
+var unknownLimits = limits{8_192, 4_096}
+
+// ollamaContextWindow is Ollama's num_ctx when none is configured.
+const ollamaContextWindow = 4_096
+
+// capabilityRegistry holds the capabilities of the built-in providers.
+// Model keys match the longest model name they prefix, so dated snapshots
+// find their model; anyModel holds the provider-wide limits.
+var capabilityRegistry = map[types.LLMProvider]providerCapabilities{
+	types.ProviderOpenAI: {streaming: true, json: true, models: openAILimits},
+	types.ProviderClaude: {streaming: true, json: true, models: map[string]limits{
+		"claude-3-haiku":    {200_000, 4_096},
+		"claude-3-5-haiku":  {200_000, 8_192},
+		"claude-3-5-sonnet": {200_000, 8_192},
+		"claude-3-7-sonnet": {200_000, 64_000},
+		"claude-sonnet-4":   {200_000, 64_000},
+		"claude-opus-4":     {200_000, 32_000},
+		anyModel:            {200_000, 8_192},
+	}},
+	types.ProviderGemini: {json: true, models: geminiLimits},
+	types.ProviderGrok: {streaming: true, models: map[string]limits{
+		"grok-3": {131_072, 16_384},
+		"grok-4": {256_000, 16_384},
+		anyModel: {131_072, 16_384},
+	}},
+	types.ProviderGroq: {streaming: true, models: map[string]limits{
+		"llama-3.3-70b-versatile": {131_072, 32_768},
+		"llama-3.1-8b-instant":    {131_072, 8_192},
+		"openai/gpt-oss":          {131_072, 65_536},
+		anyModel:                  {131_072, 8_192},
+	}},
+	types.ProviderOllama: {streaming: true, models: map[string]limits{
+		anyModel: {ollamaContextWindow, ollamaContextWindow},
+	}},
+
+	types.ProviderOpenAICompatible: {streaming: true, models: map[string]limits{anyModel: unknownLimits}},
+	types.ProviderAzureOpenAI:      {streaming: true, models: openAILimits},
+	types.ProviderBedrock: {models: map[string]limits{
+		"anthropic.claude-3-haiku":    {200_000, 4_096},
+		"anthropic.claude-3-5-haiku":  {200_000, 8_192},
+		"anthropic.claude-3-5-sonnet": {200_000, 8_192},
+		"amazon.nova-lite":            {300_000, 5_120},
+		"amazon.nova-pro":             {300_000, 5_120},
+		"meta.llama3-1-8b-instruct":   {128_000, 2_048},
+		anyModel:                      {200_000, 4_096},
+	}},
+	types.ProviderVertexAI: {streaming: true, models: geminiLimits},
+}
+
+// LookupCapabilities returns the capabilities of model on provider. An
+// exact match wins, then the longest model key that prefixes model, then
+// the provider-wide limits. Providers missing from the registry, such as
+// plugins, get conservative limits and neither streaming nor JSON.
+func LookupCapabilities(provider types.LLMProvider, model string) Capabilities {
+	entry, ok := capabilityRegistry[provider]
+	if !ok {
+		return Capabilities{ContextWindow: unknownLimits.contextWindow, MaxOutputTokens: unknownLimits.maxOutputTokens}
+	}
+
+	model = strings.ToLower(strings.TrimSpace(model))
+	found, ok := entry.models[model]
+	if !ok || model == "" {
+		best := ""
+		for key := range entry.models {
+			if key != anyModel && len(key) > len(best) && strings.HasPrefix(model, key) {
+				best = key
+			}
+		}
+		if best == "" {
+			best = anyModel
+		}
+		found = entry.models[best]
+	}
+
+	return Capabilities{
+		ContextWindow:   found.contextWindow,
+		MaxOutputTokens: found.maxOutputTokens,
+		Streaming:       entry.streaming,
+		JSON:            entry.json,
+	}
+}
+
+// ResolveCapabilities returns the capabilities of the model that provider
+// would use with credential and settings. Ollama's configured num_ctx
+// replaces its default window, and a cassette answers with the
+// capabilities of the provider it was recorded with.
+func ResolveCapabilities(provider types.LLMProvider, credential string, settings types.ProviderSettings) Capabilities {
+	if provider == types.ProviderCassette {
+		path := strings.TrimSpace(credential)
+		if path == "" {
+			path = strings.TrimSpace(os.Getenv(cassette.EnvPath))
+		}
+		if recorded, err := cassette.Load(path); err == nil && recorded.Provider != types.ProviderCassette {
+			var recordedSettings types.ProviderSettings
+			if recorded.Settings != nil {
+				recordedSettings = *recorded.Settings
+			}
+			if recorded.Model != "" {
+				recordedSettings.Model = recorded.Model
+			}
+			return ResolveCapabilities(recorded.Provider, "", recordedSettings)
+		}
+	}
+
+	capabilities := LookupCapabilities(provider, ResolveModel(provider, settings))
+	if provider == types.ProviderOllama && settings.Ollama != nil && settings.Ollama.NumCtx > 0 {
+		capabilities.ContextWindow = settings.Ollama.NumCtx
+		capabilities.MaxOutputTokens = settings.Ollama.NumCtx
+	}
+	return capabilities
+}
@@ -0,0 +1,138 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dfanso/commit-msg/internal/cassette"
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+func TestLookupCapabilities(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		provider types.LLMProvider
+		model    string
+		window   int
+	}{
+		{types.ProviderOpenAI, "gpt-4o-mini-2024-07-18", 128_000},
+		{types.ProviderOpenAI, "GPT-4.1-nano", 1_047_576},
+		{types.ProviderAzureOpenAI, "my-deployment", 128_000},
+		{types.ProviderClaude, "claude-sonnet-4-20250514", 200_000},
+		{types.ProviderOllama, "llama3.1", 4_096},
+		{types.ProviderOpenAICompatible, "local-model", 8_192},
+		{"SomePlugin", "anything", 8_192},
+	}
+	for _, tc := range cases {
+		if got := LookupCapabilities(tc.provider, tc.model).ContextWindow; got != tc.window {
+			t.Fatalf("context window of %s %s = %d, want %d", tc.provider, tc.model, got, tc.window)
+		}
+	}
+
+	if got := LookupCapabilities(types.ProviderClaude, "claude-3-7-sonnet-20250219").MaxOutputTokens; got != 64_000 {
+		t.Fatalf("unexpected max output tokens %d", got)
+	}
+}
+
+func TestDiffBudget(t *testing.T) {
+	t.Parallel()
+
+	if got := (Capabilities{ContextWindow: 128_000, MaxOutputTokens: 16_384}).DiffBudget(1_000); got != 128_000-1_000-answerReserve {
+		t.Fatalf("unexpected budget %d", got)
+	}
+	if got := (Capabilities{ContextWindow: 2_048, MaxOutputTokens: 512}).DiffBudget(1_000); got != 2_048-1_000-512 {
+		t.Fatalf("expected a small output limit to be reserved whole, got %d", got)
+	}
+	if got := (Capabilities{ContextWindow: 1_024, MaxOutputTokens: 1_024}).DiffBudget(1_000); got != minDiffBudget {
+		t.Fatalf("expected the minimum budget, got %d", got)
+	}
+}
+
+func TestResolveCapabilities(t *testing.T) {
+	t.Setenv("OLLAMA_MODEL", "")
+	t.Setenv(cassette.EnvPath, "")
+
+	ollama := types.ProviderSettings{Ollama: &types.OllamaSettings{NumCtx: 32_768}}
+	if got := ResolveCapabilities(types.ProviderOllama, "", ollama).ContextWindow; got != 32_768 {
+		t.Fatalf("expected num_ctx to set the window, got %d", got)
+	}
+
+	path := writeTestCassette(t, types.ProviderClaude)
+	if got := ResolveCapabilities(types.ProviderCassette, path, types.ProviderSettings{}); got != LookupCapabilities(types.ProviderClaude, "test-model") {
+		t.Fatalf("expected the recorded provider's capabilities, got %+v", got)
+	}
+}
+
+func TestCapabilityRegistryMatchesProviders(t *testing.T) {
+	t.Parallel()
+
+	for _, provider := range types.GetSupportedProviders() {
+		if _, isPlugin := LookupPlugin(provider); isPlugin || provider == types.ProviderCassette {
+			continue
+		}
+		entry, ok := capabilityRegistry[provider]
+		if !ok {
+			t.Fatalf("%s has no capabilities", provider)
+		}
+		if _, ok := entry.models[anyModel]; !ok {
+			t.Fatalf("%s has no provider-wide limits", provider)
+		}
+
+		instance, err := newReplayDelegate(&cassette.Cassette{Provider: provider, Model: "test-model"}, nil)
+		if err != nil {
+			t.Fatalf("failed to create %s: %v", provider, err)
+		}
+		_, streaming := instance.(StreamingProvider)
+		_, structured := instance.(StructuredProvider)
+		if entry.streaming != streaming || entry.json != structured {
+			t.Fatalf("%s records streaming=%v json=%v, implements streaming=%v json=%v", provider, entry.streaming, entry.json, streaming, structured)
+		}
+	}
+}
+
+// namedStreamingProvider streams and returns structured messages under any
+// provider name.
+type namedStreamingProvider struct {
+	name types.LLMProvider
+}
+
+func (p namedStreamingProvider) Name() types.LLMProvider { return p.name }
+
+func (p namedStreamingProvider) Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return types.GenerationResult{Message: "feat: add"}, nil
+}
+
+func (p namedStreamingProvider) GenerateStream(ctx context.Context, changes string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	return p.Generate(ctx, changes, opts)
+}
+
+func (p namedStreamingProvider) GenerateStructured(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return p.Generate(ctx, changes, opts)
+}
+
+func TestCapabilitiesFollowRegistry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		provider   types.LLMProvider
+		streams    bool
+		structured bool
+	}{
+		{types.ProviderOpenAI, true, true},
+		{types.ProviderGemini, false, true},
+		{types.ProviderGroq, true, false},
+		{types.ProviderBedrock, false, false},
+		// Providers outside the registry are judged by their interfaces.
+		{"gateway", true, true},
+	}
+	for _, tt := range tests {
+		provider := namedStreamingProvider{name: tt.provider}
+		if got := Streams(provider); got != tt.streams {
+			t.Errorf("%s: expected Streams %v, got %v", tt.provider, tt.streams, got)
+		}
+		if got := Structured(provider); got != tt.structured {
+			t.Errorf("%s: expected Structured %v, got %v", tt.provider, tt.structured, got)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/dfanso/commit-msg/internal/bedrock"
+	"github.com/dfanso/commit-msg/internal/cassette"
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/internal/vertex"
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+// cassettePlaceholder stands in for the credentials of a replayed provider.
+// Cassettes hold no credentials and replays never check them.
+const cassettePlaceholder = "cassette"
+
+// cassettePlaceholderURL stands in for endpoints a provider cannot do
+// without. Replays ignore the host of every request.
+const cassettePlaceholderURL = "https://cassette.invalid"
+
+// The Cassette factory looks up the factory of the recorded provider, so it
+// cannot be part of the factories literal.
+func init() {
+	factories[types.ProviderCassette] = newCassetteProvider
+}
+
+var (
+	recorderMu sync.RWMutex
+	recorder   *cassette.Recorder
+)
+
+// RecordTo describes every provider created from now on to r, so the
+// cassette says which provider the Cassette provider replays through. A
+// nil r stops the descriptions.
+func RecordTo(r *cassette.Recorder) {
+	recorderMu.Lock()
+	defer recorderMu.Unlock()
+	recorder = r
+}
+
+func activeRecorder() *cassette.Recorder {
+	recorderMu.RLock()
+	defer recorderMu.RUnlock()
+	return recorder
+}
+
+// cassetteProvider replays a cassette through the provider that recorded
+// it. The recorded provider builds its requests as usual and they are
+// answered from the cassette, so no network is needed and the provider's
+// parsing runs on the recorded responses.
+type cassetteProvider struct {
+	replayer *cassette.Replayer
+	delegate Provider
+}
+
+// newCassetteProvider reads the cassette path from opts.Credential or
+// COMMIT_CASSETTE.
+func newCassetteProvider(opts ProviderOptions) (Provider, error) {
+	path := strings.TrimSpace(opts.Credential)
+	if path == "" {
+		path = strings.TrimSpace(os.Getenv(cassette.EnvPath))
+	}
+	if path == "" {
+		return nil, newMissingCredentialError(types.ProviderCassette)
+	}
+
+	replayer, err := cassette.LoadReplayer(path)
+	if err != nil {
+		return nil, err
+	}
+	delegate, err := newReplayDelegate(replayer.Cassette(), opts.Config)
+	if err != nil {
+		return nil, fmt.Errorf("cassette %s: %w", path, err)
+	}
+	return &cassetteProvider{replayer: replayer, delegate: delegate}, nil
+}
+
+// newReplayDelegate builds the recorded provider with the recorded settings
+// and placeholder credentials.
+func newReplayDelegate(recorded *cassette.Cassette, config *types.Config) (Provider, error) {
+	if recorded.Provider == "" || recorded.Provider == types.ProviderCassette {
+		return nil, fmt.Errorf("llm: the cassette does not say which provider recorded it")
+	}
+
+	var settings types.ProviderSettings
+	if recorded.Settings != nil {
+		settings = *recorded.Settings
+	}
+	if recorded.Model != "" {
+		settings.Model = recorded.Model
+	}
+	opts := ProviderOptions{Credential: cassettePlaceholder, Config: config, Settings: settings}
+
+	switch recorded.Provider {
+	case types.ProviderOllama:
+		// Ollama's credential is its URL.
+		opts.Credential = ""
+	case types.ProviderOpenAICompatible, types.ProviderAzureOpenAI:
+		if opts.Settings.BaseURL == "" {
+			opts.Settings.BaseURL = cassettePlaceholderURL
+		}
+	case types.ProviderBedrock:
+		creds := bedrock.Credentials{AccessKeyID: cassettePlaceholder, SecretAccessKey: cassettePlaceholder}
+		return newBedrockProviderWithCredentials(opts, creds), nil
+	case types.ProviderVertexAI:
+		var vertexSettings types.VertexSettings
+		if settings.Vertex != nil {
+			vertexSettings = *settings.Vertex
+		}
+		vertexSettings.Auth = vertex.AuthNone
+		if vertexSettings.Project == "" {
+			vertexSettings.Project = cassettePlaceholder
+		}
+		opts.Settings.Vertex = &vertexSettings
+	}
+
+	factoryMu.RLock()
+	factory, ok := factories[recorded.Provider]
+	factoryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("llm: unsupported provider %s", recorded.Provider)
+	}
+	return factory(opts)
+}
+
+// replay routes the requests made with ctx to the cassette.
+func (p *cassetteProvider) replay(ctx context.Context) context.Context {
+	return internalHTTP.WithInterceptor(ctx, p.replayer)
+}
+
+func (p *cassetteProvider) Name() types.LLMProvider {
+	return types.ProviderCassette
+}
+
+func (p *cassetteProvider) Model() string {
+	return ProviderModel(p.delegate)
+}
+
+func (p *cassetteProvider) ListModels(ctx context.Context) ([]string, error) {
+	if lister, ok := p.delegate.(ModelLister); ok {
+		return lister.ListModels(p.replay(ctx))
+	}
+	return KnownModels(p.delegate.Name()), nil
+}
+
+// streams reports whether the recorded provider streams.
+func (p *cassetteProvider) streams() bool {
+	return Streams(p.delegate)
+}
+
+// structured reports whether the recorded provider returns structured
+// messages.
+func (p *cassetteProvider) structured() bool {
+	return Structured(p.delegate)
+}
+
+func (p *cassetteProvider) Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return p.delegate.Generate(p.replay(ctx), changes, opts)
+}
+
+// GenerateCandidates asks the recorded provider the way GenerateCandidates
+// asked it while recording.
+func (p *cassetteProvider) GenerateCandidates(ctx context.Context, changes string, opts *types.GenerationOptions, n int) ([]types.GenerationResult, error) {
+	return GenerateCandidates(p.replay(ctx), p.delegate, changes, opts, n)
+}
+
+// GenerateStructured asks the recorded provider the way
+// GenerateCommitMessage asked it while recording.
+func (p *cassetteProvider) GenerateStructured(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return GenerateCommitMessage(p.replay(ctx), p.delegate, changes, opts)
+}
+
+// GenerateStream streams from a recorded stream. The response of a provider
+// that cannot stream is delivered as a single chunk.
+func (p *cassetteProvider) GenerateStream(ctx context.Context, changes string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	if streaming, ok := p.delegate.(StreamingProvider); ok && Streams(p.delegate) {
+		return streaming.GenerateStream(p.replay(ctx), changes, opts, onChunk)
+	}
+
+	result, err := p.Generate(ctx, changes, opts)
+	if err == nil && onChunk != nil {
+		onChunk(result.Message)
+	}
+	return result, err
+}
@@ -0,0 +1,103 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dfanso/commit-msg/internal/cassette"
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+// writeTestCassette saves a cassette recorded with provider, without any
+// exchanges, and returns its path.
+func writeTestCassette(t *testing.T, provider types.LLMProvider) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	c := cassette.Cassette{Version: cassette.Version, Provider: provider, Model: "test-model"}
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCassetteProviderReplaysRecording(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("unexpected Authorization %q", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"fix: replay the session"}}],"usage":{"prompt_tokens":12,"completion_tokens":5}}`))
+	}))
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	recorder := cassette.NewRecorder(path)
+	RecordTo(recorder)
+	defer RecordTo(nil)
+
+	recorded, err := NewProvider(types.ProviderOpenAICompatible, ProviderOptions{
+		Credential: "secret",
+		Settings:   types.ProviderSettings{BaseURL: server.URL + "/v1", Model: "local-model", Headers: map[string]string{"X-Token": "hidden"}},
+	})
+	if err != nil {
+		t.Fatalf("NewProvider returned error: %v", err)
+	}
+	RecordTo(nil)
+
+	ctx := internalHTTP.WithInterceptor(context.Background(), recorder)
+	want, err := recorded.Generate(ctx, "diff --git a/main.go b/main.go", nil)
+	if err != nil {
+		t.Fatalf("recording failed: %v", err)
+	}
+	server.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") || strings.Contains(string(data), "hidden") {
+		t.Fatalf("cassette leaks a credential:\n%s", data)
+	}
+
+	provider, err := NewProvider(types.ProviderCassette, ProviderOptions{Credential: path})
+	if err != nil {
+		t.Fatalf("NewProvider(Cassette) returned error: %v", err)
+	}
+	if provider.Name() != types.ProviderCassette || ProviderModel(provider) != "local-model" {
+		t.Fatalf("unexpected replay provider %s %q", provider.Name(), ProviderModel(provider))
+	}
+
+	got, err := provider.Generate(context.Background(), "diff --git a/main.go b/main.go", nil)
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if got.Message != want.Message || got.Usage == nil || got.Usage.PromptTokens != 12 {
+		t.Fatalf("replay returned %+v, want %+v", got, want)
+	}
+}
+
+func TestNewProviderCassette(t *testing.T) {
+	t.Setenv(cassette.EnvPath, "")
+
+	if _, err := NewProvider(types.ProviderCassette, ProviderOptions{}); !errors.Is(err, ErrMissingCredential) {
+		t.Fatalf("expected ErrMissingCredential without a cassette, got %v", err)
+	}
+
+	if _, err := NewProvider(types.ProviderCassette, ProviderOptions{Credential: writeTestCassette(t, "")}); err == nil {
+		t.Fatal("expected an error for a cassette without a provider")
+	}
+
+	for _, recorded := range []types.LLMProvider{types.ProviderOllama, types.ProviderAzureOpenAI, types.ProviderBedrock, types.ProviderVertexAI} {
+		t.Run(recorded.String(), func(t *testing.T) {
+			if _, err := NewProvider(types.ProviderCassette, ProviderOptions{Credential: writeTestCassette(t, recorded)}); err != nil {
+				t.Fatalf("expected %s to replay without credentials, got %v", recorded, err)
+			}
+		})
+	}
+}
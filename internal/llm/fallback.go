@@ -0,0 +1,245 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	openai "github.com/openai/openai-go/v3"
+	"google.golang.org/api/googleapi"
+
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+// FallbackProvider tries a list of providers in order and returns the first
+// commit message produced. It moves on to the next provider only when the
+// current one fails with an error ShouldFallback accepts.
+type FallbackProvider struct {
+	providers []Provider
+}
+
+// FallbackAttempt describes the outcome of one provider tried by a
+// FallbackProvider.
+type FallbackAttempt struct {
+	Provider types.LLMProvider
+	// Model is the model the provider used, when it reports one.
+	Model    string
+	Duration time.Duration
+	// Err is nil when the provider produced the message.
+	Err error
+}
+
+// FallbackTrace holds optional hooks invoked while a FallbackProvider works
+// through its providers. Attach it to a request with WithFallbackTrace.
+type FallbackTrace struct {
+	// AttemptStart is called before each provider is tried; index is 0 for
+	// the primary provider.
+	AttemptStart func(provider types.LLMProvider, index int)
+	// AttemptDone is called after each provider finishes.
+	AttemptDone func(attempt FallbackAttempt)
+}
+
+type fallbackTraceKey struct{}
+
+// NewFallbackProvider chains providers, primary first. At least one provider
+// is required.
+func NewFallbackProvider(providers ...Provider) (*FallbackProvider, error) {
+	if len(providers) == 0 {
+		return nil, errors.New("llm: fallback chain needs at least one provider")
+	}
+	return &FallbackProvider{providers: providers}, nil
+}
+
+// WithFallbackTrace returns a context carrying trace. Hooks of a trace already
+// present in ctx keep being called, after the new ones.
+func WithFallbackTrace(ctx context.Context, trace *FallbackTrace) context.Context {
+	if previous := fallbackTraceFrom(ctx); previous != nil {
+		trace = composeTraces(trace, previous)
+	}
+	return context.WithValue(ctx, fallbackTraceKey{}, trace)
+}
+
+func fallbackTraceFrom(ctx context.Context) *FallbackTrace {
+	trace, _ := ctx.Value(fallbackTraceKey{}).(*FallbackTrace)
+	return trace
+}
+
+func composeTraces(first, second *FallbackTrace) *FallbackTrace {
+	return &FallbackTrace{
+		AttemptStart: func(provider types.LLMProvider, index int) {
+			if first.AttemptStart != nil {
+				first.AttemptStart(provider, index)
+			}
+			if second.AttemptStart != nil {
+				second.AttemptStart(provider, index)
+			}
+		},
+		AttemptDone: func(attempt FallbackAttempt) {
+			if first.AttemptDone != nil {
+				first.AttemptDone(attempt)
+			}
+			if second.AttemptDone != nil {
+				second.AttemptDone(attempt)
+			}
+		},
+	}
+}
+
+// Providers returns the names of the chained providers in the order they are tried.
+func (p *FallbackProvider) Providers() []types.LLMProvider {
+	names := make([]types.LLMProvider, len(p.providers))
+	for i, provider := range p.providers {
+		names[i] = provider.Name()
+	}
+	return names
+}
+
+// Name reports the primary provider.
+func (p *FallbackProvider) Name() types.LLMProvider {
+	return p.providers[0].Name()
+}
+
+// Model reports the primary provider's model.
+func (p *FallbackProvider) Model() string {
+	return ProviderModel(p.providers[0])
+}
+
+// streams reports whether every chained provider streams.
+func (p *FallbackProvider) streams() bool {
+	for _, provider := range p.providers {
+		if !Streams(provider) {
+			return false
+		}
+	}
+	return true
+}
+
+// structured reports whether every chained provider returns structured
+// messages.
+func (p *FallbackProvider) structured() bool {
+	for _, provider := range p.providers {
+		if !Structured(provider) {
+			return false
+		}
+	}
+	return true
+}
+
+// Generate asks each provider in turn until one succeeds.
+func (p *FallbackProvider) Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return p.run(ctx, func(provider Provider) (types.GenerationResult, error) {
+		return provider.Generate(ctx, changes, opts)
+	})
+}
+
+// GenerateStream streams from providers that support it. Providers that do
+// not stream deliver their whole message as a single chunk.
+func (p *FallbackProvider) GenerateStream(ctx context.Context, changes string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	return p.run(ctx, func(provider Provider) (types.GenerationResult, error) {
+		if streamer, ok := provider.(StreamingProvider); ok && Streams(provider) {
+			return streamer.GenerateStream(ctx, changes, opts, onChunk)
+		}
+		result, err := provider.Generate(ctx, changes, opts)
+		if err == nil && onChunk != nil {
+			onChunk(result.Message)
+		}
+		return result, err
+	})
+}
+
+// GenerateStructured asks each provider in turn for a structured message;
+// providers without structured output have their text answer parsed.
+func (p *FallbackProvider) GenerateStructured(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return p.run(ctx, func(provider Provider) (types.GenerationResult, error) {
+		return GenerateCommitMessage(ctx, provider, changes, opts)
+	})
+}
+
+// GenerateCandidates asks each provider in turn for n alternative messages.
+func (p *FallbackProvider) GenerateCandidates(ctx context.Context, changes string, opts *types.GenerationOptions, n int) ([]types.GenerationResult, error) {
+	var results []types.GenerationResult
+	_, err := p.run(ctx, func(provider Provider) (types.GenerationResult, error) {
+		var err error
+		results, err = GenerateCandidates(ctx, provider, changes, opts, n)
+		return types.GenerationResult{}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (p *FallbackProvider) run(ctx context.Context, generate func(Provider) (types.GenerationResult, error)) (types.GenerationResult, error) {
+	trace := fallbackTraceFrom(ctx)
+
+	var errs []error
+	for i, provider := range p.providers {
+		if trace != nil && trace.AttemptStart != nil {
+			trace.AttemptStart(provider.Name(), i)
+		}
+
+		start := time.Now()
+		result, err := generate(provider)
+		if trace != nil && trace.AttemptDone != nil {
+			trace.AttemptDone(FallbackAttempt{Provider: provider.Name(), Model: ProviderModel(provider), Duration: time.Since(start), Err: err})
+		}
+		if err == nil {
+			return result, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+		if ctx.Err() != nil || !ShouldFallback(err) {
+			break
+		}
+	}
+
+	if len(errs) == 1 {
+		return types.GenerationResult{}, errors.Unwrap(errs[0])
+	}
+	return types.GenerationResult{}, fmt.Errorf("all providers failed: %w", errors.Join(errs...))
+}
+
+// ShouldFallback reports whether err is worth handing over to the next
+// provider: rejected or missing credentials, rate limits, server-side
+// failures and unreachable servers. Cancellations and request errors such
+// as an invalid payload are not.
+func ShouldFallback(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, ErrMissingCredential) {
+		return true
+	}
+
+	if code, ok := statusCode(err); ok {
+		return internalHTTP.AuthStatus(code) || internalHTTP.RetryableStatus(code)
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// statusCode extracts the HTTP status from the error types returned by the
+// provider packages and SDKs.
+func statusCode(err error) (int, bool) {
+	var statusErr *internalHTTP.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode, true
+	}
+
+	var openAIErr *openai.Error
+	if errors.As(err, &openAIErr) {
+		return openAIErr.StatusCode, true
+	}
+
+	var googleErr *googleapi.Error
+	if errors.As(err, &googleErr) {
+		return googleErr.Code, true
+	}
+
+	return 0, false
+}
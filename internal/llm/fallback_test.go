@@ -0,0 +1,194 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+type scriptedProvider struct {
+	name    types.LLMProvider
+	message string
+	err     error
+	calls   int
+}
+
+func (s *scriptedProvider) Name() types.LLMProvider {
+	return s.name
+}
+
+func (s *scriptedProvider) Generate(context.Context, string, *types.GenerationOptions) (types.GenerationResult, error) {
+	s.calls++
+	if s.err != nil {
+		return types.GenerationResult{}, s.err
+	}
+	return types.GenerationResult{Message: s.message}, nil
+}
+
+func TestFallbackProviderMovesOnRetryableErrors(t *testing.T) {
+	t.Parallel()
+
+	claude := &scriptedProvider{name: types.ProviderClaude, err: internalHTTP.NewStatusError(529, "claude AI response 529")}
+	groq := &scriptedProvider{name: types.ProviderGroq, err: newMissingCredentialError(types.ProviderGroq)}
+	ollama := &scriptedProvider{name: types.ProviderOllama, message: "feat: local"}
+
+	chain, err := NewFallbackProvider(claude, groq, ollama)
+	if err != nil {
+		t.Fatalf("NewFallbackProvider returned error: %v", err)
+	}
+
+	var started []types.LLMProvider
+	var attempts []FallbackAttempt
+	ctx := WithFallbackTrace(context.Background(), &FallbackTrace{
+		AttemptStart: func(provider types.LLMProvider, _ int) { started = append(started, provider) },
+		AttemptDone:  func(attempt FallbackAttempt) { attempts = append(attempts, attempt) },
+	})
+
+	msg, err := chain.Generate(ctx, "diff", nil)
+	if err != nil {
+		t.Fatalf("expected fallback to succeed, got %v", err)
+	}
+	if msg.Message != "feat: local" {
+		t.Fatalf("unexpected message: %q", msg.Message)
+	}
+
+	if len(started) != 3 || len(attempts) != 3 {
+		t.Fatalf("expected 3 traced attempts, got %d starts and %d attempts", len(started), len(attempts))
+	}
+	if attempts[0].Err == nil || attempts[1].Err == nil || attempts[2].Err != nil {
+		t.Fatalf("unexpected attempt outcomes: %+v", attempts)
+	}
+	if attempts[2].Provider != types.ProviderOllama {
+		t.Fatalf("expected Ollama to answer, got %s", attempts[2].Provider)
+	}
+}
+
+func TestFallbackProviderStopsOnRequestErrors(t *testing.T) {
+	t.Parallel()
+
+	badRequest := internalHTTP.NewStatusError(http.StatusBadRequest, "bad request")
+	openAI := &scriptedProvider{name: types.ProviderOpenAI, err: badRequest}
+	groq := &scriptedProvider{name: types.ProviderGroq, message: "unused"}
+
+	chain, _ := NewFallbackProvider(openAI, groq)
+	_, err := chain.Generate(context.Background(), "diff", nil)
+	if !errors.Is(err, badRequest) {
+		t.Fatalf("expected the primary error, got %v", err)
+	}
+	if groq.calls != 0 {
+		t.Fatal("expected the fallback provider not to be called")
+	}
+}
+
+func TestFallbackProviderJoinsErrors(t *testing.T) {
+	t.Parallel()
+
+	chain, _ := NewFallbackProvider(
+		&scriptedProvider{name: types.ProviderClaude, err: newMissingCredentialError(types.ProviderClaude)},
+		&scriptedProvider{name: types.ProviderGroq, err: internalHTTP.NewStatusError(http.StatusTooManyRequests, "rate limited")},
+	)
+
+	_, err := chain.Generate(context.Background(), "diff", nil)
+	if err == nil || !errors.Is(err, ErrMissingCredential) {
+		t.Fatalf("expected joined error to keep the missing credential, got %v", err)
+	}
+}
+
+func TestFallbackProviderStreamsNonStreamingProviders(t *testing.T) {
+	t.Parallel()
+
+	chain, _ := NewFallbackProvider(&scriptedProvider{name: types.ProviderGemini, message: "fix: whole"})
+
+	var chunks []string
+	msg, err := chain.GenerateStream(context.Background(), "diff", nil, func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	if err != nil || msg.Message != "fix: whole" {
+		t.Fatalf("unexpected result: %q, %v", msg.Message, err)
+	}
+	if len(chunks) != 1 || chunks[0] != "fix: whole" {
+		t.Fatalf("expected the whole message as one chunk, got %v", chunks)
+	}
+}
+
+// streamingScriptedProvider streams its message in one chunk.
+type streamingScriptedProvider struct{ scriptedProvider }
+
+func (s *streamingScriptedProvider) GenerateStream(ctx context.Context, changes string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	result, err := s.Generate(ctx, changes, opts)
+	if err == nil {
+		onChunk(result.Message)
+	}
+	return result, err
+}
+
+func TestFallbackProviderReportsMemberCapabilities(t *testing.T) {
+	t.Parallel()
+
+	streaming := &streamingScriptedProvider{scriptedProvider{name: "streaming"}}
+	plain := &scriptedProvider{name: "plain"}
+
+	chain, _ := NewFallbackProvider(streaming)
+	if !Streams(chain) || Structured(chain) {
+		t.Fatalf("chain of a streaming provider: Streams = %v, Structured = %v; want true, false", Streams(chain), Structured(chain))
+	}
+
+	chain, _ = NewFallbackProvider(streaming, plain)
+	if Streams(chain) {
+		t.Fatal("chain with a provider that cannot stream reports streaming")
+	}
+
+	nested, _ := NewFallbackProvider(chain)
+	if Streams(nested) || Structured(nested) {
+		t.Fatal("nested chain reports capabilities its members lack")
+	}
+}
+
+func TestShouldFallback(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"unauthorized", internalHTTP.NewStatusError(http.StatusUnauthorized, "401"), true},
+		{"overloaded", internalHTTP.NewStatusError(529, "529"), true},
+		{"rate limited", internalHTTP.NewStatusError(http.StatusTooManyRequests, "429"), true},
+		{"not found", internalHTTP.NewStatusError(http.StatusNotFound, "404"), false},
+		{"cancelled", context.Canceled, false},
+		{"missing credential", newMissingCredentialError(types.ProviderOpenAI), true},
+		{"unknown", errors.New("boom"), false},
+	}
+
+	for _, tc := range cases {
+		if got := ShouldFallback(tc.err); got != tc.want {
+			t.Fatalf("%s: ShouldFallback = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFallbackProviderGenerateStructuredParsesText(t *testing.T) {
+	t.Parallel()
+
+	chain, _ := NewFallbackProvider(
+		&scriptedProvider{name: types.ProviderClaude, err: internalHTTP.NewStatusError(http.StatusServiceUnavailable, "unavailable")},
+		&scriptedProvider{name: types.ProviderGroq, message: "fix(git): ignore binary diffs\n\nRefs: #7"},
+	)
+
+	result, err := chain.GenerateStructured(context.Background(), "diff", nil)
+	if err != nil {
+		t.Fatalf("GenerateStructured returned error: %v", err)
+	}
+	msg := result.Commit
+	if msg == nil {
+		t.Fatal("expected the parsed commit message to be set")
+	}
+	if msg.Type != "fix" || msg.Scope != "git" || msg.Subject != "ignore binary diffs" || len(msg.Trailers) != 1 {
+		t.Fatalf("unexpected structured message: %+v", msg)
+	}
+}
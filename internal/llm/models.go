@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dfanso/commit-msg/internal/bedrock"
+	"github.com/dfanso/commit-msg/internal/chatgpt"
+	"github.com/dfanso/commit-msg/internal/claude"
+	"github.com/dfanso/commit-msg/internal/gemini"
+	"github.com/dfanso/commit-msg/internal/grok"
+	"github.com/dfanso/commit-msg/internal/groq"
+	"github.com/dfanso/commit-msg/internal/ollama"
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+// ModelLister is implemented by providers that can ask their backend which
+// models are available to the configured account or server.
+type ModelLister interface {
+	// ListModels returns the model identifiers reported by the backend.
+	ListModels(ctx context.Context) ([]string, error)
+}
+
+// ModelReporter is implemented by providers that know which model their
+// requests are sent to.
+type ModelReporter interface {
+	Model() string
+}
+
+// ProviderModel returns the model provider uses, or "" when it does not say.
+func ProviderModel(provider Provider) string {
+	if reporter, ok := provider.(ModelReporter); ok {
+		return reporter.Model()
+	}
+	return ""
+}
+
+// geminiModels are served both by the Gemini API and by Vertex AI.
+var geminiModels = []string{gemini.DefaultModel, "gemini-2.0-flash-lite", "gemini-2.5-flash", "gemini-2.5-pro"}
+
+// knownModels lists well-known models per provider. The first entry of each
+// list is the provider default.
+var knownModels = map[types.LLMProvider][]string{
+	types.ProviderOpenAI: {chatgpt.DefaultModel, "gpt-4o-mini", "gpt-4.1", "gpt-4.1-mini", "gpt-4.1-nano", "o4-mini"},
+	types.ProviderClaude: {claude.DefaultModel, "claude-3-5-haiku-20241022", "claude-3-7-sonnet-20250219", "claude-sonnet-4-20250514", "claude-opus-4-20250514"},
+	types.ProviderGemini: geminiModels,
+	types.ProviderGrok:   {grok.DefaultModel, "grok-3-mini", "grok-3", "grok-4"},
+	types.ProviderGroq:   {groq.DefaultModel, "llama-3.1-8b-instant", "openai/gpt-oss-120b", "openai/gpt-oss-20b"},
+	types.ProviderOllama: {ollama.DefaultModel, "llama3.2", "qwen2.5-coder", "mistral"},
+
+	types.ProviderBedrock:  {bedrock.DefaultModel, "anthropic.claude-3-5-haiku-20241022-v1:0", "anthropic.claude-3-5-sonnet-20241022-v2:0", "amazon.nova-lite-v1:0", "amazon.nova-pro-v1:0", "meta.llama3-1-8b-instruct-v1:0"},
+	types.ProviderVertexAI: geminiModels,
+}
+
+// modelEnvVars names the environment variable consulted when no model has
+// been configured for a provider.
+var modelEnvVars = map[types.LLMProvider]string{
+	types.ProviderOpenAI:           "OPENAI_MODEL",
+	types.ProviderClaude:           "CLAUDE_MODEL",
+	types.ProviderGemini:           "GEMINI_MODEL",
+	types.ProviderGrok:             "GROK_MODEL",
+	types.ProviderGroq:             "GROQ_MODEL",
+	types.ProviderOllama:           "OLLAMA_MODEL",
+	types.ProviderOpenAICompatible: "OPENAI_COMPATIBLE_MODEL",
+	types.ProviderAzureOpenAI:      "AZURE_OPENAI_DEPLOYMENT",
+	types.ProviderBedrock:          "BEDROCK_MODEL_ID",
+	types.ProviderVertexAI:         "VERTEX_MODEL",
+}
+
+// KnownModels returns the well-known models for provider, default first. It
+// returns nil for providers such as OpenAICompatible and AzureOpenAI whose
+// models depend entirely on the server or deployment, and for Cassette,
+// which replays the model of its recording.
+func KnownModels(provider types.LLMProvider) []string {
+	return slices.Clone(knownModels[provider])
+}
+
+// DefaultModel returns the model used for provider when nothing else is
+// configured, or "" if the provider has no default.
+func DefaultModel(provider types.LLMProvider) string {
+	if models := knownModels[provider]; len(models) > 0 {
+		return models[0]
+	}
+	return ""
+}
+
+// ModelEnvVar returns the environment variable that can select a model for
+// provider.
+func ModelEnvVar(provider types.LLMProvider) string {
+	return modelEnvVars[provider]
+}
+
+// ResolveModel picks the model for provider: the configured model first, then
+// the provider's environment variable, then the provider default.
+func ResolveModel(provider types.LLMProvider, settings types.ProviderSettings) string {
+	if model := strings.TrimSpace(settings.Model); model != "" {
+		return model
+	}
+	if envVar := modelEnvVars[provider]; envVar != "" {
+		if model := strings.TrimSpace(os.Getenv(envVar)); model != "" {
+			return model
+		}
+	}
+	return DefaultModel(provider)
+}
@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+func TestResolveModel(t *testing.T) {
+	t.Setenv("GROQ_MODEL", "")
+
+	if got := ResolveModel(types.ProviderGroq, types.ProviderSettings{}); got != DefaultModel(types.ProviderGroq) {
+		t.Fatalf("expected default model, got %q", got)
+	}
+
+	t.Setenv("GROQ_MODEL", "env-model")
+	if got := ResolveModel(types.ProviderGroq, types.ProviderSettings{}); got != "env-model" {
+		t.Fatalf("expected env model, got %q", got)
+	}
+
+	if got := ResolveModel(types.ProviderGroq, types.ProviderSettings{Model: " saved-model "}); got != "saved-model" {
+		t.Fatalf("expected configured model to win, got %q", got)
+	}
+}
+
+func TestKnownModels(t *testing.T) {
+	for _, provider := range types.GetSupportedProviders() {
+		models := KnownModels(provider)
+		if _, ok := LookupPlugin(provider); ok || provider == types.ProviderOpenAICompatible || provider == types.ProviderAzureOpenAI || provider == types.ProviderCassette {
+			if len(models) != 0 {
+				t.Fatalf("expected no known models for %s, got %v", provider, models)
+			}
+			continue
+		}
+
+		if len(models) == 0 || models[0] != DefaultModel(provider) {
+			t.Fatalf("expected %s known models to start with the default, got %v", provider, models)
+		}
+	}
+
+	models := KnownModels(types.ProviderOpenAI)
+	models[0] = "mutated"
+	if DefaultModel(types.ProviderOpenAI) == "mutated" {
+		t.Fatal("KnownModels must return a copy")
+	}
+}
+
+func TestNewProviderUsesConfiguredModel(t *testing.T) {
+	t.Setenv("CLAUDE_MODEL", "")
+
+	provider, err := NewProvider(types.ProviderClaude, ProviderOptions{
+		Credential: "key",
+		Settings:   types.ProviderSettings{Model: "claude-3-5-haiku-20241022"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	p, ok := provider.(*claudeProvider)
+	if !ok {
+		t.Fatalf("expected *claudeProvider, got %T", provider)
+	}
+
+	if p.model != "claude-3-5-haiku-20241022" {
+		t.Fatalf("expected configured model, got %q", p.model)
+	}
+
+	if _, ok := provider.(ModelLister); !ok {
+		t.Fatal("expected claude provider to list models")
+	}
+}
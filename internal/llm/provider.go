@@ -8,12 +8,17 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/dfanso/commit-msg/internal/azure"
+	"github.com/dfanso/commit-msg/internal/bedrock"
 	"github.com/dfanso/commit-msg/internal/chatgpt"
 	"github.com/dfanso/commit-msg/internal/claude"
 	"github.com/dfanso/commit-msg/internal/gemini"
 	"github.com/dfanso/commit-msg/internal/grok"
 	"github.com/dfanso/commit-msg/internal/groq"
 	"github.com/dfanso/commit-msg/internal/ollama"
+	"github.com/dfanso/commit-msg/internal/openaicompat"
+	"github.com/dfanso/commit-msg/internal/plugin"
+	"github.com/dfanso/commit-msg/internal/vertex"
 	"github.com/dfanso/commit-msg/pkg/types"
 )
 
@@ -25,13 +30,105 @@ type Provider interface {
 	// Name returns the LLM provider identifier this instance represents.
 	Name() types.LLMProvider
 	// Generate requests a commit message for the supplied repository changes.
-	Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (string, error)
+	// The result carries the token usage reported by the backend, if any.
+	Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error)
+}
+
+// StreamingProvider is implemented by providers that can emit the commit
+// message incrementally while it is being generated.
+type StreamingProvider interface {
+	Provider
+	// GenerateStream behaves like Generate but invokes onChunk with each piece
+	// of text as it arrives. The complete message is returned once the stream ends.
+	GenerateStream(ctx context.Context, changes string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error)
+}
+
+// StructuredProvider is implemented by providers that can return the commit
+// message as a types.CommitMessage through JSON mode or tool calling instead
+// of free-form text.
+type StructuredProvider interface {
+	Provider
+	// GenerateStructured behaves like Generate but also fills the result's
+	// Commit with the message split into its conventional-commit fields.
+	GenerateStructured(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error)
+}
+
+// capabilityReporter is implemented by providers that wrap others. They
+// implement every optional interface but only support what the providers
+// they wrap support.
+type capabilityReporter interface {
+	streams() bool
+	structured() bool
+}
+
+// Streams reports whether provider can stream its answer through
+// StreamingProvider. Built-in providers stream only when the capability
+// registry says so for their model.
+func Streams(provider Provider) bool {
+	if reporter, ok := provider.(capabilityReporter); ok {
+		return reporter.streams()
+	}
+	if capabilities, ok := registeredCapabilities(provider); ok && !capabilities.Streaming {
+		return false
+	}
+	_, ok := provider.(StreamingProvider)
+	return ok
+}
+
+// Structured reports whether provider can return a structured commit
+// message through StructuredProvider. Built-in providers return one only
+// when the capability registry says so for their model.
+func Structured(provider Provider) bool {
+	if reporter, ok := provider.(capabilityReporter); ok {
+		return reporter.structured()
+	}
+	if capabilities, ok := registeredCapabilities(provider); ok && !capabilities.JSON {
+		return false
+	}
+	_, ok := provider.(StructuredProvider)
+	return ok
+}
+
+// registeredCapabilities returns the registry's capabilities for provider
+// and its model. Providers missing from the registry, such as plugins,
+// report false and are judged by the interfaces they implement.
+func registeredCapabilities(provider Provider) (Capabilities, bool) {
+	if _, ok := capabilityRegistry[provider.Name()]; !ok {
+		return Capabilities{}, false
+	}
+	return LookupCapabilities(provider.Name(), ProviderModel(provider)), true
+}
+
+// GenerateCommitMessage asks provider for a structured commit message,
+// parsing the text answer of providers that do not support structured output.
+// The returned result always has Commit set.
+func GenerateCommitMessage(ctx context.Context, provider Provider, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	var (
+		result types.GenerationResult
+		err    error
+	)
+	if structured, ok := provider.(StructuredProvider); ok && Structured(provider) {
+		result, err = structured.GenerateStructured(ctx, changes, opts)
+	} else {
+		result, err = provider.Generate(ctx, changes, opts)
+	}
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
+
+	if result.Commit == nil {
+		commit := types.ParseCommitMessage(result.Message)
+		result.Commit = &commit
+	}
+	return result, nil
 }
 
 // ProviderOptions captures the data needed to construct a provider instance.
 type ProviderOptions struct {
 	Credential string
 	Config     *types.Config
+	// Settings carries the per-provider options saved by `commit llm setup`.
+	Settings types.ProviderSettings
 }
 
 // Factory describes a function capable of building a Provider.
@@ -46,9 +143,17 @@ var (
 		types.ProviderGrok:   newGrokProvider,
 		types.ProviderGroq:   newGroqProvider,
 		types.ProviderOllama: newOllamaProvider,
+
+		types.ProviderOpenAICompatible: newOpenAICompatibleProvider,
+		types.ProviderAzureOpenAI:      newAzureOpenAIProvider,
+		types.ProviderBedrock:          newBedrockProvider,
+		types.ProviderVertexAI:         newVertexAIProvider,
 	}
 )
 
+// plugins holds the external plugins registered as providers.
+var plugins = map[types.LLMProvider]plugin.Plugin{}
+
 // RegisterFactory allows callers (primarily tests) to override or extend provider creation logic.
 func RegisterFactory(name types.LLMProvider, factory Factory) {
 	factoryMu.Lock()
@@ -56,6 +161,42 @@ func RegisterFactory(name types.LLMProvider, factory Factory) {
 	factories[name] = factory
 }
 
+// RegisterPlugin makes an external plugin available as a provider named
+// after it. A plugin registered again replaces the earlier command; one
+// named like a built-in provider is refused.
+func RegisterPlugin(p plugin.Plugin) error {
+	name := types.LLMProvider(p.Name)
+	factoryMu.RLock()
+	_, builtIn := factories[name]
+	_, isPlugin := plugins[name]
+	factoryMu.RUnlock()
+	if builtIn && !isPlugin {
+		return fmt.Errorf("llm: plugin %s conflicts with a built-in provider", p.Name)
+	}
+
+	types.RegisterProvider(name)
+	factoryMu.Lock()
+	defer factoryMu.Unlock()
+	plugins[name] = p
+	factories[name] = func(opts ProviderOptions) (Provider, error) {
+		return &pluginProvider{
+			plugin:     p,
+			model:      ResolveModel(name, opts.Settings),
+			credential: opts.Credential,
+			settings:   opts.Settings,
+		}, nil
+	}
+	return nil
+}
+
+// LookupPlugin returns the plugin registered as provider, if it is one.
+func LookupPlugin(provider types.LLMProvider) (plugin.Plugin, bool) {
+	factoryMu.RLock()
+	defer factoryMu.RUnlock()
+	p, ok := plugins[provider]
+	return p, ok
+}
+
 // NewProvider returns a concrete Provider implementation for the requested name.
 func NewProvider(name types.LLMProvider, opts ProviderOptions) (Provider, error) {
 	factoryMu.RLock()
@@ -66,7 +207,15 @@ func NewProvider(name types.LLMProvider, opts ProviderOptions) (Provider, error)
 	}
 
 	opts.Config = ensureConfig(opts.Config)
-	return factory(opts)
+	provider, err := factory(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if recorder := activeRecorder(); recorder != nil {
+		recorder.Describe(name, ProviderModel(provider), opts.Settings)
+	}
+	return provider, nil
 }
 
 type missingCredentialError struct {
@@ -92,11 +241,35 @@ func ensureConfig(cfg *types.Config) *types.Config {
 	return &types.Config{}
 }
 
+// configuredSampling returns the sampling settings saved for a provider.
+func configuredSampling(settings types.ProviderSettings) types.Sampling {
+	if settings.Sampling == nil {
+		return types.Sampling{}
+	}
+	return *settings.Sampling
+}
+
+// withSampling returns opts with the sampling settings configured for the
+// provider under those of the request.
+func withSampling(opts *types.GenerationOptions, configured types.Sampling) *types.GenerationOptions {
+	if configured.IsZero() {
+		return opts
+	}
+	var merged types.GenerationOptions
+	if opts != nil {
+		merged = *opts
+	}
+	merged.Sampling = configured.Merge(merged.Sampling)
+	return &merged
+}
+
 // --- Provider implementations ------------------------------------------------
 
 type openAIProvider struct {
-	apiKey string
-	config *types.Config
+	apiKey   string
+	model    string
+	config   *types.Config
+	sampling types.Sampling
 }
 
 func newOpenAIProvider(opts ProviderOptions) (Provider, error) {
@@ -107,20 +280,42 @@ func newOpenAIProvider(opts ProviderOptions) (Provider, error) {
 	if key == "" {
 		return nil, newMissingCredentialError(types.ProviderOpenAI)
 	}
-	return &openAIProvider{apiKey: key, config: opts.Config}, nil
+	return &openAIProvider{apiKey: key, model: ResolveModel(types.ProviderOpenAI, opts.Settings), config: opts.Config, sampling: configuredSampling(opts.Settings)}, nil
 }
 
 func (p *openAIProvider) Name() types.LLMProvider {
 	return types.ProviderOpenAI
 }
 
-func (p *openAIProvider) Generate(_ context.Context, changes string, opts *types.GenerationOptions) (string, error) {
-	return chatgpt.GenerateCommitMessage(p.config, changes, p.apiKey, opts)
+func (p *openAIProvider) Model() string {
+	return p.model
+}
+
+func (p *openAIProvider) ListModels(ctx context.Context) ([]string, error) {
+	return chatgpt.ListModels(ctx, p.apiKey)
+}
+
+func (p *openAIProvider) Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return chatgpt.GenerateCommitMessage(ctx, p.config, changes, p.apiKey, p.model, withSampling(opts, p.sampling))
+}
+
+func (p *openAIProvider) GenerateCandidates(ctx context.Context, changes string, opts *types.GenerationOptions, n int) ([]types.GenerationResult, error) {
+	return chatgpt.GenerateCandidates(ctx, p.config, changes, p.apiKey, p.model, withSampling(opts, p.sampling), n)
+}
+
+func (p *openAIProvider) GenerateStructured(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return chatgpt.GenerateStructuredCommitMessage(ctx, p.config, changes, p.apiKey, p.model, withSampling(opts, p.sampling))
+}
+
+func (p *openAIProvider) GenerateStream(ctx context.Context, changes string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	return chatgpt.StreamCommitMessage(ctx, p.config, changes, p.apiKey, p.model, withSampling(opts, p.sampling), onChunk)
 }
 
 type claudeProvider struct {
-	apiKey string
-	config *types.Config
+	apiKey   string
+	model    string
+	config   *types.Config
+	sampling types.Sampling
 }
 
 func newClaudeProvider(opts ProviderOptions) (Provider, error) {
@@ -131,20 +326,38 @@ func newClaudeProvider(opts ProviderOptions) (Provider, error) {
 	if key == "" {
 		return nil, newMissingCredentialError(types.ProviderClaude)
 	}
-	return &claudeProvider{apiKey: key, config: opts.Config}, nil
+	return &claudeProvider{apiKey: key, model: ResolveModel(types.ProviderClaude, opts.Settings), config: opts.Config, sampling: configuredSampling(opts.Settings)}, nil
 }
 
 func (p *claudeProvider) Name() types.LLMProvider {
 	return types.ProviderClaude
 }
 
-func (p *claudeProvider) Generate(_ context.Context, changes string, opts *types.GenerationOptions) (string, error) {
-	return claude.GenerateCommitMessage(p.config, changes, p.apiKey, opts)
+func (p *claudeProvider) Model() string {
+	return p.model
+}
+
+func (p *claudeProvider) ListModels(ctx context.Context) ([]string, error) {
+	return claude.ListModels(ctx, p.apiKey)
+}
+
+func (p *claudeProvider) Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return claude.GenerateCommitMessage(ctx, p.config, changes, p.apiKey, p.model, withSampling(opts, p.sampling))
+}
+
+func (p *claudeProvider) GenerateStructured(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return claude.GenerateStructuredCommitMessage(ctx, p.config, changes, p.apiKey, p.model, withSampling(opts, p.sampling))
+}
+
+func (p *claudeProvider) GenerateStream(ctx context.Context, changes string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	return claude.StreamCommitMessage(ctx, p.config, changes, p.apiKey, p.model, withSampling(opts, p.sampling), onChunk)
 }
 
 type geminiProvider struct {
-	apiKey string
-	config *types.Config
+	apiKey   string
+	model    string
+	config   *types.Config
+	sampling types.Sampling
 }
 
 func newGeminiProvider(opts ProviderOptions) (Provider, error) {
@@ -155,20 +368,38 @@ func newGeminiProvider(opts ProviderOptions) (Provider, error) {
 	if key == "" {
 		return nil, newMissingCredentialError(types.ProviderGemini)
 	}
-	return &geminiProvider{apiKey: key, config: opts.Config}, nil
+	return &geminiProvider{apiKey: key, model: ResolveModel(types.ProviderGemini, opts.Settings), config: opts.Config, sampling: configuredSampling(opts.Settings)}, nil
 }
 
 func (p *geminiProvider) Name() types.LLMProvider {
 	return types.ProviderGemini
 }
 
-func (p *geminiProvider) Generate(_ context.Context, changes string, opts *types.GenerationOptions) (string, error) {
-	return gemini.GenerateCommitMessage(p.config, changes, p.apiKey, opts)
+func (p *geminiProvider) Model() string {
+	return p.model
+}
+
+func (p *geminiProvider) ListModels(ctx context.Context) ([]string, error) {
+	return gemini.ListModels(ctx, p.apiKey)
+}
+
+func (p *geminiProvider) Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return gemini.GenerateCommitMessage(ctx, p.config, changes, p.apiKey, p.model, withSampling(opts, p.sampling))
+}
+
+func (p *geminiProvider) GenerateCandidates(ctx context.Context, changes string, opts *types.GenerationOptions, n int) ([]types.GenerationResult, error) {
+	return gemini.GenerateCandidates(ctx, p.config, changes, p.apiKey, p.model, withSampling(opts, p.sampling), n)
+}
+
+func (p *geminiProvider) GenerateStructured(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return gemini.GenerateStructuredCommitMessage(ctx, p.config, changes, p.apiKey, p.model, withSampling(opts, p.sampling))
 }
 
 type grokProvider struct {
-	apiKey string
-	config *types.Config
+	apiKey   string
+	model    string
+	config   *types.Config
+	sampling types.Sampling
 }
 
 func newGrokProvider(opts ProviderOptions) (Provider, error) {
@@ -179,20 +410,34 @@ func newGrokProvider(opts ProviderOptions) (Provider, error) {
 	if key == "" {
 		return nil, newMissingCredentialError(types.ProviderGrok)
 	}
-	return &grokProvider{apiKey: key, config: opts.Config}, nil
+	return &grokProvider{apiKey: key, model: ResolveModel(types.ProviderGrok, opts.Settings), config: opts.Config, sampling: configuredSampling(opts.Settings)}, nil
 }
 
 func (p *grokProvider) Name() types.LLMProvider {
 	return types.ProviderGrok
 }
 
-func (p *grokProvider) Generate(_ context.Context, changes string, opts *types.GenerationOptions) (string, error) {
-	return grok.GenerateCommitMessage(p.config, changes, p.apiKey, opts)
+func (p *grokProvider) Model() string {
+	return p.model
+}
+
+func (p *grokProvider) ListModels(ctx context.Context) ([]string, error) {
+	return grok.ListModels(ctx, p.config, p.apiKey)
+}
+
+func (p *grokProvider) Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return grok.GenerateCommitMessage(ctx, p.config, changes, p.apiKey, p.model, withSampling(opts, p.sampling))
+}
+
+func (p *grokProvider) GenerateStream(ctx context.Context, changes string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	return grok.StreamCommitMessage(ctx, p.config, changes, p.apiKey, p.model, withSampling(opts, p.sampling), onChunk)
 }
 
 type groqProvider struct {
-	apiKey string
-	config *types.Config
+	apiKey   string
+	model    string
+	config   *types.Config
+	sampling types.Sampling
 }
 
 func newGroqProvider(opts ProviderOptions) (Provider, error) {
@@ -203,21 +448,37 @@ func newGroqProvider(opts ProviderOptions) (Provider, error) {
 	if key == "" {
 		return nil, newMissingCredentialError(types.ProviderGroq)
 	}
-	return &groqProvider{apiKey: key, config: opts.Config}, nil
+	return &groqProvider{apiKey: key, model: ResolveModel(types.ProviderGroq, opts.Settings), config: opts.Config, sampling: configuredSampling(opts.Settings)}, nil
 }
 
 func (p *groqProvider) Name() types.LLMProvider {
 	return types.ProviderGroq
 }
 
-func (p *groqProvider) Generate(_ context.Context, changes string, opts *types.GenerationOptions) (string, error) {
-	return groq.GenerateCommitMessage(p.config, changes, p.apiKey, opts)
+func (p *groqProvider) Model() string {
+	return p.model
+}
+
+func (p *groqProvider) ListModels(ctx context.Context) ([]string, error) {
+	return groq.ListModels(ctx, p.apiKey)
+}
+
+func (p *groqProvider) Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return groq.GenerateCommitMessage(ctx, p.config, changes, p.apiKey, p.model, withSampling(opts, p.sampling))
+}
+
+func (p *groqProvider) GenerateCandidates(ctx context.Context, changes string, opts *types.GenerationOptions, n int) ([]types.GenerationResult, error) {
+	return groq.GenerateCandidates(ctx, p.config, changes, p.apiKey, p.model, withSampling(opts, p.sampling), n)
+}
+
+func (p *groqProvider) GenerateStream(ctx context.Context, changes string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	return groq.StreamCommitMessage(ctx, p.config, changes, p.apiKey, p.model, withSampling(opts, p.sampling), onChunk)
 }
 
 type ollamaProvider struct {
-	url    string
-	model  string
-	config *types.Config
+	endpoint ollama.Endpoint
+	config   *types.Config
+	sampling types.Sampling
 }
 
 func newOllamaProvider(opts ProviderOptions) (Provider, error) {
@@ -225,22 +486,297 @@ func newOllamaProvider(opts ProviderOptions) (Provider, error) {
 	if url == "" {
 		url = strings.TrimSpace(os.Getenv("OLLAMA_URL"))
 		if url == "" {
-			url = "http://localhost:11434/api/generate"
+			url = ollama.DefaultURL
 		}
 	}
 
-	model := strings.TrimSpace(os.Getenv("OLLAMA_MODEL"))
-	if model == "" {
-		model = "llama3.1"
+	endpoint := ollama.Endpoint{URL: url, Model: ResolveModel(types.ProviderOllama, opts.Settings)}
+	if opts.Settings.Ollama != nil {
+		endpoint.Settings = *opts.Settings.Ollama
 	}
-
-	return &ollamaProvider{url: url, model: model, config: opts.Config}, nil
+	return &ollamaProvider{endpoint: endpoint, config: opts.Config, sampling: configuredSampling(opts.Settings)}, nil
 }
 
 func (p *ollamaProvider) Name() types.LLMProvider {
 	return types.ProviderOllama
 }
 
-func (p *ollamaProvider) Generate(_ context.Context, changes string, opts *types.GenerationOptions) (string, error) {
-	return ollama.GenerateCommitMessage(p.config, changes, p.url, p.model, opts)
+func (p *ollamaProvider) Model() string {
+	return p.endpoint.Model
+}
+
+func (p *ollamaProvider) ListModels(ctx context.Context) ([]string, error) {
+	return ollama.ListModels(ctx, p.endpoint.URL)
+}
+
+func (p *ollamaProvider) Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return ollama.GenerateCommitMessage(ctx, p.config, changes, p.endpoint, withSampling(opts, p.sampling))
+}
+
+func (p *ollamaProvider) GenerateStream(ctx context.Context, changes string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	return ollama.StreamCommitMessage(ctx, p.config, changes, p.endpoint, withSampling(opts, p.sampling), onChunk)
+}
+
+type openAICompatibleProvider struct {
+	endpoint openaicompat.Endpoint
+	config   *types.Config
+	sampling types.Sampling
+}
+
+func newOpenAICompatibleProvider(opts ProviderOptions) (Provider, error) {
+	baseURL := strings.TrimSpace(opts.Settings.BaseURL)
+	if baseURL == "" {
+		baseURL = strings.TrimSpace(os.Getenv("OPENAI_COMPATIBLE_BASE_URL"))
+	}
+	if baseURL == "" {
+		return nil, newMissingCredentialError(types.ProviderOpenAICompatible)
+	}
+
+	key := strings.TrimSpace(opts.Credential)
+	if key == "" {
+		key = strings.TrimSpace(os.Getenv("OPENAI_COMPATIBLE_API_KEY"))
+	}
+
+	return &openAICompatibleProvider{
+		endpoint: openaicompat.Endpoint{
+			BaseURL: baseURL,
+			Model:   ResolveModel(types.ProviderOpenAICompatible, opts.Settings),
+			APIKey:  key,
+			Headers: opts.Settings.Headers,
+		},
+		config:   opts.Config,
+		sampling: configuredSampling(opts.Settings),
+	}, nil
+}
+
+func (p *openAICompatibleProvider) Name() types.LLMProvider {
+	return types.ProviderOpenAICompatible
+}
+
+func (p *openAICompatibleProvider) Model() string {
+	return p.endpoint.Model
+}
+
+func (p *openAICompatibleProvider) ListModels(ctx context.Context) ([]string, error) {
+	return openaicompat.ListModels(ctx, p.endpoint)
+}
+
+func (p *openAICompatibleProvider) Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return openaicompat.GenerateCommitMessage(ctx, p.config, changes, p.endpoint, withSampling(opts, p.sampling))
+}
+
+func (p *openAICompatibleProvider) GenerateStream(ctx context.Context, changes string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	return openaicompat.StreamCommitMessage(ctx, p.config, changes, p.endpoint, withSampling(opts, p.sampling), onChunk)
+}
+
+type azureOpenAIProvider struct {
+	endpoint azure.Endpoint
+	config   *types.Config
+	sampling types.Sampling
+}
+
+func newAzureOpenAIProvider(opts ProviderOptions) (Provider, error) {
+	resourceURL := strings.TrimSpace(opts.Settings.BaseURL)
+	if resourceURL == "" {
+		resourceURL = strings.TrimSpace(os.Getenv("AZURE_OPENAI_ENDPOINT"))
+	}
+
+	credential := strings.TrimSpace(opts.Credential)
+	if credential == "" {
+		credential = strings.TrimSpace(os.Getenv("AZURE_OPENAI_API_KEY"))
+	}
+
+	deployment := ResolveModel(types.ProviderAzureOpenAI, opts.Settings)
+	if resourceURL == "" || deployment == "" || credential == "" {
+		return nil, newMissingCredentialError(types.ProviderAzureOpenAI)
+	}
+
+	endpoint := azure.Endpoint{
+		ResourceURL: resourceURL,
+		Deployment:  deployment,
+		APIVersion:  strings.TrimSpace(os.Getenv("AZURE_OPENAI_API_VERSION")),
+		Credential:  credential,
+		Headers:     opts.Settings.Headers,
+	}
+	if settings := opts.Settings.Azure; settings != nil {
+		if settings.APIVersion != "" {
+			endpoint.APIVersion = settings.APIVersion
+		}
+		endpoint.Auth = settings.Auth
+	}
+
+	return &azureOpenAIProvider{endpoint: endpoint, config: opts.Config, sampling: configuredSampling(opts.Settings)}, nil
+}
+
+func (p *azureOpenAIProvider) Name() types.LLMProvider {
+	return types.ProviderAzureOpenAI
+}
+
+// Model reports the deployment name, which Azure uses in place of a model.
+func (p *azureOpenAIProvider) Model() string {
+	return p.endpoint.Deployment
+}
+
+func (p *azureOpenAIProvider) Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return azure.GenerateCommitMessage(ctx, p.config, changes, p.endpoint, withSampling(opts, p.sampling))
+}
+
+func (p *azureOpenAIProvider) GenerateStream(ctx context.Context, changes string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	return azure.StreamCommitMessage(ctx, p.config, changes, p.endpoint, withSampling(opts, p.sampling), onChunk)
+}
+
+type bedrockProvider struct {
+	endpoint bedrock.Endpoint
+	config   *types.Config
+	sampling types.Sampling
+}
+
+// newBedrockProvider signs with the standard AWS credential sources instead
+// of a stored key, so opts.Credential is not used.
+func newBedrockProvider(opts ProviderOptions) (Provider, error) {
+	var profile string
+	if settings := opts.Settings.Bedrock; settings != nil {
+		profile = strings.TrimSpace(settings.Profile)
+	}
+
+	creds, err := bedrock.LoadCredentials(profile)
+	if err != nil {
+		return nil, newMissingCredentialError(types.ProviderBedrock)
+	}
+	return newBedrockProviderWithCredentials(opts, creds), nil
+}
+
+// newBedrockProviderWithCredentials builds a Bedrock provider that signs
+// with creds.
+func newBedrockProviderWithCredentials(opts ProviderOptions, creds bedrock.Credentials) Provider {
+	var profile, region string
+	if settings := opts.Settings.Bedrock; settings != nil {
+		profile = strings.TrimSpace(settings.Profile)
+		region = settings.Region
+	}
+
+	endpointURL := strings.TrimSpace(opts.Settings.BaseURL)
+	if endpointURL == "" {
+		endpointURL = strings.TrimSpace(os.Getenv("BEDROCK_ENDPOINT_URL"))
+	}
+
+	return &bedrockProvider{
+		endpoint: bedrock.Endpoint{
+			Region:      bedrock.ResolveRegion(region, profile),
+			ModelID:     ResolveModel(types.ProviderBedrock, opts.Settings),
+			URL:         endpointURL,
+			Credentials: creds,
+		},
+		config:   opts.Config,
+		sampling: configuredSampling(opts.Settings),
+	}
+}
+
+func (p *bedrockProvider) Name() types.LLMProvider {
+	return types.ProviderBedrock
+}
+
+func (p *bedrockProvider) Model() string {
+	return p.endpoint.ModelID
+}
+
+func (p *bedrockProvider) Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return bedrock.GenerateCommitMessage(ctx, p.config, changes, p.endpoint, withSampling(opts, p.sampling))
+}
+
+type vertexAIProvider struct {
+	endpoint vertex.Endpoint
+	config   *types.Config
+	sampling types.Sampling
+}
+
+// newVertexAIProvider reads the service account key from opts.Credential,
+// or uses Application Default Credentials when the settings ask for them.
+// The project falls back to GOOGLE_CLOUD_PROJECT and then to the project
+// of the credentials.
+func newVertexAIProvider(opts ProviderOptions) (Provider, error) {
+	var settings types.VertexSettings
+	if opts.Settings.Vertex != nil {
+		settings = *opts.Settings.Vertex
+	}
+
+	source, credentialProject, err := vertex.NewTokenSource(context.Background(), settings.Auth, opts.Credential)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", newMissingCredentialError(types.ProviderVertexAI), err)
+	}
+
+	project := strings.TrimSpace(settings.Project)
+	if project == "" {
+		project = strings.TrimSpace(os.Getenv("GOOGLE_CLOUD_PROJECT"))
+	}
+	if project == "" {
+		project = credentialProject
+	}
+	if project == "" {
+		return nil, newMissingCredentialError(types.ProviderVertexAI)
+	}
+
+	location := strings.TrimSpace(settings.Location)
+	if location == "" {
+		location = strings.TrimSpace(os.Getenv("GOOGLE_CLOUD_LOCATION"))
+	}
+	if location == "" {
+		location = vertex.DefaultLocation
+	}
+
+	endpointURL := strings.TrimSpace(opts.Settings.BaseURL)
+	if endpointURL == "" {
+		endpointURL = strings.TrimSpace(os.Getenv("VERTEX_AI_ENDPOINT"))
+	}
+
+	return &vertexAIProvider{
+		endpoint: vertex.Endpoint{
+			Project:     project,
+			Location:    location,
+			Model:       ResolveModel(types.ProviderVertexAI, opts.Settings),
+			URL:         endpointURL,
+			TokenSource: source,
+		},
+		config:   opts.Config,
+		sampling: configuredSampling(opts.Settings),
+	}, nil
+}
+
+func (p *vertexAIProvider) Name() types.LLMProvider {
+	return types.ProviderVertexAI
+}
+
+func (p *vertexAIProvider) Model() string {
+	return p.endpoint.Model
+}
+
+func (p *vertexAIProvider) Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	return vertex.GenerateCommitMessage(ctx, p.config, changes, p.endpoint, withSampling(opts, p.sampling))
+}
+
+func (p *vertexAIProvider) GenerateStream(ctx context.Context, changes string, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	return vertex.StreamCommitMessage(ctx, p.config, changes, p.endpoint, withSampling(opts, p.sampling), onChunk)
+}
+
+// pluginProvider runs an external plugin for every generation. The plugin
+// receives the stored credential and settings and decides what to do with
+// them.
+type pluginProvider struct {
+	plugin     plugin.Plugin
+	model      string
+	credential string
+	settings   types.ProviderSettings
+}
+
+func (p *pluginProvider) Name() types.LLMProvider {
+	return types.LLMProvider(p.plugin.Name)
+}
+
+func (p *pluginProvider) Model() string {
+	return p.model
+}
+
+func (p *pluginProvider) Generate(ctx context.Context, changes string, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	req := plugin.NewRequest(p.plugin.Name, p.model, p.credential, p.settings, changes, withSampling(opts, configuredSampling(p.settings)))
+	return p.plugin.Generate(ctx, req)
 }
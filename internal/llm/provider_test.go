@@ -3,8 +3,12 @@ package llm
 import (
 	"context"
 	"errors"
+	"path/filepath"
+	"slices"
 	"testing"
 
+	"github.com/dfanso/commit-msg/internal/cassette"
+	"github.com/dfanso/commit-msg/internal/plugin"
 	"github.com/dfanso/commit-msg/pkg/types"
 )
 
@@ -79,15 +83,37 @@ func TestNewProviderOllamaDefaults(t *testing.T) {
 		t.Fatalf("expected *ollamaProvider, got %T", provider)
 	}
 
-	if p.url == "" {
+	if p.endpoint.URL == "" {
 		t.Fatalf("expected default URL to be set")
 	}
 
-	if p.model == "" {
+	if p.endpoint.Model == "" {
 		t.Fatalf("expected default model to be set")
 	}
 }
 
+func TestNewProviderOllamaSettings(t *testing.T) {
+	temperature := 0.1
+	provider, err := NewProvider(types.ProviderOllama, ProviderOptions{
+		Credential: "http://gpu-box:11434",
+		Settings: types.ProviderSettings{
+			Model:  "qwen2.5-coder:7b",
+			Ollama: &types.OllamaSettings{Temperature: &temperature, KeepAlive: "-1"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error for ollama provider, got %v", err)
+	}
+
+	p := provider.(*ollamaProvider)
+	if p.endpoint.URL != "http://gpu-box:11434" || p.endpoint.Model != "qwen2.5-coder:7b" {
+		t.Fatalf("unexpected endpoint: %+v", p.endpoint)
+	}
+	if p.endpoint.Settings.Temperature == nil || *p.endpoint.Settings.Temperature != 0.1 || p.endpoint.Settings.KeepAlive != "-1" {
+		t.Fatalf("expected the Ollama settings to be passed through, got %+v", p.endpoint.Settings)
+	}
+}
+
 func TestRegisterFactoryOverrides(t *testing.T) {
 	factoryMu.Lock()
 	original := factories[types.ProviderOpenAI]
@@ -125,6 +151,234 @@ func (f fakeProvider) Name() types.LLMProvider {
 	return f.name
 }
 
-func (f fakeProvider) Generate(context.Context, string, *types.GenerationOptions) (string, error) {
-	return "", nil
+func (f fakeProvider) Generate(context.Context, string, *types.GenerationOptions) (types.GenerationResult, error) {
+	return types.GenerationResult{}, nil
+}
+
+// streamingTestSettings are the saved settings providers need beyond an
+// environment variable.
+var streamingTestSettings = map[types.LLMProvider]types.ProviderSettings{
+	types.ProviderVertexAI: {Vertex: &types.VertexSettings{Auth: "none"}},
+}
+
+func TestStreamingProviderSupport(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "key")
+	t.Setenv("CLAUDE_API_KEY", "key")
+	t.Setenv("GEMINI_API_KEY", "key")
+	t.Setenv("GROK_API_KEY", "key")
+	t.Setenv("GROQ_API_KEY", "key")
+	t.Setenv("OPENAI_COMPATIBLE_BASE_URL", "http://localhost:8000/v1")
+	t.Setenv("AZURE_OPENAI_ENDPOINT", "https://my-resource.openai.azure.com")
+	t.Setenv("AZURE_OPENAI_DEPLOYMENT", "gpt-4o")
+	t.Setenv("AZURE_OPENAI_API_KEY", "key")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "my-project")
+	t.Setenv(cassette.EnvPath, writeTestCassette(t, types.ProviderBedrock))
+
+	streaming := map[types.LLMProvider]bool{
+		types.ProviderOpenAI: true,
+		types.ProviderClaude: true,
+		types.ProviderGemini: false,
+		types.ProviderGrok:   true,
+		types.ProviderGroq:   true,
+		types.ProviderOllama: true,
+
+		types.ProviderOpenAICompatible: true,
+		types.ProviderAzureOpenAI:      true,
+		types.ProviderBedrock:          false,
+		types.ProviderVertexAI:         true,
+		types.ProviderCassette:         true,
+	}
+
+	for name, want := range streaming {
+		provider, err := NewProvider(name, ProviderOptions{Settings: streamingTestSettings[name]})
+		if err != nil {
+			t.Fatalf("NewProvider(%s) returned error: %v", name, err)
+		}
+
+		_, ok := provider.(StreamingProvider)
+		if ok != want {
+			t.Fatalf("expected %s streaming support %v, got %v", name, want, ok)
+		}
+	}
+}
+
+func TestNewProviderOpenAICompatible(t *testing.T) {
+	t.Setenv("OPENAI_COMPATIBLE_BASE_URL", "")
+	t.Setenv("OPENAI_COMPATIBLE_MODEL", "")
+	t.Setenv("OPENAI_COMPATIBLE_API_KEY", "")
+
+	if _, err := NewProvider(types.ProviderOpenAICompatible, ProviderOptions{}); !errors.Is(err, ErrMissingCredential) {
+		t.Fatalf("expected ErrMissingCredential without base URL, got %v", err)
+	}
+
+	provider, err := NewProvider(types.ProviderOpenAICompatible, ProviderOptions{
+		Credential: "secret",
+		Settings: types.ProviderSettings{
+			BaseURL: "http://localhost:1234/v1",
+			Model:   "local-model",
+			Headers: map[string]string{"X-Org": "acme"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	p, ok := provider.(*openAICompatibleProvider)
+	if !ok {
+		t.Fatalf("expected *openAICompatibleProvider, got %T", provider)
+	}
+
+	if p.endpoint.BaseURL != "http://localhost:1234/v1" || p.endpoint.Model != "local-model" || p.endpoint.APIKey != "secret" {
+		t.Fatalf("unexpected endpoint: %+v", p.endpoint)
+	}
+
+	if p.endpoint.Headers["X-Org"] != "acme" {
+		t.Fatalf("expected extra headers to be kept, got %v", p.endpoint.Headers)
+	}
+}
+
+func TestNewProviderAzureOpenAI(t *testing.T) {
+	t.Setenv("AZURE_OPENAI_ENDPOINT", "")
+	t.Setenv("AZURE_OPENAI_DEPLOYMENT", "")
+	t.Setenv("AZURE_OPENAI_API_KEY", "")
+	t.Setenv("AZURE_OPENAI_API_VERSION", "2024-06-01")
+
+	if _, err := NewProvider(types.ProviderAzureOpenAI, ProviderOptions{Credential: "secret"}); !errors.Is(err, ErrMissingCredential) {
+		t.Fatalf("expected ErrMissingCredential without an endpoint, got %v", err)
+	}
+
+	settings := types.ProviderSettings{
+		BaseURL: "https://my-resource.openai.azure.com",
+		Model:   "commit-gpt4o",
+	}
+	provider, err := NewProvider(types.ProviderAzureOpenAI, ProviderOptions{Credential: "secret", Settings: settings})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	p := provider.(*azureOpenAIProvider)
+	if p.endpoint.Deployment != "commit-gpt4o" || p.endpoint.APIVersion != "2024-06-01" || p.endpoint.Credential != "secret" {
+		t.Fatalf("unexpected endpoint: %+v", p.endpoint)
+	}
+	if ProviderModel(provider) != "commit-gpt4o" {
+		t.Fatalf("expected the deployment as the model, got %q", ProviderModel(provider))
+	}
+
+	settings.Azure = &types.AzureSettings{APIVersion: "2025-01-01-preview", Auth: "bearer"}
+	provider, err = NewProvider(types.ProviderAzureOpenAI, ProviderOptions{Credential: "token", Settings: settings})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	p = provider.(*azureOpenAIProvider)
+	if p.endpoint.APIVersion != "2025-01-01-preview" || p.endpoint.Auth != "bearer" {
+		t.Fatalf("expected the saved settings to win, got %+v", p.endpoint)
+	}
+}
+
+func TestNewProviderBedrock(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "eu-west-1")
+	t.Setenv("BEDROCK_MODEL_ID", "")
+	t.Setenv("BEDROCK_ENDPOINT_URL", "")
+
+	if _, err := NewProvider(types.ProviderBedrock, ProviderOptions{}); !errors.Is(err, ErrMissingCredential) {
+		t.Fatalf("expected ErrMissingCredential without AWS credentials, got %v", err)
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	provider, err := NewProvider(types.ProviderBedrock, ProviderOptions{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	p := provider.(*bedrockProvider)
+	if p.endpoint.Region != "eu-west-1" || p.endpoint.ModelID != DefaultModel(types.ProviderBedrock) || p.endpoint.Credentials.AccessKeyID != "AKID" {
+		t.Fatalf("unexpected endpoint: %+v", p.endpoint)
+	}
+
+	provider, err = NewProvider(types.ProviderBedrock, ProviderOptions{Settings: types.ProviderSettings{
+		BaseURL: "http://localhost:9000",
+		Model:   "amazon.nova-lite-v1:0",
+		Bedrock: &types.BedrockSettings{Region: "us-west-2"},
+	}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	p = provider.(*bedrockProvider)
+	if p.endpoint.Region != "us-west-2" || p.endpoint.URL != "http://localhost:9000" || ProviderModel(provider) != "amazon.nova-lite-v1:0" {
+		t.Fatalf("expected the saved settings to win, got %+v", p.endpoint)
+	}
+}
+
+func TestNewProviderVertexAI(t *testing.T) {
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	t.Setenv("GOOGLE_CLOUD_LOCATION", "")
+	t.Setenv("VERTEX_AI_ENDPOINT", "")
+	t.Setenv("VERTEX_MODEL", "")
+
+	if _, err := NewProvider(types.ProviderVertexAI, ProviderOptions{}); !errors.Is(err, ErrMissingCredential) {
+		t.Fatalf("expected ErrMissingCredential without a service account key, got %v", err)
+	}
+
+	emulator := types.ProviderSettings{Vertex: &types.VertexSettings{Auth: "none"}}
+	if _, err := NewProvider(types.ProviderVertexAI, ProviderOptions{Settings: emulator}); !errors.Is(err, ErrMissingCredential) {
+		t.Fatalf("expected ErrMissingCredential without a project, got %v", err)
+	}
+
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "env-project")
+	provider, err := NewProvider(types.ProviderVertexAI, ProviderOptions{Settings: emulator})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	p := provider.(*vertexAIProvider)
+	if p.endpoint.Project != "env-project" || p.endpoint.Location != "us-central1" || p.endpoint.Model != DefaultModel(types.ProviderVertexAI) || p.endpoint.TokenSource != nil {
+		t.Fatalf("unexpected endpoint: %+v", p.endpoint)
+	}
+
+	provider, err = NewProvider(types.ProviderVertexAI, ProviderOptions{Settings: types.ProviderSettings{
+		BaseURL: "http://localhost:8085",
+		Model:   "gemini-2.5-pro",
+		Vertex:  &types.VertexSettings{Project: "saved", Location: "europe-west4", Auth: "none"},
+	}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	p = provider.(*vertexAIProvider)
+	if p.endpoint.Project != "saved" || p.endpoint.Location != "europe-west4" || p.endpoint.URL != "http://localhost:8085" || p.endpoint.Model != "gemini-2.5-pro" {
+		t.Fatalf("expected the saved settings to win, got %+v", p.endpoint)
+	}
+}
+
+func TestRegisterPlugin(t *testing.T) {
+	if err := RegisterPlugin(plugin.Plugin{Name: "OpenAI", Command: []string{"openai-gateway"}}); err == nil {
+		t.Fatal("expected a plugin named like a built-in provider to be refused")
+	}
+
+	p := plugin.Plugin{Name: "test-gateway", Command: []string{"commit-msg-provider-test-gateway"}}
+	if err := RegisterPlugin(p); err != nil {
+		t.Fatalf("RegisterPlugin returned error: %v", err)
+	}
+	name := types.LLMProvider("test-gateway")
+	if !name.IsValid() || !slices.Contains(types.GetSupportedProviders(), name) {
+		t.Fatal("expected the plugin to be a supported provider")
+	}
+	if err := RegisterPlugin(plugin.Plugin{Name: "test-gateway", Command: []string{"/opt/gateway"}}); err != nil {
+		t.Fatalf("expected a plugin to be replaceable, got %v", err)
+	}
+
+	provider, err := NewProvider(name, ProviderOptions{Credential: "secret", Settings: types.ProviderSettings{Model: "big"}})
+	if err != nil {
+		t.Fatalf("NewProvider returned error: %v", err)
+	}
+	pp, ok := provider.(*pluginProvider)
+	if !ok || pp.plugin.Command[0] != "/opt/gateway" || pp.credential != "secret" || ProviderModel(provider) != "big" {
+		t.Fatalf("unexpected plugin provider: %+v", provider)
+	}
 }
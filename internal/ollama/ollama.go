@@ -2,90 +2,382 @@ package ollama
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	httpClient "github.com/dfanso/commit-msg/internal/http"
 	"github.com/dfanso/commit-msg/pkg/types"
 )
 
+// DefaultModel is used when no model has been configured for Ollama.
+const DefaultModel = "llama3.1"
+
+// DefaultURL is the address of a local Ollama server.
+const DefaultURL = "http://localhost:11434"
+
 const (
-	ollamaDefaultModel = "llama3:latest"
-	ollamaStream       = false
-	ollamaContentType  = "application/json"
+	ollamaContentType = "application/json"
+	ollamaChatPath    = "/api/chat"
+	ollamaTagsPath    = "/api/tags"
 )
 
-// OllamaRequest captures the prompt payload sent to an Ollama HTTP endpoint.
+// ErrModelNotPulled reports that the requested model is not installed on the
+// Ollama server.
+var ErrModelNotPulled = errors.New("model is not pulled")
+
+// Endpoint describes the Ollama server and model a request goes to.
+type Endpoint struct {
+	// URL locates the server, e.g. http://localhost:11434. Only its scheme and
+	// host are used, so older URLs ending in /api/generate keep working.
+	URL string
+	// Model defaults to DefaultModel when empty.
+	Model string
+	// Settings carries the sampling options and keep_alive.
+	Settings types.OllamaSettings
+}
+
+// OllamaRequest is the body sent to /api/chat.
 type OllamaRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
+	Model    string          `json:"model"`
+	Messages []types.Message `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Options  *OllamaOptions  `json:"options,omitempty"`
+	// KeepAlive is a duration string such as "10m" or a number of seconds;
+	// a negative number keeps the model loaded indefinitely.
+	KeepAlive any `json:"keep_alive,omitempty"`
+}
+
+// OllamaOptions are the model parameters Ollama accepts per request.
+type OllamaOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	NumCtx      int      `json:"num_ctx,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	Seed        *int64   `json:"seed,omitempty"`
 }
 
-// OllamaResponse represents the non-streaming response from Ollama.
+// OllamaResponse represents a response object from /api/chat. Non-streaming
+// calls return one object; streaming calls return one per line.
 type OllamaResponse struct {
-	Response string `json:"response"`
-	Done     bool   `json:"done"`
+	Message types.Message `json:"message"`
+	Done    bool          `json:"done"`
+	Error   string        `json:"error,omitempty"`
+	// PromptEvalCount and EvalCount are the prompt and response token counts,
+	// reported with the final object.
+	PromptEvalCount int `json:"prompt_eval_count,omitempty"`
+	EvalCount       int `json:"eval_count,omitempty"`
+}
+
+// ollamaTags lists the models pulled into the local Ollama instance.
+type ollamaTags struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
 }
 
 // GenerateCommitMessage uses a locally hosted Ollama model to draft a commit
 // message from repository changes and optional style guidance.
-func GenerateCommitMessage(_ *types.Config, changes string, url string, model string, opts *types.GenerationOptions) (string, error) {
-	// Use llama3:latest as the default model
-	if model == "" {
-		model = ollamaDefaultModel
-	}
-
-	// Preparing the prompt
-	prompt := types.BuildCommitPrompt(changes, opts)
-
-	// Generating the request body - add stream: false for non-streaming response
-	reqBody := map[string]interface{}{
-		"model":  model,
-		"prompt": prompt,
-		"stream": ollamaStream,
-	}
-
-	// Generating the body
-	body, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %v", err)
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+func GenerateCommitMessage(ctx context.Context, _ *types.Config, changes string, endpoint Endpoint, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	req, err := newChatRequest(ctx, changes, endpoint, opts, false)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %v", err)
+		return types.GenerationResult{}, err
 	}
-	req.Header.Set("Content-Type", ollamaContentType)
 
 	resp, err := httpClient.GetOllamaClient().Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request to Ollama: %v", err)
+		return types.GenerationResult{}, fmt.Errorf("failed to send request to Ollama: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read the full response body for better error handling
 	responseBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %v", err)
+		return types.GenerationResult{}, fmt.Errorf("failed to read response body: %v", err)
 	}
 
 	// Check HTTP status
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Ollama API returned status %d: %s", resp.StatusCode, string(responseBody))
+		return types.GenerationResult{}, statusError(resp.StatusCode, responseBody, endpoint)
 	}
 
 	// Since we set stream: false, we get a single response object
 	var response OllamaResponse
 	if err := json.Unmarshal(responseBody, &response); err != nil {
-		return "", fmt.Errorf("failed to decode response: %v", err)
+		return types.GenerationResult{}, fmt.Errorf("failed to decode response: %v", err)
 	}
 
 	// Check if we got any response
-	if response.Response == "" {
-		return "", fmt.Errorf("received empty response from Ollama")
+	if response.Message.Content == "" {
+		return types.GenerationResult{}, fmt.Errorf("received empty response from Ollama")
+	}
+
+	return types.GenerationResult{
+		Message: response.Message.Content,
+		Usage:   types.NewUsageInfo(response.PromptEvalCount, response.EvalCount),
+	}, nil
+}
+
+// StreamCommitMessage asks Ollama for a streamed response (newline-delimited
+// JSON objects) and forwards each partial response to onChunk as it arrives.
+func StreamCommitMessage(ctx context.Context, _ *types.Config, changes string, endpoint Endpoint, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	req, err := newChatRequest(ctx, changes, endpoint, opts, true)
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
+
+	resp, err := httpClient.GetOllamaClient().Do(req)
+	if err != nil {
+		return types.GenerationResult{}, fmt.Errorf("failed to send request to Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		responseBody, _ := io.ReadAll(resp.Body)
+		return types.GenerationResult{}, statusError(resp.StatusCode, responseBody, endpoint)
+	}
+
+	var message strings.Builder
+	var usage *types.UsageInfo
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var chunk OllamaResponse
+		if err := decoder.Decode(&chunk); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return types.GenerationResult{}, fmt.Errorf("failed to decode stream chunk: %v", err)
+		}
+
+		if chunk.Error != "" {
+			return types.GenerationResult{}, fmt.Errorf("Ollama stream error: %s", chunk.Error)
+		}
+
+		if chunk.Message.Content != "" {
+			message.WriteString(chunk.Message.Content)
+			if onChunk != nil {
+				onChunk(chunk.Message.Content)
+			}
+		}
+
+		if chunk.Done {
+			usage = types.NewUsageInfo(chunk.PromptEvalCount, chunk.EvalCount)
+			break
+		}
+	}
+
+	if message.Len() == 0 {
+		return types.GenerationResult{}, fmt.Errorf("received empty response from Ollama")
+	}
+
+	return types.GenerationResult{Message: message.String(), Usage: usage}, nil
+}
+
+// ListModels returns the models pulled into the Ollama instance serving url.
+// Only the scheme and host of url are used.
+func ListModels(ctx context.Context, url string) ([]string, error) {
+	tagsURL, err := TagsURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tagsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	resp, err := httpClient.GetOllamaClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		responseBody, _ := io.ReadAll(resp.Body)
+		return nil, httpClient.NewStatusError(resp.StatusCode, "Ollama API returned status %d: %s", resp.StatusCode, string(responseBody))
+	}
+
+	var tags ollamaTags
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	models := make([]string, 0, len(tags.Models))
+	for _, model := range tags.Models {
+		models = append(models, model.Name)
 	}
+	return models, nil
+}
+
+// HasModel reports whether model is among the installed models. A name
+// without a tag matches its ":latest" tag, as it does in Ollama.
+func HasModel(installed []string, model string) bool {
+	if !strings.Contains(model, ":") {
+		model += ":latest"
+	}
+	for _, name := range installed {
+		if !strings.Contains(name, ":") {
+			name += ":latest"
+		}
+		if name == model {
+			return true
+		}
+	}
+	return false
+}
+
+// TagsURL maps a configured Ollama URL such as
+// http://localhost:11434/api/generate to its /api/tags endpoint.
+func TagsURL(rawURL string) (string, error) {
+	return apiURL(rawURL, ollamaTagsPath)
+}
+
+// ChatURL maps a configured Ollama URL to its /api/chat endpoint.
+func ChatURL(rawURL string) (string, error) {
+	return apiURL(rawURL, ollamaChatPath)
+}
+
+// apiURL replaces any /api/... endpoint on rawURL with path, keeping the
+// prefix in front of it so that servers behind a reverse proxy, such as
+// https://host/ollama, stay reachable.
+func apiURL(rawURL string, path string) (string, error) {
+	parsed, err := neturl.Parse(strings.TrimSpace(rawURL))
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("invalid Ollama URL %q", rawURL)
+	}
+	base := strings.TrimSuffix(parsed.Path, "/")
+	if prefix, endpoint, found := cutLast(base, "/api"); found && !strings.Contains(strings.TrimPrefix(endpoint, "/"), "/") {
+		base = prefix
+	}
+	parsed.Path = base + path
+	parsed.RawPath = ""
+	parsed.RawQuery = ""
+	return parsed.String(), nil
+}
+
+// cutLast is strings.Cut around the last occurrence of sep.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// ParseKeepAlive validates a keep_alive setting and returns the value to send:
+// a number of seconds when value is numeric, otherwise a duration string.
+func ParseKeepAlive(value string) (any, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return seconds, nil
+	}
+	if _, err := time.ParseDuration(value); err != nil {
+		return nil, fmt.Errorf("invalid keep_alive %q: use a duration such as 10m or a number of seconds", value)
+	}
+	return value, nil
+}
+
+// newChatRequest builds the /api/chat request used by both the blocking and
+// the streaming calls.
+func newChatRequest(ctx context.Context, changes string, endpoint Endpoint, opts *types.GenerationOptions, stream bool) (*http.Request, error) {
+	chatURL, err := ChatURL(endpoint.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	model := endpoint.Model
+	if model == "" {
+		model = DefaultModel
+	}
+
+	keepAlive, err := ParseKeepAlive(endpoint.Settings.KeepAlive)
+	if err != nil {
+		return nil, err
+	}
+
+	reqBody := OllamaRequest{
+		Model:     model,
+		Messages:  types.BuildPrompt(changes, opts).Messages(),
+		Stream:    stream,
+		KeepAlive: keepAlive,
+	}
+	// The request's sampling settings replace the saved Ollama options.
+	settings := endpoint.Settings
+	sampling := types.SamplingOf(opts)
+	options := OllamaOptions{
+		Temperature: settings.Temperature,
+		NumCtx:      settings.NumCtx,
+		NumPredict:  settings.NumPredict,
+		TopP:        sampling.TopP,
+		Seed:        sampling.Seed,
+	}
+	if sampling.Temperature != nil {
+		options.Temperature = sampling.Temperature
+	}
+	if sampling.MaxTokens > 0 {
+		options.NumPredict = sampling.MaxTokens
+	}
+	if options != (OllamaOptions{}) {
+		reqBody.Options = &options
+	}
+
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, chatURL, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", ollamaContentType)
+
+	return req, nil
+}
+
+// statusError turns a failed response into an error. Ollama answers 404 when
+// the model has not been pulled, which gets a message saying how to fix it.
+func statusError(statusCode int, body []byte, endpoint Endpoint) error {
+	err := httpClient.NewStatusError(statusCode, "Ollama API returned status %d: %s", statusCode, string(body))
+
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	if statusCode != http.StatusNotFound || json.Unmarshal(body, &apiErr) != nil || !strings.Contains(apiErr.Error, "not found") {
+		return err
+	}
+
+	model := endpoint.Model
+	if model == "" {
+		model = DefaultModel
+	}
+	return &modelNotPulledError{model: model, status: err}
+}
+
+// modelNotPulledError tells the user which model to pull. It unwraps to the
+// 404 status error so fallback treats it like any other missing model.
+type modelNotPulledError struct {
+	model  string
+	status *httpClient.StatusError
+}
+
+func (e *modelNotPulledError) Error() string {
+	return fmt.Sprintf("Ollama model %q is not pulled: run 'ollama pull %s' or choose an installed model with 'commit llm update'", e.model, e.model)
+}
+
+func (e *modelNotPulledError) Unwrap() error {
+	return e.status
+}
 
-	return response.Response, nil
+func (e *modelNotPulledError) Is(target error) bool {
+	return target == ErrModelNotPulled
 }
@@ -1,12 +1,17 @@
 package ollama
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	httpClient "github.com/dfanso/commit-msg/internal/http"
 	"github.com/dfanso/commit-msg/pkg/types"
 )
 
@@ -16,7 +21,7 @@ func TestGenerateCommitMessage(t *testing.T) {
 	t.Run("returns error for empty URL", func(t *testing.T) {
 		t.Parallel()
 
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", "", "model", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", Endpoint{URL: "", Model: "model"}, nil)
 		if err == nil {
 			t.Fatal("expected error for empty URL")
 		}
@@ -25,7 +30,7 @@ func TestGenerateCommitMessage(t *testing.T) {
 	t.Run("returns error for empty changes", func(t *testing.T) {
 		t.Parallel()
 
-		_, err := GenerateCommitMessage(&types.Config{}, "", "http://localhost:11434/api/generate", "model", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "", Endpoint{URL: "http://localhost:11434/api/generate", Model: "model"}, nil)
 		if err == nil {
 			t.Fatal("expected error for empty changes")
 		}
@@ -35,8 +40,8 @@ func TestGenerateCommitMessage(t *testing.T) {
 		t.Parallel()
 
 		expectedResponse := OllamaResponse{
-			Response: "feat: add new feature",
-			Done:     true,
+			Message: types.Message{Role: "assistant", Content: "feat: add new feature"},
+			Done:    true,
 		}
 
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -53,8 +58,8 @@ func TestGenerateCommitMessage(t *testing.T) {
 				t.Fatalf("failed to decode request: %v", err)
 			}
 
-			if req["model"] != "llama3:latest" {
-				t.Fatalf("expected model 'llama3:latest', got %v", req["model"])
+			if req["model"] != DefaultModel {
+				t.Fatalf("expected model %q, got %v", DefaultModel, req["model"])
 			}
 
 			if req["stream"] != false {
@@ -67,7 +72,7 @@ func TestGenerateCommitMessage(t *testing.T) {
 		t.Cleanup(server.Close)
 
 		// Test with empty model to verify default is used
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", server.URL, "", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", Endpoint{URL: server.URL}, nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -77,8 +82,8 @@ func TestGenerateCommitMessage(t *testing.T) {
 		t.Parallel()
 
 		expectedResponse := OllamaResponse{
-			Response: "feat: add new feature",
-			Done:     true,
+			Message: types.Message{Role: "assistant", Content: "feat: add new feature"},
+			Done:    true,
 		}
 
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -96,7 +101,7 @@ func TestGenerateCommitMessage(t *testing.T) {
 		}))
 		t.Cleanup(server.Close)
 
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", server.URL, "custom-model", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", Endpoint{URL: server.URL, Model: "custom-model"}, nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -110,8 +115,10 @@ func TestGenerateCommitMessageWithMockServer(t *testing.T) {
 		t.Parallel()
 
 		expectedResponse := OllamaResponse{
-			Response: "feat: add new feature",
-			Done:     true,
+			Message:         types.Message{Role: "assistant", Content: "feat: add new feature"},
+			Done:            true,
+			PromptEvalCount: 26,
+			EvalCount:       290,
 		}
 
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -120,13 +127,17 @@ func TestGenerateCommitMessageWithMockServer(t *testing.T) {
 		}))
 		t.Cleanup(server.Close)
 
-		result, err := GenerateCommitMessage(&types.Config{}, "some changes", server.URL, "llama3:latest", nil)
+		result, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", Endpoint{URL: server.URL, Model: "llama3:latest"}, nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 
-		if result != expectedResponse.Response {
-			t.Fatalf("expected response '%s', got '%s'", expectedResponse.Response, result)
+		if result.Message != expectedResponse.Message.Content {
+			t.Fatalf("expected response '%s', got '%s'", expectedResponse.Message.Content, result.Message)
+		}
+
+		if result.Usage == nil || result.Usage.PromptTokens != 26 || result.Usage.CompletionTokens != 290 {
+			t.Fatalf("unexpected usage: %+v", result.Usage)
 		}
 	})
 
@@ -139,7 +150,7 @@ func TestGenerateCommitMessageWithMockServer(t *testing.T) {
 		}))
 		t.Cleanup(server.Close)
 
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", server.URL, "llama3:latest", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", Endpoint{URL: server.URL, Model: "llama3:latest"}, nil)
 		if err == nil {
 			t.Fatal("expected error for API error response")
 		}
@@ -158,7 +169,7 @@ func TestGenerateCommitMessageWithMockServer(t *testing.T) {
 		}))
 		t.Cleanup(server.Close)
 
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", server.URL, "llama3:latest", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", Endpoint{URL: server.URL, Model: "llama3:latest"}, nil)
 		if err == nil {
 			t.Fatal("expected error for invalid JSON response")
 		}
@@ -172,8 +183,8 @@ func TestGenerateCommitMessageWithMockServer(t *testing.T) {
 		t.Parallel()
 
 		expectedResponse := OllamaResponse{
-			Response: "",
-			Done:     true,
+			Message: types.Message{Role: "assistant", Content: ""},
+			Done:    true,
 		}
 
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -182,7 +193,7 @@ func TestGenerateCommitMessageWithMockServer(t *testing.T) {
 		}))
 		t.Cleanup(server.Close)
 
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", server.URL, "llama3:latest", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", Endpoint{URL: server.URL, Model: "llama3:latest"}, nil)
 		if err == nil {
 			t.Fatal("expected error for empty response content")
 		}
@@ -196,7 +207,7 @@ func TestGenerateCommitMessageWithMockServer(t *testing.T) {
 		t.Parallel()
 
 		// Use an invalid URL to simulate network error
-		_, err := GenerateCommitMessage(&types.Config{}, "some changes", "http://localhost:99999/invalid", "llama3:latest", nil)
+		_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", Endpoint{URL: "http://localhost:99999/invalid", Model: "llama3:latest"}, nil)
 		if err == nil {
 			t.Fatal("expected error for network error")
 		}
@@ -212,20 +223,21 @@ func TestGenerateCommitMessageIncludesStyleInstructions(t *testing.T) {
 	}
 
 	expectedResponse := OllamaResponse{
-		Response: "feat: add new feature",
-		Done:     true,
+		Message: types.Message{Role: "assistant", Content: "feat: add new feature"},
+		Done:    true,
 	}
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var req map[string]interface{}
+		var req OllamaRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			t.Fatalf("failed to decode request: %v", err)
 		}
 
-		prompt, ok := req["prompt"].(string)
-		if !ok {
-			t.Fatal("expected prompt to be a string")
+		last := len(req.Messages) - 1
+		if last < 1 || req.Messages[0].Role != "system" || req.Messages[last].Role != "user" {
+			t.Fatalf("expected system and user messages, got %+v", req.Messages)
 		}
+		prompt := req.Messages[last].Content
 
 		// Check that style instruction is included in the prompt
 		if !strings.Contains(prompt, "Use a casual tone") {
@@ -242,7 +254,7 @@ func TestGenerateCommitMessageIncludesStyleInstructions(t *testing.T) {
 	}))
 	t.Cleanup(server.Close)
 
-	_, err := GenerateCommitMessage(&types.Config{}, "some changes", server.URL, "llama3:latest", opts)
+	_, err := GenerateCommitMessage(context.Background(), &types.Config{}, "some changes", Endpoint{URL: server.URL, Model: "llama3:latest"}, opts)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -255,20 +267,21 @@ func TestGenerateCommitMessageWithLongChanges(t *testing.T) {
 	longChanges := strings.Repeat("This is a test change. ", 1000)
 
 	expectedResponse := OllamaResponse{
-		Response: "feat: add new feature",
-		Done:     true,
+		Message: types.Message{Role: "assistant", Content: "feat: add new feature"},
+		Done:    true,
 	}
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var req map[string]interface{}
+		var req OllamaRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			t.Fatalf("failed to decode request: %v", err)
 		}
 
-		prompt, ok := req["prompt"].(string)
-		if !ok {
-			t.Fatal("expected prompt to be a string")
+		last := len(req.Messages) - 1
+		if last < 1 || req.Messages[0].Role != "system" || req.Messages[last].Role != "user" {
+			t.Fatalf("expected system and user messages, got %+v", req.Messages)
 		}
+		prompt := req.Messages[last].Content
 
 		// Check that the long changes are included in the prompt
 		if !strings.Contains(prompt, longChanges) {
@@ -280,7 +293,7 @@ func TestGenerateCommitMessageWithLongChanges(t *testing.T) {
 	}))
 	t.Cleanup(server.Close)
 
-	_, err := GenerateCommitMessage(&types.Config{}, longChanges, server.URL, "llama3:latest", nil)
+	_, err := GenerateCommitMessage(context.Background(), &types.Config{}, longChanges, Endpoint{URL: server.URL, Model: "llama3:latest"}, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -298,20 +311,21 @@ Line 2
 Line 3`
 
 	expectedResponse := OllamaResponse{
-		Response: "feat: add new feature",
-		Done:     true,
+		Message: types.Message{Role: "assistant", Content: "feat: add new feature"},
+		Done:    true,
 	}
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var req map[string]interface{}
+		var req OllamaRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			t.Fatalf("failed to decode request: %v", err)
 		}
 
-		prompt, ok := req["prompt"].(string)
-		if !ok {
-			t.Fatal("expected prompt to be a string")
+		last := len(req.Messages) - 1
+		if last < 1 || req.Messages[0].Role != "system" || req.Messages[last].Role != "user" {
+			t.Fatalf("expected system and user messages, got %+v", req.Messages)
 		}
+		prompt := req.Messages[last].Content
 
 		// Check that special characters are included in the prompt
 		if !strings.Contains(prompt, "ñáéíóú 🚀 🎉") {
@@ -323,7 +337,7 @@ Line 3`
 	}))
 	t.Cleanup(server.Close)
 
-	_, err := GenerateCommitMessage(&types.Config{}, changes, server.URL, "llama3:latest", nil)
+	_, err := GenerateCommitMessage(context.Background(), &types.Config{}, changes, Endpoint{URL: server.URL, Model: "llama3:latest"}, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -333,8 +347,8 @@ func TestOllamaRequestSerialization(t *testing.T) {
 	t.Parallel()
 
 	req := OllamaRequest{
-		Model:  "llama3:latest",
-		Prompt: "test prompt",
+		Model:    "llama3:latest",
+		Messages: []types.Message{{Role: "user", Content: "test prompt"}},
 	}
 
 	data, err := json.Marshal(req)
@@ -351,8 +365,12 @@ func TestOllamaRequestSerialization(t *testing.T) {
 		t.Fatalf("expected model %s, got %s", req.Model, unmarshaled.Model)
 	}
 
-	if unmarshaled.Prompt != req.Prompt {
-		t.Fatalf("expected prompt %s, got %s", req.Prompt, unmarshaled.Prompt)
+	if len(unmarshaled.Messages) != 1 || unmarshaled.Messages[0] != req.Messages[0] {
+		t.Fatalf("expected messages %+v, got %+v", req.Messages, unmarshaled.Messages)
+	}
+
+	if bytes.Contains(data, []byte("options")) || bytes.Contains(data, []byte("keep_alive")) {
+		t.Fatalf("expected unset options to be omitted, got %s", data)
 	}
 }
 
@@ -360,7 +378,7 @@ func TestOllamaResponseDeserialization(t *testing.T) {
 	t.Parallel()
 
 	jsonData := `{
-		"response": "feat: add new feature",
+		"message": {"role": "assistant", "content": "feat: add new feature"},
 		"done": true
 	}`
 
@@ -369,8 +387,8 @@ func TestOllamaResponseDeserialization(t *testing.T) {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if resp.Response != "feat: add new feature" {
-		t.Fatalf("expected response 'feat: add new feature', got %s", resp.Response)
+	if resp.Message.Content != "feat: add new feature" {
+		t.Fatalf("expected response 'feat: add new feature', got %s", resp.Message.Content)
 	}
 
 	if resp.Done != true {
@@ -386,8 +404,8 @@ func TestGenerateCommitMessageWithConfig(t *testing.T) {
 	}
 
 	expectedResponse := OllamaResponse{
-		Response: "feat: add new feature",
-		Done:     true,
+		Message: types.Message{Role: "assistant", Content: "feat: add new feature"},
+		Done:    true,
 	}
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -396,7 +414,7 @@ func TestGenerateCommitMessageWithConfig(t *testing.T) {
 	}))
 	t.Cleanup(server.Close)
 
-	_, err := GenerateCommitMessage(config, "some changes", server.URL, "llama3:latest", nil)
+	_, err := GenerateCommitMessage(context.Background(), config, "some changes", Endpoint{URL: server.URL, Model: "llama3:latest"}, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -406,8 +424,8 @@ func TestGenerateCommitMessageWithNilConfig(t *testing.T) {
 	t.Parallel()
 
 	expectedResponse := OllamaResponse{
-		Response: "feat: add new feature",
-		Done:     true,
+		Message: types.Message{Role: "assistant", Content: "feat: add new feature"},
+		Done:    true,
 	}
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -416,8 +434,253 @@ func TestGenerateCommitMessageWithNilConfig(t *testing.T) {
 	}))
 	t.Cleanup(server.Close)
 
-	_, err := GenerateCommitMessage(nil, "some changes", server.URL, "llama3:latest", nil)
+	_, err := GenerateCommitMessage(context.Background(), nil, "some changes", Endpoint{URL: server.URL, Model: "llama3:latest"}, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestGenerateCommitMessageSendsOptions(t *testing.T) {
+	t.Parallel()
+
+	temperature := 0.2
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("expected /api/chat, got %s", r.URL.Path)
+		}
+
+		var req map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+
+		options, _ := req["options"].(map[string]any)
+		if options["temperature"] != 0.2 || options["num_ctx"] != float64(8192) || options["num_predict"] != float64(-1) {
+			t.Errorf("unexpected options: %v", req["options"])
+		}
+		if req["keep_alive"] != "10m" {
+			t.Errorf("expected keep_alive 10m, got %v", req["keep_alive"])
+		}
+
+		json.NewEncoder(w).Encode(OllamaResponse{Message: types.Message{Role: "assistant", Content: "feat: add options"}, Done: true})
+	}))
+	t.Cleanup(server.Close)
+
+	endpoint := Endpoint{
+		URL:      server.URL + "/api/generate",
+		Model:    "llama3.1",
+		Settings: types.OllamaSettings{Temperature: &temperature, NumCtx: 8192, NumPredict: -1, KeepAlive: "10m"},
+	}
+	if _, err := GenerateCommitMessage(context.Background(), nil, "some changes", endpoint, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	endpoint.Settings.KeepAlive = "forever"
+	if _, err := GenerateCommitMessage(context.Background(), nil, "some changes", endpoint, nil); err == nil {
+		t.Fatal("expected an invalid keep_alive to be rejected")
+	}
+}
+
+func TestParseKeepAlive(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]any{
+		"":    nil,
+		"10m": "10m",
+		"-1":  float64(-1),
+		"300": float64(300),
+	}
+	for input, expected := range cases {
+		got, err := ParseKeepAlive(input)
+		if err != nil || got != expected {
+			t.Fatalf("ParseKeepAlive(%q) = %v, %v; want %v", input, got, err, expected)
+		}
+	}
+}
+
+func TestGenerateCommitMessageModelNotPulled(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model \"qwen2.5-coder\" not found, try pulling it first"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	endpoint := Endpoint{URL: server.URL, Model: "qwen2.5-coder"}
+	_, err := GenerateCommitMessage(context.Background(), nil, "some changes", endpoint, nil)
+	if !errors.Is(err, ErrModelNotPulled) {
+		t.Fatalf("expected ErrModelNotPulled, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "ollama pull qwen2.5-coder") {
+		t.Fatalf("expected the pull command in %q", err)
+	}
+
+	var statusErr *httpClient.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected the 404 status to be kept, got %v", err)
+	}
+
+	_, err = StreamCommitMessage(context.Background(), nil, "some changes", endpoint, nil, nil)
+	if !errors.Is(err, ErrModelNotPulled) {
+		t.Fatalf("expected ErrModelNotPulled from the stream, got %v", err)
+	}
+}
+
+func TestHasModel(t *testing.T) {
+	t.Parallel()
+
+	installed := []string{"llama3.1:latest", "qwen2.5-coder:7b"}
+	if !HasModel(installed, "llama3.1") || !HasModel(installed, "qwen2.5-coder:7b") {
+		t.Fatal("expected installed models to match")
+	}
+	if HasModel(installed, "qwen2.5-coder") || HasModel(installed, "mistral") {
+		t.Fatal("expected models that are not pulled not to match")
+	}
+}
+
+func TestStreamCommitMessage(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+
+		if req["stream"] != true {
+			t.Fatalf("expected stream true, got %v", req["stream"])
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(w)
+		encoder.Encode(OllamaResponse{Message: types.Message{Role: "assistant", Content: "feat: "}})
+		encoder.Encode(OllamaResponse{Message: types.Message{Role: "assistant", Content: "stream tokens"}})
+		encoder.Encode(OllamaResponse{Done: true, PromptEvalCount: 10, EvalCount: 4})
+	}))
+	t.Cleanup(server.Close)
+
+	var chunks []string
+	msg, err := StreamCommitMessage(context.Background(), &types.Config{}, "some changes", Endpoint{URL: server.URL, Model: "model"}, nil, func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if msg.Message != "feat: stream tokens" {
+		t.Fatalf("unexpected message: %q", msg.Message)
+	}
+
+	if msg.Usage == nil || msg.Usage.TotalTokens != 14 {
+		t.Fatalf("unexpected usage: %+v", msg.Usage)
+	}
+
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(chunks))
+	}
+}
+
+func TestStreamCommitMessageError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(OllamaResponse{Error: "model not found"})
+	}))
+	t.Cleanup(server.Close)
+
+	_, err := StreamCommitMessage(context.Background(), &types.Config{}, "some changes", Endpoint{URL: server.URL, Model: "missing"}, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "model not found") {
+		t.Fatalf("expected stream error to be surfaced, got %v", err)
+	}
+}
+
+func TestGenerateCommitMessageHonorsContext(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := GenerateCommitMessage(ctx, &types.Config{}, "some changes", Endpoint{URL: server.URL, Model: "model"}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestListModels(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/tags" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"models":[{"name":"llama3.1:latest"},{"name":"qwen2.5-coder:7b"}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	models, err := ListModels(context.Background(), server.URL+"/api/generate")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Join(models, ",") != "llama3.1:latest,qwen2.5-coder:7b" {
+		t.Fatalf("unexpected models: %v", models)
+	}
+}
+
+func TestTagsURL(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"http://localhost:11434/api/generate": "http://localhost:11434/api/tags",
+		"http://ollama.lan:11434":             "http://ollama.lan:11434/api/tags",
+		"https://example.com/ollama":          "https://example.com/ollama/api/tags",
+		"https://example.com/ollama/":         "https://example.com/ollama/api/tags",
+		"https://example.com/ollama/api/chat": "https://example.com/ollama/api/tags",
+		"https://example.com/ollama/api":      "https://example.com/ollama/api/tags",
+	}
+	for input, expected := range cases {
+		got, err := TagsURL(input)
+		if err != nil || got != expected {
+			t.Fatalf("TagsURL(%q) = %q, %v; want %q", input, got, err, expected)
+		}
+	}
+
+	if _, err := TagsURL("localhost"); err == nil {
+		t.Fatal("expected error for URL without host")
+	}
+
+	if got, err := ChatURL("http://localhost:11434/api/generate"); err != nil || got != "http://localhost:11434/api/chat" {
+		t.Fatalf("ChatURL() = %q, %v", got, err)
+	}
+}
+
+func TestNewChatRequestSamplingOverridesSettings(t *testing.T) {
+	t.Parallel()
+
+	temperature := 0.7
+	endpoint := Endpoint{URL: DefaultURL, Settings: types.OllamaSettings{Temperature: &temperature, NumCtx: 8192, NumPredict: 128}}
+	sampling := types.DeterministicSampling()
+	sampling.MaxTokens = 512
+
+	req, err := newChatRequest(context.Background(), "some changes", endpoint, &types.GenerationOptions{Sampling: sampling}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var body OllamaRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+	options := body.Options
+	if options == nil || *options.Temperature != 0 || options.NumPredict != 512 || options.NumCtx != 8192 || *options.Seed != types.DeterministicSeed {
+		t.Fatalf("unexpected options: %+v", options)
+	}
+}
@@ -0,0 +1,306 @@
+// Package openaicompat talks to self-hosted servers that implement the OpenAI
+// chat completions protocol, such as vLLM, llama.cpp server and LM Studio.
+package openaicompat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+const (
+	chatCompletionsPath    = "/chat/completions"
+	modelsPath             = "/models"
+	contentTypeJSON        = "application/json"
+	contentTypeEventStream = "text/event-stream"
+	authorizationPrefix    = "Bearer "
+	streamDone             = "[DONE]"
+)
+
+// Endpoint describes how to reach an OpenAI-compatible server.
+type Endpoint struct {
+	// BaseURL is the API root, e.g. http://localhost:8000/v1. A URL that already
+	// ends in /chat/completions is used as-is.
+	BaseURL string
+	// Model is sent as the "model" field; some servers ignore it.
+	Model string
+	// APIKey is optional and sent as a bearer token when set.
+	APIKey string
+	// Headers are extra HTTP headers added to every request.
+	Headers map[string]string
+	// Query holds parameters added to every request URL, such as Azure's
+	// api-version.
+	Query url.Values
+	// Client sends the requests; nil selects the long-timeout client meant
+	// for local servers.
+	Client *http.Client
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model         string               `json:"model,omitempty"`
+	Messages      []chatMessage        `json:"messages"`
+	Stream        bool                 `json:"stream,omitempty"`
+	StreamOptions *types.StreamOptions `json:"stream_options,omitempty"`
+	Temperature   *float64             `json:"temperature,omitempty"`
+	MaxTokens     int                  `json:"max_tokens,omitempty"`
+	TopP          *float64             `json:"top_p,omitempty"`
+	Seed          *int64               `json:"seed,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Usage types.UsageInfo `json:"usage"`
+}
+
+type modelList struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+type chatStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *types.UsageInfo `json:"usage,omitempty"`
+}
+
+// GenerateCommitMessage requests a commit message from an OpenAI-compatible
+// chat completions endpoint.
+func GenerateCommitMessage(ctx context.Context, _ *types.Config, changes string, endpoint Endpoint, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	req, err := newChatRequest(ctx, changes, endpoint, opts, false)
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
+
+	resp, err := endpoint.httpClient().Do(req)
+	if err != nil {
+		return types.GenerationResult{}, fmt.Errorf("failed to call OpenAI-compatible API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return types.GenerationResult{}, fmt.Errorf("failed to read OpenAI-compatible response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return types.GenerationResult{}, internalHTTP.NewStatusError(resp.StatusCode, "OpenAI-compatible API returned status %d: %s", resp.StatusCode, string(responseBody))
+	}
+
+	var completion chatResponse
+	if err := json.Unmarshal(responseBody, &completion); err != nil {
+		return types.GenerationResult{}, fmt.Errorf("failed to decode OpenAI-compatible response: %w", err)
+	}
+
+	if len(completion.Choices) == 0 || completion.Choices[0].Message.Content == "" {
+		return types.GenerationResult{}, fmt.Errorf("OpenAI-compatible API returned empty response")
+	}
+
+	return types.GenerationResult{
+		Message: completion.Choices[0].Message.Content,
+		Usage:   types.NewUsageInfo(completion.Usage.PromptTokens, completion.Usage.CompletionTokens),
+	}, nil
+}
+
+// StreamCommitMessage requests a streamed completion and forwards each content
+// delta to onChunk, returning the assembled message when the stream ends.
+func StreamCommitMessage(ctx context.Context, _ *types.Config, changes string, endpoint Endpoint, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	req, err := newChatRequest(ctx, changes, endpoint, opts, true)
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
+	req.Header.Set("Accept", contentTypeEventStream)
+
+	resp, err := endpoint.httpClient().Do(req)
+	if err != nil {
+		return types.GenerationResult{}, fmt.Errorf("failed to call OpenAI-compatible API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		responseBody, _ := io.ReadAll(resp.Body)
+		return types.GenerationResult{}, internalHTTP.NewStatusError(resp.StatusCode, "OpenAI-compatible API returned status %d: %s", resp.StatusCode, string(responseBody))
+	}
+
+	var message strings.Builder
+	var usage *types.UsageInfo
+	err = internalHTTP.ReadServerSentEvents(resp.Body, func(event internalHTTP.ServerSentEvent) error {
+		if event.Data == streamDone {
+			return io.EOF
+		}
+
+		var chunk chatStreamChunk
+		if err := json.Unmarshal([]byte(event.Data), &chunk); err != nil {
+			return fmt.Errorf("failed to decode OpenAI-compatible stream chunk: %w", err)
+		}
+
+		if chunk.Usage != nil {
+			usage = types.NewUsageInfo(chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens)
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			message.WriteString(choice.Delta.Content)
+			if onChunk != nil {
+				onChunk(choice.Delta.Content)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return types.GenerationResult{}, fmt.Errorf("failed to read OpenAI-compatible stream: %w", err)
+	}
+
+	if message.Len() == 0 {
+		return types.GenerationResult{}, fmt.Errorf("OpenAI-compatible API returned empty response")
+	}
+
+	return types.GenerationResult{Message: message.String(), Usage: usage}, nil
+}
+
+// ListModels queries the server's /models endpoint and returns the model IDs
+// it reports.
+func ListModels(ctx context.Context, endpoint Endpoint) ([]string, error) {
+	if strings.TrimSpace(endpoint.BaseURL) == "" {
+		return nil, fmt.Errorf("OpenAI-compatible base URL is required")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, withQuery(ModelsURL(endpoint.BaseURL), endpoint), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OpenAI-compatible request: %w", err)
+	}
+	setHeaders(req, endpoint)
+
+	resp, err := endpoint.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call OpenAI-compatible API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAI-compatible response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, internalHTTP.NewStatusError(resp.StatusCode, "OpenAI-compatible API returned status %d: %s", resp.StatusCode, string(responseBody))
+	}
+
+	var list modelList
+	if err := json.Unmarshal(responseBody, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode OpenAI-compatible model list: %w", err)
+	}
+
+	models := make([]string, 0, len(list.Data))
+	for _, model := range list.Data {
+		models = append(models, model.ID)
+	}
+	return models, nil
+}
+
+// ChatCompletionsURL resolves the chat completions URL for a base URL.
+func ChatCompletionsURL(baseURL string) string {
+	return apiRoot(baseURL) + chatCompletionsPath
+}
+
+// ModelsURL resolves the model listing URL for a base URL.
+func ModelsURL(baseURL string) string {
+	return apiRoot(baseURL) + modelsPath
+}
+
+// apiRoot strips trailing slashes and a /chat/completions suffix so either
+// form of URL can be configured.
+func apiRoot(baseURL string) string {
+	trimmed := strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	return strings.TrimSuffix(trimmed, chatCompletionsPath)
+}
+
+// withQuery appends the endpoint's query parameters to target.
+func withQuery(target string, endpoint Endpoint) string {
+	if len(endpoint.Query) == 0 {
+		return target
+	}
+	return target + "?" + endpoint.Query.Encode()
+}
+
+func newChatRequest(ctx context.Context, changes string, endpoint Endpoint, opts *types.GenerationOptions, stream bool) (*http.Request, error) {
+	if changes == "" {
+		return nil, fmt.Errorf("no changes provided for commit message generation")
+	}
+
+	if strings.TrimSpace(endpoint.BaseURL) == "" {
+		return nil, fmt.Errorf("OpenAI-compatible base URL is required")
+	}
+
+	// Unset sampling fields are left out, so the server's defaults apply.
+	sampling := types.SamplingOf(opts)
+	payload := chatRequest{
+		Model:       endpoint.Model,
+		Stream:      stream,
+		Temperature: sampling.Temperature,
+		MaxTokens:   sampling.MaxTokens,
+		TopP:        sampling.TopP,
+		Seed:        sampling.Seed,
+	}
+	for _, message := range types.BuildPrompt(changes, opts).Messages() {
+		payload.Messages = append(payload.Messages, chatMessage{Role: message.Role, Content: message.Content})
+	}
+	if stream {
+		// Servers that do not know stream_options ignore it.
+		payload.StreamOptions = &types.StreamOptions{IncludeUsage: true}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal OpenAI-compatible request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, withQuery(ChatCompletionsURL(endpoint.BaseURL), endpoint), bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OpenAI-compatible request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", contentTypeJSON)
+	setHeaders(req, endpoint)
+
+	return req, nil
+}
+
+// setHeaders adds the bearer token, when configured, and any extra headers.
+func setHeaders(req *http.Request, endpoint Endpoint) {
+	if endpoint.APIKey != "" {
+		req.Header.Set("Authorization", authorizationPrefix+endpoint.APIKey)
+	}
+	for name, value := range endpoint.Headers {
+		req.Header.Set(name, value)
+	}
+}
+
+// httpClient defaults to the long-timeout client because these servers
+// usually run inference on local hardware, like Ollama.
+func (e Endpoint) httpClient() *http.Client {
+	if e.Client != nil {
+		return e.Client
+	}
+	return internalHTTP.GetOllamaClient()
+}
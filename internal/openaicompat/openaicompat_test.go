@@ -0,0 +1,202 @@
+package openaicompat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+func TestChatCompletionsURL(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"http://localhost:8000/v1":                    "http://localhost:8000/v1/chat/completions",
+		"http://localhost:8000/v1/":                   "http://localhost:8000/v1/chat/completions",
+		"http://localhost:1234/v1/chat/completions":   "http://localhost:1234/v1/chat/completions",
+		" http://localhost:1234/v1/chat/completions/": "http://localhost:1234/v1/chat/completions",
+	}
+
+	for input, expected := range cases {
+		if got := ChatCompletionsURL(input); got != expected {
+			t.Fatalf("ChatCompletionsURL(%q) = %q, want %q", input, got, expected)
+		}
+	}
+}
+
+func TestGenerateCommitMessage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sends model, key and extra headers", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/chat/completions" {
+				t.Fatalf("unexpected path: %s", r.URL.Path)
+			}
+
+			if got := r.Header.Get("Authorization"); got != "Bearer local-key" {
+				t.Fatalf("unexpected authorization header: %q", got)
+			}
+
+			if got := r.Header.Get("X-Team"); got != "platform" {
+				t.Fatalf("expected extra header, got %q", got)
+			}
+
+			var req chatRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+
+			if req.Model != "qwen2.5-coder" {
+				t.Fatalf("unexpected model: %q", req.Model)
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"feat: add local provider"}}],"usage":{"prompt_tokens":200,"completion_tokens":9,"total_tokens":209}}`))
+		}))
+		t.Cleanup(server.Close)
+
+		msg, err := GenerateCommitMessage(context.Background(), &types.Config{}, "diff", Endpoint{
+			BaseURL: server.URL + "/v1",
+			Model:   "qwen2.5-coder",
+			APIKey:  "local-key",
+			Headers: map[string]string{"X-Team": "platform"},
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if msg.Message != "feat: add local provider" {
+			t.Fatalf("unexpected message: %q", msg.Message)
+		}
+
+		if msg.Usage == nil || msg.Usage.TotalTokens != 209 {
+			t.Fatalf("unexpected usage: %+v", msg.Usage)
+		}
+	})
+
+	t.Run("omits authorization without key", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if got := r.Header.Get("Authorization"); got != "" {
+				t.Fatalf("expected no authorization header, got %q", got)
+			}
+			w.Write([]byte(`{"choices":[{"message":{"content":"fix: handle nil"}}]}`))
+		}))
+		t.Cleanup(server.Close)
+
+		if _, err := GenerateCommitMessage(context.Background(), &types.Config{}, "diff", Endpoint{BaseURL: server.URL}, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("returns error for missing base URL", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := GenerateCommitMessage(context.Background(), &types.Config{}, "diff", Endpoint{}, nil); err == nil {
+			t.Fatal("expected error for missing base URL")
+		}
+	})
+
+	t.Run("returns error for non-OK status", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "model not loaded", http.StatusNotFound)
+		}))
+		t.Cleanup(server.Close)
+
+		if _, err := GenerateCommitMessage(context.Background(), &types.Config{}, "diff", Endpoint{BaseURL: server.URL}, nil); err == nil {
+			t.Fatal("expected error for non-OK status")
+		}
+	})
+}
+
+func TestStreamCommitMessage(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"docs: \"}}]}\n\n"))
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"update readme\"}}]}\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	var chunks []string
+	msg, err := StreamCommitMessage(context.Background(), &types.Config{}, "diff", Endpoint{BaseURL: server.URL}, nil, func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if msg.Message != "docs: update readme" || len(chunks) != 2 {
+		t.Fatalf("unexpected stream result %q (%d chunks)", msg.Message, len(chunks))
+	}
+
+	if msg.Usage != nil {
+		t.Fatalf("expected no usage when the server reports none, got %+v", msg.Usage)
+	}
+}
+
+func TestListModels(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/models" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer local-key" {
+			t.Fatalf("unexpected authorization header: %q", got)
+		}
+		w.Write([]byte(`{"object":"list","data":[{"id":"qwen2.5-coder"},{"id":"llama3.1"}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	models, err := ListModels(context.Background(), Endpoint{
+		BaseURL: server.URL + "/v1/chat/completions",
+		APIKey:  "local-key",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(models) != 2 || models[0] != "qwen2.5-coder" || models[1] != "llama3.1" {
+		t.Fatalf("unexpected models: %v", models)
+	}
+}
+
+func TestNewChatRequestSampling(t *testing.T) {
+	t.Parallel()
+
+	endpoint := Endpoint{BaseURL: "http://localhost:8000/v1", Model: "local-model"}
+	decode := func(opts *types.GenerationOptions) map[string]any {
+		t.Helper()
+		req, err := newChatRequest(context.Background(), "diff", endpoint, opts, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var payload map[string]any
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		return payload
+	}
+
+	for _, key := range []string{"temperature", "max_tokens", "top_p", "seed"} {
+		if _, ok := decode(nil)[key]; ok {
+			t.Fatalf("expected no %s without sampling settings", key)
+		}
+	}
+
+	payload := decode(&types.GenerationOptions{Sampling: types.DeterministicSampling()})
+	if payload["temperature"] != float64(0) || payload["seed"] != float64(types.DeterministicSeed) {
+		t.Fatalf("unexpected sampling in request: %v", payload)
+	}
+}
@@ -0,0 +1,247 @@
+// Package plugin runs provider backends that live outside this binary, in
+// the spirit of git credential helpers. A plugin is any executable named
+// commit-msg-provider-<name> on PATH, or a command declared in config.json.
+// It is started once per generation with the "generate" argument, reads a
+// Request as JSON on stdin and writes a Response as JSON on stdout.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/google/shlex"
+
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+// Prefix starts the name of every plugin executable found on PATH.
+const Prefix = "commit-msg-provider-"
+
+// ProtocolVersion is sent with every request so plugins can reject requests
+// they do not understand.
+const ProtocolVersion = 1
+
+// actionGenerate is the argument a plugin is started with.
+const actionGenerate = "generate"
+
+// Plugin is an external provider and the command that runs it.
+type Plugin struct {
+	// Name is the provider name the plugin is registered under.
+	Name string
+	// Command is the executable followed by any arguments.
+	Command []string
+}
+
+// Request is what a plugin receives on stdin.
+type Request struct {
+	Version  int    `json:"version"`
+	Provider string `json:"provider"`
+	// Model is the configured model, if any.
+	Model string `json:"model,omitempty"`
+	// Credential is the secret stored for the provider by `commit llm setup`.
+	Credential string            `json:"credential,omitempty"`
+	BaseURL    string            `json:"base_url,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	// Prompt is the complete prompt: the system message, the examples and
+	// the user message with the changes.
+	Prompt PromptPayload `json:"prompt"`
+	// Changes is the diff the prompt was built from.
+	Changes string         `json:"changes"`
+	Options OptionsPayload `json:"options"`
+}
+
+// PromptPayload carries the prompt both as separate parts and as the chat
+// messages most APIs expect.
+type PromptPayload struct {
+	System   string          `json:"system"`
+	User     string          `json:"user"`
+	Messages []types.Message `json:"messages"`
+}
+
+// OptionsPayload mirrors types.GenerationOptions.
+type OptionsPayload struct {
+	StyleInstruction string `json:"style_instruction,omitempty"`
+	Attempt          int    `json:"attempt,omitempty"`
+	// Sampling holds the temperature, max_tokens, top_p and seed to use,
+	// when any is set.
+	Sampling *types.Sampling `json:"sampling,omitempty"`
+}
+
+// Response is what a plugin writes to stdout.
+type Response struct {
+	Message string           `json:"message"`
+	Usage   *types.UsageInfo `json:"usage,omitempty"`
+	// Error reports a failure; the message is shown to the user.
+	Error string `json:"error,omitempty"`
+	// Status optionally carries the HTTP status behind Error, so rate limits
+	// and server errors trigger the fallback providers.
+	Status int `json:"status,omitempty"`
+}
+
+// NewRequest builds the request for changes.
+func NewRequest(name, model, credential string, settings types.ProviderSettings, changes string, opts *types.GenerationOptions) Request {
+	prompt := types.BuildPrompt(changes, opts)
+	req := Request{
+		Version:    ProtocolVersion,
+		Provider:   name,
+		Model:      model,
+		Credential: credential,
+		BaseURL:    settings.BaseURL,
+		Headers:    settings.Headers,
+		Prompt: PromptPayload{
+			System:   prompt.System,
+			User:     prompt.User,
+			Messages: prompt.Messages(),
+		},
+		Changes: changes,
+	}
+	if opts != nil {
+		req.Options = OptionsPayload{StyleInstruction: opts.StyleInstruction, Attempt: opts.Attempt}
+		if !opts.Sampling.IsZero() {
+			sampling := opts.Sampling
+			req.Options.Sampling = &sampling
+		}
+	}
+	return req
+}
+
+// Generate runs the plugin with req and returns the message it produced.
+// Cancelling ctx kills the plugin.
+func (p Plugin) Generate(ctx context.Context, req Request) (types.GenerationResult, error) {
+	if len(p.Command) == 0 {
+		return types.GenerationResult{}, fmt.Errorf("plugin %s has no command", p.Name)
+	}
+
+	input, err := json.Marshal(req)
+	if err != nil {
+		return types.GenerationResult{}, fmt.Errorf("failed to marshal plugin request: %w", err)
+	}
+
+	args := append(slices.Clone(p.Command[1:]), actionGenerate)
+	cmd := exec.CommandContext(ctx, p.Command[0], args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return types.GenerationResult{}, ctxErr
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return types.GenerationResult{}, fmt.Errorf("plugin %s failed: %w: %s", p.Name, err, msg)
+		}
+		return types.GenerationResult{}, fmt.Errorf("plugin %s failed: %w", p.Name, err)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return types.GenerationResult{}, fmt.Errorf("plugin %s wrote invalid JSON: %w", p.Name, err)
+	}
+	if resp.Error != "" {
+		if resp.Status != 0 {
+			return types.GenerationResult{}, internalHTTP.NewStatusError(resp.Status, "plugin %s: %s", p.Name, resp.Error)
+		}
+		return types.GenerationResult{}, fmt.Errorf("plugin %s: %s", p.Name, resp.Error)
+	}
+	if strings.TrimSpace(resp.Message) == "" {
+		return types.GenerationResult{}, fmt.Errorf("plugin %s returned empty response", p.Name)
+	}
+
+	result := types.GenerationResult{Message: resp.Message}
+	if resp.Usage != nil {
+		result.Usage = types.NewUsageInfo(resp.Usage.PromptTokens, resp.Usage.CompletionTokens).WithCachedPrompt(resp.Usage.CachedPromptTokens)
+	}
+	return result, nil
+}
+
+// Discover finds the plugin executables in the directories of pathList,
+// which is formatted like PATH. As with command lookup, the first directory
+// providing a name wins. Empty and relative entries are skipped, as
+// exec.LookPath refuses them, so the current directory never provides a
+// plugin.
+func Discover(pathList string) []Plugin {
+	var plugins []Plugin
+	seen := make(map[string]bool)
+	for _, dir := range filepath.SplitList(pathList) {
+		if !filepath.IsAbs(dir) {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok || seen[name] {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, Plugin{Name: name, Command: []string{path}})
+		}
+	}
+	return plugins
+}
+
+// Declared turns the plugins of config.json, a map from provider name to a
+// command line, into plugins. Command lines are split like a shell would.
+func Declared(commands map[string]string) ([]Plugin, error) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	plugins := make([]Plugin, 0, len(names))
+	for _, name := range names {
+		command, err := shlex.Split(commands[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid command for plugin %s: %w", name, err)
+		}
+		if strings.TrimSpace(name) == "" || len(command) == 0 {
+			return nil, errors.New("every plugin needs a name and a command")
+		}
+		plugins = append(plugins, Plugin{Name: name, Command: command})
+	}
+	return plugins, nil
+}
+
+// pluginName extracts the provider name from an executable's file name.
+func pluginName(fileName string) (string, bool) {
+	if !strings.HasPrefix(fileName, Prefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(fileName, Prefix)
+	if runtime.GOOS == "windows" {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return name, name != ""
+}
+
+// isExecutable reports whether path is a regular file the user may run. On
+// Windows, where there is no execute bit, the extension decides.
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(path))
+		return ext == ".exe" || ext == ".bat" || ext == ".cmd"
+	}
+	return info.Mode().Perm()&0o111 != 0
+}
@@ -0,0 +1,156 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+// writeScript creates an executable shell script in dir.
+func writeScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func skipOnWindows(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts need a POSIX shell")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+	skipOnWindows(t)
+
+	dir := t.TempDir()
+	requestPath := filepath.Join(dir, "request.json")
+	script := writeScript(t, dir, "commit-msg-provider-gateway", `
+[ "$1" = "generate" ] || { echo "unexpected action $1" >&2; exit 2; }
+cat > "`+requestPath+`"
+echo '{"message":"feat: route through the gateway","usage":{"prompt_tokens":40,"completion_tokens":6}}'
+`)
+
+	p := Plugin{Name: "gateway", Command: []string{script}}
+	settings := types.ProviderSettings{BaseURL: "https://gateway.internal", Headers: map[string]string{"X-Team": "core"}}
+	opts := &types.GenerationOptions{StyleInstruction: "be terse", Attempt: 2}
+	result, err := p.Generate(context.Background(), NewRequest("gateway", "big-model", "secret", settings, "diff --git a/x b/x", opts))
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if result.Message != "feat: route through the gateway" || result.Usage == nil || result.Usage.TotalTokens != 46 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	data, err := os.ReadFile(requestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatalf("plugin received invalid JSON: %v", err)
+	}
+	if req.Version != ProtocolVersion || req.Model != "big-model" || req.Credential != "secret" || req.BaseURL != "https://gateway.internal" || req.Headers["X-Team"] != "core" {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+	if req.Options.Attempt != 2 || req.Changes != "diff --git a/x b/x" || req.Prompt.Messages[0].Role != types.RoleSystem {
+		t.Fatalf("unexpected prompt or options: %+v", req)
+	}
+	if !strings.Contains(req.Prompt.User, "be terse") {
+		t.Fatalf("expected the style instruction in the user prompt, got %q", req.Prompt.User)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	t.Parallel()
+	skipOnWindows(t)
+
+	dir := t.TempDir()
+
+	crash := Plugin{Name: "crash", Command: []string{writeScript(t, dir, "crash", "echo 'gateway unreachable' >&2\nexit 1\n")}}
+	if _, err := crash.Generate(context.Background(), Request{}); err == nil || !strings.Contains(err.Error(), "gateway unreachable") {
+		t.Fatalf("expected stderr in the error, got %v", err)
+	}
+
+	limited := Plugin{Name: "limited", Command: []string{writeScript(t, dir, "limited", `echo '{"error":"slow down","status":429}'`)}}
+	_, err := limited.Generate(context.Background(), Request{})
+	var statusErr *internalHTTP.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected a 429 status error, got %v", err)
+	}
+
+	garbage := Plugin{Name: "garbage", Command: []string{writeScript(t, dir, "garbage", "echo hello")}}
+	if _, err := garbage.Generate(context.Background(), Request{}); err == nil || !strings.Contains(err.Error(), "invalid JSON") {
+		t.Fatalf("expected an invalid JSON error, got %v", err)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	t.Parallel()
+	skipOnWindows(t)
+
+	first, second := t.TempDir(), t.TempDir()
+	writeScript(t, first, "commit-msg-provider-gateway", "exit 0\n")
+	writeScript(t, second, "commit-msg-provider-gateway", "exit 0\n")
+	writeScript(t, second, "commit-msg-provider-local", "exit 0\n")
+	writeScript(t, second, "unrelated-tool", "exit 0\n")
+	if err := os.WriteFile(filepath.Join(second, "commit-msg-provider-notes"), []byte("not executable"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	plugins := Discover(first + string(os.PathListSeparator) + second)
+	if len(plugins) != 2 {
+		t.Fatalf("expected two plugins, got %+v", plugins)
+	}
+	if plugins[0].Name != "gateway" || plugins[0].Command[0] != filepath.Join(first, "commit-msg-provider-gateway") {
+		t.Fatalf("expected the first directory to win, got %+v", plugins[0])
+	}
+	if plugins[1].Name != "local" {
+		t.Fatalf("unexpected plugin %+v", plugins[1])
+	}
+}
+
+func TestDiscoverSkipsRelativeDirectories(t *testing.T) {
+	skipOnWindows(t)
+
+	dir := t.TempDir()
+	writeScript(t, dir, "commit-msg-provider-gateway", "exit 0\n")
+	t.Chdir(dir)
+
+	sep := string(os.PathListSeparator)
+	if plugins := Discover(sep + "." + sep + filepath.Join("..", filepath.Base(dir))); len(plugins) != 0 {
+		t.Fatalf("expected no plugins from the current directory, got %+v", plugins)
+	}
+}
+
+func TestDeclared(t *testing.T) {
+	t.Parallel()
+
+	plugins, err := Declared(map[string]string{
+		"gateway": `/opt/gateway/bin/provider --region "eu west"`,
+		"alpha":   "alpha-provider",
+	})
+	if err != nil {
+		t.Fatalf("Declared returned error: %v", err)
+	}
+	if len(plugins) != 2 || plugins[0].Name != "alpha" || strings.Join(plugins[1].Command, "|") != "/opt/gateway/bin/provider|--region|eu west" {
+		t.Fatalf("unexpected plugins: %+v", plugins)
+	}
+
+	if _, err := Declared(map[string]string{"empty": "  "}); err == nil {
+		t.Fatal("expected an error for an empty command")
+	}
+}
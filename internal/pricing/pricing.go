@@ -0,0 +1,285 @@
+// Package pricing estimates what a generation cost from the tokens it used.
+// Prices are kept per provider and model; the built-in table can be
+// overridden with a pricing.json file next to config.json.
+package pricing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+	StoreUtils "github.com/dfanso/commit-msg/utils"
+)
+
+// Wildcard is the model key of a provider-wide rate, used when no model
+// entry matches.
+const Wildcard = "*"
+
+// FileName is the name of the override file in the config directory.
+const FileName = "pricing.json"
+
+// Rate is what a model charges, in US dollars per million tokens.
+type Rate struct {
+	Input float64 `json:"input"`
+	// CachedInput applies to prompt tokens served from the provider's prompt
+	// cache. Zero means cached tokens cost the same as other input.
+	CachedInput float64 `json:"cached_input,omitempty"`
+	Output      float64 `json:"output"`
+}
+
+// Cost returns the price of usage at this rate.
+func (r Rate) Cost(usage *types.UsageInfo) float64 {
+	if usage == nil {
+		return 0
+	}
+
+	cached := min(max(usage.CachedPromptTokens, 0), usage.PromptTokens)
+	cachedRate := r.CachedInput
+	if cachedRate == 0 {
+		cachedRate = r.Input
+	}
+
+	cost := float64(usage.PromptTokens-cached)*r.Input +
+		float64(cached)*cachedRate +
+		float64(usage.CompletionTokens)*r.Output
+	return cost / 1_000_000
+}
+
+// Table maps providers to their models' rates. Model keys are matched
+// case-insensitively; Wildcard holds the provider-wide rate.
+type Table map[types.LLMProvider]map[string]Rate
+
+// openAIRates are OpenAI's list prices. Azure OpenAI charges the same, and
+// deployments named after their model find its rate.
+var openAIRates = map[string]Rate{
+	"gpt-4o":       {Input: 2.50, CachedInput: 1.25, Output: 10.00},
+	"gpt-4o-mini":  {Input: 0.15, CachedInput: 0.075, Output: 0.60},
+	"gpt-4.1":      {Input: 2.00, CachedInput: 0.50, Output: 8.00},
+	"gpt-4.1-mini": {Input: 0.40, CachedInput: 0.10, Output: 1.60},
+	"gpt-4.1-nano": {Input: 0.10, CachedInput: 0.025, Output: 0.40},
+	"o4-mini":      {Input: 1.10, CachedInput: 0.275, Output: 4.40},
+	Wildcard:       {Input: 2.50, CachedInput: 1.25, Output: 10.00},
+}
+
+// geminiRates are the Gemini API list prices, which Vertex AI matches.
+var geminiRates = map[string]Rate{
+	"gemini-2.0-flash":      {Input: 0.10, CachedInput: 0.025, Output: 0.40},
+	"gemini-2.0-flash-lite": {Input: 0.075, Output: 0.30},
+	"gemini-2.5-flash":      {Input: 0.30, CachedInput: 0.075, Output: 2.50},
+	"gemini-2.5-pro":        {Input: 1.25, CachedInput: 0.31, Output: 10.00},
+	Wildcard:                {Input: 0.10, CachedInput: 0.025, Output: 0.40},
+}
+
+// builtinRates are list prices at the time of writing. Models missing here
+// fall back to the provider's Wildcard rate, which is the default model's.
+var builtinRates = Table{
+	types.ProviderOpenAI:      openAIRates,
+	types.ProviderAzureOpenAI: openAIRates,
+	types.ProviderClaude: {
+		"claude-3-haiku":    {Input: 0.25, CachedInput: 0.03, Output: 1.25},
+		"claude-3-5-haiku":  {Input: 0.80, CachedInput: 0.08, Output: 4.00},
+		"claude-3-5-sonnet": {Input: 3.00, CachedInput: 0.30, Output: 15.00},
+		"claude-3-7-sonnet": {Input: 3.00, CachedInput: 0.30, Output: 15.00},
+		"claude-sonnet-4":   {Input: 3.00, CachedInput: 0.30, Output: 15.00},
+		"claude-opus-4":     {Input: 15.00, CachedInput: 1.50, Output: 75.00},
+		Wildcard:            {Input: 3.00, CachedInput: 0.30, Output: 15.00},
+	},
+	types.ProviderGemini:   geminiRates,
+	types.ProviderVertexAI: geminiRates,
+	types.ProviderGrok: {
+		"grok-3-mini":      {Input: 0.30, CachedInput: 0.075, Output: 0.50},
+		"grok-3-mini-fast": {Input: 0.60, CachedInput: 0.15, Output: 4.00},
+		"grok-3":           {Input: 3.00, CachedInput: 0.75, Output: 15.00},
+		"grok-3-fast":      {Input: 5.00, CachedInput: 1.25, Output: 25.00},
+		"grok-4":           {Input: 3.00, CachedInput: 0.75, Output: 15.00},
+		Wildcard:           {Input: 0.60, CachedInput: 0.15, Output: 4.00},
+	},
+	types.ProviderGroq: {
+		"llama-3.3-70b-versatile": {Input: 0.59, Output: 0.79},
+		"llama-3.1-8b-instant":    {Input: 0.05, Output: 0.08},
+		"openai/gpt-oss-120b":     {Input: 0.15, Output: 0.75},
+		"openai/gpt-oss-20b":      {Input: 0.10, Output: 0.50},
+		Wildcard:                  {Input: 0.59, Output: 0.79},
+	},
+	types.ProviderBedrock: {
+		"anthropic.claude-3-haiku":    {Input: 0.25, Output: 1.25},
+		"anthropic.claude-3-5-haiku":  {Input: 0.80, Output: 4.00},
+		"anthropic.claude-3-5-sonnet": {Input: 3.00, Output: 15.00},
+		"amazon.nova-lite":            {Input: 0.06, Output: 0.24},
+		"amazon.nova-pro":             {Input: 0.80, Output: 3.20},
+		"meta.llama3-1-8b-instruct":   {Input: 0.22, Output: 0.22},
+		Wildcard:                      {Input: 0.25, Output: 1.25},
+	},
+	types.ProviderOllama: {
+		// Local models cost nothing per token.
+		Wildcard: {},
+	},
+	types.ProviderCassette: {
+		// Replays send nothing to a provider.
+		Wildcard: {},
+	},
+}
+
+// Registry looks up rates by provider and model. A nil Registry knows no
+// rates.
+type Registry struct {
+	rates Table
+
+	// overridesPath is an override file merged on the first lookup rather
+	// than when the registry is created; see Default.
+	overridesPath string
+	overridesOnce sync.Once
+}
+
+// New returns a registry holding a copy of rates.
+func New(rates Table) *Registry {
+	r := &Registry{rates: make(Table)}
+	r.Merge(rates)
+	return r
+}
+
+// Builtin returns a registry with the built-in prices.
+func Builtin() *Registry {
+	return New(builtinRates)
+}
+
+// Merge adds rates to the registry, replacing existing entries for the same
+// provider and model.
+func (r *Registry) Merge(rates Table) {
+	for provider, models := range rates {
+		if r.rates[provider] == nil {
+			r.rates[provider] = make(map[string]Rate)
+		}
+		for model, rate := range models {
+			r.rates[provider][strings.ToLower(strings.TrimSpace(model))] = rate
+		}
+	}
+}
+
+// LoadFile merges the overrides in the JSON file at path, shaped like
+// {"OpenAI": {"gpt-4o": {"input": 2.5, "cached_input": 1.25, "output": 10}}}.
+// Providers that are neither built in nor registered are skipped and
+// returned, sorted; the other overrides still apply. A missing file is not
+// an error, and a malformed one leaves the registry unchanged.
+func (r *Registry) LoadFile(path string) (skipped []types.LLMProvider, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var overrides Table
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("invalid pricing file %s: %w", path, err)
+	}
+	for provider, models := range overrides {
+		for model, rate := range models {
+			if rate.Input < 0 || rate.CachedInput < 0 || rate.Output < 0 {
+				return nil, fmt.Errorf("invalid pricing file %s: negative rate for %s %s", path, provider, model)
+			}
+		}
+		if !provider.IsValid() {
+			skipped = append(skipped, provider)
+			delete(overrides, provider)
+		}
+	}
+	slices.Sort(skipped)
+
+	r.Merge(overrides)
+	return skipped, nil
+}
+
+// loadOverrides merges the pending override file, once. Problems are
+// reported as warnings and leave the prices as they were.
+func (r *Registry) loadOverrides() {
+	r.overridesOnce.Do(func() {
+		if r.overridesPath == "" {
+			return
+		}
+		skipped, err := r.LoadFile(r.overridesPath)
+		if err != nil {
+			fmt.Printf("Warning: Failed to load pricing overrides: %v\n", err)
+		}
+		for _, provider := range skipped {
+			fmt.Printf("Warning: Ignoring pricing overrides for unknown provider %q\n", provider)
+		}
+	})
+}
+
+// Lookup returns the rate for model. An exact match wins, then the longest
+// model key that prefixes model (so dated snapshots such as
+// "gpt-4o-mini-2024-07-18" find "gpt-4o-mini"), then the Wildcard rate.
+func (r *Registry) Lookup(provider types.LLMProvider, model string) (Rate, bool) {
+	if r == nil {
+		return Rate{}, false
+	}
+	r.loadOverrides()
+	models := r.rates[provider]
+	if len(models) == 0 {
+		return Rate{}, false
+	}
+
+	model = strings.ToLower(strings.TrimSpace(model))
+	if rate, ok := models[model]; ok && model != "" {
+		return rate, true
+	}
+
+	best := ""
+	for key := range models {
+		if key != Wildcard && len(key) > len(best) && strings.HasPrefix(model, key) {
+			best = key
+		}
+	}
+	if best != "" {
+		return models[best], true
+	}
+
+	rate, ok := models[Wildcard]
+	return rate, ok
+}
+
+// Cost returns the price of usage on model, or 0 when no rate is known.
+func (r *Registry) Cost(provider types.LLMProvider, model string, usage *types.UsageInfo) float64 {
+	rate, _ := r.Lookup(provider, model)
+	return rate.Cost(usage)
+}
+
+// FilePath returns the location of the user's pricing overrides.
+func FilePath() (string, error) {
+	configPath, err := StoreUtils.GetConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), FileName), nil
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultRegistry *Registry
+)
+
+// Default returns the registry shared by every cost report: the built-in
+// prices with the user's overrides applied. The overrides are read on the
+// first lookup rather than here, so that plugin providers registered after
+// startup are known by then. A broken override file is reported and ignored.
+func Default() *Registry {
+	defaultOnce.Do(func() {
+		defaultRegistry = Builtin()
+
+		path, err := FilePath()
+		if err != nil {
+			fmt.Printf("Warning: Failed to load pricing overrides: %v\n", err)
+			return
+		}
+		defaultRegistry.overridesPath = path
+	})
+	return defaultRegistry
+}
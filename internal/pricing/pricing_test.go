@@ -0,0 +1,140 @@
+package pricing
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	registry := Builtin()
+	cases := []struct {
+		provider types.LLMProvider
+		model    string
+		want     Rate
+		found    bool
+	}{
+		{types.ProviderOpenAI, "gpt-4o-mini", builtinRates[types.ProviderOpenAI]["gpt-4o-mini"], true},
+		{types.ProviderOpenAI, "GPT-4o-mini-2024-07-18", builtinRates[types.ProviderOpenAI]["gpt-4o-mini"], true},
+		{types.ProviderGrok, "grok-3-mini-fast-beta", builtinRates[types.ProviderGrok]["grok-3-mini-fast"], true},
+		{types.ProviderClaude, "claude-next", builtinRates[types.ProviderClaude][Wildcard], true},
+		{types.ProviderOllama, "llama3.1", Rate{}, true},
+		{types.ProviderOpenAICompatible, "local-model", Rate{}, false},
+	}
+
+	for _, tc := range cases {
+		got, found := registry.Lookup(tc.provider, tc.model)
+		if got != tc.want || found != tc.found {
+			t.Fatalf("Lookup(%s, %q) = %+v, %v; want %+v, %v", tc.provider, tc.model, got, found, tc.want, tc.found)
+		}
+	}
+
+	var nilRegistry *Registry
+	if _, found := nilRegistry.Lookup(types.ProviderOpenAI, "gpt-4o"); found {
+		t.Fatal("expected a nil registry to know no rates")
+	}
+}
+
+func TestRateCost(t *testing.T) {
+	t.Parallel()
+
+	rate := Rate{Input: 2, CachedInput: 0.5, Output: 8}
+	usage := types.NewUsageInfo(1_000_000, 500_000).WithCachedPrompt(400_000)
+
+	// 600k fresh input at $2, 400k cached at $0.50 and 500k output at $8.
+	if got, want := rate.Cost(usage), 1.2+0.2+4.0; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("Cost = %f, want %f", got, want)
+	}
+
+	// Without a cached rate, cached tokens are billed as input.
+	if got, want := (Rate{Input: 2, Output: 8}).Cost(usage), 2.0+4.0; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("Cost without cached rate = %f, want %f", got, want)
+	}
+
+	if got := rate.Cost(nil); got != 0 {
+		t.Fatalf("Cost(nil) = %f, want 0", got)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	registry := Builtin()
+
+	if _, err := registry.LoadFile(filepath.Join(dir, "missing.json")); err != nil {
+		t.Fatalf("expected a missing file to be ignored, got %v", err)
+	}
+
+	path := filepath.Join(dir, FileName)
+	overrides := `{
+		"OpenAI": {"gpt-4o": {"input": 1, "cached_input": 0.5, "output": 4}},
+		"OpenAICompatible": {"*": {"input": 0.2, "output": 0.4}}
+	}`
+	if err := os.WriteFile(path, []byte(overrides), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := registry.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile returned error: %v", err)
+	}
+
+	if rate, _ := registry.Lookup(types.ProviderOpenAI, "gpt-4o"); rate != (Rate{Input: 1, CachedInput: 0.5, Output: 4}) {
+		t.Fatalf("expected the override to replace gpt-4o, got %+v", rate)
+	}
+	if rate, _ := registry.Lookup(types.ProviderOpenAI, "gpt-4o-mini"); rate != builtinRates[types.ProviderOpenAI]["gpt-4o-mini"] {
+		t.Fatalf("expected other models to keep their built-in rate, got %+v", rate)
+	}
+	if _, found := registry.Lookup(types.ProviderOpenAICompatible, "anything"); !found {
+		t.Fatal("expected the override to price OpenAI-compatible models")
+	}
+
+	for _, invalid := range []string{
+		`{"OpenAI": {"gpt-4o": {"input": -1, "output": 1}}}`,
+		`{"OpenAI": `,
+	} {
+		if err := os.WriteFile(path, []byte(invalid), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Builtin().LoadFile(path); err == nil {
+			t.Fatalf("expected %s to be rejected", invalid)
+		}
+	}
+
+	// Unknown providers are skipped without losing the other overrides.
+	mixed := `{"Mistral": {"*": {"input": 1, "output": 1}}, "Claude": {"*": {"input": 7, "output": 9}}}`
+	if err := os.WriteFile(path, []byte(mixed), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	registry = Builtin()
+	skipped, err := registry.LoadFile(path)
+	if err != nil || len(skipped) != 1 || skipped[0] != "Mistral" {
+		t.Fatalf("LoadFile = %v, %v; want Mistral skipped", skipped, err)
+	}
+	if rate, _ := registry.Lookup(types.ProviderClaude, "unknown-model"); rate != (Rate{Input: 7, Output: 9}) {
+		t.Fatalf("expected the Claude override to apply, got %+v", rate)
+	}
+}
+
+func TestOverridesLoadOnFirstLookup(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte(`{"pricing-test-plugin": {"*": {"input": 3, "output": 6}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The plugin registers after the registry is created, as it does when
+	// the CLI starts.
+	registry := Builtin()
+	registry.overridesPath = path
+	types.RegisterProvider("pricing-test-plugin")
+
+	if rate, found := registry.Lookup("pricing-test-plugin", "any"); !found || rate != (Rate{Input: 3, Output: 6}) {
+		t.Fatalf("expected the plugin override to apply, got %+v, %v", rate, found)
+	}
+}
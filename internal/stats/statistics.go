@@ -6,13 +6,21 @@ import (
 	"strings"
 
 	"github.com/dfanso/commit-msg/internal/display"
+	"github.com/dfanso/commit-msg/internal/git"
 	"github.com/dfanso/commit-msg/internal/utils"
 	"github.com/dfanso/commit-msg/pkg/types"
 )
 
-// GetFileStatistics collects comprehensive file statistics from Git
+// GetFileStatistics collects file statistics from Git for the changes in
+// the scope of config. Changed files outside the scope are only counted.
 func GetFileStatistics(config *types.RepoConfig) (*display.FileStatistics, error) {
+	scope, err := git.ResolveScope(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the change scope: %w", err)
+	}
+
 	stats := &display.FileStatistics{
+		Scope:          scope,
 		StagedFiles:    []string{},
 		UnstagedFiles:  []string{},
 		UntrackedFiles: []string{},
@@ -53,11 +61,29 @@ func GetFileStatistics(config *types.RepoConfig) (*display.FileStatistics, error
 	stats.UnstagedFiles = utils.FilterEmpty(stats.UnstagedFiles)
 	stats.UntrackedFiles = utils.FilterEmpty(stats.UntrackedFiles)
 
+	if !scope.IncludesUnstaged() {
+		stats.OmittedFiles += len(stats.UnstagedFiles)
+		stats.UnstagedFiles = []string{}
+	}
+	if !scope.IncludesUntracked() {
+		stats.OmittedFiles += len(stats.UntrackedFiles)
+		stats.UntrackedFiles = []string{}
+	}
+
 	stats.TotalFiles = len(stats.StagedFiles) + len(stats.UnstagedFiles) + len(stats.UntrackedFiles)
 
-	// Get line statistics from staged changes
-	if len(stats.StagedFiles) > 0 {
-		statCmd := exec.Command("git", "-C", config.Path, "diff", "--cached", "--numstat")
+	// Get line statistics from the staged and, in scope, unstaged changes
+	for _, diff := range []struct {
+		args  []string
+		files []string
+	}{
+		{args: []string{"diff", "--cached", "--numstat"}, files: stats.StagedFiles},
+		{args: []string{"diff", "--numstat"}, files: stats.UnstagedFiles},
+	} {
+		if len(diff.files) == 0 {
+			continue
+		}
+		statCmd := exec.Command("git", append([]string{"-C", config.Path}, diff.args...)...)
 		statOutput, err := statCmd.Output()
 		if err != nil {
 			return nil, fmt.Errorf("failed to get line statistics: %w", err)
@@ -134,7 +134,8 @@ func TestGetFileStatisticsWithChanges(t *testing.T) {
 	}
 
 	config := &types.RepoConfig{
-		Path: dir,
+		Path:  dir,
+		Scope: types.ScopeUntracked,
 	}
 
 	stats, err := GetFileStatistics(config)
@@ -172,10 +173,28 @@ func TestGetFileStatisticsWithChanges(t *testing.T) {
 		t.Fatalf("expected %d total files, got %d", expectedTotal, stats.TotalFiles)
 	}
 
-	// Check line statistics (should only include staged files)
+	// Check line statistics (staged and unstaged files)
 	if stats.LinesAdded == 0 {
 		t.Fatal("expected lines added > 0 for staged files")
 	}
+
+	// By default only the staged file is described
+	stats, err = GetFileStatistics(&types.RepoConfig{Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Scope != types.ScopeStaged || stats.TotalFiles != 1 || len(stats.StagedFiles) != 1 || stats.OmittedFiles != 2 {
+		t.Fatalf("expected only the staged file, got %+v", stats)
+	}
+
+	// Without the untracked file
+	stats, err = GetFileStatistics(&types.RepoConfig{Path: dir, Scope: types.ScopeAll})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.TotalFiles != 2 || len(stats.UntrackedFiles) != 0 || stats.OmittedFiles != 1 {
+		t.Fatalf("expected the staged and unstaged files, got %+v", stats)
+	}
 }
 
 func TestGetFileStatisticsWithLineNumbers(t *testing.T) {
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/dfanso/commit-msg/internal/pricing"
 	"github.com/dfanso/commit-msg/pkg/types"
 	StoreUtils "github.com/dfanso/commit-msg/utils"
 )
@@ -17,6 +18,8 @@ type StatsManager struct {
 	mu       sync.RWMutex
 	stats    *types.UsageStats
 	filePath string
+	// prices values events that arrive without a cost.
+	prices *pricing.Registry
 }
 
 // NewStatsManager creates a new statistics manager instance.
@@ -32,6 +35,7 @@ func NewStatsManager() (*StatsManager, error) {
 	
 	manager := &StatsManager{
 		filePath: statsPath,
+		prices:   pricing.Default(),
 		stats: &types.UsageStats{
 			ProviderStats: make(map[types.LLMProvider]*types.ProviderStats),
 		},
@@ -62,9 +66,22 @@ func (sm *StatsManager) RecordGeneration(event *types.GenerationEvent) error {
 	} else {
 		sm.stats.FailedGenerations++
 	}
+	if event.Cancelled {
+		sm.stats.CancelledGenerations++
+	}
+
+	if event.Cost == 0 && event.Success && !event.CacheHit {
+		event.Cost = sm.prices.Cost(event.Provider, event.Model, &types.UsageInfo{
+			PromptTokens:       event.PromptTokens,
+			CompletionTokens:   event.CompletionTokens,
+			CachedPromptTokens: event.CachedPromptTokens,
+		})
+	}
 
 	sm.stats.TotalCost += event.Cost
 	sm.stats.TotalTokensUsed += event.TokensUsed
+	sm.stats.TotalPromptTokens += event.PromptTokens
+	sm.stats.TotalCompletionTokens += event.CompletionTokens
 	sm.stats.LastUse = now
 
 	if sm.stats.FirstUse == "" {
@@ -102,6 +119,8 @@ func (sm *StatsManager) RecordGeneration(event *types.GenerationEvent) error {
 
 	providerStats.TotalCost += event.Cost
 	providerStats.TotalTokensUsed += event.TokensUsed
+	providerStats.TotalPromptTokens += event.PromptTokens
+	providerStats.TotalCompletionTokens += event.CompletionTokens
 	providerStats.LastUsed = now
 
 	// Update provider average generation time
@@ -127,10 +146,13 @@ func (sm *StatsManager) GetStats() *types.UsageStats {
 		TotalGenerations:      sm.stats.TotalGenerations,
 		SuccessfulGenerations: sm.stats.SuccessfulGenerations,
 		FailedGenerations:     sm.stats.FailedGenerations,
+		CancelledGenerations:  sm.stats.CancelledGenerations,
 		FirstUse:              sm.stats.FirstUse,
 		LastUse:               sm.stats.LastUse,
 		TotalCost:             sm.stats.TotalCost,
 		TotalTokensUsed:       sm.stats.TotalTokensUsed,
+		TotalPromptTokens:     sm.stats.TotalPromptTokens,
+		TotalCompletionTokens: sm.stats.TotalCompletionTokens,
 		CacheHits:             sm.stats.CacheHits,
 		CacheMisses:           sm.stats.CacheMisses,
 		AverageGenerationTime: sm.stats.AverageGenerationTime,
@@ -146,6 +168,8 @@ func (sm *StatsManager) GetStats() *types.UsageStats {
 			FailedUses:            stats.FailedUses,
 			TotalCost:             stats.TotalCost,
 			TotalTokensUsed:       stats.TotalTokensUsed,
+			TotalPromptTokens:     stats.TotalPromptTokens,
+			TotalCompletionTokens: stats.TotalCompletionTokens,
 			AverageGenerationTime: stats.AverageGenerationTime,
 			FirstUsed:             stats.FirstUsed,
 			LastUsed:              stats.LastUsed,
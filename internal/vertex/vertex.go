@@ -0,0 +1,312 @@
+// Package vertex talks to Gemini models on Google Cloud Vertex AI. Unlike
+// the Gemini API, Vertex AI is addressed by project and location and
+// authenticates with OAuth2 access tokens minted from a service account or
+// Application Default Credentials.
+package vertex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	internalHTTP "github.com/dfanso/commit-msg/internal/http"
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+// DefaultLocation is used when no location has been configured.
+const DefaultLocation = "us-central1"
+
+// Scope is the OAuth2 scope Vertex AI requests are authorised with.
+const Scope = "https://www.googleapis.com/auth/cloud-platform"
+
+// Credential types; the service account key is the secret kept in the
+// keyring.
+const (
+	// AuthServiceAccount signs in with a service account JSON key.
+	AuthServiceAccount = "service-account"
+	// AuthADC uses Application Default Credentials: GOOGLE_APPLICATION_CREDENTIALS,
+	// the gcloud user credentials or the metadata server.
+	AuthADC = "adc"
+	// AuthNone sends no token, for local emulators.
+	AuthNone = "none"
+)
+
+const (
+	vertexTemperature      = 0.2
+	contentTypeJSON        = "application/json"
+	contentTypeEventStream = "text/event-stream"
+	roleModel              = "model"
+)
+
+// Endpoint describes how to reach a Gemini model on Vertex AI.
+type Endpoint struct {
+	Project  string
+	Location string
+	Model    string
+	// URL overrides the regional API host, e.g. for a local emulator.
+	URL string
+	// TokenSource authorises every request; nil sends none.
+	TokenSource oauth2.TokenSource
+}
+
+// NewTokenSource returns the token source for auth. serviceAccountJSON is
+// the stored key and is only read for AuthServiceAccount. The project ID
+// found in the credentials is returned alongside, if any.
+func NewTokenSource(ctx context.Context, auth, serviceAccountJSON string) (oauth2.TokenSource, string, error) {
+	var (
+		creds *google.Credentials
+		err   error
+	)
+	// Tokens are fetched through the configured proxy and certificate
+	// authorities, but never through an Interceptor: a cassette must not
+	// hold them.
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, internalHTTP.GetDirectClient())
+
+	switch auth {
+	case AuthNone:
+		return nil, "", nil
+	case "", AuthServiceAccount:
+		if strings.TrimSpace(serviceAccountJSON) == "" {
+			return nil, "", errors.New("Vertex AI service account key is required")
+		}
+		creds, err = google.CredentialsFromJSON(ctx, []byte(serviceAccountJSON), Scope)
+	case AuthADC:
+		creds, err = google.FindDefaultCredentials(ctx, Scope)
+	default:
+		return nil, "", fmt.Errorf("unknown Vertex AI credential type %q, expected %q, %q or %q", auth, AuthServiceAccount, AuthADC, AuthNone)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load Vertex AI credentials: %w", err)
+	}
+	return creds.TokenSource, creds.ProjectID, nil
+}
+
+// BaseURL returns the API host serving location.
+func BaseURL(location string) string {
+	if location == "global" {
+		return "https://aiplatform.googleapis.com"
+	}
+	return "https://" + location + "-aiplatform.googleapis.com"
+}
+
+// LocationURL returns the root of the project and location's resources.
+func LocationURL(endpoint Endpoint) string {
+	base := strings.TrimRight(strings.TrimSpace(endpoint.URL), "/")
+	if base == "" {
+		base = BaseURL(endpoint.Location)
+	}
+	return base + "/v1/projects/" + url.PathEscape(endpoint.Project) + "/locations/" + url.PathEscape(endpoint.Location)
+}
+
+// ModelURL returns the publisher model resource of endpoint, to which the
+// generate methods are appended.
+func ModelURL(endpoint Endpoint) string {
+	return LocationURL(endpoint) + "/publishers/google/models/" + url.PathEscape(endpoint.Model)
+}
+
+type part struct {
+	Text string `json:"text"`
+}
+
+type content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []part `json:"parts"`
+}
+
+type generationConfig struct {
+	Temperature     float64  `json:"temperature"`
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
+	TopP            *float64 `json:"topP,omitempty"`
+	Seed            *int64   `json:"seed,omitempty"`
+}
+
+type generateRequest struct {
+	Contents          []content        `json:"contents"`
+	SystemInstruction *content         `json:"systemInstruction,omitempty"`
+	GenerationConfig  generationConfig `json:"generationConfig"`
+}
+
+type usageMetadata struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount"`
+}
+
+type generateResponse struct {
+	Candidates []struct {
+		Content content `json:"content"`
+	} `json:"candidates"`
+	UsageMetadata *usageMetadata `json:"usageMetadata"`
+}
+
+// text joins the parts of the first candidate.
+func (r generateResponse) text() string {
+	if len(r.Candidates) == 0 {
+		return ""
+	}
+	var text strings.Builder
+	for _, p := range r.Candidates[0].Content.Parts {
+		text.WriteString(p.Text)
+	}
+	return text.String()
+}
+
+func (u *usageMetadata) info() *types.UsageInfo {
+	if u == nil {
+		return nil
+	}
+	return types.NewUsageInfo(u.PromptTokenCount, u.CandidatesTokenCount).WithCachedPrompt(u.CachedContentTokenCount)
+}
+
+// GenerateCommitMessage asks the model for a commit message.
+func GenerateCommitMessage(ctx context.Context, _ *types.Config, changes string, endpoint Endpoint, opts *types.GenerationOptions) (types.GenerationResult, error) {
+	req, err := newGenerateRequest(ctx, ModelURL(endpoint)+":generateContent", changes, endpoint, opts)
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
+
+	resp, err := internalHTTP.GetGenerationClient().Do(req)
+	if err != nil {
+		return types.GenerationResult{}, fmt.Errorf("failed to call Vertex AI: %w", err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return types.GenerationResult{}, fmt.Errorf("failed to read Vertex AI response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return types.GenerationResult{}, internalHTTP.NewStatusError(resp.StatusCode, "Vertex AI returned status %d: %s", resp.StatusCode, string(responseBody))
+	}
+
+	var generated generateResponse
+	if err := json.Unmarshal(responseBody, &generated); err != nil {
+		return types.GenerationResult{}, fmt.Errorf("failed to decode Vertex AI response: %w", err)
+	}
+
+	message := generated.text()
+	if message == "" {
+		return types.GenerationResult{}, fmt.Errorf("Vertex AI returned empty response")
+	}
+	return types.GenerationResult{Message: message, Usage: generated.UsageMetadata.info()}, nil
+}
+
+// StreamCommitMessage streams the answer as server-sent events, passing each
+// piece of text to onChunk.
+func StreamCommitMessage(ctx context.Context, _ *types.Config, changes string, endpoint Endpoint, opts *types.GenerationOptions, onChunk func(string)) (types.GenerationResult, error) {
+	req, err := newGenerateRequest(ctx, ModelURL(endpoint)+":streamGenerateContent?alt=sse", changes, endpoint, opts)
+	if err != nil {
+		return types.GenerationResult{}, err
+	}
+	req.Header.Set("Accept", contentTypeEventStream)
+
+	resp, err := internalHTTP.GetGenerationClient().Do(req)
+	if err != nil {
+		return types.GenerationResult{}, fmt.Errorf("failed to call Vertex AI: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		responseBody, _ := io.ReadAll(resp.Body)
+		return types.GenerationResult{}, internalHTTP.NewStatusError(resp.StatusCode, "Vertex AI returned status %d: %s", resp.StatusCode, string(responseBody))
+	}
+
+	var message strings.Builder
+	var usage *types.UsageInfo
+	err = internalHTTP.ReadServerSentEvents(resp.Body, func(event internalHTTP.ServerSentEvent) error {
+		var chunk generateResponse
+		if err := json.Unmarshal([]byte(event.Data), &chunk); err != nil {
+			return fmt.Errorf("failed to decode Vertex AI stream chunk: %w", err)
+		}
+		// Every chunk repeats the running usage; the last one is complete.
+		if info := chunk.UsageMetadata.info(); info != nil {
+			usage = info
+		}
+		if text := chunk.text(); text != "" {
+			message.WriteString(text)
+			if onChunk != nil {
+				onChunk(text)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return types.GenerationResult{}, fmt.Errorf("failed to read Vertex AI stream: %w", err)
+	}
+
+	if message.Len() == 0 {
+		return types.GenerationResult{}, fmt.Errorf("Vertex AI returned empty response")
+	}
+	return types.GenerationResult{Message: message.String(), Usage: usage}, nil
+}
+
+// newGenerateRequest builds an authorised request for target. The system
+// prompt goes into systemInstruction and the few-shot examples become
+// earlier turns, with the assistant's turns in Gemini's "model" role.
+func newGenerateRequest(ctx context.Context, target, changes string, endpoint Endpoint, opts *types.GenerationOptions) (*http.Request, error) {
+	if changes == "" {
+		return nil, fmt.Errorf("no changes provided for commit message generation")
+	}
+	if endpoint.Project == "" || endpoint.Location == "" || endpoint.Model == "" {
+		return nil, errors.New("Vertex AI project, location and model are required")
+	}
+
+	prompt := types.BuildPrompt(changes, opts)
+	sampling := types.SamplingOf(opts)
+	payload := generateRequest{
+		SystemInstruction: &content{Parts: []part{{Text: prompt.System}}},
+		GenerationConfig: generationConfig{
+			Temperature:     sampling.TemperatureOr(vertexTemperature),
+			MaxOutputTokens: sampling.MaxTokens,
+			TopP:            sampling.TopP,
+			Seed:            sampling.Seed,
+		},
+	}
+	for _, turn := range prompt.Turns() {
+		role := turn.Role
+		if role == types.RoleAssistant {
+			role = roleModel
+		}
+		payload.Contents = append(payload.Contents, content{Role: role, Parts: []part{{Text: turn.Content}}})
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Vertex AI request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Vertex AI request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentTypeJSON)
+
+	if err := Authorize(req, endpoint.TokenSource); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// Authorize adds a bearer token from source to req. A nil source leaves the
+// request unauthenticated.
+func Authorize(req *http.Request, source oauth2.TokenSource) error {
+	if source == nil {
+		return nil
+	}
+	token, err := source.Token()
+	if err != nil {
+		return fmt.Errorf("failed to get a Vertex AI access token: %w", err)
+	}
+	token.SetAuthHeader(req)
+	return nil
+}
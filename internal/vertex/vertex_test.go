@@ -0,0 +1,208 @@
+package vertex
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dfanso/commit-msg/pkg/types"
+)
+
+// serviceAccountKey returns a service account key whose tokens are minted
+// by tokenURL.
+func serviceAccountKey(t *testing.T, tokenURL string) string {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "key-project",
+		"private_key_id": "1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "commit@key-project.iam.gserviceaccount.com",
+		"token_uri":      tokenURL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestGenerateCommitMessageWithServiceAccount(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if err := r.ParseForm(); err != nil || r.Form.Get("assertion") == "" {
+				t.Errorf("expected a JWT assertion, got %v", r.Form)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"vertex-token","token_type":"Bearer","expires_in":3600}`))
+			return
+		}
+
+		if r.URL.Path != "/v1/projects/my-project/locations/europe-west4/publishers/google/models/gemini-2.0-flash:generateContent" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer vertex-token" {
+			t.Errorf("Authorization = %q", got)
+		}
+
+		var payload generateRequest
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if payload.SystemInstruction == nil || !strings.Contains(payload.SystemInstruction.Parts[0].Text, "commit message") {
+			t.Errorf("expected the system prompt in systemInstruction, got %+v", payload.SystemInstruction)
+		}
+		for _, turn := range payload.Contents {
+			if turn.Role != types.RoleUser && turn.Role != roleModel {
+				t.Errorf("unexpected role %q", turn.Role)
+			}
+		}
+
+		w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"feat: add vertex "},{"text":"provider"}]}}],"usageMetadata":{"promptTokenCount":80,"candidatesTokenCount":4,"totalTokenCount":84}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	source, project, err := NewTokenSource(context.Background(), AuthServiceAccount, serviceAccountKey(t, server.URL+"/token"))
+	if err != nil {
+		t.Fatalf("NewTokenSource returned error: %v", err)
+	}
+	if project != "key-project" {
+		t.Fatalf("expected the key's project, got %q", project)
+	}
+
+	result, err := GenerateCommitMessage(context.Background(), nil, "diff --git a/x b/x", Endpoint{
+		Project:     "my-project",
+		Location:    "europe-west4",
+		Model:       "gemini-2.0-flash",
+		URL:         server.URL,
+		TokenSource: source,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Message != "feat: add vertex provider" || result.Usage == nil || result.Usage.TotalTokens != 84 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestStreamCommitMessageAgainstEmulator(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, ":streamGenerateContent") || r.URL.Query().Get("alt") != "sse" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("expected no Authorization header, got %q", got)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"fix: \"}]}}]}\n\n"))
+		w.Write([]byte("data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"stream\"}]}}],\"usageMetadata\":{\"promptTokenCount\":10,\"candidatesTokenCount\":3}}\n\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	source, _, err := NewTokenSource(context.Background(), AuthNone, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var chunks []string
+	result, err := StreamCommitMessage(context.Background(), nil, "diff", Endpoint{
+		Project:     "p",
+		Location:    DefaultLocation,
+		Model:       "gemini-2.5-flash",
+		URL:         server.URL,
+		TokenSource: source,
+	}, nil, func(chunk string) { chunks = append(chunks, chunk) })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Message != "fix: stream" || len(chunks) != 2 || result.Usage == nil || result.Usage.TotalTokens != 13 {
+		t.Fatalf("unexpected result %+v with chunks %q", result, chunks)
+	}
+}
+
+func TestNewTokenSourceErrors(t *testing.T) {
+	t.Parallel()
+
+	if _, _, err := NewTokenSource(context.Background(), AuthServiceAccount, ""); err == nil {
+		t.Fatal("expected an error without a service account key")
+	}
+	if _, _, err := NewTokenSource(context.Background(), AuthServiceAccount, "{not json"); err == nil {
+		t.Fatal("expected an error for a malformed key")
+	}
+	if _, _, err := NewTokenSource(context.Background(), "oauth", ""); err == nil || !strings.Contains(err.Error(), "oauth") {
+		t.Fatalf("expected an unknown credential type error, got %v", err)
+	}
+}
+
+func TestModelURL(t *testing.T) {
+	t.Parallel()
+
+	got := ModelURL(Endpoint{Project: "p", Location: "us-central1", Model: "gemini-2.0-flash"})
+	if got != "https://us-central1-aiplatform.googleapis.com/v1/projects/p/locations/us-central1/publishers/google/models/gemini-2.0-flash" {
+		t.Fatalf("ModelURL() = %q", got)
+	}
+	if got := BaseURL("global"); got != "https://aiplatform.googleapis.com" {
+		t.Fatalf("BaseURL(global) = %q", got)
+	}
+}
+
+func TestGenerateCommitMessageSendsSampling(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			GenerationConfig map[string]any `json:"generationConfig"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		want := map[string]any{"temperature": 0.0, "maxOutputTokens": 128.0, "seed": 42.0}
+		for key, value := range want {
+			if payload.GenerationConfig[key] != value {
+				t.Errorf("generationConfig[%q] = %v, want %v", key, payload.GenerationConfig[key], value)
+			}
+		}
+		w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":"chore: seed"}]}}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	source, _, err := NewTokenSource(context.Background(), AuthNone, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sampling := types.DeterministicSampling()
+	sampling.MaxTokens = 128
+	result, err := GenerateCommitMessage(context.Background(), nil, "diff", Endpoint{
+		Project:     "p",
+		Location:    DefaultLocation,
+		Model:       "gemini-2.5-flash",
+		URL:         server.URL,
+		TokenSource: source,
+	}, &types.GenerationOptions{Sampling: sampling})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Message != "chore: seed" {
+		t.Fatalf("unexpected message %q", result.Message)
+	}
+}
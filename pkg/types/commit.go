@@ -0,0 +1,347 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// BreakingChangeTrailer is the trailer key conventional commits use to
+// describe a breaking change.
+const BreakingChangeTrailer = "BREAKING CHANGE"
+
+// MaxSubjectLength is the longest header git tooling displays comfortably.
+const MaxSubjectLength = 72
+
+// ConventionalCommitTypes lists the commit types accepted by Validate.
+var ConventionalCommitTypes = []string{
+	"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert",
+}
+
+// CommitMessage is a commit message split into its conventional-commit parts.
+type CommitMessage struct {
+	// Type is the change category, e.g. "feat" or "fix". It is empty for
+	// messages that do not follow the conventional header format.
+	Type string `json:"type"`
+	// Scope optionally names the area of the code base that changed.
+	Scope string `json:"scope"`
+	// Subject is the short summary following the type and scope.
+	Subject string `json:"subject"`
+	// Body is the free-form description separated from the header by a blank line.
+	Body string `json:"body"`
+	// Breaking marks a change that is not backwards compatible.
+	Breaking bool `json:"breaking"`
+	// Trailers are the "Key: value" lines closing the message.
+	Trailers []Trailer `json:"trailers"`
+
+	// breakingMarker records a "!" in a parsed header, which is kept even
+	// when a BREAKING CHANGE trailer describes the break as well.
+	breakingMarker bool
+	// source is the text the message was parsed from and rendered what its
+	// fields rendered to at the time. String returns source as long as the
+	// fields are unchanged, so parsing and rendering keep a message as written.
+	source   string
+	rendered string
+}
+
+// Trailer is a single git trailer such as "Refs: #123".
+type Trailer struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+var (
+	headerPattern  = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*)(?:\(([^()]*)\))?(!)?:\s+(.+)$`)
+	trailerPattern = regexp.MustCompile(`^(BREAKING CHANGE|BREAKING-CHANGE|[A-Za-z][A-Za-z0-9-]*):\s+(.+)$`)
+)
+
+// ParseCommitMessage splits free-form LLM output into a CommitMessage. JSON
+// objects produced by structured output are decoded; anything else is read as
+// a plain git commit message. Surrounding code fences and quotes are dropped.
+func ParseCommitMessage(text string) CommitMessage {
+	text = stripWrapping(text)
+
+	if strings.HasPrefix(text, "{") {
+		if msg, err := DecodeCommitMessage([]byte(text)); err == nil {
+			return msg
+		}
+	}
+
+	lines := strings.Split(text, "\n")
+	msg := parseHeader(strings.TrimSpace(lines[0]))
+
+	paragraphs := splitParagraphs(lines[1:])
+	if n := len(paragraphs); n > 0 {
+		if trailers, ok := parseTrailers(paragraphs[n-1]); ok {
+			msg.Trailers = trailers
+			paragraphs = paragraphs[:n-1]
+		}
+	}
+	msg.Body = strings.Join(paragraphs, "\n\n")
+
+	for _, trailer := range msg.Trailers {
+		if isBreakingKey(trailer.Key) {
+			msg.Breaking = true
+		}
+	}
+
+	msg.source = text
+	msg.rendered = msg.render()
+	return msg
+}
+
+// DecodeCommitMessage reads the JSON object requested through structured
+// output. The subject is required.
+func DecodeCommitMessage(data []byte) (CommitMessage, error) {
+	var msg CommitMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return CommitMessage{}, fmt.Errorf("invalid structured commit message: %w", err)
+	}
+
+	msg.Type = strings.TrimSpace(msg.Type)
+	msg.Scope = strings.TrimSpace(msg.Scope)
+	msg.Subject = strings.TrimSpace(msg.Subject)
+	msg.Body = strings.TrimSpace(msg.Body)
+
+	trailers := msg.Trailers[:0]
+	for _, trailer := range msg.Trailers {
+		trailer.Key = strings.TrimSpace(trailer.Key)
+		trailer.Value = strings.TrimSpace(trailer.Value)
+		if trailer.Key != "" && trailer.Value != "" {
+			trailers = append(trailers, trailer)
+		}
+	}
+	msg.Trailers = trailers
+	if len(msg.Trailers) == 0 {
+		msg.Trailers = nil
+	}
+
+	if msg.Subject == "" {
+		return CommitMessage{}, errors.New("invalid structured commit message: subject is empty")
+	}
+	return msg, nil
+}
+
+// Header renders the first line, e.g. "feat(cli)!: add --model flag". The "!"
+// marker is only added when no BREAKING CHANGE trailer describes the break,
+// unless the parsed header had one.
+func (m CommitMessage) Header() string {
+	if m.Type == "" {
+		return m.Subject
+	}
+
+	var header strings.Builder
+	header.WriteString(m.Type)
+	if m.Scope != "" {
+		header.WriteString("(" + m.Scope + ")")
+	}
+	if m.Breaking && (m.breakingMarker || !m.hasBreakingTrailer()) {
+		header.WriteString("!")
+	}
+	header.WriteString(": ")
+	header.WriteString(m.Subject)
+	return header.String()
+}
+
+// String renders the full commit message as passed to git commit. A parsed
+// message whose fields were not changed is returned as it was written.
+func (m CommitMessage) String() string {
+	text := m.render()
+	if m.source != "" && text == m.rendered {
+		return m.source
+	}
+	return text
+}
+
+// render builds the message from its fields.
+func (m CommitMessage) render() string {
+	parts := []string{m.Header()}
+	if body := strings.TrimSpace(m.Body); body != "" {
+		parts = append(parts, body)
+	}
+	if len(m.Trailers) > 0 {
+		lines := make([]string, len(m.Trailers))
+		for i, trailer := range m.Trailers {
+			lines[i] = trailer.Key + ": " + trailer.Value
+		}
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Validate reports whether the message is a well-formed conventional commit:
+// a known type (matched case-insensitively), a subject, and a header no longer
+// than MaxSubjectLength.
+func (m CommitMessage) Validate() error {
+	var problems []string
+
+	switch {
+	case m.Type == "":
+		problems = append(problems, "missing conventional commit type (e.g. feat, fix)")
+	case !slices.Contains(ConventionalCommitTypes, strings.ToLower(m.Type)):
+		problems = append(problems, fmt.Sprintf("unknown commit type %q, expected one of: %s", m.Type, strings.Join(ConventionalCommitTypes, ", ")))
+	}
+	if strings.ContainsAny(m.Scope, " \t()") {
+		problems = append(problems, fmt.Sprintf("scope %q must not contain spaces or parentheses", m.Scope))
+	}
+	if strings.TrimSpace(m.Subject) == "" {
+		problems = append(problems, "subject is empty")
+	}
+	if length := len([]rune(m.Header())); length > MaxSubjectLength {
+		problems = append(problems, fmt.Sprintf("header is %d characters (limit %d)", length, MaxSubjectLength))
+	}
+	for _, trailer := range m.Trailers {
+		if !trailerPattern.MatchString(trailer.Key + ": " + trailer.Value) {
+			problems = append(problems, fmt.Sprintf("invalid trailer %q", trailer.Key))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// CommitMessageSchema returns the JSON schema describing CommitMessage for
+// providers that support structured output. Every property is required so the
+// schema also satisfies strict modes; unused fields are sent empty.
+func CommitMessageSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"type": map[string]any{
+				"type":        "string",
+				"description": "Conventional commit type: " + strings.Join(ConventionalCommitTypes, ", "),
+			},
+			"scope": map[string]any{
+				"type":        "string",
+				"description": "Optional area of the code base affected, or an empty string",
+			},
+			"subject": map[string]any{
+				"type":        "string",
+				"description": "Imperative summary without trailing period",
+			},
+			"body": map[string]any{
+				"type":        "string",
+				"description": "Optional explanation of what changed and why, or an empty string",
+			},
+			"breaking": map[string]any{
+				"type":        "boolean",
+				"description": "True when the change is not backwards compatible",
+			},
+			"trailers": map[string]any{
+				"type":        "array",
+				"description": "Optional git trailers such as Refs or BREAKING CHANGE",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"key":   map[string]any{"type": "string"},
+						"value": map[string]any{"type": "string"},
+					},
+					"required":             []string{"key", "value"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		"required":             []string{"type", "scope", "subject", "body", "breaking", "trailers"},
+		"additionalProperties": false,
+	}
+}
+
+func (m CommitMessage) hasBreakingTrailer() bool {
+	for _, trailer := range m.Trailers {
+		if isBreakingKey(trailer.Key) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseHeader(line string) CommitMessage {
+	match := headerPattern.FindStringSubmatch(line)
+	if match == nil {
+		return CommitMessage{Subject: line}
+	}
+	return CommitMessage{
+		Type:           match[1],
+		Scope:          strings.TrimSpace(match[2]),
+		Breaking:       match[3] == "!",
+		Subject:        strings.TrimSpace(match[4]),
+		breakingMarker: match[3] == "!",
+	}
+}
+
+// splitParagraphs groups lines into blank-line separated paragraphs.
+func splitParagraphs(lines []string) []string {
+	var paragraphs []string
+	var current []string
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				paragraphs = append(paragraphs, strings.Join(current, "\n"))
+				current = nil
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		paragraphs = append(paragraphs, strings.Join(current, "\n"))
+	}
+	return paragraphs
+}
+
+// ParseTrailers reads "Key: value" lines, ignoring blank ones.
+func ParseTrailers(block string) ([]Trailer, error) {
+	var trailers []Trailer
+	for _, line := range strings.Split(block, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		match := trailerPattern.FindStringSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf("invalid trailer %q, expected \"Key: value\"", line)
+		}
+		trailers = append(trailers, Trailer{Key: match[1], Value: strings.TrimSpace(match[2])})
+	}
+	return trailers, nil
+}
+
+// parseTrailers reads paragraph as a trailer block; every line must be a trailer.
+func parseTrailers(paragraph string) ([]Trailer, bool) {
+	trailers, err := ParseTrailers(paragraph)
+	return trailers, err == nil && len(trailers) > 0
+}
+
+func isBreakingKey(key string) bool {
+	return key == BreakingChangeTrailer || key == "BREAKING-CHANGE"
+}
+
+// stripWrapping removes Markdown code fences and quotes that models sometimes
+// put around the message.
+func stripWrapping(text string) string {
+	text = strings.TrimSpace(text)
+
+	if strings.HasPrefix(text, "```") && strings.HasSuffix(text, "```") && len(text) >= 6 {
+		text = strings.TrimSuffix(text, "```")
+		if newline := strings.Index(text, "\n"); newline >= 0 {
+			text = text[newline+1:]
+		} else {
+			text = strings.TrimPrefix(text, "```")
+		}
+		text = strings.TrimSpace(text)
+	}
+
+	for _, quote := range []string{`"`, "'", "`"} {
+		if len(text) >= 2 && strings.HasPrefix(text, quote) && strings.HasSuffix(text, quote) {
+			text = strings.TrimSpace(text[1 : len(text)-1])
+		}
+	}
+
+	return text
+}
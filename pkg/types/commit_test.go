@@ -0,0 +1,130 @@
+package types
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCommitMessage(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		input string
+		want  CommitMessage
+	}{
+		{
+			name:  "conventional header",
+			input: "feat(cli): add --model flag",
+			want:  CommitMessage{Type: "feat", Scope: "cli", Subject: "add --model flag"},
+		},
+		{
+			name:  "breaking marker",
+			input: "refactor!: drop the legacy config",
+			want:  CommitMessage{Type: "refactor", Subject: "drop the legacy config", Breaking: true, breakingMarker: true},
+		},
+		{
+			name:  "free-form subject",
+			input: "Update README with setup steps",
+			want:  CommitMessage{Subject: "Update README with setup steps"},
+		},
+		{
+			name:  "body and trailers",
+			input: "fix: handle empty diffs\n\n- Skip the request\n- Show a hint\n\nRefs: #12\nBREAKING CHANGE: empty diffs now exit 0",
+			want: CommitMessage{
+				Type:     "fix",
+				Subject:  "handle empty diffs",
+				Body:     "- Skip the request\n- Show a hint",
+				Breaking: true,
+				Trailers: []Trailer{{Key: "Refs", Value: "#12"}, {Key: "BREAKING CHANGE", Value: "empty diffs now exit 0"}},
+			},
+		},
+		{
+			name:  "code fence",
+			input: "```text\ndocs: fix typo\n```",
+			want:  CommitMessage{Type: "docs", Subject: "fix typo"},
+		},
+		{
+			name:  "json object",
+			input: `{"type":"feat","scope":"","subject":"add parser","body":"","breaking":false,"trailers":[]}`,
+			want:  CommitMessage{Type: "feat", Subject: "add parser"},
+		},
+	}
+
+	for _, tc := range cases {
+		got := ParseCommitMessage(tc.input)
+		// The kept source text is covered by TestCommitMessageRoundTrip.
+		got.source, got.rendered = "", ""
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s: ParseCommitMessage = %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCommitMessageRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	messages := []string{
+		"feat(api)!: remove v1 endpoints",
+		"fix: handle empty diffs\n\nSkip the request entirely.\n\nRefs: #12",
+		"chore: bump deps\n\nBREAKING CHANGE: requires Go 1.24",
+		"Update README",
+		// A "!" next to a BREAKING CHANGE trailer.
+		"feat(api)!: drop v1\n\nBREAKING CHANGE: v1 clients must upgrade",
+		// A closing body paragraph that reads like trailers.
+		"docs: describe limits\n\nDefault:  10 requests\nMaximum:   50 requests",
+	}
+
+	for _, message := range messages {
+		if got := ParseCommitMessage(message).String(); got != message {
+			t.Fatalf("round trip changed the message:\n got: %q\nwant: %q", got, message)
+		}
+	}
+
+	// Edited fields are rendered again, keeping the parsed "!".
+	edited := ParseCommitMessage("feat(api)!: drop v1\n\nBREAKING CHANGE: v1 clients must upgrade")
+	edited.Subject = "drop the v1 endpoints"
+	if got, want := edited.String(), "feat(api)!: drop the v1 endpoints\n\nBREAKING CHANGE: v1 clients must upgrade"; got != want {
+		t.Fatalf("edited message = %q, want %q", got, want)
+	}
+}
+
+func TestCommitMessageValidate(t *testing.T) {
+	t.Parallel()
+
+	if err := (CommitMessage{Type: "Feat", Scope: "cli", Subject: "add flag"}).Validate(); err != nil {
+		t.Fatalf("expected a valid message, got %v", err)
+	}
+
+	invalid := CommitMessage{Type: "feature", Scope: "two words", Subject: strings.Repeat("x", 80)}
+	err := invalid.Validate()
+	if err == nil {
+		t.Fatal("expected validation to fail")
+	}
+	for _, fragment := range []string{"unknown commit type", "scope", "header is"} {
+		if !strings.Contains(err.Error(), fragment) {
+			t.Fatalf("expected %q in %v", fragment, err)
+		}
+	}
+}
+
+func TestDecodeCommitMessageRequiresSubject(t *testing.T) {
+	t.Parallel()
+
+	if _, err := DecodeCommitMessage([]byte(`{"type":"feat","subject":"  "}`)); err == nil {
+		t.Fatal("expected an empty subject to be rejected")
+	}
+}
+
+func TestParseTrailers(t *testing.T) {
+	t.Parallel()
+
+	trailers, err := ParseTrailers("Refs: #1\n\nCo-authored-by: Dev <dev@example.com>")
+	if err != nil || len(trailers) != 2 {
+		t.Fatalf("unexpected result: %v, %v", trailers, err)
+	}
+	if _, err := ParseTrailers("not a trailer"); err == nil {
+		t.Fatal("expected an invalid line to be rejected")
+	}
+}
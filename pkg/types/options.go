@@ -1,5 +1,10 @@
 package types
 
+import (
+	"errors"
+	"fmt"
+)
+
 // GenerationOptions controls how commit messages should be produced by LLM providers.
 type GenerationOptions struct {
 	// StyleInstruction contains optional tone/style guidance appended to the base prompt.
@@ -7,4 +12,127 @@ type GenerationOptions struct {
 	// Attempt records the 1-indexed attempt number for this generation request.
 	// Attempt > 1 signals that the LLM should provide an alternative output.
 	Attempt int
+	// Sampling overrides the provider's sampling settings for this request.
+	Sampling Sampling
+	// PreviousMessage is the current message of a commit that is amended or
+	// reworded, given to the LLM as context.
+	PreviousMessage string
+}
+
+// SamplingOf returns the sampling settings of opts, which may be nil.
+func SamplingOf(opts *GenerationOptions) Sampling {
+	if opts == nil {
+		return Sampling{}
+	}
+	return opts.Sampling
+}
+
+// DeterministicSeed is the seed deterministic mode sends to providers that
+// accept one.
+const DeterministicSeed int64 = 42
+
+// Sampling holds the sampling settings of a generation request. Unset
+// fields keep the provider's defaults.
+type Sampling struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	// MaxTokens caps the tokens of the answer.
+	MaxTokens int      `json:"max_tokens,omitempty"`
+	TopP      *float64 `json:"top_p,omitempty"`
+	// Seed asks providers that support one for repeatable answers. Claude,
+	// Bedrock and the Gemini API do not.
+	Seed *int64 `json:"seed,omitempty"`
+}
+
+// DeterministicSampling asks for the most repeatable answers: temperature
+// 0 and DeterministicSeed.
+func DeterministicSampling() Sampling {
+	temperature := 0.0
+	seed := DeterministicSeed
+	return Sampling{Temperature: &temperature, Seed: &seed}
+}
+
+// Merge returns s with the fields set in over replacing its own.
+func (s Sampling) Merge(over Sampling) Sampling {
+	if over.Temperature != nil {
+		s.Temperature = over.Temperature
+	}
+	if over.MaxTokens > 0 {
+		s.MaxTokens = over.MaxTokens
+	}
+	if over.TopP != nil {
+		s.TopP = over.TopP
+	}
+	if over.Seed != nil {
+		s.Seed = over.Seed
+	}
+	return s
+}
+
+// IsZero reports whether no setting is made.
+func (s Sampling) IsZero() bool {
+	return s.Temperature == nil && s.MaxTokens == 0 && s.TopP == nil && s.Seed == nil
+}
+
+// TemperatureOr returns the configured temperature, or fallback.
+func (s Sampling) TemperatureOr(fallback float64) float64 {
+	if s.Temperature != nil {
+		return *s.Temperature
+	}
+	return fallback
+}
+
+// MaxTokensOr returns the configured output cap, or fallback.
+func (s Sampling) MaxTokensOr(fallback int) int {
+	if s.MaxTokens > 0 {
+		return s.MaxTokens
+	}
+	return fallback
+}
+
+// Validate reports settings no provider accepts.
+func (s Sampling) Validate() error {
+	if s.Temperature != nil && (*s.Temperature < 0 || *s.Temperature > 2) {
+		return fmt.Errorf("temperature %g is outside 0-2", *s.Temperature)
+	}
+	if s.MaxTokens < 0 {
+		return errors.New("max_tokens cannot be negative")
+	}
+	if s.TopP != nil && (*s.TopP <= 0 || *s.TopP > 1) {
+		return fmt.Errorf("top_p %g is outside (0, 1]", *s.TopP)
+	}
+	return nil
+}
+
+// GenerationResult is what a provider produced for one generation request.
+type GenerationResult struct {
+	// Message is the commit message text.
+	Message string
+	// Commit holds the message split into its fields when the provider
+	// returned structured output.
+	Commit *CommitMessage
+	// Usage holds the token counts reported by the API, or nil when the API
+	// did not report any.
+	Usage *UsageInfo
+}
+
+// NewUsageInfo records prompt and completion token counts, deriving the total.
+// It returns nil when both counts are zero, i.e. nothing was reported.
+func NewUsageInfo(promptTokens, completionTokens int) *UsageInfo {
+	if promptTokens == 0 && completionTokens == 0 {
+		return nil
+	}
+	return &UsageInfo{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}
+}
+
+// WithCachedPrompt records how many prompt tokens came from the provider's
+// prompt cache. It is a no-op on nil.
+func (u *UsageInfo) WithCachedPrompt(tokens int) *UsageInfo {
+	if u != nil {
+		u.CachedPromptTokens = tokens
+	}
+	return u
 }
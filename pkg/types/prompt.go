@@ -1,51 +1,177 @@
 package types
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 )
 
-// CommitPrompt is the base instruction template sent to LLM providers before
-// appending repository changes and optional style guidance.
-var CommitPrompt = `I need a concise git commit message based on the following changes from my Git repository.
-Please generate a commit message that:
+// Chat roles used when a Prompt is flattened into messages.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// SystemPrompt holds the standing instructions sent in the system role.
+var SystemPrompt = `You are an assistant that writes clear, concise git commit messages.
+Given the changes from a Git repository, write a commit message that:
 1. Starts with a verb in the present tense (e.g., "Add", "Fix", "Update", "Feat", "Refactor", etc.)
 2. Is clear and descriptive
 3. Focuses on the "what" and "why" of the changes
 4. Is no longer than 50-72 characters for the first line
 5. Can include a more detailed description after a blank line if needed
-6. Dont say any other stuff only include the commit msg
+6. Contains only the commit message, with no other commentary`
 
-here is a sample commit msgs:
-'Feat: Add Gemini LLM support for commit messages
+// changesHeading introduces the repository changes in a user message.
+const changesHeading = "Here are the changes:\n\n"
 
-- Adds Gemini as an alternative LLM for generating commit
-- messages, configurable via the COMMIT_LLM environment
-- variable. Also updates dependencies and README.'
-Here are the changes:
-`
+// exampleChanges and exampleMessage form the few-shot pair showing the
+// expected answer for a small diff.
+const (
+	exampleChanges = `diff --git a/internal/gemini/gemini.go b/internal/gemini/gemini.go
+new file mode 100644
+--- /dev/null
++++ b/internal/gemini/gemini.go
+@@ -0,0 +1,6 @@
++package gemini
++
++// GenerateCommitMessage asks Gemini for a commit message.
++func GenerateCommitMessage(ctx context.Context, changes, apiKey string) (string, error) {
+diff --git a/cmd/main.go b/cmd/main.go
+--- a/cmd/main.go
++++ b/cmd/main.go
+@@ -20,2 +20,4 @@
+ 	switch os.Getenv("COMMIT_LLM") {
++	case "gemini":
++		msg, err = gemini.GenerateCommitMessage(ctx, changes, os.Getenv("GEMINI_API_KEY"))`
 
-// BuildCommitPrompt constructs the prompt that will be sent to the LLM, applying
-// any optional tone/style instructions before appending the repository changes.
-func BuildCommitPrompt(changes string, opts *GenerationOptions) string {
-	var builder strings.Builder
-	builder.WriteString(CommitPrompt)
+	exampleMessage = `feat: add Gemini LLM support for commit messages
+
+Adds Gemini as an alternative LLM for generating commit messages,
+configurable via the COMMIT_LLM environment variable.`
+)
+
+// exampleCommit is exampleMessage as a structured answer.
+var exampleCommit = CommitMessage{
+	Type:    "feat",
+	Subject: "add Gemini LLM support for commit messages",
+	Body:    "Adds Gemini as an alternative LLM for generating commit messages, configurable via the COMMIT_LLM environment variable.",
+}
+
+// StructuredOutputInstruction is added to the system message when the
+// provider returns the commit message as a JSON object following
+// CommitMessageSchema.
+const StructuredOutputInstruction = `Return the commit message as the requested JSON object:
+- "type" is the conventional commit type and "scope" the optional area affected
+- "subject" is the imperative summary without the type prefix or a trailing period
+- "body" explains what changed and why; leave it empty for trivial changes
+- set "breaking" for backwards-incompatible changes and describe them in a "BREAKING CHANGE" trailer`
+
+// Prompt is a commit message request split by role: standing instructions
+// for the system role, few-shot examples, and the user message holding the
+// changes. Providers map it onto their native roles.
+type Prompt struct {
+	System   string
+	Examples []PromptExample
+	// User carries the per-request guidance followed by the changes.
+	User string
+}
+
+// PromptExample is a few-shot pair: a sample request and the answer expected
+// for it.
+type PromptExample struct {
+	User      string
+	Assistant string
+}
+
+// BuildPrompt constructs the prompt that will be sent to the LLM, applying
+// any optional tone/style instructions before the repository changes.
+func BuildPrompt(changes string, opts *GenerationOptions) Prompt {
+	return buildPrompt(changes, opts, false)
+}
+
+// BuildStructuredPrompt is BuildPrompt for providers asked to answer with a
+// CommitMessage JSON object; the example answer is JSON as well.
+func BuildStructuredPrompt(changes string, opts *GenerationOptions) Prompt {
+	return buildPrompt(changes, opts, true)
+}
+
+func buildPrompt(changes string, opts *GenerationOptions, structured bool) Prompt {
+	prompt := Prompt{
+		System: SystemPrompt,
+		Examples: []PromptExample{
+			{User: changesHeading + exampleChanges, Assistant: exampleMessage},
+		},
+	}
 
+	if structured {
+		prompt.System += "\n\n" + StructuredOutputInstruction
+		example, _ := json.Marshal(exampleCommit)
+		prompt.Examples[0].Assistant = string(example)
+	}
+
+	var builder strings.Builder
 	if opts != nil {
 		if opts.Attempt > 1 {
-			builder.WriteString("\n\nRegeneration context:\n")
+			builder.WriteString("Regeneration context:\n")
 			builder.WriteString(fmt.Sprintf("- This is attempt #%d.\n", opts.Attempt))
-			builder.WriteString("- Provide a commit message that is meaningfully different from earlier attempts.\n")
+			builder.WriteString("- Provide a commit message that is meaningfully different from earlier attempts.\n\n")
 		}
 
 		if strings.TrimSpace(opts.StyleInstruction) != "" {
-			builder.WriteString("\n\nAdditional instructions:\n")
+			builder.WriteString("Additional instructions:\n")
 			builder.WriteString(strings.TrimSpace(opts.StyleInstruction))
+			builder.WriteString("\n\n")
 		}
-	}
 
-	builder.WriteString("\n\n")
+		if strings.TrimSpace(opts.PreviousMessage) != "" {
+			builder.WriteString("Current commit message:\n")
+			builder.WriteString(strings.TrimSpace(opts.PreviousMessage))
+			builder.WriteString("\n\nRewrite it to describe the changes below, keeping what is still accurate.\n\n")
+		}
+	}
+	builder.WriteString(changesHeading)
 	builder.WriteString(changes)
+	prompt.User = builder.String()
+
+	return prompt
+}
 
+// Messages flattens the prompt for chat APIs with a system role: the system
+// message, the examples as user/assistant turns, then the user message.
+func (p Prompt) Messages() []Message {
+	messages := make([]Message, 0, 2+2*len(p.Examples))
+	if p.System != "" {
+		messages = append(messages, Message{Role: RoleSystem, Content: p.System})
+	}
+	messages = append(messages, p.Turns()...)
+	return messages
+}
+
+// Turns returns the examples and the user message without the system
+// message, for APIs that take the system prompt separately.
+func (p Prompt) Turns() []Message {
+	turns := make([]Message, 0, 1+2*len(p.Examples))
+	for _, example := range p.Examples {
+		turns = append(turns,
+			Message{Role: RoleUser, Content: example.User},
+			Message{Role: RoleAssistant, Content: example.Assistant},
+		)
+	}
+	return append(turns, Message{Role: RoleUser, Content: p.User})
+}
+
+// String renders the whole prompt as text, one labelled block per message.
+// It is used for previews and token estimates.
+func (p Prompt) String() string {
+	var builder strings.Builder
+	for i, message := range p.Messages() {
+		if i > 0 {
+			builder.WriteString("\n\n")
+		}
+		builder.WriteString("[" + message.Role + "]\n")
+		builder.WriteString(message.Content)
+	}
 	return builder.String()
 }
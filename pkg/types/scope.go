@@ -0,0 +1,43 @@
+package types
+
+// ChangeScope selects the changes a commit message describes, so that it
+// matches what the commit will contain.
+type ChangeScope string
+
+const (
+	// ScopeAuto describes the index when it holds changes and every change,
+	// untracked files included, otherwise. Nothing is staged for the commit.
+	ScopeAuto ChangeScope = ""
+	// ScopeStaged describes the index, which is what 'git commit' commits.
+	ScopeStaged ChangeScope = "staged"
+	// ScopeAll adds the unstaged changes of tracked files, as 'git commit
+	// -a' commits them.
+	ScopeAll ChangeScope = "all"
+	// ScopeUntracked adds untracked files to ScopeAll.
+	ScopeUntracked ChangeScope = "untracked"
+)
+
+// IncludesUnstaged reports whether unstaged changes of tracked files are
+// described.
+func (s ChangeScope) IncludesUnstaged() bool {
+	return s == ScopeAll || s == ScopeUntracked
+}
+
+// IncludesUntracked reports whether untracked files are described.
+func (s ChangeScope) IncludesUntracked() bool {
+	return s == ScopeUntracked
+}
+
+// Describe names the changes in scope for display.
+func (s ChangeScope) Describe() string {
+	switch s {
+	case ScopeStaged:
+		return "staged changes"
+	case ScopeAll:
+		return "staged and unstaged changes"
+	case ScopeUntracked:
+		return "staged, unstaged and untracked changes"
+	default:
+		return "staged changes, or all changes when nothing is staged"
+	}
+}
@@ -1,5 +1,10 @@
 package types
 
+import (
+	"slices"
+	"sync"
+)
+
 // LLMProvider identifies the large language model backend used to author
 // commit messages.
 type LLMProvider string
@@ -11,6 +16,18 @@ const (
 	ProviderGrok   LLMProvider = "Grok"
 	ProviderGroq   LLMProvider = "Groq"
 	ProviderOllama LLMProvider = "Ollama"
+	// ProviderOpenAICompatible targets any server speaking the OpenAI chat
+	// completions protocol (vLLM, llama.cpp server, LM Studio, ...).
+	ProviderOpenAICompatible LLMProvider = "OpenAICompatible"
+	// ProviderAzureOpenAI targets a model deployment on Azure OpenAI.
+	ProviderAzureOpenAI LLMProvider = "AzureOpenAI"
+	// ProviderBedrock targets a model hosted on Amazon Bedrock.
+	ProviderBedrock LLMProvider = "Bedrock"
+	// ProviderVertexAI targets Gemini models on Google Cloud Vertex AI.
+	ProviderVertexAI LLMProvider = "VertexAI"
+	// ProviderCassette replays a recorded session from a cassette file
+	// without a network.
+	ProviderCassette LLMProvider = "Cassette"
 )
 
 // String returns the provider identifier as a plain string.
@@ -18,26 +35,63 @@ func (p LLMProvider) String() string {
 	return string(p)
 }
 
-// IsValid reports whether the provider is part of the supported set.
+// IsValid reports whether the provider is built in or has been registered
+// with RegisterProvider.
 func (p LLMProvider) IsValid() bool {
+	if p.isBuiltIn() {
+		return true
+	}
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	return slices.Contains(registered, p)
+}
+
+func (p LLMProvider) isBuiltIn() bool {
 	switch p {
-	case ProviderOpenAI, ProviderClaude, ProviderGemini, ProviderGrok, ProviderGroq, ProviderOllama:
+	case ProviderOpenAI, ProviderClaude, ProviderGemini, ProviderGrok, ProviderGroq, ProviderOllama, ProviderOpenAICompatible, ProviderAzureOpenAI, ProviderBedrock, ProviderVertexAI, ProviderCassette:
 		return true
 	default:
 		return false
 	}
 }
 
-// GetSupportedProviders returns all available provider enums.
+var (
+	registeredMu sync.RWMutex
+	registered   []LLMProvider
+)
+
+// RegisterProvider adds a provider that is not built in, such as an external
+// plugin, to the supported set. Registering a provider twice is a no-op.
+func RegisterProvider(p LLMProvider) {
+	if p.isBuiltIn() {
+		return
+	}
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	if !slices.Contains(registered, p) {
+		registered = append(registered, p)
+	}
+}
+
+// GetSupportedProviders returns all available provider enums: the built-in
+// providers followed by the registered ones.
 func GetSupportedProviders() []LLMProvider {
-	return []LLMProvider{
+	providers := []LLMProvider{
 		ProviderOpenAI,
 		ProviderClaude,
 		ProviderGemini,
 		ProviderGrok,
 		ProviderGroq,
 		ProviderOllama,
+		ProviderOpenAICompatible,
+		ProviderAzureOpenAI,
+		ProviderBedrock,
+		ProviderVertexAI,
+		ProviderCassette,
 	}
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	return append(providers, registered...)
 }
 
 // GetSupportedProviderStrings returns the human-friendly names for providers.
@@ -62,21 +116,121 @@ type Config struct {
 	Repos   map[string]RepoConfig `json:"repos"`
 }
 
+// ProviderSettings holds the non-secret, per-provider options persisted in the
+// config file. Secrets such as API keys stay in the OS keyring.
+type ProviderSettings struct {
+	BaseURL string            `json:"base_url,omitempty"`
+	Model   string            `json:"model,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Sampling holds the temperature, output cap, top-p and seed sent with
+	// every request, unless a style preset or deterministic mode replaces
+	// them.
+	Sampling *Sampling `json:"sampling,omitempty"`
+	// Ollama holds the request options only the Ollama provider understands.
+	Ollama *OllamaSettings `json:"ollama,omitempty"`
+	// Azure holds the Azure OpenAI request options. For Azure, BaseURL is
+	// the resource endpoint and Model the deployment name.
+	Azure *AzureSettings `json:"azure,omitempty"`
+	// Bedrock holds the AWS region and profile. For Bedrock, BaseURL
+	// overrides the runtime endpoint and Model is the model ID.
+	Bedrock *BedrockSettings `json:"bedrock,omitempty"`
+	// Vertex holds the Google Cloud project, location and credential type.
+	// For Vertex AI, BaseURL overrides the regional API host.
+	Vertex *VertexSettings `json:"vertex,omitempty"`
+}
+
+// VertexSettings address a Gemini model on Vertex AI and say which
+// credential signs the requests.
+type VertexSettings struct {
+	Project  string `json:"project,omitempty"`
+	Location string `json:"location,omitempty"`
+	// Auth is "service-account" (the default), when the service account key
+	// is kept in the keyring, "adc" for Application Default Credentials, or
+	// "none" for emulators.
+	Auth string `json:"auth,omitempty"`
+}
+
+// BedrockSettings select where Bedrock requests go and which AWS
+// credentials sign them.
+type BedrockSettings struct {
+	Region string `json:"region,omitempty"`
+	// Profile names a profile in the shared AWS credentials or config file.
+	// When empty, the AWS environment variables and AWS_PROFILE apply.
+	Profile string `json:"profile,omitempty"`
+}
+
+// NetworkSettings say how requests reach the providers. Empty fields keep
+// the defaults: the proxy environment variables and the system roots.
+type NetworkSettings struct {
+	// Proxy is the URL of the proxy every request goes through, in place of
+	// HTTPS_PROXY and HTTP_PROXY.
+	Proxy string `json:"proxy,omitempty"`
+	// NoProxy lists the hosts reached without the proxy, in NO_PROXY syntax,
+	// in place of NO_PROXY.
+	NoProxy string `json:"no_proxy,omitempty"`
+	// CABundle is a PEM file of certificate authorities trusted in addition
+	// to the system roots, such as the one of a TLS-inspecting gateway.
+	CABundle string `json:"ca_bundle,omitempty"`
+	// ClientCert and ClientKey are the PEM files of the certificate
+	// presented for mutual TLS.
+	ClientCert string `json:"client_cert,omitempty"`
+	ClientKey  string `json:"client_key,omitempty"`
+}
+
+// IsZero reports whether no setting is made.
+func (s NetworkSettings) IsZero() bool {
+	return s == NetworkSettings{}
+}
+
+// AzureSettings select the REST API version of an Azure OpenAI deployment
+// and how the stored credential is sent.
+type AzureSettings struct {
+	APIVersion string `json:"api_version,omitempty"`
+	// Auth is "api-key" (the default) or "bearer" for Microsoft Entra ID
+	// access tokens.
+	Auth string `json:"auth,omitempty"`
+}
+
+// OllamaSettings are the model options and keep_alive sent with every
+// Ollama request. Zero values leave the server's defaults in place.
+type OllamaSettings struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	// NumCtx is the context window in tokens.
+	NumCtx int `json:"num_ctx,omitempty"`
+	// NumPredict caps the generated tokens; -1 means no limit.
+	NumPredict int `json:"num_predict,omitempty"`
+	// KeepAlive is how long the model stays loaded after a request, as a
+	// duration such as "10m" or a number of seconds ("-1" keeps it loaded).
+	KeepAlive string `json:"keep_alive,omitempty"`
+}
+
 // RepoConfig tracks metadata for a configured Git repository.
 type RepoConfig struct {
 	Path    string `json:"path"`
 	LastRun string `json:"last_run"`
+	// Scope selects the changes that are read and described.
+	Scope ChangeScope `json:"scope,omitempty"`
 }
 
 // GrokRequest represents a chat completion request sent to X.AI's API.
 type GrokRequest struct {
-	Messages    []Message `json:"messages"`
-	Model       string    `json:"model"`
-	Stream      bool      `json:"stream"`
-	Temperature float64   `json:"temperature"`
+	Messages      []Message      `json:"messages"`
+	Model         string         `json:"model"`
+	Stream        bool           `json:"stream"`
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	Temperature   float64        `json:"temperature"`
+	MaxTokens     int            `json:"max_tokens,omitempty"`
+	TopP          *float64       `json:"top_p,omitempty"`
+	Seed          *int64         `json:"seed,omitempty"`
+}
+
+// StreamOptions configures streamed chat completions; IncludeUsage asks for
+// a final chunk carrying the token usage.
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
-// Message captures the role/content pairs exchanged with Grok.
+// Message is one role/content turn of a chat request.
 type Message struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
@@ -100,17 +254,22 @@ type Choice struct {
 	FinishReason string  `json:"finish_reason"`
 }
 
-// UsageInfo reports token usage statistics from Grok responses.
+// UsageInfo reports the token usage of a generation request as counted by
+// the provider.
 type UsageInfo struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+	// CachedPromptTokens is the part of PromptTokens served from the
+	// provider's prompt cache, which is billed at a lower rate.
+	CachedPromptTokens int `json:"cached_prompt_tokens,omitempty"`
 }
 
 // CacheEntry represents a cached commit message with metadata.
 type CacheEntry struct {
 	Message          string      `json:"message"`
 	Provider         LLMProvider `json:"provider"`
+	Model            string      `json:"model,omitempty"`
 	DiffHash         string      `json:"diff_hash"`
 	StyleInstruction string      `json:"style_instruction,omitempty"`
 	Attempt          int         `json:"attempt"`
@@ -147,11 +306,14 @@ type UsageStats struct {
 	TotalGenerations    int                        `json:"total_generations"`
 	SuccessfulGenerations int                      `json:"successful_generations"`
 	FailedGenerations   int                        `json:"failed_generations"`
+	CancelledGenerations int                       `json:"cancelled_generations"`
 	ProviderStats       map[LLMProvider]*ProviderStats `json:"provider_stats"`
 	FirstUse            string                     `json:"first_use"`
 	LastUse             string                     `json:"last_use"`
 	TotalCost           float64                    `json:"total_cost"`
 	TotalTokensUsed     int                        `json:"total_tokens_used"`
+	TotalPromptTokens   int                        `json:"total_prompt_tokens"`
+	TotalCompletionTokens int                      `json:"total_completion_tokens"`
 	CacheHits           int                        `json:"cache_hits"`
 	CacheMisses         int                        `json:"cache_misses"`
 	AverageGenerationTime float64                  `json:"average_generation_time_ms"`
@@ -165,6 +327,8 @@ type ProviderStats struct {
 	FailedUses            int         `json:"failed_uses"`
 	TotalCost             float64     `json:"total_cost"`
 	TotalTokensUsed       int         `json:"total_tokens_used"`
+	TotalPromptTokens     int         `json:"total_prompt_tokens"`
+	TotalCompletionTokens int         `json:"total_completion_tokens"`
 	AverageGenerationTime float64     `json:"average_generation_time_ms"`
 	FirstUsed             string      `json:"first_used"`
 	LastUsed              string      `json:"last_used"`
@@ -174,12 +338,17 @@ type ProviderStats struct {
 // GenerationEvent represents a single commit message generation event for tracking.
 type GenerationEvent struct {
 	Provider      LLMProvider `json:"provider"`
+	Model         string      `json:"model,omitempty"`
 	Success       bool        `json:"success"`
 	GenerationTime float64     `json:"generation_time_ms"`
 	TokensUsed    int         `json:"tokens_used"`
+	PromptTokens  int         `json:"prompt_tokens"`
+	CompletionTokens int      `json:"completion_tokens"`
+	CachedPromptTokens int    `json:"cached_prompt_tokens,omitempty"`
 	Cost          float64     `json:"cost"`
 	CacheHit      bool        `json:"cache_hit"`
 	CacheChecked  bool        `json:"cache_checked"`
 	Timestamp     string      `json:"timestamp"`
 	ErrorMessage  string      `json:"error_message,omitempty"`
+	Cancelled     bool        `json:"cancelled,omitempty"`
 }
@@ -33,27 +33,26 @@ func TestConfigJSONMarshalling(t *testing.T) {
 	}
 }
 
-func TestCommitPromptContent(t *testing.T) {
+func TestSystemPromptContent(t *testing.T) {
 	t.Parallel()
 
 	requiredFragments := []string{
 		"Starts with a verb",
 		"Is clear and descriptive",
-		"Here are the changes",
 	}
 
 	for _, fragment := range requiredFragments {
-		if !strings.Contains(CommitPrompt, fragment) {
-			t.Fatalf("CommitPrompt missing fragment %q", fragment)
+		if !strings.Contains(SystemPrompt, fragment) {
+			t.Fatalf("SystemPrompt missing fragment %q", fragment)
 		}
 	}
 }
 
-func TestBuildCommitPromptDefault(t *testing.T) {
+func TestBuildPromptDefault(t *testing.T) {
 	t.Parallel()
 
 	changes := "diff --git a/main.go b/main.go"
-	prompt := BuildCommitPrompt(changes, nil)
+	prompt := BuildPrompt(changes, nil).User
 
 	if !strings.HasSuffix(prompt, changes) {
 		t.Fatalf("expected prompt to end with changes, got %q", prompt)
@@ -64,12 +63,12 @@ func TestBuildCommitPromptDefault(t *testing.T) {
 	}
 }
 
-func TestBuildCommitPromptWithInstructions(t *testing.T) {
+func TestBuildPromptWithInstructions(t *testing.T) {
 	t.Parallel()
 
 	changes := "diff --git a/main.go b/main.go"
 	options := &GenerationOptions{StyleInstruction: "Use a playful tone."}
-	prompt := BuildCommitPrompt(changes, options)
+	prompt := BuildPrompt(changes, options).User
 
 	if !strings.Contains(prompt, "Additional instructions:") {
 		t.Fatalf("expected prompt to contain additional instructions block")
@@ -84,12 +83,12 @@ func TestBuildCommitPromptWithInstructions(t *testing.T) {
 	}
 }
 
-func TestBuildCommitPromptWithAttempt(t *testing.T) {
+func TestBuildPromptWithAttempt(t *testing.T) {
 	t.Parallel()
 
 	changes := "diff --git a/main.go b/main.go"
 	options := &GenerationOptions{Attempt: 3}
-	prompt := BuildCommitPrompt(changes, options)
+	prompt := BuildPrompt(changes, options).User
 
 	if !strings.Contains(prompt, "Regeneration context:") {
 		t.Fatalf("expected regeneration context section, got %q", prompt)
@@ -103,3 +102,93 @@ func TestBuildCommitPromptWithAttempt(t *testing.T) {
 		t.Fatalf("expected prompt to end with changes, got %q", prompt)
 	}
 }
+
+func TestBuildPromptWithPreviousMessage(t *testing.T) {
+	t.Parallel()
+
+	changes := "diff --git a/main.go b/main.go"
+	options := &GenerationOptions{PreviousMessage: "fix stuff\n"}
+	prompt := BuildPrompt(changes, options).User
+
+	if !strings.Contains(prompt, "Current commit message:\nfix stuff\n") {
+		t.Fatalf("expected the current message in the prompt, got %q", prompt)
+	}
+
+	if !strings.HasSuffix(prompt, changes) {
+		t.Fatalf("expected prompt to end with changes, got %q", prompt)
+	}
+}
+
+func TestPromptMessages(t *testing.T) {
+	t.Parallel()
+
+	prompt := BuildPrompt("diff --git a/main.go b/main.go", &GenerationOptions{StyleInstruction: "Be brief."})
+
+	messages := prompt.Messages()
+	roles := make([]string, 0, len(messages))
+	for _, message := range messages {
+		roles = append(roles, message.Role)
+	}
+	if strings.Join(roles, ",") != "system,user,assistant,user" {
+		t.Fatalf("unexpected roles %v", roles)
+	}
+	if messages[0].Content != SystemPrompt || messages[3].Content != prompt.User {
+		t.Fatalf("expected the system prompt first and the changes last, got %+v", messages)
+	}
+	if strings.Contains(prompt.System, "Be brief.") {
+		t.Fatal("expected the style instruction in the user message, not the system message")
+	}
+
+	if turns := prompt.Turns(); len(turns) != 3 || turns[0].Role != RoleUser {
+		t.Fatalf("expected turns without the system message, got %+v", turns)
+	}
+
+	if text := prompt.String(); !strings.HasPrefix(text, "[system]\n") || !strings.HasSuffix(text, prompt.User) {
+		t.Fatalf("unexpected rendering %q", text)
+	}
+}
+
+func TestBuildStructuredPrompt(t *testing.T) {
+	t.Parallel()
+
+	prompt := BuildStructuredPrompt("diff", nil)
+
+	if !strings.Contains(prompt.System, StructuredOutputInstruction) {
+		t.Fatal("expected the structured output instruction in the system message")
+	}
+
+	commit, err := DecodeCommitMessage([]byte(prompt.Examples[0].Assistant))
+	if err != nil || commit.Type != "feat" {
+		t.Fatalf("expected a JSON example answer, got %q: %v", prompt.Examples[0].Assistant, err)
+	}
+
+	if plain := ParseCommitMessage(exampleMessage); plain.Header() != commit.Header() || plain.Validate() != nil {
+		t.Fatalf("expected the plain example %q to match the structured one %q", plain.Header(), commit.Header())
+	}
+}
+
+func TestSamplingMergeAndValidate(t *testing.T) {
+	t.Parallel()
+
+	low, high := 0.2, 3.0
+	base := Sampling{Temperature: &low, MaxTokens: 500}
+	merged := base.Merge(DeterministicSampling())
+	if *merged.Temperature != 0 || merged.MaxTokens != 500 || merged.Seed == nil || *merged.Seed != DeterministicSeed {
+		t.Fatalf("unexpected merge result %+v", merged)
+	}
+	if err := merged.Validate(); err != nil {
+		t.Fatalf("expected valid settings, got %v", err)
+	}
+
+	data, _ := json.Marshal(merged)
+	if string(data) != `{"temperature":0,"max_tokens":500,"seed":42}` {
+		t.Fatalf("unexpected JSON %s", data)
+	}
+
+	if err := (Sampling{Temperature: &high}).Validate(); err == nil {
+		t.Fatal("expected a temperature above 2 to be rejected")
+	}
+	if err := (Sampling{TopP: new(float64)}).Validate(); err == nil {
+		t.Fatal("expected top_p 0 to be rejected")
+	}
+}